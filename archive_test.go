@@ -0,0 +1,389 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// newArchiveTestUpstream creates a bare git repo with one committed file
+// and returns its path. fetchUpstream drives `git fetch` against this
+// path directly rather than over HTTP -- goblet's archive support only
+// needs a working managedRepository, and the HTTP route that would
+// serve ".../archive.{format}?ref=..." isn't present in this checkout (see
+// WriteArchive's doc comment) -- so there's no httpProxyServer to push
+// the commit through.
+func newArchiveTestUpstream(t *testing.T) string {
+	t.Helper()
+
+	upstream := t.TempDir()
+	runGitForTest(t, upstream, "init", "--bare", "-b", "main")
+
+	work := t.TempDir()
+	runGitForTest(t, work, "init", "-b", "main")
+	runGitForTest(t, work, "config", "user.email", "archive-test@example.com")
+	runGitForTest(t, work, "config", "user.name", "archive test")
+	if err := os.WriteFile(filepath.Join(work, "hello.txt"), []byte("hello from the archive test\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	runGitForTest(t, work, "add", "hello.txt")
+	runGitForTest(t, work, "commit", "-m", "add hello.txt")
+	runGitForTest(t, work, "push", upstream, "main")
+
+	return upstream
+}
+
+func runGitForTest(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = []string{}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
+
+func openArchiveTestRepo(t *testing.T) *managedRepository {
+	t.Helper()
+
+	upstream := newArchiveTestUpstream(t)
+	upstreamURL, err := url.Parse(upstream)
+	if err != nil {
+		t.Fatalf("failed to parse upstream path as a URL: %v", err)
+	}
+
+	config := &ServerConfig{
+		LocalDiskCacheRoot: t.TempDir(),
+		URLCanonializer:    func(u *url.URL) (*url.URL, error) { return upstreamURL, nil },
+		TokenSource:        func(u *url.URL) (*oauth2.Token, error) { return &oauth2.Token{}, nil },
+	}
+
+	repo, err := openManagedRepository(config, upstreamURL)
+	if err != nil {
+		t.Fatalf("openManagedRepository() failed: %v", err)
+	}
+	return repo
+}
+
+func TestWriteArchiveTar(t *testing.T) {
+	repo := openArchiveTestRepo(t)
+
+	var buf bytes.Buffer
+	if err := repo.WriteArchive(context.Background(), &buf, "main", "tar"); err != nil {
+		t.Fatalf("WriteArchive() failed: %v", err)
+	}
+
+	tr := tar.NewReader(&buf)
+	found := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read tar entry: %v", err)
+		}
+		if hdr.Name != "hello.txt" {
+			continue
+		}
+		found = true
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("failed to read hello.txt from archive: %v", err)
+		}
+		if got, want := string(content), "hello from the archive test\n"; got != want {
+			t.Errorf("hello.txt content = %q, want %q", got, want)
+		}
+	}
+	if !found {
+		t.Error("archive did not contain hello.txt")
+	}
+}
+
+func TestWriteArchiveTarGz(t *testing.T) {
+	repo := openArchiveTestRepo(t)
+
+	var buf bytes.Buffer
+	if err := repo.WriteArchive(context.Background(), &buf, "main", "tar.gz"); err != nil {
+		t.Fatalf("WriteArchive() failed: %v", err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("archive is not valid gzip: %v", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	names := []string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read tar entry: %v", err)
+		}
+		names = append(names, hdr.Name)
+	}
+	if len(names) == 0 {
+		t.Error("archive contained no entries")
+	}
+}
+
+func TestWriteArchiveTarBz2(t *testing.T) {
+	if _, err := exec.LookPath("bzip2"); err != nil {
+		t.Skip("bzip2 binary not found")
+	}
+
+	repo := openArchiveTestRepo(t)
+
+	var buf bytes.Buffer
+	if err := repo.WriteArchive(context.Background(), &buf, "main", "tar.bz2"); err != nil {
+		t.Fatalf("WriteArchive() failed: %v", err)
+	}
+
+	tr := tar.NewReader(bzip2.NewReader(&buf))
+	names := []string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read tar entry: %v", err)
+		}
+		names = append(names, hdr.Name)
+	}
+	if len(names) == 0 {
+		t.Error("archive contained no entries")
+	}
+}
+
+func TestWriteArchiveUnsupportedFormat(t *testing.T) {
+	repo := openArchiveTestRepo(t)
+
+	var buf bytes.Buffer
+	if err := repo.WriteArchive(context.Background(), &buf, "main", "rar"); err == nil {
+		t.Error("WriteArchive() with an unsupported format succeeded, want an error")
+	}
+}
+
+func TestArchiveContentType(t *testing.T) {
+	tests := []struct {
+		format          string
+		wantContentType string
+		wantOK          bool
+	}{
+		{"tar", "application/x-tar", true},
+		{"tar.gz", "application/gzip", true},
+		{"tar.bz2", "application/x-bzip2", true},
+		{"zip", "application/zip", true},
+		{"rar", "", false},
+	}
+	for _, tt := range tests {
+		contentType, ok := archiveContentType(tt.format)
+		if ok != tt.wantOK {
+			t.Errorf("archiveContentType(%q) ok = %v, want %v", tt.format, ok, tt.wantOK)
+		}
+		if contentType != tt.wantContentType {
+			t.Errorf("archiveContentType(%q) = %q, want %q", tt.format, contentType, tt.wantContentType)
+		}
+	}
+}
+
+func TestArchiveFilename(t *testing.T) {
+	tests := []struct {
+		path string
+		oid  string
+		want string
+	}{
+		{"/org/my-repo.git", "0123456789abcdef", "my-repo-0123456789ab.zip"},
+		{"/org/my-repo", "0123456789abcdef", "my-repo-0123456789ab.zip"},
+		{"/", "0123456789abcdef", "repo-0123456789ab.zip"},
+	}
+	for _, tt := range tests {
+		u := &url.URL{Path: tt.path}
+		if got := archiveFilename(u, tt.oid, "zip"); got != tt.want {
+			t.Errorf("archiveFilename(%q, %q, zip) = %q, want %q", tt.path, tt.oid, got, tt.want)
+		}
+	}
+}
+
+func TestArchiveCacheScopedPerRepo(t *testing.T) {
+	cache, err := NewArchiveCache(t.TempDir(), DefaultArchiveCacheSize)
+	if err != nil {
+		t.Fatalf("NewArchiveCache() failed: %v", err)
+	}
+
+	keyA := archiveKey{Repo: "/cache/a/repo", OID: "deadbeef", Format: "tar"}
+	keyB := archiveKey{Repo: "/cache/b/repo", OID: "deadbeef", Format: "tar"}
+
+	pathA := cache.Reserve(keyA)
+	pathB := cache.Reserve(keyB)
+	if pathA == pathB {
+		t.Fatalf("Reserve() gave the same path %q for two different repos with the same OID and format", pathA)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(pathA), 0750); err != nil {
+		t.Fatalf("cannot create dir for pathA: %v", err)
+	}
+	if err := os.WriteFile(pathA, []byte("archive for repo a"), 0644); err != nil {
+		t.Fatalf("cannot write pathA: %v", err)
+	}
+	cache.Put(keyA, pathA)
+
+	if _, ok := cache.Get(keyB); ok {
+		t.Error("Get(keyB) found an entry, want a miss since only keyA was Put")
+	}
+	if gotPath, ok := cache.Get(keyA); !ok || gotPath != pathA {
+		t.Errorf("Get(keyA) = (%q, %v), want (%q, true)", gotPath, ok, pathA)
+	}
+}
+
+func TestWriteArchiveCachesGeneratedFile(t *testing.T) {
+	repo := openArchiveTestRepo(t)
+
+	var first bytes.Buffer
+	if err := repo.WriteArchive(context.Background(), &first, "main", "tar"); err != nil {
+		t.Fatalf("WriteArchive() failed: %v", err)
+	}
+
+	cache, err := archiveCacheFor(repo.config)
+	if err != nil {
+		t.Fatalf("archiveCacheFor() failed: %v", err)
+	}
+	oid, err := repo.resolveArchiveRef(context.Background(), "main")
+	if err != nil {
+		t.Fatalf("resolveArchiveRef() failed: %v", err)
+	}
+	path, ok := cache.Get(archiveKey{Repo: repo.localDiskPath, OID: oid, Format: "tar"})
+	if !ok {
+		t.Fatal("archive was not registered in the cache")
+	}
+
+	var second bytes.Buffer
+	if err := repo.WriteArchive(context.Background(), &second, "main", "tar"); err != nil {
+		t.Fatalf("second WriteArchive() failed: %v", err)
+	}
+	if first.String() != second.String() {
+		t.Error("second WriteArchive() did not return the same bytes as the cached archive")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("cached archive file missing: %v", err)
+	}
+}
+
+func TestArchiveFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path       string
+		wantFormat string
+		wantOK     bool
+	}{
+		{"/org/repo/archive.tar", "tar", true},
+		{"/org/repo/archive.tar.gz", "tar.gz", true},
+		{"/org/repo/archive.tar.bz2", "tar.bz2", true},
+		{"/org/repo/archive.zip", "zip", true},
+		{"/org/repo/info/refs", "", false},
+		{"/org/subarchive.tar", "", false},
+		{"/org/myarchive.zip", "", false},
+	}
+	for _, tt := range tests {
+		format, ok := archiveFormatFromPath(tt.path)
+		if format != tt.wantFormat || ok != tt.wantOK {
+			t.Errorf("archiveFormatFromPath(%q) = (%q, %v), want (%q, %v)", tt.path, format, ok, tt.wantFormat, tt.wantOK)
+		}
+	}
+}
+
+func TestArchiveHandlerServesGeneratedArchive(t *testing.T) {
+	repo := openArchiveTestRepo(t)
+
+	req := httptest.NewRequest("GET", "/org/repo/archive.tar.gz?ref=main", nil)
+	w := httptest.NewRecorder()
+	archiveHandler(w, req, repo)
+
+	resp := w.Result()
+	if resp.StatusCode != 200 {
+		t.Fatalf("status = %d, want 200; body: %s", resp.StatusCode, w.Body.String())
+	}
+	if got, want := resp.Header.Get("Content-Type"), "application/gzip"; got != want {
+		t.Errorf("Content-Type = %q, want %q", got, want)
+	}
+	if got := resp.Header.Get("Content-Disposition"); got == "" {
+		t.Error("Content-Disposition header is empty, want an attachment filename")
+	}
+
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("response body is not valid gzip: %v", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	found := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read tar entry: %v", err)
+		}
+		if hdr.Name == "hello.txt" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("served archive did not contain hello.txt")
+	}
+}
+
+func TestArchiveHandlerUnrecognizedFormatReturns404(t *testing.T) {
+	repo := openArchiveTestRepo(t)
+
+	req := httptest.NewRequest("GET", "/org/repo/info/refs", nil)
+	w := httptest.NewRecorder()
+	archiveHandler(w, req, repo)
+
+	if w.Result().StatusCode != 404 {
+		t.Errorf("status = %d, want 404 for a path with no archive suffix", w.Result().StatusCode)
+	}
+}
+
+func TestArchiveHandlerUnknownRefReturns404(t *testing.T) {
+	repo := openArchiveTestRepo(t)
+
+	req := httptest.NewRequest("GET", "/org/repo/archive.tar?ref=does-not-exist", nil)
+	w := httptest.NewRecorder()
+	archiveHandler(w, req, repo)
+
+	if w.Result().StatusCode != 404 {
+		t.Errorf("status = %d, want 404 for an unresolvable ref", w.Result().StatusCode)
+	}
+}
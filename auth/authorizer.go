@@ -0,0 +1,53 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auth defines the small interface goblet's request authorizers
+// (googlehook's Google-only hook, auth/oidc.Authorizer, auth/mtls's
+// client-certificate authorizer, or a caller's own implementation) are
+// built around, so goblet-server can select one by flag instead of
+// hard-coding googlehook.NewRequestAuthorizer.
+package auth
+
+import "net/http"
+
+// Authorizer decides whether an incoming Git smart-HTTP request may
+// proceed, returning a non-nil error (conventionally a
+// google.golang.org/grpc/status error, so callers can tell
+// Unauthenticated from PermissionDenied) to reject it.
+type Authorizer interface {
+	AuthorizeRequest(r *http.Request) error
+}
+
+// Func adapts a plain func(*http.Request) error -- the shape
+// goblet.ServerConfig.RequestAuthorizer itself expects, and the one
+// googlehook.NewRequestAuthorizer already returns -- into an Authorizer.
+type Func func(r *http.Request) error
+
+// AuthorizeRequest implements Authorizer.
+func (f Func) AuthorizeRequest(r *http.Request) error { return f(r) }
+
+// RequestAuthorizerFunc adapts an Authorizer back down to the
+// func(*http.Request) error shape goblet.ServerConfig.RequestAuthorizer
+// expects, so any
+// Authorizer -- regardless of which package built it -- can be plugged
+// in as `config.RequestAuthorizer = auth.RequestAuthorizerFunc(a)`.
+func RequestAuthorizerFunc(a Authorizer) func(r *http.Request) error {
+	return a.AuthorizeRequest
+}
+
+// None is an Authorizer that allows every request. It's for
+// -authorizer=none deployments that delegate all access control to a
+// front door the proxy trusts unconditionally, e.g. a network
+// perimeter or an upstream goblet.AuthBackend.
+var None Authorizer = Func(func(*http.Request) error { return nil })
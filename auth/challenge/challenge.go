@@ -0,0 +1,205 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package challenge implements RFC 7235 WWW-Authenticate challenge
+// negotiation, modeled on the Docker Registry v2 bearer token scheme, so
+// that goblet's proxy endpoints can advertise how to obtain a token instead
+// of returning a bare 401.
+package challenge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Challenge is a single parsed "<scheme> <params>" entry from a
+// WWW-Authenticate header.
+type Challenge struct {
+	Scheme string
+	Params map[string]string
+}
+
+// Param returns the named parameter, or "" if it wasn't present.
+func (c Challenge) Param(name string) string {
+	return c.Params[name]
+}
+
+// Bearer builds the Bearer challenge goblet returns to unauthenticated
+// clients, following the Docker Registry v2 token scheme:
+// WWW-Authenticate: Bearer realm="...",service="...",scope="..."
+func Bearer(realm, service, scope string) Challenge {
+	params := map[string]string{"realm": realm}
+	if service != "" {
+		params["service"] = service
+	}
+	if scope != "" {
+		params["scope"] = scope
+	}
+	return Challenge{Scheme: "Bearer", Params: params}
+}
+
+// String renders the challenge back into WWW-Authenticate header syntax.
+// Parameter order is not significant per RFC 7235, but is sorted here for
+// deterministic output.
+func (c Challenge) String() string {
+	if len(c.Params) == 0 {
+		return c.Scheme
+	}
+	keys := make([]string, 0, len(c.Params))
+	for k := range c.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%q", k, c.Params[k]))
+	}
+	return c.Scheme + " " + strings.Join(parts, ",")
+}
+
+// tokenizer walks a WWW-Authenticate header value one lexical token at a
+// time: either a quoted string, or a run of characters up to the next
+// separator (space, comma, or '=').
+type tokenizer struct {
+	s   string
+	pos int
+}
+
+func (t *tokenizer) skipSpace() {
+	for t.pos < len(t.s) && isSpace(t.s[t.pos]) {
+		t.pos++
+	}
+}
+
+func (t *tokenizer) skipCommas() {
+	for t.pos < len(t.s) && (isSpace(t.s[t.pos]) || t.s[t.pos] == ',') {
+		t.pos++
+	}
+}
+
+func (t *tokenizer) peekIsQuote() bool {
+	return t.pos < len(t.s) && t.s[t.pos] == '"'
+}
+
+func (t *tokenizer) readQuoted() (string, error) {
+	if !t.peekIsQuote() {
+		return "", fmt.Errorf("expected a quoted value at offset %d", t.pos)
+	}
+	var b strings.Builder
+	i := t.pos + 1
+	for i < len(t.s) {
+		c := t.s[i]
+		if c == '\\' && i+1 < len(t.s) {
+			b.WriteByte(t.s[i+1])
+			i += 2
+			continue
+		}
+		if c == '"' {
+			t.pos = i + 1
+			return b.String(), nil
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return "", fmt.Errorf("unterminated quoted value")
+}
+
+// readToken reads an unquoted token: a run of non-space, non-comma,
+// non-'=' characters.
+func (t *tokenizer) readToken() string {
+	start := t.pos
+	for t.pos < len(t.s) && !isSpace(t.s[t.pos]) && t.s[t.pos] != ',' && t.s[t.pos] != '=' {
+		t.pos++
+	}
+	return t.s[start:t.pos]
+}
+
+// Parse parses a WWW-Authenticate header value into its individual
+// challenges. It handles case-insensitive scheme names, quoted parameter
+// values, and multiple challenges in a single header (each challenge is a
+// scheme token followed by a comma-separated name=value parameter list; a
+// new challenge starts wherever a bare token is not followed by '=').
+func Parse(header string) ([]Challenge, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil, fmt.Errorf("empty WWW-Authenticate header")
+	}
+
+	t := &tokenizer{s: header}
+	var challenges []Challenge
+	for {
+		t.skipCommas()
+		if t.pos >= len(t.s) {
+			break
+		}
+
+		scheme := t.readToken()
+		if scheme == "" {
+			return nil, fmt.Errorf("malformed WWW-Authenticate header: %q", header)
+		}
+		params := map[string]string{}
+
+		for {
+			save := t.pos
+			t.skipSpace()
+			// A trailing comma between challenges is consumed by skipCommas above.
+			if t.pos < len(t.s) && t.s[t.pos] == ',' {
+				t.pos++
+				t.skipSpace()
+			}
+			if t.pos >= len(t.s) {
+				break
+			}
+
+			name := t.readToken()
+			if name == "" || t.pos >= len(t.s) || t.s[t.pos] != '=' {
+				// Not a "name=value" pair: this is the next
+				// challenge's scheme token. Rewind and stop.
+				t.pos = save
+				break
+			}
+			t.pos++ // consume '='
+
+			var value string
+			var err error
+			if t.peekIsQuote() {
+				value, err = t.readQuoted()
+				if err != nil {
+					return nil, fmt.Errorf("malformed WWW-Authenticate header: %w", err)
+				}
+			} else {
+				value = t.readToken()
+			}
+			params[strings.ToLower(name)] = value
+		}
+
+		challenges = append(challenges, Challenge{Scheme: scheme, Params: params})
+	}
+	return challenges, nil
+}
+
+func isSpace(b byte) bool { return b == ' ' || b == '\t' }
+
+// ByScheme returns the first challenge matching scheme (case-insensitive),
+// or false if none matched.
+func ByScheme(challenges []Challenge, scheme string) (Challenge, bool) {
+	for _, c := range challenges {
+		if strings.EqualFold(c.Scheme, scheme) {
+			return c, true
+		}
+	}
+	return Challenge{}, false
+}
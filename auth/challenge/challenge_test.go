@@ -0,0 +1,93 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package challenge
+
+import "testing"
+
+func TestParseSingleBearerChallenge(t *testing.T) {
+	header := `Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:org/repo:pull"`
+	cs, err := Parse(header)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(cs) != 1 {
+		t.Fatalf("got %d challenges, want 1", len(cs))
+	}
+	c := cs[0]
+	if c.Scheme != "Bearer" {
+		t.Errorf("Scheme = %q, want Bearer", c.Scheme)
+	}
+	if got, want := c.Param("realm"), "https://auth.example.com/token"; got != want {
+		t.Errorf("realm = %q, want %q", got, want)
+	}
+	if got, want := c.Param("scope"), "repository:org/repo:pull"; got != want {
+		t.Errorf("scope = %q, want %q", got, want)
+	}
+}
+
+func TestParseMultipleChallenges(t *testing.T) {
+	header := `Bearer realm="https://a",service="s", Basic realm="goblet"`
+	cs, err := Parse(header)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(cs) != 2 {
+		t.Fatalf("got %d challenges, want 2: %+v", len(cs), cs)
+	}
+	if cs[0].Scheme != "Bearer" || cs[1].Scheme != "Basic" {
+		t.Errorf("schemes = [%s, %s], want [Bearer, Basic]", cs[0].Scheme, cs[1].Scheme)
+	}
+	if got := cs[1].Param("realm"); got != "goblet" {
+		t.Errorf("Basic realm = %q, want goblet", got)
+	}
+}
+
+func TestParseCaseInsensitiveScheme(t *testing.T) {
+	cs, err := Parse(`bearer realm="x"`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, ok := ByScheme(cs, "Bearer"); !ok {
+		t.Errorf("ByScheme(\"Bearer\") did not match lowercase scheme %q", cs[0].Scheme)
+	}
+}
+
+func TestParseEmptyHeader(t *testing.T) {
+	if _, err := Parse(""); err == nil {
+		t.Error("Parse(\"\") should return an error")
+	}
+}
+
+func TestParseEscapedQuote(t *testing.T) {
+	cs, err := Parse(`Bearer realm="has \"quote\" inside"`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got, want := cs[0].Param("realm"), `has "quote" inside`; got != want {
+		t.Errorf("realm = %q, want %q", got, want)
+	}
+}
+
+func TestChallengeStringRoundTrip(t *testing.T) {
+	c := Bearer("https://auth.example.com/token", "registry.example.com", "repository:org/repo:pull")
+	header := c.String()
+	cs, err := Parse(header)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", header, err)
+	}
+	if got := cs[0].Param("scope"); got != "repository:org/repo:pull" {
+		t.Errorf("round-tripped scope = %q", got)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package challenge
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// TokenMinter exchanges a Bearer challenge for an access token by calling
+// the challenge's realm, e.g. POST-ing to realm?service=...&scope=....
+type TokenMinter interface {
+	Mint(c Challenge) (token string, err error)
+}
+
+// AuthenticatedHTTPClient wraps an http.Client and transparently performs
+// the Bearer challenge handshake: it sends the request, and on a 401 with a
+// WWW-Authenticate header, mints a token via minter, caches it by scope,
+// and retries the request once with the token attached.
+type AuthenticatedHTTPClient struct {
+	Client *http.Client
+	Minter TokenMinter
+
+	mu     sync.Mutex
+	cache  map[string]string // scope -> token
+	scopes map[string]string // request URL -> scope of its last challenge
+}
+
+// NewAuthenticatedHTTPClient creates a client using minter to resolve
+// challenges. If client is nil, http.DefaultClient is used.
+func NewAuthenticatedHTTPClient(client *http.Client, minter TokenMinter) *AuthenticatedHTTPClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &AuthenticatedHTTPClient{
+		Client: client,
+		Minter: minter,
+		cache:  map[string]string{},
+	}
+}
+
+// Do sends req, performing the Bearer challenge handshake and one retry if
+// the server returns 401 with a Bearer WWW-Authenticate challenge.
+func (c *AuthenticatedHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("cannot buffer request body for retry: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if token, ok := c.cachedTokenFor(req.URL.String()); ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	challenges, parseErr := Parse(resp.Header.Get("WWW-Authenticate"))
+	if parseErr != nil {
+		return resp, nil // nothing more we can do; hand the 401 back
+	}
+	bearer, ok := ByScheme(challenges, "Bearer")
+	if !ok {
+		return resp, nil
+	}
+
+	token, err := c.Minter.Mint(bearer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mint a token for challenge %q: %w", bearer, err)
+	}
+	c.cacheToken(req.URL.String(), bearer.Param("scope"), token)
+
+	retry := req.Clone(req.Context())
+	if body != nil {
+		retry.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	retry.Header.Set("Authorization", "Bearer "+token)
+	return c.Client.Do(retry)
+}
+
+// cachedTokenFor returns the token cached for the scope that rawURL was
+// last challenged with.
+func (c *AuthenticatedHTTPClient) cachedTokenFor(rawURL string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	scope, ok := c.scopes[rawURL]
+	if !ok {
+		return "", false
+	}
+	token, ok := c.cache[scope]
+	return token, ok
+}
+
+func (c *AuthenticatedHTTPClient) cacheToken(rawURL, scope, token string) {
+	if scope == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.scopes == nil {
+		c.scopes = map[string]string{}
+	}
+	c.scopes[rawURL] = scope
+	c.cache[scope] = token
+}
@@ -0,0 +1,46 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package challenge
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/goblet"
+)
+
+func init() {
+	goblet.RegisterTokenSourceFactory("challenge", newFromProviderConfig)
+}
+
+// providerConfig is the goblet.Registry provider_config schema for the
+// "challenge" provider.
+type providerConfig struct {
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+func newFromProviderConfig(raw json.RawMessage) (goblet.URLTokenSource, error) {
+	var cfg providerConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("challenge: cannot parse provider config: %w", err)
+	}
+	if cfg.RefreshToken == "" && cfg.Username == "" {
+		return nil, fmt.Errorf("challenge: provider config needs either refresh_token or username/password")
+	}
+	ts := NewTokenSource(Credentials{Username: cfg.Username, Password: cfg.Password, RefreshToken: cfg.RefreshToken})
+	return goblet.URLTokenSourceFunc(ts.Token), nil
+}
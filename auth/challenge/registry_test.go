@@ -0,0 +1,38 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package challenge
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewFromProviderConfigRejectsEmptyCredentials(t *testing.T) {
+	raw, _ := json.Marshal(providerConfig{})
+	if _, err := newFromProviderConfig(raw); err == nil {
+		t.Error("newFromProviderConfig() succeeded with no credentials, want an error")
+	}
+}
+
+func TestNewFromProviderConfigBuildsFromRefreshToken(t *testing.T) {
+	raw, _ := json.Marshal(providerConfig{RefreshToken: "my-refresh-token"})
+	src, err := newFromProviderConfig(raw)
+	if err != nil {
+		t.Fatalf("newFromProviderConfig() error = %v", err)
+	}
+	if src == nil {
+		t.Error("newFromProviderConfig() returned a nil URLTokenSource")
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package challenge
+
+import "net/http"
+
+// Resolver decides the realm, service, and scope a 401 response should
+// challenge the caller with for a given request, e.g. so the scope can
+// encode the specific repository being accessed.
+type Resolver interface {
+	// Resolve returns the Bearer challenge parameters for r. repoPath is
+	// the canonicalized repository path the request is for, or "" if
+	// the request isn't repository-scoped.
+	Resolve(r *http.Request, repoPath string) (realm, service, scope string)
+}
+
+// ResolverFunc adapts a function to a Resolver.
+type ResolverFunc func(r *http.Request, repoPath string) (realm, service, scope string)
+
+// Resolve implements Resolver.
+func (f ResolverFunc) Resolve(r *http.Request, repoPath string) (string, string, string) {
+	return f(r, repoPath)
+}
+
+// WriteUnauthorized writes a 401 response with a WWW-Authenticate: Bearer
+// challenge built from resolver, falling back to Basic if resolver is nil.
+func WriteUnauthorized(w http.ResponseWriter, r *http.Request, repoPath string, resolver Resolver) {
+	if resolver == nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="goblet"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	realm, service, scope := resolver.Resolve(r, repoPath)
+	w.Header().Set("WWW-Authenticate", Bearer(realm, service, scope).String())
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
@@ -0,0 +1,268 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package challenge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+)
+
+// Credentials supplies the client credentials a TokenSource presents to
+// a challenge's realm: either a username/password pair for the Docker
+// distribution spec's "fetch token with basic auth" flow, or a
+// RefreshToken for its OAuth2 Refresh Token grant variant. Exactly one
+// of the two should be set.
+type Credentials struct {
+	Username string
+	Password string
+
+	// RefreshToken, if set, is exchanged via the OAuth2 Refresh Token
+	// grant instead of basic auth, taking priority over Username/Password.
+	RefreshToken string
+}
+
+// TokenSource implements the func(*url.URL) (*oauth2.Token, error)
+// contract goblet's ServerConfig.TokenSource expects, for upstreams that
+// authenticate the Docker Registry v2 way: an unauthenticated request
+// comes back 401 with a WWW-Authenticate: Bearer challenge naming a
+// realm, service, and scope, and the caller exchanges those three for an
+// access token at the realm instead of presenting a static credential to
+// the upstream itself. The zero value is not usable; construct one with
+// NewTokenSource.
+type TokenSource struct {
+	// Client performs both the probe request to the upstream and the
+	// token exchange at the challenge's realm. Defaults to
+	// http.DefaultClient if nil.
+	Client *http.Client
+	// Credentials authenticates the token exchange at the realm.
+	Credentials Credentials
+
+	group singleflight.Group
+
+	mu    sync.Mutex
+	cache map[scopeKey]*oauth2.Token
+
+	challengeMu sync.Mutex
+	challenges  map[string]challengeCacheEntry
+}
+
+// scopeKey caches a token by the three challenge parameters the Docker
+// distribution spec scopes it to.
+type scopeKey struct {
+	realm, service, scope string
+}
+
+// challengeCacheEntry remembers, for one upstream URL, either the Bearer
+// challenge it issued on its last 401 or that it issued no challenge at
+// all (noAuth), so a later Token call for the same URL can go straight
+// to the token cache instead of probing the upstream again.
+type challengeCacheEntry struct {
+	bearer Challenge
+	noAuth bool
+}
+
+// NewTokenSource returns a TokenSource that exchanges challenges using
+// creds.
+func NewTokenSource(creds Credentials) *TokenSource {
+	return &TokenSource{
+		Credentials: creds,
+		cache:       map[scopeKey]*oauth2.Token{},
+		challenges:  map[string]challengeCacheEntry{},
+	}
+}
+
+// Token implements the ServerConfig.TokenSource signature. The first
+// call for a given u probes it with an unauthenticated GET and
+// remembers whether it came back a 401 with a Bearer challenge; later
+// calls for the same u skip that probe and go straight to the
+// remembered challenge, so a cached, unexpired token is served without
+// ever touching the network. Probing an upstream that needs no token at
+// all is remembered the same way, so Token keeps returning a nil token
+// for it without probing again.
+func (ts *TokenSource) Token(u *url.URL) (*oauth2.Token, error) {
+	entry, ok := ts.cachedChallenge(u.String())
+	if !ok {
+		var err error
+		entry, err = ts.probe(u)
+		if err != nil {
+			return nil, err
+		}
+		ts.storeChallenge(u.String(), entry)
+	}
+	if entry.noAuth {
+		return nil, nil
+	}
+
+	key := scopeKey{realm: entry.bearer.Param("realm"), service: entry.bearer.Param("service"), scope: entry.bearer.Param("scope")}
+	if tok, ok := ts.cached(key); ok {
+		return tok, nil
+	}
+
+	v, err, _ := ts.group.Do(key.realm+"\x00"+key.service+"\x00"+key.scope, func() (interface{}, error) {
+		return ts.exchange(entry.bearer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	tok := v.(*oauth2.Token)
+	ts.store(key, tok)
+	return tok, nil
+}
+
+// probe issues an unauthenticated GET to u and reports the Bearer
+// challenge from a 401 response, or noAuth if u answered with anything
+// else.
+func (ts *TokenSource) probe(u *url.URL) (challengeCacheEntry, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return challengeCacheEntry{}, fmt.Errorf("cannot construct probe request for %s: %w", u, err)
+	}
+	resp, err := ts.client().Do(req)
+	if err != nil {
+		return challengeCacheEntry{}, fmt.Errorf("cannot probe %s for a challenge: %w", u, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return challengeCacheEntry{noAuth: true}, nil
+	}
+
+	challenges, err := Parse(resp.Header.Get("WWW-Authenticate"))
+	if err != nil {
+		return challengeCacheEntry{}, fmt.Errorf("cannot parse challenge from %s: %w", u, err)
+	}
+	bearer, ok := ByScheme(challenges, "Bearer")
+	if !ok {
+		return challengeCacheEntry{}, fmt.Errorf("%s returned a 401 with no Bearer challenge", u)
+	}
+	return challengeCacheEntry{bearer: bearer}, nil
+}
+
+// exchange performs a GET realm?service=...&scope=... using
+// ts.Credentials, per the Docker distribution spec's token endpoint.
+func (ts *TokenSource) exchange(bearer Challenge) (*oauth2.Token, error) {
+	realm := bearer.Param("realm")
+	if realm == "" {
+		return nil, fmt.Errorf("challenge %q has no realm", bearer)
+	}
+
+	q := url.Values{}
+	if service := bearer.Param("service"); service != "" {
+		q.Set("service", service)
+	}
+	if scope := bearer.Param("scope"); scope != "" {
+		q.Set("scope", scope)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, realm, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot construct token request for realm %q: %w", realm, err)
+	}
+	if ts.Credentials.RefreshToken != "" {
+		q.Set("grant_type", "refresh_token")
+		q.Set("refresh_token", ts.Credentials.RefreshToken)
+	} else if ts.Credentials.Username != "" {
+		req.SetBasicAuth(ts.Credentials.Username, ts.Credentials.Password)
+	}
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := ts.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach token realm %q: %w", realm, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("token realm %q returned status %d", realm, resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+		IssuedAt    string `json:"issued_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("cannot decode token realm %q response: %w", realm, err)
+	}
+
+	accessToken := body.Token
+	if accessToken == "" {
+		accessToken = body.AccessToken
+	}
+	if accessToken == "" {
+		return nil, fmt.Errorf("token realm %q returned no token", realm)
+	}
+
+	issuedAt := time.Now()
+	if body.IssuedAt != "" {
+		if t, err := time.Parse(time.RFC3339, body.IssuedAt); err == nil {
+			issuedAt = t
+		}
+	}
+	var expiry time.Time
+	if body.ExpiresIn > 0 {
+		expiry = issuedAt.Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+
+	return &oauth2.Token{AccessToken: accessToken, TokenType: "Bearer", Expiry: expiry}, nil
+}
+
+func (ts *TokenSource) client() *http.Client {
+	if ts.Client != nil {
+		return ts.Client
+	}
+	return http.DefaultClient
+}
+
+func (ts *TokenSource) cached(key scopeKey) (*oauth2.Token, bool) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	tok, ok := ts.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if !tok.Expiry.IsZero() && time.Now().After(tok.Expiry) {
+		delete(ts.cache, key)
+		return nil, false
+	}
+	return tok, true
+}
+
+func (ts *TokenSource) store(key scopeKey, tok *oauth2.Token) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.cache[key] = tok
+}
+
+func (ts *TokenSource) cachedChallenge(key string) (challengeCacheEntry, bool) {
+	ts.challengeMu.Lock()
+	defer ts.challengeMu.Unlock()
+	entry, ok := ts.challenges[key]
+	return entry, ok
+}
+
+func (ts *TokenSource) storeChallenge(key string, entry challengeCacheEntry) {
+	ts.challengeMu.Lock()
+	defer ts.challengeMu.Unlock()
+	ts.challenges[key] = entry
+}
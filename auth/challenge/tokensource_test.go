@@ -0,0 +1,181 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package challenge
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTokenSourceChallengeThenExchange(t *testing.T) {
+	var exchanges int32
+
+	var authServer *httptest.Server
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer minted-token" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+			`Bearer realm=%q,service="registry.example.com",scope="repository:org/repo:pull"`, authServer.URL+"/token"))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer upstream.Close()
+
+	authServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&exchanges, 1)
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "robot" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if got, want := r.URL.Query().Get("scope"), "repository:org/repo:pull"; got != want {
+			t.Errorf("token request scope = %q, want %q", got, want)
+		}
+		fmt.Fprint(w, `{"token":"minted-token","expires_in":3600}`)
+	}))
+	defer authServer.Close()
+
+	ts := NewTokenSource(Credentials{Username: "robot", Password: "secret"})
+	u, _ := url.Parse(upstream.URL)
+
+	tok, err := ts.Token(u)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if tok.AccessToken != "minted-token" {
+		t.Errorf("AccessToken = %q, want minted-token", tok.AccessToken)
+	}
+	if tok.TokenType != "Bearer" {
+		t.Errorf("TokenType = %q, want Bearer", tok.TokenType)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, upstream.URL, nil)
+	req.Header.Set("Authorization", tok.TokenType+" "+tok.AccessToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("authenticated retry failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("authenticated retry status = %d, want 200", resp.StatusCode)
+	}
+
+	if _, err := ts.Token(u); err != nil {
+		t.Fatalf("second Token() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&exchanges); got != 1 {
+		t.Errorf("token realm hit %d times, want 1 (second call should hit the cache)", got)
+	}
+}
+
+func TestTokenSourceCachedTokenSkipsProbe(t *testing.T) {
+	var probes int32
+
+	var authServer *httptest.Server
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&probes, 1)
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+			`Bearer realm=%q,service="registry.example.com",scope="repository:org/repo:pull"`, authServer.URL+"/token"))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer upstream.Close()
+
+	authServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"token":"minted-token","expires_in":3600}`)
+	}))
+	defer authServer.Close()
+
+	ts := NewTokenSource(Credentials{Username: "robot", Password: "secret"})
+	u, _ := url.Parse(upstream.URL)
+
+	if _, err := ts.Token(u); err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if _, err := ts.Token(u); err != nil {
+		t.Fatalf("second Token() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&probes); got != 1 {
+		t.Errorf("upstream probed %d times, want 1 (second call should skip the probe and hit the token cache)", got)
+	}
+}
+
+func TestTokenSourceNoChallengeReturnsNilToken(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	ts := NewTokenSource(Credentials{Username: "robot", Password: "secret"})
+	u, _ := url.Parse(upstream.URL)
+
+	tok, err := ts.Token(u)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if tok != nil {
+		t.Errorf("Token() = %+v, want nil for an upstream requiring no auth", tok)
+	}
+}
+
+func TestTokenSourceRejectsChallengeWithoutBearer(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="goblet"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer upstream.Close()
+
+	ts := NewTokenSource(Credentials{Username: "robot", Password: "secret"})
+	u, _ := url.Parse(upstream.URL)
+
+	if _, err := ts.Token(u); err == nil {
+		t.Error("Token() succeeded against a Basic-only challenge, want an error")
+	}
+}
+
+func TestTokenSourceUsesRefreshTokenGrant(t *testing.T) {
+	var authServer *httptest.Server
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q,service="registry.example.com"`, authServer.URL+"/token"))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer upstream.Close()
+
+	authServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.Query().Get("grant_type"), "refresh_token"; got != want {
+			t.Errorf("grant_type = %q, want %q", got, want)
+		}
+		if got, want := r.URL.Query().Get("refresh_token"), "my-refresh-token"; got != want {
+			t.Errorf("refresh_token = %q, want %q", got, want)
+		}
+		fmt.Fprint(w, `{"access_token":"refreshed-token","expires_in":60}`)
+	}))
+	defer authServer.Close()
+
+	ts := NewTokenSource(Credentials{RefreshToken: "my-refresh-token"})
+	u, _ := url.Parse(upstream.URL)
+
+	tok, err := ts.Token(u)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if tok.AccessToken != "refreshed-token" {
+		t.Errorf("AccessToken = %q, want refreshed-token", tok.AccessToken)
+	}
+}
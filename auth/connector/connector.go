@@ -0,0 +1,96 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package connector defines the identity-provider connector interface
+// goblet uses to resolve caller identity for the proxy, modeled on the
+// connector pattern used by identity brokers like Dex. Concrete connectors
+// (oidc, github, keycloak, gitlab) live in sibling packages so deployments
+// only pull in the dependencies of the connectors they enable.
+package connector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// ConnectorHeader is the request header clients use to select a connector
+// by name, e.g. "X-Goblet-Connector: github".
+const ConnectorHeader = "X-Goblet-Connector"
+
+// Identity is the resolved caller identity, handed to
+// goblet.ServerConfig.RequestAuthorizer for scope decisions.
+type Identity struct {
+	// Subject is the connector-scoped unique identifier for the caller.
+	Subject string
+	// Email, if the connector's credentials carried one.
+	Email string
+	// Groups the caller belongs to, if any.
+	Groups []string
+	// ConnectorName is the name of the Connector that produced this
+	// identity, e.g. "oidc" or "github".
+	ConnectorName string
+	// Extra carries connector-specific claims not covered above.
+	Extra map[string]string
+}
+
+// Credentials is the raw material a Connector authenticates, e.g. a bearer
+// token or an authorization code.
+type Credentials struct {
+	// Token is the bearer token or authorization code presented by the
+	// caller.
+	Token string
+}
+
+// Connector authenticates Credentials into an Identity and can refresh a
+// previously-issued Identity without requiring the caller to
+// re-authenticate from scratch.
+type Connector interface {
+	// Name identifies the connector, e.g. "oidc", "github", "keycloak",
+	// "gitlab". This is the value clients select via the
+	// X-Goblet-Connector header.
+	Name() string
+
+	// Authenticate verifies creds and returns the resolved identity.
+	Authenticate(ctx context.Context, creds Credentials) (Identity, error)
+
+	// Refresh re-validates an existing identity, e.g. re-checking
+	// expiry or re-fetching group membership. Connectors that have
+	// nothing to refresh may just re-verify and return the same
+	// identity.
+	Refresh(ctx context.Context, identity Identity) (Identity, error)
+}
+
+// Registry looks up a Connector by name, e.g. from the X-Goblet-Connector
+// header or host-based routing.
+type Registry map[string]Connector
+
+// Get returns the connector registered under name.
+func (r Registry) Get(name string) (Connector, error) {
+	c, ok := r[name]
+	if !ok {
+		return nil, fmt.Errorf("no connector registered for %q", name)
+	}
+	return c, nil
+}
+
+// FromRequest picks the connector for r, honoring the X-Goblet-Connector
+// header when present and falling back to defaultName otherwise.
+func FromRequest(r *http.Request, registry Registry, defaultName string) (Connector, error) {
+	name := r.Header.Get(ConnectorHeader)
+	if name == "" {
+		name = defaultName
+	}
+	return registry.Get(name)
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const githubUserAPI = "https://api.github.com/user"
+
+// GitHubConnector resolves a GitHub access token to the caller's GitHub
+// identity. It does not exchange OAuth codes itself; ClientID and
+// ClientSecret are not used by Authenticate.
+type GitHubConnector struct {
+	ClientID     string
+	ClientSecret string
+	HTTPClient   *http.Client
+}
+
+// Name implements Connector.
+func (c *GitHubConnector) Name() string { return "github" }
+
+// Authenticate implements Connector. creds.Token is treated as an
+// already-exchanged GitHub access token; callers that only have an
+// authorization code must exchange it before calling Authenticate.
+func (c *GitHubConnector) Authenticate(ctx context.Context, creds Credentials) (Identity, error) {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, githubUserAPI, nil)
+	if err != nil {
+		return Identity{}, fmt.Errorf("github: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+creds.Token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Identity{}, fmt.Errorf("github: cannot reach %s: %w", githubUserAPI, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Identity{}, fmt.Errorf("github: %s returned %d", githubUserAPI, resp.StatusCode)
+	}
+
+	var user struct {
+		Login string `json:"login"`
+		ID    int64  `json:"id"`
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return Identity{}, fmt.Errorf("github: cannot parse user response: %w", err)
+	}
+
+	return Identity{
+		Subject:       fmt.Sprintf("%d", user.ID),
+		Email:         user.Email,
+		ConnectorName: c.Name(),
+		Extra:         map[string]string{"login": user.Login},
+	}, nil
+}
+
+// Refresh implements Connector by returning identity unchanged; it makes
+// no GitHub API call.
+func (c *GitHubConnector) Refresh(ctx context.Context, identity Identity) (Identity, error) {
+	return identity, nil
+}
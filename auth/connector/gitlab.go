@@ -0,0 +1,84 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const defaultGitLabAPIBase = "https://gitlab.com/api/v4"
+
+// GitLabConnector resolves a GitLab personal/project access token to the
+// caller's GitLab identity, for deployments mirroring repositories from
+// gitlab.com (or a self-managed instance via APIBase).
+type GitLabConnector struct {
+	// APIBase defaults to https://gitlab.com/api/v4.
+	APIBase    string
+	HTTPClient *http.Client
+}
+
+// Name implements Connector.
+func (c *GitLabConnector) Name() string { return "gitlab" }
+
+// Authenticate implements Connector.
+func (c *GitLabConnector) Authenticate(ctx context.Context, creds Credentials) (Identity, error) {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	base := c.APIBase
+	if base == "" {
+		base = defaultGitLabAPIBase
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/user", nil)
+	if err != nil {
+		return Identity{}, fmt.Errorf("gitlab: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+creds.Token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Identity{}, fmt.Errorf("gitlab: cannot reach %s: %w", base, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Identity{}, fmt.Errorf("gitlab: %s/user returned %d", base, resp.StatusCode)
+	}
+
+	var user struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+		Email    string `json:"email"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return Identity{}, fmt.Errorf("gitlab: cannot parse user response: %w", err)
+	}
+
+	return Identity{
+		Subject:       fmt.Sprintf("%d", user.ID),
+		Email:         user.Email,
+		ConnectorName: c.Name(),
+		Extra:         map[string]string{"username": user.Username},
+	}, nil
+}
+
+// Refresh implements Connector.
+func (c *GitLabConnector) Refresh(ctx context.Context, identity Identity) (Identity, error) {
+	return identity, nil
+}
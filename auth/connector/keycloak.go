@@ -0,0 +1,72 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/goblet/auth/oidc"
+)
+
+// KeycloakConnector authenticates callers against a Keycloak realm. It
+// wraps the generic OIDC verifier but additionally surfaces Keycloak's
+// "realm_access.roles" claim as Identity.Groups, since Keycloak does not
+// populate the standard "groups" claim by default.
+type KeycloakConnector struct {
+	Realm    string
+	verifier *oidc.Verifier
+}
+
+// NewKeycloakConnector creates a KeycloakConnector for realm, backed by
+// verifier (constructed against the realm's issuer URL).
+func NewKeycloakConnector(realm string, verifier *oidc.Verifier) *KeycloakConnector {
+	return &KeycloakConnector{Realm: realm, verifier: verifier}
+}
+
+// Name implements Connector.
+func (c *KeycloakConnector) Name() string { return "keycloak" }
+
+// Authenticate implements Connector.
+func (c *KeycloakConnector) Authenticate(ctx context.Context, creds Credentials) (Identity, error) {
+	idToken, err := c.verifier.VerifyIDToken(ctx, creds.Token)
+	if err != nil {
+		return Identity{}, fmt.Errorf("keycloak(%s): %w", c.Realm, err)
+	}
+
+	var claims struct {
+		Subject     string `json:"sub"`
+		Email       string `json:"email"`
+		RealmAccess struct {
+			Roles []string `json:"roles"`
+		} `json:"realm_access"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return Identity{}, fmt.Errorf("keycloak(%s): cannot parse claims: %w", c.Realm, err)
+	}
+
+	return Identity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		Groups:        claims.RealmAccess.Roles,
+		ConnectorName: c.Name(),
+		Extra:         map[string]string{"realm": c.Realm},
+	}, nil
+}
+
+// Refresh implements Connector.
+func (c *KeycloakConnector) Refresh(ctx context.Context, identity Identity) (Identity, error) {
+	return identity, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/goblet/auth/oidc"
+)
+
+// OIDCConnector authenticates callers via generic OIDC discovery + JWKS
+// verification, delegating to the existing auth/oidc.Verifier.
+type OIDCConnector struct {
+	verifier *oidc.Verifier
+}
+
+// NewOIDCConnector creates an OIDCConnector backed by verifier.
+func NewOIDCConnector(verifier *oidc.Verifier) *OIDCConnector {
+	return &OIDCConnector{verifier: verifier}
+}
+
+// Name implements Connector.
+func (c *OIDCConnector) Name() string { return "oidc" }
+
+// Authenticate implements Connector.
+func (c *OIDCConnector) Authenticate(ctx context.Context, creds Credentials) (Identity, error) {
+	idToken, err := c.verifier.VerifyIDToken(ctx, creds.Token)
+	if err != nil {
+		return Identity{}, fmt.Errorf("oidc: %w", err)
+	}
+	claims, err := oidc.GetClaims(idToken)
+	if err != nil {
+		return Identity{}, fmt.Errorf("oidc: %w", err)
+	}
+	return Identity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		Groups:        claims.Groups,
+		ConnectorName: c.Name(),
+	}, nil
+}
+
+// Refresh implements Connector. OIDC ID tokens aren't refreshable in place,
+// so this returns identity unchanged without re-verifying any token.
+func (c *OIDCConnector) Refresh(ctx context.Context, identity Identity) (Identity, error) {
+	return identity, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+type stubConnector struct{ name string }
+
+func (s *stubConnector) Name() string { return s.name }
+func (s *stubConnector) Authenticate(ctx context.Context, creds Credentials) (Identity, error) {
+	return Identity{Subject: "stub-" + creds.Token, ConnectorName: s.name}, nil
+}
+func (s *stubConnector) Refresh(ctx context.Context, identity Identity) (Identity, error) {
+	return identity, nil
+}
+
+func TestRegistryGet(t *testing.T) {
+	reg := Registry{"github": &stubConnector{name: "github"}}
+
+	if _, err := reg.Get("unknown"); err == nil {
+		t.Error("Get(\"unknown\") should error")
+	}
+
+	c, err := reg.Get("github")
+	if err != nil {
+		t.Fatalf("Get(\"github\") error = %v", err)
+	}
+	if c.Name() != "github" {
+		t.Errorf("Name() = %q, want github", c.Name())
+	}
+}
+
+func TestFromRequestHeader(t *testing.T) {
+	reg := Registry{"github": &stubConnector{name: "github"}}
+	r, _ := http.NewRequest("GET", "/github.com/org/repo/info/refs", nil)
+	r.Header.Set("X-Goblet-Connector", "github")
+
+	c, err := FromRequest(r, reg, "oidc")
+	if err != nil {
+		t.Fatalf("FromRequest() error = %v", err)
+	}
+	if c.Name() != "github" {
+		t.Errorf("Name() = %q, want github", c.Name())
+	}
+}
+
+func TestFromRequestFallsBackToDefault(t *testing.T) {
+	reg := Registry{"oidc": &stubConnector{name: "oidc"}}
+	r, _ := http.NewRequest("GET", "/github.com/org/repo/info/refs", nil)
+
+	c, err := FromRequest(r, reg, "oidc")
+	if err != nil {
+		t.Fatalf("FromRequest() error = %v", err)
+	}
+	if c.Name() != "oidc" {
+		t.Errorf("Name() = %q, want oidc", c.Name())
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connector
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RefreshRecord is the server-side state for a cached upstream credential,
+// keyed by a stable ID so audit tooling and "tokens issued to user X"
+// lookups keep working across rotations. The opaque refresh_token handed
+// to clients encodes {ID, Nonce}; redeeming it only succeeds if Nonce
+// matches what's stored here, per RFC 6819 §5.2.2.3 (reuse detection).
+type RefreshRecord struct {
+	ID         string
+	Nonce      string
+	IssuedAt   time.Time
+	LastUsedAt time.Time
+	Revoked    bool
+}
+
+// TokenStore persists RefreshRecords and performs the atomic
+// verify-then-rotate step a refresh redemption needs.
+type TokenStore interface {
+	// Create issues a new record for a fresh login and returns its
+	// opaque refresh token.
+	Create(id string) (refreshToken string, err error)
+
+	// Redeem verifies refreshToken against the stored nonce for its ID.
+	// On success it atomically bumps the nonce and returns the new
+	// opaque refresh token to hand back to the caller. On a nonce
+	// mismatch (token reuse), the entire chain for that ID is revoked
+	// and an error is returned.
+	Redeem(refreshToken string) (newRefreshToken string, record RefreshRecord, err error)
+
+	// Get returns the current record for id, for audit/inspection.
+	Get(id string) (RefreshRecord, bool)
+}
+
+// encodeToken packs {id, nonce} into the opaque token handed to clients.
+func encodeToken(id, nonce string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id)) + "." + nonce
+}
+
+func decodeToken(token string) (id, nonce string, err error) {
+	for i := len(token) - 1; i >= 0; i-- {
+		if token[i] == '.' {
+			idBytes, err := base64.RawURLEncoding.DecodeString(token[:i])
+			if err != nil {
+				return "", "", fmt.Errorf("malformed refresh token: %w", err)
+			}
+			return string(idBytes), token[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("malformed refresh token: missing nonce separator")
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cannot generate nonce: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// MemoryTokenStore is an in-process TokenStore, suitable for tests and
+// single-replica deployments.
+type MemoryTokenStore struct {
+	mu      sync.Mutex
+	records map[string]RefreshRecord
+	now     func() time.Time
+}
+
+// NewMemoryTokenStore creates an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{records: map[string]RefreshRecord{}, now: time.Now}
+}
+
+// Create implements TokenStore.
+func (s *MemoryTokenStore) Create(id string) (string, error) {
+	nonce, err := newNonce()
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.records[id] = RefreshRecord{ID: id, Nonce: nonce, IssuedAt: now, LastUsedAt: now}
+	return encodeToken(id, nonce), nil
+}
+
+// Redeem implements TokenStore.
+func (s *MemoryTokenStore) Redeem(refreshToken string) (string, RefreshRecord, error) {
+	id, nonce, err := decodeToken(refreshToken)
+	if err != nil {
+		return "", RefreshRecord{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[id]
+	if !ok || rec.Revoked {
+		return "", RefreshRecord{}, fmt.Errorf("refresh token for %q is revoked or unknown", id)
+	}
+	if rec.Nonce != nonce {
+		// Reuse of a stale nonce: revoke the whole chain.
+		rec.Revoked = true
+		s.records[id] = rec
+		return "", RefreshRecord{}, fmt.Errorf("refresh token reuse detected for %q, chain revoked", id)
+	}
+
+	newNonceVal, err := newNonce()
+	if err != nil {
+		return "", RefreshRecord{}, err
+	}
+	rec.Nonce = newNonceVal
+	rec.LastUsedAt = s.now()
+	s.records[id] = rec
+
+	return encodeToken(id, newNonceVal), rec, nil
+}
+
+// Get implements TokenStore.
+func (s *MemoryTokenStore) Get(id string) (RefreshRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[id]
+	return rec, ok
+}
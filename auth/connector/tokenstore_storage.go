@@ -0,0 +1,149 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/google/goblet/storage"
+)
+
+// StorageTokenStore persists RefreshRecords as JSON objects in a
+// storage.Provider (e.g. the Minio-backed S3Provider), so refresh state
+// survives restarts and is shared across replicas.
+//
+// Cross-replica atomicity is only as strong as the storage backend's
+// read-modify-write support; this implementation serializes Redeem calls
+// within a single process with a mutex and is safe for single-replica
+// deployments. Multi-replica deployments should use a backend with
+// conditional writes (see the content-addressed write/read work) to close
+// the race between the read and the write below.
+type StorageTokenStore struct {
+	provider storage.Provider
+	prefix   string
+	mu       sync.Mutex
+}
+
+// NewStorageTokenStore creates a StorageTokenStore that stores records
+// under "<prefix>/<id>.json".
+func NewStorageTokenStore(provider storage.Provider, prefix string) *StorageTokenStore {
+	return &StorageTokenStore{provider: provider, prefix: prefix}
+}
+
+func (s *StorageTokenStore) path(id string) string {
+	return s.prefix + "/" + id + ".json"
+}
+
+func (s *StorageTokenStore) read(ctx context.Context, id string) (RefreshRecord, bool, error) {
+	r, err := s.provider.Reader(ctx, s.path(id))
+	if err != nil {
+		return RefreshRecord{}, false, nil
+	}
+	defer r.Close()
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return RefreshRecord{}, false, fmt.Errorf("cannot read refresh record %q: %w", id, err)
+	}
+	var rec RefreshRecord
+	if err := json.Unmarshal(bs, &rec); err != nil {
+		return RefreshRecord{}, false, fmt.Errorf("cannot parse refresh record %q: %w", id, err)
+	}
+	return rec, true, nil
+}
+
+func (s *StorageTokenStore) write(ctx context.Context, rec RefreshRecord) error {
+	w, err := s.provider.Writer(ctx, s.path(rec.ID))
+	if err != nil {
+		return fmt.Errorf("cannot open refresh record %q for write: %w", rec.ID, err)
+	}
+	bs, err := json.Marshal(rec)
+	if err != nil {
+		_ = w.Close()
+		return fmt.Errorf("cannot marshal refresh record %q: %w", rec.ID, err)
+	}
+	if _, err := w.Write(bs); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("cannot write refresh record %q: %w", rec.ID, err)
+	}
+	return w.Close()
+}
+
+// Create implements TokenStore.
+func (s *StorageTokenStore) Create(id string) (string, error) {
+	nonce, err := newNonce()
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := context.Background()
+	rec := RefreshRecord{ID: id, Nonce: nonce}
+	if err := s.write(ctx, rec); err != nil {
+		return "", err
+	}
+	return encodeToken(id, nonce), nil
+}
+
+// Redeem implements TokenStore.
+func (s *StorageTokenStore) Redeem(refreshToken string) (string, RefreshRecord, error) {
+	id, nonce, err := decodeToken(refreshToken)
+	if err != nil {
+		return "", RefreshRecord{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx := context.Background()
+	rec, ok, err := s.read(ctx, id)
+	if err != nil {
+		return "", RefreshRecord{}, err
+	}
+	if !ok || rec.Revoked {
+		return "", RefreshRecord{}, fmt.Errorf("refresh token for %q is revoked or unknown", id)
+	}
+	if rec.Nonce != nonce {
+		rec.Revoked = true
+		_ = s.write(ctx, rec)
+		return "", RefreshRecord{}, fmt.Errorf("refresh token reuse detected for %q, chain revoked", id)
+	}
+
+	newNonceVal, err := newNonce()
+	if err != nil {
+		return "", RefreshRecord{}, err
+	}
+	rec.Nonce = newNonceVal
+	if err := s.write(ctx, rec); err != nil {
+		return "", RefreshRecord{}, err
+	}
+	return encodeToken(id, newNonceVal), rec, nil
+}
+
+// Get implements TokenStore.
+func (s *StorageTokenStore) Get(id string) (RefreshRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok, err := s.read(context.Background(), id)
+	if err != nil || !ok {
+		return RefreshRecord{}, false
+	}
+	return rec, true
+}
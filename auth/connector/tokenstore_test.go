@@ -0,0 +1,81 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connector
+
+import "testing"
+
+func TestMemoryTokenStoreRotation(t *testing.T) {
+	store := NewMemoryTokenStore()
+
+	token1, err := store.Create("user-1")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	token2, rec, err := store.Redeem(token1)
+	if err != nil {
+		t.Fatalf("Redeem(token1) error = %v", err)
+	}
+	if rec.ID != "user-1" {
+		t.Errorf("record ID = %q, want user-1", rec.ID)
+	}
+	if token2 == token1 {
+		t.Error("Redeem should rotate to a new refresh token")
+	}
+
+	if _, _, err := store.Redeem(token2); err != nil {
+		t.Errorf("Redeem(token2) should succeed: %v", err)
+	}
+}
+
+func TestMemoryTokenStoreReuseRevokesChain(t *testing.T) {
+	store := NewMemoryTokenStore()
+
+	token1, err := store.Create("user-1")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	token2, _, err := store.Redeem(token1)
+	if err != nil {
+		t.Fatalf("Redeem(token1) error = %v", err)
+	}
+
+	// Reusing the stale token1 should revoke the whole chain.
+	if _, _, err := store.Redeem(token1); err == nil {
+		t.Fatal("reusing a stale refresh token should fail")
+	}
+
+	if _, _, err := store.Redeem(token2); err == nil {
+		t.Error("chain should be revoked after reuse is detected, but the current token still worked")
+	}
+}
+
+func TestMemoryTokenStoreGet(t *testing.T) {
+	store := NewMemoryTokenStore()
+	if _, ok := store.Get("missing"); ok {
+		t.Error("Get(\"missing\") should return ok=false")
+	}
+
+	if _, err := store.Create("user-2"); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	rec, ok := store.Get("user-2")
+	if !ok {
+		t.Fatal("Get(\"user-2\") should find the created record")
+	}
+	if rec.Revoked {
+		t.Error("freshly created record should not be revoked")
+	}
+}
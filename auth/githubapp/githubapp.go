@@ -0,0 +1,271 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package githubapp implements a goblet TokenSource backed by a GitHub
+// App: it mints a short-lived app JWT, resolves the installation that
+// covers the requested upstream's org/repo, exchanges that for an
+// installation access token, and caches the result per owner/repo
+// until GitHub's own expiry approaches.
+package githubapp
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultBaseURL = "https://api.github.com"
+	appJWTTTL      = 9 * time.Minute // GitHub caps this at 10 minutes.
+)
+
+// TokenSource mints GitHub App installation tokens for goblet's
+// ServerConfig.TokenSource hook. The zero value is not usable; construct
+// one with New.
+type TokenSource struct {
+	// AppID is the GitHub App's numeric ID, used as the JWT issuer.
+	AppID int64
+	// PrivateKey signs the app JWT; GitHub App private keys are RSA.
+	PrivateKey *rsa.PrivateKey
+	// BaseURL is the GitHub API root, overridden for GitHub Enterprise
+	// Server (e.g. "https://ghe.example.com/api/v3"). Defaults to
+	// defaultBaseURL.
+	BaseURL string
+	// HTTPClient is used for all GitHub API calls. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+	// InstallationIDs, if set, maps a GitHub org/owner to its
+	// installation ID, skipping the GET /repos/{owner}/{repo}/installation
+	// lookup for owners it covers. Owners not present here still fall
+	// back to that lookup.
+	InstallationIDs map[string]int64
+	// Repositories, if non-empty, is passed to the access-token exchange
+	// to scope the minted token to only these repository names, instead
+	// of every repository the installation covers.
+	Repositories []string
+	// Permissions, if non-empty, is passed to the access-token exchange
+	// to scope the minted token down to a subset of the installation's
+	// permissions (GitHub's documented permission names, e.g.
+	// "contents": "read").
+	Permissions map[string]string
+
+	mu           sync.Mutex
+	installation map[string]*cachedInstallationToken // keyed by owner/repo
+	group        singleflight.Group                  // keyed by owner/repo, collapses concurrent mints
+}
+
+type cachedInstallationToken struct {
+	token  *oauth2.Token
+	expiry time.Time
+}
+
+// New returns a TokenSource for the given GitHub App ID, signing
+// installation token requests with privateKey.
+func New(appID int64, privateKey *rsa.PrivateKey) *TokenSource {
+	return &TokenSource{AppID: appID, PrivateKey: privateKey}
+}
+
+func (ts *TokenSource) baseURL() string {
+	if ts.BaseURL != "" {
+		return strings.TrimSuffix(ts.BaseURL, "/")
+	}
+	return defaultBaseURL
+}
+
+func (ts *TokenSource) httpClient() *http.Client {
+	if ts.HTTPClient != nil {
+		return ts.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// Token implements goblet's TokenSource hook: given the upstream repo
+// URL, it returns a Bearer token scoped to that repo's installation,
+// reusing a cached one until it's within a minute of GitHub's expiry.
+// Concurrent calls for the same owner/repo that both miss the cache are
+// collapsed by ts.group into a single installation-resolution-plus-mint
+// round trip.
+func (ts *TokenSource) Token(upstreamURL *url.URL) (*oauth2.Token, error) {
+	owner, repo, err := ownerAndRepo(upstreamURL)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := owner + "/" + repo
+
+	ts.mu.Lock()
+	if cached, ok := ts.installation[cacheKey]; ok && time.Now().Before(cached.expiry) {
+		tok := cached.token
+		ts.mu.Unlock()
+		return tok, nil
+	}
+	ts.mu.Unlock()
+
+	v, err, _ := ts.group.Do(cacheKey, func() (interface{}, error) {
+		installationID, ok := ts.InstallationIDs[owner]
+		if !ok {
+			var err error
+			installationID, err = ts.installationID(owner, repo)
+			if err != nil {
+				return nil, fmt.Errorf("cannot resolve installation for %s/%s: %w", owner, repo, err)
+			}
+		}
+		tok, expiry, err := ts.mintInstallationToken(installationID)
+		if err != nil {
+			return nil, fmt.Errorf("cannot mint installation token for %s/%s: %w", owner, repo, err)
+		}
+
+		ts.mu.Lock()
+		if ts.installation == nil {
+			ts.installation = make(map[string]*cachedInstallationToken)
+		}
+		ts.installation[cacheKey] = &cachedInstallationToken{token: tok, expiry: expiry.Add(-time.Minute)}
+		ts.mu.Unlock()
+
+		return tok, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("githubapp: %w", err)
+	}
+	return v.(*oauth2.Token), nil
+}
+
+// TenantID resolves upstreamURL's GitHub organization into a tenant
+// identifier for IsolationConfig.Mode == IsolationTenant, so cache
+// directories come out as /cache/tenant-<org>/github.com/<org>/<repo>
+// without the caller having to re-derive the org from the URL itself.
+func (ts *TokenSource) TenantID(upstreamURL *url.URL) (string, error) {
+	owner, _, err := ownerAndRepo(upstreamURL)
+	if err != nil {
+		return "", err
+	}
+	return owner, nil
+}
+
+// ownerAndRepo extracts "owner" and "repo" from an upstream URL of the
+// form https://github.com/<owner>/<repo>(.git).
+func ownerAndRepo(u *url.URL) (owner, repo string, err error) {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("githubapp: cannot parse owner/repo from %s", u)
+	}
+	return parts[0], strings.TrimSuffix(parts[1], ".git"), nil
+}
+
+// appJWT mints a short-lived JWT identifying the App itself, used to
+// authenticate the installation-resolution and token-minting calls.
+func (ts *TokenSource) appJWT() (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		IssuedAt:  jwt.NewNumericDate(now.Add(-60 * time.Second)), // tolerate clock drift
+		ExpiresAt: jwt.NewNumericDate(now.Add(appJWTTTL)),
+		Issuer:    fmt.Sprintf("%d", ts.AppID),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(ts.PrivateKey)
+}
+
+// installationID looks up the installation ID covering owner/repo via
+// GET /repos/{owner}/{repo}/installation.
+func (ts *TokenSource) installationID(owner, repo string) (int64, error) {
+	appToken, err := ts.appJWT()
+	if err != nil {
+		return 0, fmt.Errorf("cannot sign app JWT: %w", err)
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/repos/%s/%s/installation", ts.baseURL(), owner, repo), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+appToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := ts.httpClient().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("installation lookup returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("cannot parse installation response: %w", err)
+	}
+	return body.ID, nil
+}
+
+// mintInstallationToken exchanges the App's identity for an installation
+// access token via POST /app/installations/{id}/access_tokens, narrowing
+// it to ts.Repositories / ts.Permissions when either is set.
+func (ts *TokenSource) mintInstallationToken(installationID int64) (*oauth2.Token, time.Time, error) {
+	appToken, err := ts.appJWT()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("cannot sign app JWT: %w", err)
+	}
+
+	var reqBody io.Reader
+	if len(ts.Repositories) > 0 || len(ts.Permissions) > 0 {
+		body, err := json.Marshal(struct {
+			Repositories []string          `json:"repositories,omitempty"`
+			Permissions  map[string]string `json:"permissions,omitempty"`
+		}{ts.Repositories, ts.Permissions})
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("cannot encode access-token request: %w", err)
+		}
+		reqBody = bytes.NewReader(body)
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", ts.baseURL(), installationID)
+	req, err := http.NewRequest("POST", url, reqBody)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+appToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := ts.httpClient().Do(req)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, time.Time{}, fmt.Errorf("access-token exchange returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, time.Time{}, fmt.Errorf("cannot parse access-token response: %w", err)
+	}
+
+	return &oauth2.Token{AccessToken: body.Token, TokenType: "Bearer", Expiry: body.ExpiresAt}, body.ExpiresAt, nil
+}
@@ -0,0 +1,227 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package githubapp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestTokenSource(t *testing.T, server *httptest.Server) *TokenSource {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate test key: %v", err)
+	}
+	ts := New(42, key)
+	ts.BaseURL = server.URL
+	return ts
+}
+
+// newTestGitHubServer stubs the two GitHub App endpoints this package
+// calls, verifying the app JWT on each request and handing out a
+// distinct installation token per call so tests can tell a fresh mint
+// apart from a cache hit.
+func newTestGitHubServer(t *testing.T, appID int64, publicKey *rsa.PublicKey) (*httptest.Server, *int32) {
+	t.Helper()
+	var mintCount int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/an-org/a-repo/installation", func(w http.ResponseWriter, r *http.Request) {
+		if !validAppJWT(t, r, appID, publicKey) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"id": 1234}`)
+	})
+	mux.HandleFunc("/app/installations/1234/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !validAppJWT(t, r, appID, publicKey) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := atomic.AddInt32(&mintCount, 1)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "installation-token-%d", "expires_at": %q}`, n, time.Now().Add(time.Hour).Format(time.RFC3339))
+	})
+	return httptest.NewServer(mux), &mintCount
+}
+
+func validAppJWT(t *testing.T, r *http.Request, wantAppID int64, publicKey *rsa.PublicKey) bool {
+	t.Helper()
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	token, err := jwt.ParseWithClaims(strings.TrimPrefix(auth, "Bearer "), &jwt.RegisteredClaims{}, func(*jwt.Token) (interface{}, error) {
+		return publicKey, nil
+	})
+	if err != nil || !token.Valid {
+		return false
+	}
+	claims := token.Claims.(*jwt.RegisteredClaims)
+	return claims.Issuer == fmt.Sprintf("%d", wantAppID)
+}
+
+func TestTokenSource_Token(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate test key: %v", err)
+	}
+	server, mintCount := newTestGitHubServer(t, 42, &key.PublicKey)
+	defer server.Close()
+
+	ts := New(42, key)
+	ts.BaseURL = server.URL
+
+	upstreamURL, _ := url.Parse("https://github.com/an-org/a-repo")
+
+	tok, err := ts.Token(upstreamURL)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if tok.AccessToken != "installation-token-1" {
+		t.Errorf("AccessToken = %q, want installation-token-1", tok.AccessToken)
+	}
+	if tok.TokenType != "Bearer" {
+		t.Errorf("TokenType = %q, want Bearer", tok.TokenType)
+	}
+
+	// A second call for the same repo should be served from cache, not
+	// mint another installation token.
+	if _, err := ts.Token(upstreamURL); err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if got := atomic.LoadInt32(mintCount); got != 1 {
+		t.Errorf("installation token minted %d times, want 1 (second call should hit the cache)", got)
+	}
+}
+
+func TestTokenSource_TenantID(t *testing.T) {
+	ts := newTestTokenSource(t, httptest.NewServer(http.NotFoundHandler()))
+	upstreamURL, _ := url.Parse("https://github.com/an-org/a-repo")
+
+	tenant, err := ts.TenantID(upstreamURL)
+	if err != nil {
+		t.Fatalf("TenantID() error = %v", err)
+	}
+	if tenant != "an-org" {
+		t.Errorf("TenantID() = %q, want %q", tenant, "an-org")
+	}
+}
+
+func TestTokenSource_InstallationIDsSkipsLookup(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate test key: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/an-org/a-repo/installation", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("installation lookup should be skipped when InstallationIDs covers the owner")
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/app/installations/999/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		if !validAppJWT(t, r, 42, &key.PublicKey) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "installation-token", "expires_at": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ts := New(42, key)
+	ts.BaseURL = server.URL
+	ts.InstallationIDs = map[string]int64{"an-org": 999}
+
+	upstreamURL, _ := url.Parse("https://github.com/an-org/a-repo")
+	tok, err := ts.Token(upstreamURL)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if tok.AccessToken != "installation-token" {
+		t.Errorf("AccessToken = %q, want installation-token", tok.AccessToken)
+	}
+}
+
+// TestTokenSource_ScopesAccessTokenRequest checks that a non-empty
+// Repositories/Permissions configuration is sent as the JSON body of the
+// access-token exchange, narrowing the minted token as GitHub documents.
+func TestTokenSource_ScopesAccessTokenRequest(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate test key: %v", err)
+	}
+
+	var gotBody struct {
+		Repositories []string          `json:"repositories"`
+		Permissions  map[string]string `json:"permissions"`
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/an-org/a-repo/installation", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": 1234}`)
+	})
+	mux.HandleFunc("/app/installations/1234/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			t.Fatalf("cannot decode access-token request body: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "scoped-token", "expires_at": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ts := New(42, key)
+	ts.BaseURL = server.URL
+	ts.Repositories = []string{"a-repo"}
+	ts.Permissions = map[string]string{"contents": "read"}
+
+	upstreamURL, _ := url.Parse("https://github.com/an-org/a-repo")
+	if _, err := ts.Token(upstreamURL); err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	if len(gotBody.Repositories) != 1 || gotBody.Repositories[0] != "a-repo" {
+		t.Errorf("access-token request repositories = %v, want [a-repo]", gotBody.Repositories)
+	}
+	if gotBody.Permissions["contents"] != "read" {
+		t.Errorf("access-token request permissions = %v, want contents=read", gotBody.Permissions)
+	}
+}
+
+func TestTokenSource_TokenRejectsUnparsableURL(t *testing.T) {
+	ts := newTestTokenSource(t, httptest.NewServer(http.NotFoundHandler()))
+	upstreamURL, _ := url.Parse("https://github.com/only-an-org")
+
+	if _, err := ts.Token(upstreamURL); err == nil {
+		t.Error("Token() error = nil, want an error for a URL with no repo segment")
+	}
+}
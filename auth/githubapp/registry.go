@@ -0,0 +1,83 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package githubapp
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/google/goblet"
+)
+
+func init() {
+	goblet.RegisterTokenSourceFactory("githubapp", newFromProviderConfig)
+}
+
+// providerConfig is the goblet.Registry provider_config schema for the
+// "githubapp" provider.
+type providerConfig struct {
+	AppID           int64             `json:"app_id"`
+	PrivateKeyPEM   string            `json:"private_key_pem"`
+	BaseURL         string            `json:"base_url,omitempty"`
+	InstallationIDs map[string]int64  `json:"installation_ids,omitempty"`
+	Repositories    []string          `json:"repositories,omitempty"`
+	Permissions     map[string]string `json:"permissions,omitempty"`
+}
+
+func newFromProviderConfig(raw json.RawMessage) (goblet.URLTokenSource, error) {
+	var cfg providerConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("githubapp: cannot parse provider config: %w", err)
+	}
+	if cfg.AppID == 0 {
+		return nil, fmt.Errorf("githubapp: provider config missing app_id")
+	}
+	block, _ := pem.Decode([]byte(cfg.PrivateKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("githubapp: provider config private_key_pem is not valid PEM")
+	}
+	key, err := parsePrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("githubapp: cannot parse private_key_pem: %w", err)
+	}
+
+	ts := New(cfg.AppID, key)
+	ts.BaseURL = cfg.BaseURL
+	ts.InstallationIDs = cfg.InstallationIDs
+	ts.Repositories = cfg.Repositories
+	ts.Permissions = cfg.Permissions
+	return goblet.URLTokenSourceFunc(ts.Token), nil
+}
+
+// parsePrivateKey accepts both PKCS#1 ("RSA PRIVATE KEY") and PKCS#8
+// ("PRIVATE KEY") encodings, since GitHub App keys are commonly
+// distributed in either form.
+func parsePrivateKey(der []byte) (*rsa.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not RSA")
+	}
+	return rsaKey, nil
+}
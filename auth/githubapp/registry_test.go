@@ -0,0 +1,75 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package githubapp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+)
+
+func TestNewFromProviderConfigRejectsMissingAppID(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate test key: %v", err)
+	}
+	raw, _ := json.Marshal(providerConfig{PrivateKeyPEM: encodePKCS1(key)})
+	if _, err := newFromProviderConfig(raw); err == nil {
+		t.Error("newFromProviderConfig() succeeded with no app_id, want an error")
+	}
+}
+
+func TestNewFromProviderConfigRejectsInvalidPEM(t *testing.T) {
+	raw, _ := json.Marshal(providerConfig{AppID: 42, PrivateKeyPEM: "not a pem block"})
+	if _, err := newFromProviderConfig(raw); err == nil {
+		t.Error("newFromProviderConfig() succeeded with invalid PEM, want an error")
+	}
+}
+
+func TestNewFromProviderConfigAcceptsPKCS1AndPKCS8(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate test key: %v", err)
+	}
+
+	for name, pemStr := range map[string]string{"PKCS1": encodePKCS1(key), "PKCS8": encodePKCS8(t, key)} {
+		t.Run(name, func(t *testing.T) {
+			raw, _ := json.Marshal(providerConfig{AppID: 42, PrivateKeyPEM: pemStr})
+			src, err := newFromProviderConfig(raw)
+			if err != nil {
+				t.Fatalf("newFromProviderConfig() error = %v", err)
+			}
+			if src == nil {
+				t.Error("newFromProviderConfig() returned a nil URLTokenSource")
+			}
+		})
+	}
+}
+
+func encodePKCS1(key *rsa.PrivateKey) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+func encodePKCS8(t *testing.T, key *rsa.PrivateKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("cannot marshal PKCS8 key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RequestAuthorizer authorizes Git smart-HTTP requests using the client
+// certificate already verified by the TLS listener (tls.Config.ClientCAs
+// set to the trusted CA bundle, tls.Config.ClientAuth set to
+// tls.RequireAndVerifyClientCert): it does not itself validate the
+// certificate chain, only extracts the principal SignHandler bound into
+// it (CommonName, falling back to the first DNS SAN) and, if
+// AllowedPrincipals is non-empty, checks it against that list. Its
+// AuthorizeRequest signature matches goblet.ServerConfig.RequestAuthorizer.
+type RequestAuthorizer struct {
+	// AllowedPrincipals, if non-empty, restricts access to these
+	// principals. Leave empty to allow any client certificate the
+	// listener's ClientCAs pool verified.
+	AllowedPrincipals []string
+}
+
+// NewRequestAuthorizer creates a RequestAuthorizer.
+func NewRequestAuthorizer() *RequestAuthorizer {
+	return &RequestAuthorizer{}
+}
+
+// AuthorizeRequest implements the goblet.ServerConfig.RequestAuthorizer
+// signature.
+func (a *RequestAuthorizer) AuthorizeRequest(r *http.Request) error {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return status.Error(codes.Unauthenticated, "no client certificate presented")
+	}
+	principal := principalFromCert(r.TLS.PeerCertificates[0])
+	if principal == "" {
+		return status.Error(codes.Unauthenticated, "client certificate has no usable CommonName or DNS SAN")
+	}
+	if len(a.AllowedPrincipals) > 0 {
+		found := false
+		for _, p := range a.AllowedPrincipals {
+			if p == principal {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return status.Errorf(codes.PermissionDenied, "certificate principal %q not authorized", principal)
+		}
+	}
+
+	ctx := context.WithValue(r.Context(), principalKey, principal)
+	*r = *r.WithContext(ctx)
+	return nil
+}
+
+// principalFromCert returns the identity SignHandler bound into cert: the
+// CommonName if set, otherwise the first DNS SAN.
+func principalFromCert(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return ""
+}
+
+type contextKey string
+
+const principalKey contextKey = "mtls_principal"
+
+// GetPrincipalFromContext retrieves the client certificate principal
+// RequestAuthorizer.AuthorizeRequest stored in the request context.
+func GetPrincipalFromContext(ctx context.Context) (string, bool) {
+	principal, ok := ctx.Value(principalKey).(string)
+	return principal, ok
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mtls adds mutual-TLS client-certificate authentication to
+// goblet, for CI workloads that would rather present a short-lived
+// client certificate than a bearer token (the pattern smallstep
+// popularized with step-ca).
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// DefaultLeafLifetime is the default validity window for certificates
+// minted by a CertificateAuthority, matching the short-lived-cert pattern.
+const DefaultLeafLifetime = 24 * time.Hour
+
+// CertificateAuthority signs certificate signing requests into short-lived
+// leaf certificates binding the caller's resolved identity into the
+// certificate's Subject/SAN.
+type CertificateAuthority interface {
+	// Sign issues a leaf certificate for csr, with identity recorded in
+	// the certificate (as the CommonName and a DNS SAN) so the HTTPS
+	// listener can extract it back out on future connections.
+	Sign(csr *x509.CertificateRequest, identity string) (*x509.Certificate, error)
+}
+
+// LocalCA is a CertificateAuthority backed by an in-process intermediate
+// certificate, suitable for a built-in, zero-config CA embedded in the
+// goblet server binary.
+type LocalCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+
+	// Lifetime is how long issued leaf certificates are valid for.
+	// Defaults to DefaultLeafLifetime if zero.
+	Lifetime time.Duration
+
+	// Now returns the current time; overridable for tests.
+	Now func() time.Time
+}
+
+// NewLocalCA generates a fresh self-signed intermediate CA certificate and
+// key, embedded for the lifetime of the process.
+func NewLocalCA() (*LocalCA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate CA key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate CA serial: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "goblet built-in intermediate CA"},
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot self-sign CA certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse generated CA certificate: %w", err)
+	}
+
+	return &LocalCA{cert: cert, key: key}, nil
+}
+
+// Certificate returns the CA's own certificate, for building the server's
+// ClientCAPool.
+func (ca *LocalCA) Certificate() *x509.Certificate { return ca.cert }
+
+// Sign implements CertificateAuthority.
+func (ca *LocalCA) Sign(csr *x509.CertificateRequest, identity string) (*x509.Certificate, error) {
+	if identity == "" {
+		return nil, fmt.Errorf("identity must not be empty")
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid CSR signature: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate leaf serial: %w", err)
+	}
+
+	lifetime := ca.Lifetime
+	if lifetime == 0 {
+		lifetime = DefaultLeafLifetime
+	}
+	now := time.Now
+	if ca.Now != nil {
+		now = ca.Now
+	}
+	issuedAt := now()
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: identity},
+		DNSNames:     []string{identity},
+		NotBefore:    issuedAt.Add(-1 * time.Minute),
+		NotAfter:     issuedAt.Add(lifetime),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, csr.PublicKey, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign leaf certificate: %w", err)
+	}
+	return x509.ParseCertificate(der)
+}
+
+// IdentityFromCert extracts the caller identity a LocalCA-issued
+// certificate was bound to, for use by the HTTPS listener once a client
+// cert has been verified against ClientCAPool.
+func IdentityFromCert(cert *x509.Certificate) string {
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return cert.Subject.CommonName
+}
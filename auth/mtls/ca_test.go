@@ -0,0 +1,79 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+)
+
+func generateCSR(t *testing.T, commonName string) (*x509.CertificateRequest, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate key: %v", err)
+	}
+	template := &x509.CertificateRequest{Subject: pkix.Name{CommonName: commonName}}
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		t.Fatalf("cannot create CSR: %v", err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		t.Fatalf("cannot parse CSR: %v", err)
+	}
+	return csr, key
+}
+
+func TestLocalCASignAndVerify(t *testing.T) {
+	ca, err := NewLocalCA()
+	if err != nil {
+		t.Fatalf("NewLocalCA() error = %v", err)
+	}
+
+	csr, _ := generateCSR(t, "ignored-subject")
+	leaf, err := ca.Sign(csr, "ci-job-42@example.com")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Certificate())
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:     pool,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		t.Errorf("issued leaf certificate did not verify against the CA: %v", err)
+	}
+
+	if got := IdentityFromCert(leaf); got != "ci-job-42@example.com" {
+		t.Errorf("IdentityFromCert() = %q, want ci-job-42@example.com", got)
+	}
+}
+
+func TestLocalCARejectsEmptyIdentity(t *testing.T) {
+	ca, err := NewLocalCA()
+	if err != nil {
+		t.Fatalf("NewLocalCA() error = %v", err)
+	}
+	csr, _ := generateCSR(t, "x")
+	if _, err := ca.Sign(csr, ""); err == nil {
+		t.Error("Sign() with empty identity should fail")
+	}
+}
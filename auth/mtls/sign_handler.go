@@ -0,0 +1,80 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+)
+
+// IdentityResolver authenticates the bearer token presented alongside a
+// CSR to /ca/sign and returns the identity to bind into the issued
+// certificate, e.g. by delegating to an auth/connector.Connector.
+type IdentityResolver func(r *http.Request) (identity string, err error)
+
+// SignHandler serves POST /ca/sign: it accepts a PEM-encoded CSR plus an
+// OIDC-issued bearer token (or any bearer token IdentityResolver accepts),
+// and returns a PEM-encoded short-lived client certificate. This is the
+// bridge that lets a CI job exchange its OIDC token for a Git client cert.
+type SignHandler struct {
+	CA       CertificateAuthority
+	Resolver IdentityResolver
+}
+
+// NewSignHandler creates a SignHandler.
+func NewSignHandler(ca CertificateAuthority, resolver IdentityResolver) *SignHandler {
+	return &SignHandler{CA: ca, Resolver: resolver}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *SignHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identity, err := h.Resolver(r)
+	if err != nil {
+		http.Error(w, "unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "cannot read request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	block, _ := pem.Decode(body)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		http.Error(w, "expected a PEM-encoded CERTIFICATE REQUEST", http.StatusUnprocessableEntity)
+		return
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		http.Error(w, "invalid CSR: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	cert, err := h.CA.Sign(csr, identity)
+	if err != nil {
+		http.Error(w, "cannot sign certificate: "+err.Error(), http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	_ = pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
@@ -21,52 +21,194 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/google/goblet/auth/challenge"
+	"github.com/google/goblet/auth/token"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-// Authorizer implements request authorization using OIDC tokens.
+// RepoPolicy further restricts access to repositories matching one of
+// Authorizer.RepoPolicies' prefixes, distinguishing pull (read) from
+// push (write) access. A list left empty imposes no extra restriction
+// for that action beyond Authorizer's repo-wide Allowed* fields; to deny
+// an action entirely for matching repositories, use a list that can
+// never match (e.g. a single placeholder group no one is a member of).
+type RepoPolicy struct {
+	AllowedReadGroups  []string
+	AllowedWriteGroups []string
+	AllowedReadEmails  []string
+	AllowedWriteEmails []string
+}
+
+// authorize reports whether claims may perform action against a
+// repository this policy governs.
+func (p RepoPolicy) authorize(action string, claims *Claims) error {
+	groups, emails := p.AllowedReadGroups, p.AllowedReadEmails
+	if action == token.ActionPush {
+		groups, emails = p.AllowedWriteGroups, p.AllowedWriteEmails
+	}
+	if len(groups) == 0 && len(emails) == 0 {
+		return nil
+	}
+	if matchesAny(groups, claims.Groups) || contains(emails, claims.Email) {
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "not authorized for %s on this repository", action)
+}
+
+// Authorizer authorizes Git smart-HTTP requests using an OIDC bearer
+// token, then evaluates a rule-based policy against the verified
+// claims: repo-wide allow-lists plus optional per-repository-prefix
+// overrides. A zero-value policy (every Allowed* field and RepoPolicies
+// left unset) authorizes any claims that verify, matching the module's
+// previous behavior of treating every valid token as fully authorized.
 type Authorizer struct {
 	verifier *Verifier
+
+	// AllowedGroups, AllowedEmails, and AllowedDomains, if non-empty,
+	// each independently gate access: when set, claims must match at
+	// least one of AllowedGroups or AllowedEmails (whichever are
+	// non-empty) and, separately, an email domain in AllowedDomains if
+	// that list is non-empty.
+	AllowedGroups  []string
+	AllowedEmails  []string
+	AllowedDomains []string
+	// RequireEmailVerified rejects claims whose EmailVerified is false.
+	RequireEmailVerified bool
+	// RepoPolicies maps a repository path prefix (as matched against the
+	// path token.RepoAndActionFromGitPath derives for the request) to a
+	// RepoPolicy that further restricts read/write access for
+	// repositories under that prefix. The longest matching prefix wins;
+	// a request whose path doesn't match any recognized Git smart-HTTP
+	// endpoint, or whose repository matches no prefix here, is governed
+	// only by the repo-wide Allowed* fields above.
+	RepoPolicies map[string]RepoPolicy
+
+	// AllowDevTokens accepts any bearer token of the form "dev-token-*"
+	// as authorized, bypassing VerifyAccessToken and every policy field
+	// above, for local development and testing against a real IdP is
+	// impractical. It defaults to false and must be set explicitly; a
+	// zero-value Authorizer never accepts a dev token.
+	AllowDevTokens bool
 }
 
-// NewAuthorizer creates a new OIDC authorizer.
+// NewAuthorizer creates a new OIDC authorizer backed by verifier. Its
+// policy fields start unset (authorize any token that verifies); set
+// AllowedGroups/AllowedEmails/AllowedDomains/RequireEmailVerified/
+// RepoPolicies on the returned Authorizer to narrow that down.
 func NewAuthorizer(verifier *Verifier) *Authorizer {
 	return &Authorizer{
 		verifier: verifier,
 	}
 }
 
-// AuthorizeRequest authorizes an HTTP request by verifying the OIDC token.
+// AuthorizeRequest authorizes an HTTP request: it verifies the bearer
+// token (via Verifier.VerifyAccessToken, so either a JWT or, if
+// introspection is configured, an opaque access token is accepted), then
+// evaluates the Authorizer's policy against the resulting claims and the
+// repository/action the request is for. Its signature matches
+// goblet.ServerConfig.RequestAuthorizer, so an Authorizer can be plugged
+// in directly, e.g. `cfg.RequestAuthorizer = authorizer.AuthorizeRequest`.
 func (a *Authorizer) AuthorizeRequest(r *http.Request) error {
-	token := ExtractBearerToken(r)
-	if token == "" {
+	tok := ExtractBearerToken(r)
+	if tok == "" {
 		return status.Error(codes.Unauthenticated, "no bearer token found in request")
 	}
 
-	// Try to verify as ID token (JWT format)
-	idToken, err := a.verifier.VerifyIDToken(r.Context(), token)
-	if err != nil {
-		// For development/testing, allow dev tokens
-		if strings.HasPrefix(token, "dev-token-") {
-			return nil
-		}
-		return status.Errorf(codes.Unauthenticated, "failed to verify token: %v", err)
+	// For development/testing, allow dev tokens without a real IdP --
+	// only when explicitly opted into via AllowDevTokens, so this
+	// shortcut can't be reached by a default config.
+	if a.AllowDevTokens && strings.HasPrefix(tok, "dev-token-") {
+		return nil
 	}
 
-	// Extract claims for logging/authorization
-	claims, err := GetClaims(idToken)
+	claims, err := a.verifier.VerifyAccessToken(r.Context(), tok)
 	if err != nil {
-		return status.Errorf(codes.Internal, "failed to extract claims: %v", err)
+		return status.Errorf(codes.Unauthenticated, "failed to verify token: %v", err)
 	}
 
-	// Store claims in context for later use
+	// Store claims in context for later use.
 	ctx := context.WithValue(r.Context(), claimsKey, claims)
 	*r = *r.WithContext(ctx)
 
+	return a.authorize(r, claims)
+}
+
+// authorize evaluates the Authorizer's policy against claims and, if the
+// request is for a repository matching one of RepoPolicies' prefixes,
+// that RepoPolicy's read/write restriction.
+func (a *Authorizer) authorize(r *http.Request, claims *Claims) error {
+	if a.RequireEmailVerified && !claims.EmailVerified {
+		return status.Error(codes.PermissionDenied, "email not verified")
+	}
+	if len(a.AllowedGroups) > 0 || len(a.AllowedEmails) > 0 {
+		if !matchesAny(a.AllowedGroups, claims.Groups) && !contains(a.AllowedEmails, claims.Email) {
+			return status.Error(codes.PermissionDenied, "not authorized")
+		}
+	}
+	if len(a.AllowedDomains) > 0 && !contains(a.AllowedDomains, emailDomain(claims.Email)) {
+		return status.Error(codes.PermissionDenied, "email domain not authorized")
+	}
+
+	if len(a.RepoPolicies) == 0 {
+		return nil
+	}
+	repo, action, err := token.RepoAndActionFromGitPath(r)
+	if err != nil {
+		// Not a recognized Git smart-HTTP path; no repo-scoped policy
+		// applies.
+		return nil
+	}
+	if policy, ok := matchRepoPolicy(a.RepoPolicies, repo); ok {
+		return policy.authorize(action, claims)
+	}
 	return nil
 }
 
+// matchRepoPolicy returns the RepoPolicy registered under the longest
+// prefix of policies' keys that repo starts with.
+func matchRepoPolicy(policies map[string]RepoPolicy, repo string) (RepoPolicy, bool) {
+	var best string
+	var bestPolicy RepoPolicy
+	found := false
+	for prefix, policy := range policies {
+		if strings.HasPrefix(repo, prefix) && len(prefix) >= len(best) {
+			best, bestPolicy, found = prefix, policy, true
+		}
+	}
+	return bestPolicy, found
+}
+
+// matchesAny reports whether any of want appears in have.
+func matchesAny(want, have []string) bool {
+	for _, w := range want {
+		if contains(have, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether v is present in list.
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// emailDomain returns the part of email after "@", or "" if email has no
+// "@".
+func emailDomain(email string) string {
+	i := strings.LastIndex(email, "@")
+	if i < 0 {
+		return ""
+	}
+	return email[i+1:]
+}
+
 type contextKey string
 
 const claimsKey contextKey = "oidc_claims"
@@ -76,3 +218,22 @@ func GetClaimsFromContext(ctx context.Context) (*Claims, bool) {
 	claims, ok := ctx.Value(claimsKey).(*Claims)
 	return claims, ok
 }
+
+// RequireAuth returns HTTP middleware that authorizes each request with
+// authorizer before forwarding to next, writing a 401 with a
+// WWW-Authenticate challenge (via the auth/challenge package) when no
+// token verifies, or a plain 403 when a verified token's claims don't
+// satisfy the policy.
+func RequireAuth(authorizer *Authorizer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := authorizer.AuthorizeRequest(r); err != nil {
+			if status.Code(err) == codes.PermissionDenied {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			challenge.WriteUnauthorized(w, r, "", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEmailDomain(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"alice@example.com", "example.com"},
+		{"no-at-sign", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := emailDomain(tt.email); got != tt.want {
+			t.Errorf("emailDomain(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRepoPolicy_LongestPrefixWins(t *testing.T) {
+	policies := map[string]RepoPolicy{
+		"github.com/org":          {AllowedReadGroups: []string{"everyone"}},
+		"github.com/org/internal": {AllowedReadGroups: []string{"internal-team"}},
+	}
+
+	policy, ok := matchRepoPolicy(policies, "github.com/org/internal/secrets")
+	if !ok {
+		t.Fatal("matchRepoPolicy() ok = false, want true")
+	}
+	if len(policy.AllowedReadGroups) != 1 || policy.AllowedReadGroups[0] != "internal-team" {
+		t.Errorf("matched policy = %+v, want the longer github.com/org/internal prefix's policy", policy)
+	}
+
+	if _, ok := matchRepoPolicy(policies, "gitlab.com/other"); ok {
+		t.Error("matchRepoPolicy() ok = true for a repo matching no prefix, want false")
+	}
+}
+
+func TestRepoPolicy_Authorize(t *testing.T) {
+	policy := RepoPolicy{
+		AllowedReadGroups:  []string{"readers"},
+		AllowedWriteGroups: []string{"writers"},
+	}
+
+	if err := policy.authorize("pull", &Claims{Groups: []string{"readers"}}); err != nil {
+		t.Errorf("authorize(pull) for a reader = %v, want nil", err)
+	}
+	if err := policy.authorize("push", &Claims{Groups: []string{"readers"}}); err == nil {
+		t.Error("authorize(push) for a read-only group = nil, want a permission error")
+	}
+	if err := policy.authorize("push", &Claims{Groups: []string{"writers"}}); err != nil {
+		t.Errorf("authorize(push) for a writer = %v, want nil", err)
+	}
+}
+
+func TestAuthorizer_Authorize_RepoWidePolicy(t *testing.T) {
+	a := &Authorizer{
+		AllowedGroups:        []string{"engineering"},
+		RequireEmailVerified: true,
+	}
+	r := httptest.NewRequest(http.MethodGet, "/github.com/org/repo/git-upload-pack", nil)
+
+	if err := a.authorize(r, &Claims{Groups: []string{"engineering"}, EmailVerified: true}); err != nil {
+		t.Errorf("authorize() for an allowed, verified group member = %v, want nil", err)
+	}
+	if err := a.authorize(r, &Claims{Groups: []string{"sales"}, EmailVerified: true}); err == nil {
+		t.Error("authorize() for a non-member group = nil, want a permission error")
+	}
+	if err := a.authorize(r, &Claims{Groups: []string{"engineering"}, EmailVerified: false}); err == nil {
+		t.Error("authorize() for an unverified email = nil, want a permission error")
+	}
+}
+
+func TestAuthorizer_Authorize_RepoPolicyOverride(t *testing.T) {
+	a := &Authorizer{
+		RepoPolicies: map[string]RepoPolicy{
+			"org/restricted": {AllowedWriteGroups: []string{"maintainers"}},
+		},
+	}
+
+	push := httptest.NewRequest(http.MethodPost, "/org/restricted/git-receive-pack", nil)
+	if err := a.authorize(push, &Claims{Groups: []string{"engineering"}}); err == nil {
+		t.Error("authorize(push) for a non-maintainer on a restricted repo = nil, want a permission error")
+	}
+	if err := a.authorize(push, &Claims{Groups: []string{"maintainers"}}); err != nil {
+		t.Errorf("authorize(push) for a maintainer = %v, want nil", err)
+	}
+
+	pull := httptest.NewRequest(http.MethodGet, "/org/other-repo/git-upload-pack", nil)
+	if err := a.authorize(pull, &Claims{Groups: []string{"anyone"}}); err != nil {
+		t.Errorf("authorize(pull) for a repo matching no policy prefix = %v, want nil", err)
+	}
+}
+
+func TestAuthorizeRequest_DevToken(t *testing.T) {
+	a := NewAuthorizer(nil)
+	a.AllowDevTokens = true
+	r := httptest.NewRequest(http.MethodGet, "/github.com/org/repo/git-upload-pack", nil)
+	r.Header.Set("Authorization", "Bearer dev-token-anything")
+
+	if err := a.AuthorizeRequest(r); err != nil {
+		t.Errorf("AuthorizeRequest() for a dev token with AllowDevTokens set = %v, want nil", err)
+	}
+}
+
+func TestNewAuthorizer_DevTokensRejectedByDefault(t *testing.T) {
+	a := NewAuthorizer(nil)
+	if a.AllowDevTokens {
+		t.Error("NewAuthorizer().AllowDevTokens = true, want false so dev tokens are rejected unless explicitly enabled")
+	}
+}
+
+func TestAuthorizeRequest_NoToken(t *testing.T) {
+	a := NewAuthorizer(nil)
+	r := httptest.NewRequest(http.MethodGet, "/github.com/org/repo/git-upload-pack", nil)
+
+	if err := a.AuthorizeRequest(r); err == nil {
+		t.Error("AuthorizeRequest() for a request with no bearer token = nil, want an error")
+	}
+}
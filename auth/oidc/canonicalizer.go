@@ -24,9 +24,92 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-// CanonicalizeURL converts a proxy-style URL path to a canonical upstream Git URL.
-// It supports paths like: /github.com/owner/repo, /gitlab.com/owner/repo, etc.
-func CanonicalizeURL(u *url.URL) (*url.URL, error) {
+// HostRule allow-lists one upstream host, or path alias, that
+// CanonicalizeURL may resolve a request's repository path against.
+type HostRule struct {
+	// Host is the literal first path segment this rule matches, e.g.
+	// "gitlab.company.internal" or an alias like "gh" that doesn't look
+	// like a hostname at all.
+	Host string
+
+	// HostSuffix, if set instead of Host, allow-lists any first path
+	// segment ending in this suffix (e.g. ".corp.example.com") instead
+	// of requiring an exact match, for a fleet of hosts on one domain.
+	HostSuffix string
+
+	// UpstreamHost is the upstream hostname to canonicalize to. Defaults
+	// to the matched segment itself, so only alias rules
+	// (HostRule{Host: "gh", UpstreamHost: "github.com"}) need to set it.
+	UpstreamHost string
+
+	// Scheme is the upstream scheme. Defaults to "https".
+	Scheme string
+
+	// PathPrefix, if set, is inserted ahead of the remaining path
+	// segments, for an UpstreamHost that roots every repository under a
+	// fixed path such as "/orgs".
+	PathPrefix string
+
+	// MaxPathDepth caps the number of path segments allowed after the
+	// host (2 for "owner/repo", 3 for a GitLab subgroup). Zero means
+	// unlimited.
+	MaxPathDepth int
+}
+
+func (r HostRule) matches(host string) bool {
+	if r.HostSuffix != "" {
+		return strings.HasSuffix(host, r.HostSuffix)
+	}
+	return r.Host == host
+}
+
+// HostPolicy is the allow-list CanonicalizeURL consults to decide which
+// hosts, or path aliases, it will canonicalize a request's repository
+// path against. A nil policy preserves CanonicalizeURL's original
+// behavior: any first path segment containing a dot is accepted
+// unchanged, with no depth limit or alias mapping.
+type HostPolicy struct {
+	Rules []HostRule
+}
+
+func (p *HostPolicy) find(host string) (HostRule, bool) {
+	if p == nil {
+		return HostRule{}, false
+	}
+	for _, r := range p.Rules {
+		if r.matches(host) {
+			return r, true
+		}
+	}
+	return HostRule{}, false
+}
+
+// CanonicalURL is the parsed result of a successful CanonicalizeURL
+// call: the upstream host and the repo path beneath it, plus the
+// assembled Endpoint, so callers that need to key a cache on canonical
+// repo identity (the LFS handler, the health checker) don't have to
+// re-derive Host and RepoPath by re-parsing Endpoint themselves.
+type CanonicalURL struct {
+	// Host is the upstream hostname, after alias resolution.
+	Host string
+	// RepoPath is the path beneath Host, without a leading slash, e.g.
+	// "group/subgroup/project".
+	RepoPath string
+	// Endpoint is the canonical upstream URL: scheme://Host/RepoPath.
+	Endpoint *url.URL
+}
+
+// CanonicalizeURL converts a proxy-style URL path to a canonical
+// upstream Git URL. It supports paths like /github.com/owner/repo,
+// /gitlab.com/group/subgroup/project, or, with a matching HostPolicy
+// rule, an aliased path like /gh/owner/repo.
+//
+// policy may be nil, in which case any first path segment containing a
+// dot is accepted unchanged (the module's original behavior); a
+// non-nil policy restricts accepted hosts to its Rules and additionally
+// allows mapping aliases, rewriting the upstream scheme or path prefix,
+// and enforcing a MaxPathDepth.
+func CanonicalizeURL(u *url.URL, policy *HostPolicy) (*CanonicalURL, error) {
 	path := u.Path
 
 	// Remove Git endpoint suffixes
@@ -48,26 +131,73 @@ func CanonicalizeURL(u *url.URL) (*url.URL, error) {
 		return nil, status.Error(codes.InvalidArgument, "empty repository path")
 	}
 
-	// Split path into host and repo path
-	parts := strings.SplitN(path, "/", 2)
-	if len(parts) < 2 {
+	segments := strings.Split(path, "/")
+	for _, seg := range segments {
+		if seg == "" || seg == "." || seg == ".." {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid repository path: %s (empty or \"..\" segment)", path)
+		}
+	}
+	if len(segments) < 2 {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid repository path: %s (expected host/owner/repo)", path)
 	}
 
-	host := parts[0]
-	repoPath := parts[1]
+	host := segments[0]
+	repoSegments := segments[1:]
+
+	rule, matched := policy.find(host)
+	if !matched {
+		if policy != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "host not allow-listed: %s", host)
+		}
+		// No policy configured: fall back to the original bare check.
+		if !strings.Contains(host, ".") {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid host: %s", host)
+		}
+	}
+
+	if rule.MaxPathDepth > 0 && len(repoSegments) > rule.MaxPathDepth {
+		return nil, status.Errorf(codes.InvalidArgument, "repository path too deep: %s (max %d segments)", path, rule.MaxPathDepth)
+	}
 
-	// Validate host (basic check for domain format)
-	if !strings.Contains(host, ".") {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid host: %s", host)
+	upstreamHost := rule.UpstreamHost
+	if upstreamHost == "" {
+		upstreamHost = host
 	}
+	scheme := rule.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+
+	repoPath := strings.Join(repoSegments, "/")
+	if rule.PathPrefix != "" {
+		prefix := strings.Trim(rule.PathPrefix, "/")
+		repoPath = prefix + "/" + repoPath
+	}
+
+	return &CanonicalURL{
+		Host:     upstreamHost,
+		RepoPath: repoPath,
+		Endpoint: &url.URL{
+			Scheme: scheme,
+			Host:   upstreamHost,
+			Path:   "/" + repoPath,
+		},
+	}, nil
+}
+
+// CanonicalizeLFSURL is CanonicalizeURL's counterpart for Git LFS
+// requests: it strips the LFS-specific "/info/lfs/objects/batch" and
+// "/info/lfs/objects/<oid>" suffixes instead of the smart-HTTP ones, so
+// a repo's LFS traffic resolves to the same upstream repo identity as
+// its git traffic.
+func CanonicalizeLFSURL(u *url.URL, policy *HostPolicy) (*CanonicalURL, error) {
+	path := u.Path
 
-	// Construct canonical URL
-	canonical := &url.URL{
-		Scheme: "https",
-		Host:   host,
-		Path:   "/" + repoPath,
+	if strings.HasSuffix(path, "/info/lfs/objects/batch") {
+		path = strings.TrimSuffix(path, "/info/lfs/objects/batch")
+	} else if idx := strings.LastIndex(path, "/info/lfs/objects/"); idx >= 0 {
+		path = path[:idx]
 	}
 
-	return canonical, nil
+	return CanonicalizeURL(&url.URL{Path: path}, policy)
 }
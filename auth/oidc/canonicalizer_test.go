@@ -0,0 +1,185 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestCanonicalizeURLNoPolicy(t *testing.T) {
+	cases := []struct {
+		path         string
+		wantHost     string
+		wantRepoPath string
+	}{
+		{"/github.com/owner/repo", "github.com", "owner/repo"},
+		{"/github.com/owner/repo.git", "github.com", "owner/repo"},
+		{"/github.com/owner/repo/info/refs", "github.com", "owner/repo"},
+		{"/github.com/owner/repo/git-upload-pack", "github.com", "owner/repo"},
+		{"/github.com/owner/repo/git-receive-pack", "github.com", "owner/repo"},
+		{"/gitlab.com/group/subgroup/project", "gitlab.com", "group/subgroup/project"},
+	}
+	for _, c := range cases {
+		got, err := CanonicalizeURL(&url.URL{Path: c.path}, nil)
+		if err != nil {
+			t.Errorf("CanonicalizeURL(%q) unexpected error: %v", c.path, err)
+			continue
+		}
+		if got.Host != c.wantHost || got.RepoPath != c.wantRepoPath {
+			t.Errorf("CanonicalizeURL(%q) = {Host: %q, RepoPath: %q}, want {Host: %q, RepoPath: %q}",
+				c.path, got.Host, got.RepoPath, c.wantHost, c.wantRepoPath)
+		}
+		wantEndpoint := "https://" + c.wantHost + "/" + c.wantRepoPath
+		if got.Endpoint.String() != wantEndpoint {
+			t.Errorf("CanonicalizeURL(%q).Endpoint = %q, want %q", c.path, got.Endpoint.String(), wantEndpoint)
+		}
+	}
+}
+
+func TestCanonicalizeURLNoPolicyRejections(t *testing.T) {
+	cases := []string{
+		"",
+		"/",
+		"/internal-host-without-a-dot/owner/repo",
+		"/github.com",
+		"/github.com/owner/..",
+		"/github.com/owner//repo",
+		"/github.com/../repo",
+	}
+	for _, path := range cases {
+		if _, err := CanonicalizeURL(&url.URL{Path: path}, nil); err == nil {
+			t.Errorf("CanonicalizeURL(%q) succeeded, want error", path)
+		}
+	}
+}
+
+func TestCanonicalizeURLHostPolicy(t *testing.T) {
+	policy := &HostPolicy{
+		Rules: []HostRule{
+			{Host: "gh", UpstreamHost: "github.com"},
+			{HostSuffix: ".corp.example.com"},
+			{Host: "internal-git", MaxPathDepth: 2},
+		},
+	}
+
+	t.Run("alias rewrites host", func(t *testing.T) {
+		got, err := CanonicalizeURL(&url.URL{Path: "/gh/owner/repo"}, policy)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Host != "github.com" || got.RepoPath != "owner/repo" {
+			t.Errorf("got %+v, want Host github.com, RepoPath owner/repo", got)
+		}
+	})
+
+	t.Run("suffix match allows any host under the suffix", func(t *testing.T) {
+		got, err := CanonicalizeURL(&url.URL{Path: "/git.corp.example.com/team/project"}, policy)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Host != "git.corp.example.com" {
+			t.Errorf("Host = %q, want git.corp.example.com", got.Host)
+		}
+	})
+
+	t.Run("unlisted host is rejected even with a dot", func(t *testing.T) {
+		if _, err := CanonicalizeURL(&url.URL{Path: "/github.com/owner/repo"}, policy); err == nil {
+			t.Errorf("expected host not on the allow-list to be rejected")
+		}
+	})
+
+	t.Run("MaxPathDepth rejects deeper paths", func(t *testing.T) {
+		if _, err := CanonicalizeURL(&url.URL{Path: "/internal-git/group/subgroup/project"}, policy); err == nil {
+			t.Errorf("expected path exceeding MaxPathDepth to be rejected")
+		}
+		if _, err := CanonicalizeURL(&url.URL{Path: "/internal-git/owner/repo"}, policy); err != nil {
+			t.Errorf("unexpected error within MaxPathDepth: %v", err)
+		}
+	})
+}
+
+func TestCanonicalizeURLPathPrefixAndScheme(t *testing.T) {
+	policy := &HostPolicy{
+		Rules: []HostRule{
+			{Host: "internal", Scheme: "http", PathPrefix: "/orgs"},
+		},
+	}
+	got, err := CanonicalizeURL(&url.URL{Path: "/internal/team/repo"}, policy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantEndpoint := "http://internal/orgs/team/repo"
+	if got.Endpoint.String() != wantEndpoint {
+		t.Errorf("Endpoint = %q, want %q", got.Endpoint.String(), wantEndpoint)
+	}
+}
+
+func TestCanonicalizeLFSURL(t *testing.T) {
+	cases := []struct {
+		path         string
+		wantRepoPath string
+	}{
+		{"/github.com/owner/repo/info/lfs/objects/batch", "owner/repo"},
+		{"/github.com/owner/repo/info/lfs/objects/abc123", "owner/repo"},
+	}
+	for _, c := range cases {
+		got, err := CanonicalizeLFSURL(&url.URL{Path: c.path}, nil)
+		if err != nil {
+			t.Errorf("CanonicalizeLFSURL(%q) unexpected error: %v", c.path, err)
+			continue
+		}
+		if got.RepoPath != c.wantRepoPath {
+			t.Errorf("CanonicalizeLFSURL(%q).RepoPath = %q, want %q", c.path, got.RepoPath, c.wantRepoPath)
+		}
+	}
+}
+
+// FuzzCanonicalizeURL checks that CanonicalizeURL never panics on
+// arbitrary input paths, with or without a HostPolicy restricting the
+// accepted hosts.
+func FuzzCanonicalizeURL(f *testing.F) {
+	seeds := []string{
+		"/github.com/owner/repo",
+		"/gitlab.com/group/subgroup/project.git",
+		"/github.com/owner/repo/info/refs",
+		"",
+		"/",
+		"/../../etc/passwd",
+		"/host/owner/../repo",
+		"/host//repo",
+		"/gh/owner/repo",
+	}
+	for _, s := range seeds {
+		f.Add(s)
+	}
+
+	policy := &HostPolicy{
+		Rules: []HostRule{
+			{Host: "gh", UpstreamHost: "github.com"},
+			{HostSuffix: ".corp.example.com"},
+		},
+	}
+
+	f.Fuzz(func(t *testing.T, path string) {
+		u := &url.URL{Path: path}
+		if got, err := CanonicalizeURL(u, nil); err == nil && got == nil {
+			t.Fatalf("CanonicalizeURL(%q, nil) returned nil result with nil error", path)
+		}
+		if got, err := CanonicalizeURL(u, policy); err == nil && got == nil {
+			t.Fatalf("CanonicalizeURL(%q, policy) returned nil result with nil error", path)
+		}
+	})
+}
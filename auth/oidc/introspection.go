@@ -0,0 +1,245 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultIntrospectionCacheSize bounds how many distinct tokens
+// newIntrospectionCache retains before evicting the least-recently-used
+// one.
+const DefaultIntrospectionCacheSize = 256
+
+// DefaultIntrospectionCacheTTLCap is the default
+// Config.IntrospectionCacheTTLCap.
+const DefaultIntrospectionCacheTTLCap = 5 * time.Minute
+
+// DefaultIntrospectionNegativeCacheTTL is how long an inactive/rejected
+// introspection result is cached, short enough that a just-revoked token
+// starts failing everywhere soon after, but long enough to absorb a
+// retry storm against a down introspection endpoint.
+const DefaultIntrospectionNegativeCacheTTL = 10 * time.Second
+
+// introspectionResponse is the RFC 7662 introspection response body.
+// Only the fields the verifier needs are modeled; the rest (token_type,
+// aud, iat, ...) are left for the provider to include or omit freely.
+type introspectionResponse struct {
+	Active bool     `json:"active"`
+	Sub    string   `json:"sub"`
+	Exp    int64    `json:"exp"`
+	Scope  string   `json:"scope"`
+	Email  string   `json:"email"`
+	Groups []string `json:"groups"`
+	Name   string   `json:"name"`
+}
+
+// verifyByIntrospection POSTs token to Config.IntrospectionURL per RFC
+// 7662, caching the result (success keyed by the response's own exp,
+// capped at IntrospectionCacheTTLCap; failure briefly) so a burst of git
+// requests carrying the same access token doesn't hit the identity
+// provider once per request.
+func (v *Verifier) verifyByIntrospection(ctx context.Context, token string) (*Claims, error) {
+	key := introspectionCacheKey(token)
+
+	if claims, err, ok := v.cache.lookup(key); ok {
+		return claims, err
+	}
+
+	claims, exp, err := v.introspect(ctx, token)
+	v.cache.store(key, claims, exp, err, v.config.introspectionCacheTTLCap())
+	return claims, err
+}
+
+// introspect performs the introspection HTTP call itself, with no
+// caching. exp is the token's own expiry as reported by the
+// introspection response, used by the caller to cap how long the
+// result is cached.
+func (v *Verifier) introspect(ctx context.Context, token string) (claims *Claims, exp time.Time, err error) {
+	clientID := v.config.IntrospectionClientID
+	if clientID == "" {
+		clientID = v.config.ClientID
+	}
+	clientSecret := v.config.IntrospectionClientSecret
+	if clientSecret == "" {
+		clientSecret = v.config.ClientSecret
+	}
+
+	form := url.Values{
+		"token":           {token},
+		"token_type_hint": {"access_token"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.config.IntrospectionURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to build introspection request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientID, clientSecret)
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("introspection request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("introspection endpoint returned HTTP %d", resp.StatusCode)
+	}
+
+	var introspected introspectionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&introspected); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to decode introspection response: %w", err)
+	}
+
+	if !introspected.Active {
+		return nil, time.Time{}, fmt.Errorf("token is not active")
+	}
+	if introspected.Exp != 0 {
+		exp = time.Unix(introspected.Exp, 0)
+		if exp.Before(time.Now()) {
+			return nil, time.Time{}, fmt.Errorf("token is expired")
+		}
+	}
+
+	claims = &Claims{
+		Email:   introspected.Email,
+		Name:    introspected.Name,
+		Groups:  introspected.Groups,
+		Subject: introspected.Sub,
+		// Introspection carries no separate email_verified claim in the
+		// base RFC 7662 response; a verified-at-source access token from
+		// the IdP is treated as verified.
+		EmailVerified: introspected.Email != "",
+	}
+	return claims, exp, nil
+}
+
+func (c *Config) introspectionCacheTTLCap() time.Duration {
+	if c.IntrospectionCacheTTLCap > 0 {
+		return c.IntrospectionCacheTTLCap
+	}
+	return DefaultIntrospectionCacheTTLCap
+}
+
+// introspectionCacheKey hashes token so the cache (and any logs built
+// from it) never holds the raw bearer token.
+func introspectionCacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// introspectionCacheEntry is a cached introspection result, either
+// claims or an error, valid until expires.
+type introspectionCacheEntry struct {
+	claims  *Claims
+	err     error
+	expires time.Time
+}
+
+func (e introspectionCacheEntry) fresh(now time.Time) bool {
+	return now.Before(e.expires)
+}
+
+// introspectionCache is an in-memory, size-bounded LRU of introspection
+// results keyed by the SHA-256 hash of the token, mirroring the main
+// module's LRUTokenCache.
+type introspectionCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // of *introspectionCacheListEntry; most-recently-used at the front.
+
+	// now, if set, replaces time.Now; tests use this to control expiry
+	// without sleeping.
+	now func() time.Time
+}
+
+type introspectionCacheListEntry struct {
+	key   string
+	entry introspectionCacheEntry
+}
+
+func newIntrospectionCache() *introspectionCache {
+	return &introspectionCache{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (c *introspectionCache) lookup(key string) (claims *Claims, err error, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, found := c.entries[key]
+	if !found {
+		return nil, nil, false
+	}
+	ce := el.Value.(*introspectionCacheListEntry)
+	if !ce.entry.fresh(c.now()) {
+		return nil, nil, false
+	}
+	c.order.MoveToFront(el)
+	return ce.entry.claims, ce.entry.err, true
+}
+
+// store caches claims/err until expires, computed as
+// min(exp - now, ttlCap) for a successful lookup with a reported
+// expiry, ttlCap alone if exp is zero, or a short negative-cache TTL on
+// error.
+func (c *introspectionCache) store(key string, claims *Claims, exp time.Time, err error, ttlCap time.Duration) {
+	now := c.now()
+
+	expires := now.Add(ttlCap)
+	if !exp.IsZero() {
+		if capped := now.Add(ttlCap); exp.Before(capped) {
+			expires = exp
+		} else {
+			expires = capped
+		}
+	}
+	if err != nil {
+		expires = now.Add(DefaultIntrospectionNegativeCacheTTL)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := introspectionCacheEntry{claims: claims, err: err, expires: expires}
+	if el, found := c.entries[key]; found {
+		el.Value.(*introspectionCacheListEntry).entry = entry
+		c.order.MoveToFront(el)
+		return
+	}
+
+	el := c.order.PushFront(&introspectionCacheListEntry{key: key, entry: entry})
+	c.entries[key] = el
+
+	if c.order.Len() > DefaultIntrospectionCacheSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*introspectionCacheListEntry).key)
+	}
+}
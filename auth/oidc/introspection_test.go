@@ -0,0 +1,144 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newIntrospectionTestVerifier builds a Verifier whose introspection
+// fields are wired to handler, without standing up a real OIDC discovery
+// endpoint (verifyByIntrospection never touches v.provider/v.verifier).
+func newIntrospectionTestVerifier(t *testing.T, handler http.HandlerFunc) (*Verifier, *int) {
+	t.Helper()
+
+	var mu sync.Mutex
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return &Verifier{
+		config: &Config{
+			IntrospectionURL:      server.URL,
+			IntrospectionClientID: "client",
+		},
+		httpClient: server.Client(),
+		cache:      newIntrospectionCache(),
+	}, &calls
+}
+
+func TestVerifyByIntrospection_Active(t *testing.T) {
+	v, calls := newIntrospectionTestVerifier(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"active": true,
+			"sub":    "user-1",
+			"email":  "user@example.com",
+			"groups": []string{"engineering"},
+			"exp":    time.Now().Add(time.Hour).Unix(),
+		})
+	})
+
+	claims, err := v.verifyByIntrospection(context.Background(), "opaque-token")
+	if err != nil {
+		t.Fatalf("verifyByIntrospection() error = %v", err)
+	}
+	if claims.Subject != "user-1" || claims.Email != "user@example.com" {
+		t.Errorf("claims = %+v, want subject user-1 / email user@example.com", claims)
+	}
+	if len(claims.Groups) != 1 || claims.Groups[0] != "engineering" {
+		t.Errorf("claims.Groups = %v, want [engineering]", claims.Groups)
+	}
+	if *calls != 1 {
+		t.Errorf("introspection endpoint called %d times, want 1", *calls)
+	}
+
+	// A second call for the same token should be served from cache.
+	if _, err := v.verifyByIntrospection(context.Background(), "opaque-token"); err != nil {
+		t.Fatalf("second verifyByIntrospection() error = %v", err)
+	}
+	if *calls != 1 {
+		t.Errorf("introspection endpoint called %d times after cache hit, want 1", *calls)
+	}
+}
+
+func TestVerifyByIntrospection_Inactive(t *testing.T) {
+	v, _ := newIntrospectionTestVerifier(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"active": false})
+	})
+
+	if _, err := v.verifyByIntrospection(context.Background(), "revoked-token"); err == nil {
+		t.Error("verifyByIntrospection() error = nil, want an error for an inactive token")
+	}
+}
+
+func TestVerifyByIntrospection_Expired(t *testing.T) {
+	v, _ := newIntrospectionTestVerifier(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"active": true,
+			"sub":    "user-1",
+			"exp":    time.Now().Add(-time.Hour).Unix(),
+		})
+	})
+
+	if _, err := v.verifyByIntrospection(context.Background(), "expired-token"); err == nil {
+		t.Error("verifyByIntrospection() error = nil, want an error for an expired token")
+	}
+}
+
+func TestVerifyByIntrospection_NegativeCache(t *testing.T) {
+	v, calls := newIntrospectionTestVerifier(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	if _, err := v.verifyByIntrospection(context.Background(), "bad-token"); err == nil {
+		t.Fatal("verifyByIntrospection() error = nil, want an error for a 500 response")
+	}
+	if _, err := v.verifyByIntrospection(context.Background(), "bad-token"); err == nil {
+		t.Fatal("second verifyByIntrospection() error = nil, want the cached error")
+	}
+	if *calls != 1 {
+		t.Errorf("introspection endpoint called %d times, want 1 (second lookup should hit the negative cache)", *calls)
+	}
+}
+
+func TestIntrospectionCache_TTLCapAppliesToDistantExpiry(t *testing.T) {
+	now := time.Now()
+	c := newIntrospectionCache()
+	c.now = func() time.Time { return now }
+
+	exp := now.Add(24 * time.Hour)
+	c.store("key", &Claims{Subject: "user-1"}, exp, nil, 5*time.Minute)
+
+	c.now = func() time.Time { return now.Add(time.Minute) }
+	if _, _, ok := c.lookup("key"); !ok {
+		t.Error("lookup() ok = false before the TTL cap elapsed, want true")
+	}
+
+	c.now = func() time.Time { return now.Add(10 * time.Minute) }
+	if _, _, ok := c.lookup("key"); ok {
+		t.Error("lookup() ok = true after the TTL cap elapsed, want false (should have been capped below the token's own exp)")
+	}
+}
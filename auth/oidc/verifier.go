@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 )
@@ -30,6 +31,9 @@ type Verifier struct {
 	provider *oidc.Provider
 	verifier *oidc.IDTokenVerifier
 	config   *Config
+
+	httpClient *http.Client
+	cache      *introspectionCache
 }
 
 // Config holds OIDC configuration.
@@ -37,6 +41,24 @@ type Config struct {
 	IssuerURL    string
 	ClientID     string
 	ClientSecret string
+
+	// IntrospectionURL is the RFC 7662 token introspection endpoint used
+	// by VerifyAccessToken as a fallback when a token doesn't verify as
+	// an ID token (JWT) -- i.e. it's an opaque access token, as Dex and
+	// most other providers issue by default. Leave "" to disable
+	// introspection and fail such tokens outright, as before.
+	IntrospectionURL string
+	// IntrospectionClientID is sent as the HTTP Basic auth username for
+	// the introspection request. Defaults to ClientID if "".
+	IntrospectionClientID string
+	// IntrospectionClientSecret is sent as the HTTP Basic auth password
+	// for the introspection request. Defaults to ClientSecret if "".
+	IntrospectionClientSecret string
+	// IntrospectionCacheTTLCap bounds how long a successful introspection
+	// result is cached, even if the token's own exp claim is further out,
+	// so a revoked-but-not-yet-expired token is re-checked periodically.
+	// Defaults to DefaultIntrospectionCacheTTLCap if zero.
+	IntrospectionCacheTTLCap time.Duration
 }
 
 // NewVerifier creates a new OIDC verifier.
@@ -58,23 +80,29 @@ func NewVerifier(ctx context.Context, config *Config) (*Verifier, error) {
 	})
 
 	return &Verifier{
-		provider: provider,
-		verifier: verifier,
-		config:   config,
+		provider:   provider,
+		verifier:   verifier,
+		config:     config,
+		httpClient: http.DefaultClient,
+		cache:      newIntrospectionCache(),
 	}, nil
 }
 
-// VerifyAccessToken verifies an access token (opaque token).
-// For Dex, we need to verify it as an ID token or use introspection.
-func (v *Verifier) VerifyAccessToken(ctx context.Context, token string) error {
-	// Try to verify as ID token first
-	_, err := v.verifier.Verify(ctx, token)
-	if err != nil {
-		// If that fails, we could implement token introspection
-		// For now, return the error
-		return fmt.Errorf("failed to verify token: %w", err)
+// VerifyAccessToken verifies an access token and returns its claims. It
+// tries ID-token (JWT) verification first; if that fails and
+// Config.IntrospectionURL is set, it falls back to RFC 7662 token
+// introspection, so opaque access tokens (Dex's default) are accepted
+// too, not just JWTs.
+func (v *Verifier) VerifyAccessToken(ctx context.Context, token string) (*Claims, error) {
+	idToken, err := v.verifier.Verify(ctx, token)
+	if err == nil {
+		return GetClaims(idToken)
+	}
+
+	if v.config.IntrospectionURL == "" {
+		return nil, fmt.Errorf("failed to verify token: %w", err)
 	}
-	return nil
+	return v.verifyByIntrospection(ctx, token)
 }
 
 // VerifyIDToken verifies an ID token (JWT).
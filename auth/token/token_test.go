@@ -0,0 +1,144 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestAuthorizer(t *testing.T) (*Authorizer, func(repo string, actions ...string) string) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	issue := func(repo string, actions ...string) string {
+		tok, err := Sign(jwt.SigningMethodEdDSA, priv, "test-key", repo, actions, time.Minute)
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		return tok
+	}
+
+	return NewAuthorizer(StaticKeySource{Key: pub}), issue
+}
+
+func TestAuthorizer_AllowsScopedAction(t *testing.T) {
+	a, issue := newTestAuthorizer(t)
+	tok := issue("org/repo-a", ActionPull)
+
+	r := httptest.NewRequest(http.MethodGet, "/org/repo-a/info/refs?service=git-upload-pack", nil)
+	r.Header.Set("Authorization", "Bearer "+tok)
+
+	if err := a.AuthorizeRequest(r); err != nil {
+		t.Errorf("AuthorizeRequest() error = %v, want nil", err)
+	}
+}
+
+func TestAuthorizer_DeniesDifferentRepository(t *testing.T) {
+	a, issue := newTestAuthorizer(t)
+	tok := issue("org/repo-a", ActionPull)
+
+	r := httptest.NewRequest(http.MethodGet, "/org/repo-b/info/refs?service=git-upload-pack", nil)
+	r.Header.Set("Authorization", "Bearer "+tok)
+
+	if err := a.AuthorizeRequest(r); err == nil {
+		t.Error("AuthorizeRequest() error = nil, want permission denied for a different repository")
+	}
+}
+
+func TestAuthorizer_DeniesUnscopedAction(t *testing.T) {
+	a, issue := newTestAuthorizer(t)
+	tok := issue("org/repo-a", ActionPull)
+
+	r := httptest.NewRequest(http.MethodPost, "/org/repo-a/git-receive-pack", nil)
+	r.Header.Set("Authorization", "Bearer "+tok)
+
+	if err := a.AuthorizeRequest(r); err == nil {
+		t.Error("AuthorizeRequest() error = nil, want permission denied for push with a pull-only token")
+	}
+}
+
+func TestAuthorizer_DeniesMissingToken(t *testing.T) {
+	a, _ := newTestAuthorizer(t)
+
+	r := httptest.NewRequest(http.MethodGet, "/org/repo-a/info/refs?service=git-upload-pack", nil)
+	if err := a.AuthorizeRequest(r); err == nil {
+		t.Error("AuthorizeRequest() error = nil, want unauthenticated for a missing token")
+	}
+}
+
+func TestAuthorizer_DeniesExpiredToken(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	tok, err := Sign(jwt.SigningMethodEdDSA, priv, "test-key", "org/repo-a", []string{ActionPull}, -time.Minute)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	a := NewAuthorizer(StaticKeySource{Key: pub})
+	r := httptest.NewRequest(http.MethodGet, "/org/repo-a/info/refs?service=git-upload-pack", nil)
+	r.Header.Set("Authorization", "Bearer "+tok)
+
+	if err := a.AuthorizeRequest(r); err == nil {
+		t.Error("AuthorizeRequest() error = nil, want rejection of an expired token")
+	}
+}
+
+func TestRepoAndActionFromGitPath(t *testing.T) {
+	tests := []struct {
+		url        string
+		wantRepo   string
+		wantAction string
+		wantErr    bool
+	}{
+		{url: "/org/repo.git/info/refs?service=git-upload-pack", wantRepo: "org/repo", wantAction: ActionPull},
+		{url: "/org/repo/info/refs?service=git-receive-pack", wantRepo: "org/repo", wantAction: ActionPush},
+		{url: "/org/repo/git-upload-pack", wantRepo: "org/repo", wantAction: ActionPull},
+		{url: "/org/repo/git-receive-pack", wantRepo: "org/repo", wantAction: ActionPush},
+		{url: "/org/repo/info/refs", wantErr: true},
+		{url: "/org/repo/unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			repo, action, err := RepoAndActionFromGitPath(r)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("RepoAndActionFromGitPath(%q) error = nil, want error", tt.url)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RepoAndActionFromGitPath(%q) error = %v", tt.url, err)
+			}
+			if repo != tt.wantRepo || action != tt.wantAction {
+				t.Errorf("RepoAndActionFromGitPath(%q) = (%q, %q), want (%q, %q)", tt.url, repo, action, tt.wantRepo, tt.wantAction)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tokensource provides a reusable oauth2.TokenSource for goblet
+// clients (the CLI, git credential helpers, CI scripts) that transparently
+// refreshes an expired access token using its refresh token and persists
+// the rotated token back to disk, so repeated invocations of a short-lived
+// process share one refresh chain instead of minting a fresh login each
+// time.
+package tokensource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// storedToken mirrors the on-disk JSON shape written by cmd/dex-token.
+type storedToken struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	IDToken      string    `json:"id_token,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// FileTokenSource wraps an oauth2.TokenSource so that every time it mints
+// a new token (because the previous one expired), the new token is
+// persisted back to Path. It is safe for concurrent use.
+type FileTokenSource struct {
+	Path string
+
+	mu     sync.Mutex
+	base   oauth2.TokenSource
+	cached *oauth2.Token
+}
+
+// Load reads the token at path and wraps it in a FileTokenSource that
+// refreshes via config's token endpoint and persists rotated tokens back
+// to path.
+func Load(ctx context.Context, path string, config *oauth2.Config) (*FileTokenSource, error) {
+	tok, err := Peek(path)
+	if err != nil {
+		return nil, err
+	}
+	return &FileTokenSource{
+		Path: path,
+		base: config.TokenSource(ctx, tok),
+	}, nil
+}
+
+// Peek reads the token cached at path as-is, without wrapping it in a
+// refreshing TokenSource. Callers that only need to inspect or print
+// the cached access token (e.g. a `token` CLI subcommand) can use this
+// instead of constructing an oauth2.Config just to call Load.
+func Peek(path string) (*oauth2.Token, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read token file %q: %w", path, err)
+	}
+	var st storedToken
+	if err := json.Unmarshal(bs, &st); err != nil {
+		return nil, fmt.Errorf("cannot parse token file %q: %w", path, err)
+	}
+
+	tok := &oauth2.Token{
+		AccessToken:  st.AccessToken,
+		TokenType:    st.TokenType,
+		RefreshToken: st.RefreshToken,
+		Expiry:       st.Expiry,
+	}
+	if st.IDToken != "" {
+		tok = tok.WithExtra(map[string]interface{}{"id_token": st.IDToken})
+	}
+	return tok, nil
+}
+
+// SaveToken writes tok to path in the same JSON shape Load/Peek expect,
+// for a caller (e.g. an interactive login flow) that mints the first
+// token directly rather than through a FileTokenSource's refresh.
+func SaveToken(path string, tok *oauth2.Token) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("cannot create token cache directory: %w", err)
+	}
+	return writeStoredToken(path, tok)
+}
+
+// Token implements oauth2.TokenSource. It reuses the cached token while
+// valid and, once the base source mints a new one, persists it to Path
+// before returning it.
+func (f *FileTokenSource) Token() (*oauth2.Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.cached.Valid() {
+		return f.cached, nil
+	}
+
+	tok, err := f.base.Token()
+	if err != nil {
+		return nil, fmt.Errorf("cannot refresh token: %w", err)
+	}
+	if f.cached == nil || tok.AccessToken != f.cached.AccessToken {
+		if err := f.persist(tok); err != nil {
+			// The token is still usable even if we couldn't save
+			// it; the next process just won't reuse this
+			// refresh.
+			fmt.Fprintf(os.Stderr, "warning: cannot persist refreshed token to %s: %v\n", f.Path, err)
+		}
+	}
+	f.cached = tok
+	return tok, nil
+}
+
+func (f *FileTokenSource) persist(tok *oauth2.Token) error {
+	return writeStoredToken(f.Path, tok)
+}
+
+func writeStoredToken(path string, tok *oauth2.Token) error {
+	idToken, _ := tok.Extra("id_token").(string)
+	st := storedToken{
+		AccessToken:  tok.AccessToken,
+		TokenType:    tok.TokenType,
+		IDToken:      idToken,
+		RefreshToken: tok.RefreshToken,
+		Expiry:       tok.Expiry,
+	}
+	bs, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bs, 0600)
+}
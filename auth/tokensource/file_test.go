@@ -0,0 +1,125 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokensource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// newTestTokenServer serves token refresh requests, minting a new access
+// token with an incrementing suffix on every call so tests can tell
+// refreshes apart.
+func newTestTokenServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	calls := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, `{"access_token":"tok-%d","token_type":"Bearer","refresh_token":"refresh-%d","expires_in":3600}`, calls, calls)
+	}))
+}
+
+func TestLoadAndPersist(t *testing.T) {
+	server := newTestTokenServer(t)
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token.json")
+	initial := storedToken{
+		AccessToken:  "expired-token",
+		RefreshToken: "refresh-0",
+		Expiry:       time.Now().Add(-time.Hour), // already expired, forces a refresh
+	}
+	bs, _ := json.Marshal(initial)
+	if err := os.WriteFile(path, bs, 0600); err != nil {
+		t.Fatalf("cannot write seed token: %v", err)
+	}
+
+	config := &oauth2.Config{
+		ClientID: "test-client",
+		Endpoint: oauth2.Endpoint{TokenURL: server.URL},
+	}
+
+	ts, err := Load(context.Background(), path, config)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tok, err := ts.Token()
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if tok.AccessToken != "tok-1" {
+		t.Errorf("AccessToken = %q, want tok-1", tok.AccessToken)
+	}
+
+	// The refreshed token should have been persisted back to disk.
+	bs, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cannot read persisted token: %v", err)
+	}
+	var persisted storedToken
+	if err := json.Unmarshal(bs, &persisted); err != nil {
+		t.Fatalf("cannot parse persisted token: %v", err)
+	}
+	if persisted.AccessToken != "tok-1" {
+		t.Errorf("persisted AccessToken = %q, want tok-1", persisted.AccessToken)
+	}
+
+	// A second Token() call with a still-valid cached token should not
+	// hit the server again.
+	tok2, err := ts.Token()
+	if err != nil {
+		t.Fatalf("second Token() error = %v", err)
+	}
+	if tok2.AccessToken != tok.AccessToken {
+		t.Errorf("second Token() minted a new token unexpectedly: %q vs %q", tok2.AccessToken, tok.AccessToken)
+	}
+}
+
+func TestSaveTokenAndPeek(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "new", "tokens.json")
+
+	tok := &oauth2.Token{
+		AccessToken:  "access-1",
+		TokenType:    "Bearer",
+		RefreshToken: "refresh-1",
+		Expiry:       time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+	if err := SaveToken(path, tok); err != nil {
+		t.Fatalf("SaveToken() error = %v", err)
+	}
+
+	got, err := Peek(path)
+	if err != nil {
+		t.Fatalf("Peek() error = %v", err)
+	}
+	if got.AccessToken != tok.AccessToken || got.RefreshToken != tok.RefreshToken {
+		t.Errorf("Peek() = %+v, want AccessToken/RefreshToken matching %+v", got, tok)
+	}
+	if !got.Expiry.Equal(tok.Expiry) {
+		t.Errorf("Peek().Expiry = %v, want %v", got.Expiry, tok.Expiry)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/goblet"
+)
+
+func init() {
+	goblet.RegisterTokenSourceFactory("vault", newFromProviderConfig)
+}
+
+// providerConfig is the goblet.Registry provider_config schema for the
+// "vault" provider.
+type providerConfig struct {
+	Address               string `json:"address"`
+	Method                string `json:"method,omitempty"` // "approle" (default) or "kubernetes"
+	RoleID                string `json:"role_id,omitempty"`
+	SecretID              string `json:"secret_id,omitempty"`
+	Role                  string `json:"role,omitempty"`
+	ServiceAccountJWTPath string `json:"service_account_jwt_path,omitempty"`
+	PathTemplate          string `json:"path_template"`
+}
+
+func newFromProviderConfig(raw json.RawMessage) (goblet.URLTokenSource, error) {
+	var cfg providerConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("vault: cannot parse provider config: %w", err)
+	}
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("vault: provider config missing address")
+	}
+	if cfg.PathTemplate == "" {
+		return nil, fmt.Errorf("vault: provider config missing path_template")
+	}
+
+	ts := New(cfg.Address)
+	switch cfg.Method {
+	case "", "approle":
+		ts.Method = AuthMethodAppRole
+	case "kubernetes":
+		ts.Method = AuthMethodKubernetes
+	default:
+		return nil, fmt.Errorf("vault: provider config has unknown method %q", cfg.Method)
+	}
+	ts.RoleID, ts.SecretID = cfg.RoleID, cfg.SecretID
+	ts.Role = cfg.Role
+	ts.ServiceAccountJWTPath = cfg.ServiceAccountJWTPath
+	ts.PathTemplate = cfg.PathTemplate
+
+	return goblet.URLTokenSourceFunc(ts.Token), nil
+}
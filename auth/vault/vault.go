@@ -0,0 +1,410 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vault implements a goblet TokenSource backed by HashiCorp
+// Vault: it authenticates via AppRole or a Kubernetes service-account
+// JWT, reads the upstream's credential from a per-host/org secret path,
+// and renews the lease in the background, so operators can avoid
+// embedding long-lived PATs in goblet config.
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+)
+
+// AuthMethod selects how TokenSource logs in to Vault.
+type AuthMethod int
+
+const (
+	// AuthMethodAppRole logs in with RoleID/SecretID against
+	// auth/approle/login.
+	AuthMethodAppRole AuthMethod = iota
+	// AuthMethodKubernetes logs in with the pod's service-account JWT
+	// against auth/kubernetes/login.
+	AuthMethodKubernetes
+)
+
+// defaultServiceAccountJWTPath is where Kubernetes projects a pod's
+// service-account token by default.
+const defaultServiceAccountJWTPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// expirySkew is subtracted from a lease's reported duration so a token
+// is renewed/refetched slightly before Vault actually revokes it.
+const expirySkew = 30 * time.Second
+
+// defaultLeaseCacheTTL is used in place of leaseDuration - expirySkew
+// when a lease reports a duration too short (or zero, e.g. a
+// non-expiring periodic token or a secret engine that doesn't set a
+// TTL) for that subtraction to produce a usable, non-past expiry. It
+// mirrors LRUTokenCache.store's Expiry.IsZero() handling: cache the
+// result for a reasonable default instead of computing a lifetime that
+// would make every call refetch (or log in) again immediately.
+const defaultLeaseCacheTTL = 24 * time.Hour
+
+// leaseExpiry returns the time at which a lease reported as lasting
+// leaseDuration should be treated as needing renewal/refetch: skew
+// before its actual expiry, normally, or defaultLeaseCacheTTL out if
+// leaseDuration is too short (including zero) for that skew to leave
+// any useful lifetime.
+func leaseExpiry(now time.Time, leaseDuration time.Duration) time.Time {
+	if leaseDuration <= expirySkew {
+		return now.Add(defaultLeaseCacheTTL)
+	}
+	return now.Add(leaseDuration - expirySkew)
+}
+
+// TokenSource mints goblet upstream credentials by reading them out of
+// Vault, satisfying the func(*url.URL) (*oauth2.Token, error) contract
+// ServerConfig.TokenSource expects. The zero value is not usable;
+// construct one with New.
+type TokenSource struct {
+	// Address is the Vault server's base URL, e.g. "https://vault.internal:8200".
+	Address string
+	// HTTPClient performs all Vault API calls. Defaults to
+	// http.DefaultClient if nil.
+	HTTPClient *http.Client
+
+	// Method selects AuthMethodAppRole or AuthMethodKubernetes.
+	Method AuthMethod
+	// RoleID and SecretID authenticate AuthMethodAppRole.
+	RoleID, SecretID string
+	// Role authenticates AuthMethodKubernetes, naming the Vault role
+	// bound to the pod's service account.
+	Role string
+	// ServiceAccountJWTPath is read fresh on every AuthMethodKubernetes
+	// login. Defaults to defaultServiceAccountJWTPath.
+	ServiceAccountJWTPath string
+
+	// PathTemplate maps an upstream to the Vault secret path read for
+	// its credential, e.g. "github/token/:org", where ":org" is
+	// replaced by the first path segment of the *url.URL passed to
+	// Token. A template with no ":org" placeholder reads one shared
+	// secret for every upstream (a generic KV path).
+	PathTemplate string
+
+	loginGroup  singleflight.Group
+	secretGroup singleflight.Group
+
+	mu               sync.Mutex
+	vaultToken       string
+	vaultTokenExpiry time.Time
+
+	secretMu sync.Mutex
+	secrets  map[string]*oauth2.Token
+}
+
+// New returns a TokenSource that logs in to the Vault server at address.
+func New(address string) *TokenSource {
+	return &TokenSource{
+		Address: address,
+		secrets: map[string]*oauth2.Token{},
+	}
+}
+
+// Error reports a non-2xx response from Vault itself, as opposed to a
+// network failure reaching it, carrying the status code so the caller
+// can distinguish a permission/not-found denial (403/404) -- which
+// should surface to the client as a 401 -- from anything else, which
+// should surface as a 502.
+type Error struct {
+	// Path is the Vault API path that returned StatusCode, e.g.
+	// "auth/approle/login" or "github/token/an-org".
+	Path string
+	// StatusCode is Vault's HTTP response status.
+	StatusCode int
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("vault %s returned status %d", e.Path, e.StatusCode)
+}
+
+// Denied reports whether StatusCode is one the caller should treat as a
+// credential denial (403 permission denied, 404 secret/role not found)
+// rather than a Vault or network failure.
+func (e *Error) Denied() bool {
+	return e.StatusCode == http.StatusForbidden || e.StatusCode == http.StatusNotFound
+}
+
+// Token implements the ServerConfig.TokenSource signature: it resolves
+// u to a Vault secret path via PathTemplate, returning a cached token
+// for that path if one hasn't expired, and otherwise logging in (if
+// needed) and reading the secret, caching the result and starting a
+// renewer goroutine if Vault reports the lease as renewable.
+func (ts *TokenSource) Token(u *url.URL) (*oauth2.Token, error) {
+	path := ts.secretPath(u)
+
+	if tok, ok := ts.cachedSecret(path); ok {
+		return tok, nil
+	}
+
+	v, err, _ := ts.secretGroup.Do(path, func() (interface{}, error) {
+		return ts.fetchSecret(path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*oauth2.Token), nil
+}
+
+// secretPath substitutes the first path segment of u (its org/owner) for
+// ":org" in PathTemplate.
+func (ts *TokenSource) secretPath(u *url.URL) string {
+	org := strings.TrimPrefix(u.Path, "/")
+	if i := strings.Index(org, "/"); i >= 0 {
+		org = org[:i]
+	}
+	return strings.ReplaceAll(ts.PathTemplate, ":org", org)
+}
+
+func (ts *TokenSource) cachedSecret(path string) (*oauth2.Token, bool) {
+	ts.secretMu.Lock()
+	defer ts.secretMu.Unlock()
+	tok, ok := ts.secrets[path]
+	if !ok {
+		return nil, false
+	}
+	if !tok.Expiry.IsZero() && time.Now().After(tok.Expiry) {
+		delete(ts.secrets, path)
+		return nil, false
+	}
+	return tok, true
+}
+
+func (ts *TokenSource) storeSecret(path string, tok *oauth2.Token) {
+	ts.secretMu.Lock()
+	defer ts.secretMu.Unlock()
+	ts.secrets[path] = tok
+}
+
+// fetchSecret logs in if necessary, reads path, caches the resulting
+// token, and starts a renewer goroutine for a renewable lease.
+func (ts *TokenSource) fetchSecret(path string) (*oauth2.Token, error) {
+	vaultToken, err := ts.login()
+	if err != nil {
+		return nil, err
+	}
+
+	data, leaseID, leaseDuration, renewable, err := ts.readSecret(vaultToken, path)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, _ := data["token"].(string)
+	if accessToken == "" {
+		return nil, fmt.Errorf("vault secret %q has no string \"token\" field", path)
+	}
+
+	tok := &oauth2.Token{AccessToken: accessToken, TokenType: "Bearer", Expiry: leaseExpiry(time.Now(), leaseDuration)}
+	ts.storeSecret(path, tok)
+
+	if renewable && leaseID != "" {
+		go ts.renew(path, leaseID, leaseDuration)
+	}
+	return tok, nil
+}
+
+// renew keeps a renewable lease alive slightly ahead of its expiry,
+// extending the cached token's Expiry on every successful renewal so
+// Token keeps serving it from the cache instead of treating it as
+// expired and fetching (and renewing) it a second time. renew stops
+// once a renewal fails (the lease has been revoked, or Vault is
+// unreachable) -- the next Token call will log in and fetch fresh.
+func (ts *TokenSource) renew(path, leaseID string, leaseDuration time.Duration) {
+	for {
+		wait := leaseDuration - expirySkew
+		if wait <= 0 {
+			return
+		}
+		time.Sleep(wait)
+
+		nextDuration, err := ts.renewLease(leaseID)
+		if err != nil {
+			return
+		}
+		leaseDuration = nextDuration
+		ts.extendSecret(path, leaseDuration)
+	}
+}
+
+// extendSecret pushes out the cached token's Expiry after a successful
+// lease renewal, leaving the AccessToken itself untouched since Vault's
+// renew API extends the existing lease rather than minting a new secret.
+// It's a no-op if path's entry has since been evicted or replaced.
+func (ts *TokenSource) extendSecret(path string, leaseDuration time.Duration) {
+	ts.secretMu.Lock()
+	defer ts.secretMu.Unlock()
+	tok, ok := ts.secrets[path]
+	if !ok {
+		return
+	}
+	tok.Expiry = leaseExpiry(time.Now(), leaseDuration)
+}
+
+// login returns a cached, unexpired Vault token, logging in via
+// AuthMethodAppRole or AuthMethodKubernetes if none is cached.
+func (ts *TokenSource) login() (string, error) {
+	ts.mu.Lock()
+	if ts.vaultToken != "" && time.Now().Before(ts.vaultTokenExpiry) {
+		tok := ts.vaultToken
+		ts.mu.Unlock()
+		return tok, nil
+	}
+	ts.mu.Unlock()
+
+	v, err, _ := ts.loginGroup.Do("login", func() (interface{}, error) {
+		return ts.doLogin()
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+func (ts *TokenSource) doLogin() (string, error) {
+	var path string
+	var body map[string]string
+	switch ts.Method {
+	case AuthMethodKubernetes:
+		jwtPath := ts.ServiceAccountJWTPath
+		if jwtPath == "" {
+			jwtPath = defaultServiceAccountJWTPath
+		}
+		jwt, err := os.ReadFile(jwtPath)
+		if err != nil {
+			return "", fmt.Errorf("cannot read kubernetes service account token %q: %w", jwtPath, err)
+		}
+		path = "auth/kubernetes/login"
+		body = map[string]string{"role": ts.Role, "jwt": string(jwt)}
+	default:
+		path = "auth/approle/login"
+		body = map[string]string{"role_id": ts.RoleID, "secret_id": ts.SecretID}
+	}
+
+	var resp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int64  `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	if err := ts.call(http.MethodPost, path, body, &resp); err != nil {
+		return "", err
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault %s returned no client_token", path)
+	}
+
+	ts.mu.Lock()
+	ts.vaultToken = resp.Auth.ClientToken
+	ts.vaultTokenExpiry = leaseExpiry(time.Now(), time.Duration(resp.Auth.LeaseDuration)*time.Second)
+	ts.mu.Unlock()
+
+	return resp.Auth.ClientToken, nil
+}
+
+// readSecret performs an authenticated GET of path, returning its data
+// map and lease metadata.
+func (ts *TokenSource) readSecret(vaultToken, path string) (data map[string]interface{}, leaseID string, leaseDuration time.Duration, renewable bool, err error) {
+	var resp struct {
+		Data          map[string]interface{} `json:"data"`
+		LeaseID       string                  `json:"lease_id"`
+		LeaseDuration int64                   `json:"lease_duration"`
+		Renewable     bool                    `json:"renewable"`
+	}
+	if err := ts.authenticatedCall(http.MethodGet, vaultToken, path, nil, &resp); err != nil {
+		return nil, "", 0, false, err
+	}
+	return resp.Data, resp.LeaseID, time.Duration(resp.LeaseDuration) * time.Second, resp.Renewable, nil
+}
+
+// renewLease extends leaseID and returns its new duration.
+func (ts *TokenSource) renewLease(leaseID string) (time.Duration, error) {
+	vaultToken, err := ts.login()
+	if err != nil {
+		return 0, err
+	}
+	var resp struct {
+		LeaseDuration int64 `json:"lease_duration"`
+	}
+	body := map[string]string{"lease_id": leaseID}
+	if err := ts.authenticatedCall(http.MethodPut, vaultToken, "sys/leases/renew", body, &resp); err != nil {
+		return 0, err
+	}
+	return time.Duration(resp.LeaseDuration) * time.Second, nil
+}
+
+// authenticatedCall is call with an X-Vault-Token header attached.
+func (ts *TokenSource) authenticatedCall(method, vaultToken, path string, body interface{}, out interface{}) error {
+	return ts.doCall(method, path, body, vaultToken, out)
+}
+
+// call is authenticatedCall without a token, for the login endpoints.
+func (ts *TokenSource) call(method, path string, body interface{}, out interface{}) error {
+	return ts.doCall(method, path, body, "", out)
+}
+
+func (ts *TokenSource) doCall(method, path string, body interface{}, vaultToken string, out interface{}) error {
+	var reqBody *bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("cannot encode vault request for %q: %w", path, err)
+		}
+		reqBody = bytes.NewReader(b)
+	} else {
+		reqBody = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(ts.Address, "/")+"/v1/"+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("cannot construct vault request for %q: %w", path, err)
+	}
+	if vaultToken != "" {
+		req.Header.Set("X-Vault-Token", vaultToken)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ts.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach vault at %q: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &Error{Path: path, StatusCode: resp.StatusCode}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("cannot decode vault response for %q: %w", path, err)
+	}
+	return nil
+}
+
+func (ts *TokenSource) client() *http.Client {
+	if ts.HTTPClient != nil {
+		return ts.HTTPClient
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,276 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// newTestVaultServer stubs AppRole login and a single KV-style secret
+// read at "github/token/an-org", counting logins so tests can tell a
+// fresh login apart from a cached one.
+func newTestVaultServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var logins int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			RoleID   string `json:"role_id"`
+			SecretID string `json:"secret_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("cannot decode approle login request: %v", err)
+		}
+		if req.RoleID != "test-role" || req.SecretID != "test-secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		atomic.AddInt32(&logins, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{
+				"client_token":   "vault-client-token",
+				"lease_duration": 3600,
+			},
+		})
+	})
+	mux.HandleFunc("/v1/github/token/an-org", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Vault-Token"); got != "vault-client-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data":           map[string]interface{}{"token": "scoped-installation-token"},
+			"lease_id":       "github/token/an-org/abcd",
+			"lease_duration": 3600,
+			"renewable":      false,
+		})
+	})
+	return httptest.NewServer(mux), &logins
+}
+
+func TestTokenSourceFetchesAndCachesSecret(t *testing.T) {
+	server, logins := newTestVaultServer(t)
+	defer server.Close()
+
+	ts := New(server.URL)
+	ts.RoleID, ts.SecretID = "test-role", "test-secret"
+	ts.PathTemplate = "github/token/:org"
+
+	upstreamURL, _ := url.Parse("https://github.com/an-org/a-repo")
+
+	tok, err := ts.Token(upstreamURL)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if tok.AccessToken != "scoped-installation-token" {
+		t.Errorf("AccessToken = %q, want scoped-installation-token", tok.AccessToken)
+	}
+	if tok.TokenType != "Bearer" {
+		t.Errorf("TokenType = %q, want Bearer", tok.TokenType)
+	}
+
+	if _, err := ts.Token(upstreamURL); err != nil {
+		t.Fatalf("second Token() error = %v", err)
+	}
+	if got := atomic.LoadInt32(logins); got != 1 {
+		t.Errorf("vault logged in %d times, want 1 (second call should hit the secret cache)", got)
+	}
+}
+
+func TestExtendSecretUpdatesCachedExpiry(t *testing.T) {
+	ts := New("http://vault.invalid")
+	const path = "github/token/an-org"
+	ts.storeSecret(path, &oauth2.Token{AccessToken: "scoped-installation-token", Expiry: time.Now().Add(-time.Hour)})
+
+	ts.extendSecret(path, time.Hour)
+
+	tok, ok := ts.cachedSecret(path)
+	if !ok {
+		t.Fatal("cachedSecret() ok = false after extendSecret, want the renewed entry to stay cached")
+	}
+	if !tok.Expiry.After(time.Now()) {
+		t.Errorf("Expiry = %v, want a time in the future after a successful renewal", tok.Expiry)
+	}
+	if tok.AccessToken != "scoped-installation-token" {
+		t.Errorf("AccessToken = %q, want the original token unchanged by renewal", tok.AccessToken)
+	}
+}
+
+func TestLeaseExpiryFloorsShortOrZeroLeaseDuration(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		leaseDuration time.Duration
+	}{
+		{"zero lease_duration", 0},
+		{"lease_duration under expirySkew", expirySkew / 2},
+		{"lease_duration equal to expirySkew", expirySkew},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := leaseExpiry(now, tt.leaseDuration)
+			if !got.After(now) {
+				t.Errorf("leaseExpiry(%v) = %v, want a time after now so a non-expiring or dev-mode lease still gets cached instead of refetched on every call", tt.leaseDuration, got)
+			}
+			if want := now.Add(defaultLeaseCacheTTL); !got.Equal(want) {
+				t.Errorf("leaseExpiry(%v) = %v, want %v (defaultLeaseCacheTTL out)", tt.leaseDuration, got, want)
+			}
+		})
+	}
+}
+
+func TestTokenSourceCachesSecretWithZeroLeaseDuration(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": "vault-client-token", "lease_duration": 3600},
+		})
+	})
+	var reads int32
+	mux.HandleFunc("/v1/github/token/an-org", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&reads, 1)
+		// A dev-mode Vault or a secret engine with no TTL configured
+		// reports lease_duration: 0.
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data":           map[string]interface{}{"token": "scoped-installation-token"},
+			"lease_duration": 0,
+			"renewable":      false,
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ts := New(server.URL)
+	ts.RoleID, ts.SecretID = "test-role", "test-secret"
+	ts.PathTemplate = "github/token/:org"
+
+	upstreamURL, _ := url.Parse("https://github.com/an-org/a-repo")
+	if _, err := ts.Token(upstreamURL); err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if _, err := ts.Token(upstreamURL); err != nil {
+		t.Fatalf("second Token() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&reads); got != 1 {
+		t.Errorf("vault read the secret %d times, want 1 (a zero lease_duration should still be cached rather than refetched on every call)", got)
+	}
+}
+
+func TestTokenSourceKubernetesAuth(t *testing.T) {
+	jwtPath := t.TempDir() + "/token"
+	if err := os.WriteFile(jwtPath, []byte("fake-service-account-jwt"), 0600); err != nil {
+		t.Fatalf("cannot write service account jwt: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/kubernetes/login", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Role, JWT string }
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("cannot decode kubernetes login request: %v", err)
+		}
+		if req.Role != "goblet" || req.JWT != "fake-service-account-jwt" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": "k8s-client-token", "lease_duration": 3600},
+		})
+	})
+	mux.HandleFunc("/v1/secret/goblet", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Vault-Token"); got != "k8s-client-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"token": "k8s-backed-token"},
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ts := New(server.URL)
+	ts.Method = AuthMethodKubernetes
+	ts.Role = "goblet"
+	ts.ServiceAccountJWTPath = jwtPath
+	ts.PathTemplate = "secret/goblet"
+
+	upstreamURL, _ := url.Parse("https://gitlab.example.com/org/repo")
+	tok, err := ts.Token(upstreamURL)
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if tok.AccessToken != "k8s-backed-token" {
+		t.Errorf("AccessToken = %q, want k8s-backed-token", tok.AccessToken)
+	}
+}
+
+func TestTokenSourceDeniedSecretReturnsTypedError(t *testing.T) {
+	server, _ := newTestVaultServer(t)
+	defer server.Close()
+
+	ts := New(server.URL)
+	ts.RoleID, ts.SecretID = "test-role", "test-secret"
+	ts.PathTemplate = "github/token/:org"
+
+	upstreamURL, _ := url.Parse("https://github.com/unknown-org/a-repo")
+	_, err := ts.Token(upstreamURL)
+	if err == nil {
+		t.Fatal("Token() succeeded for an unconfigured secret path, want an error")
+	}
+	verr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("error type = %T, want *vault.Error", err)
+	}
+	if !verr.Denied() {
+		t.Errorf("Denied() = false for status %d, want true", verr.StatusCode)
+	}
+}
+
+func TestTokenSourceLoginFailureIsNotDenied(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ts := New(server.URL)
+	ts.RoleID, ts.SecretID = "test-role", "test-secret"
+	ts.PathTemplate = "github/token/:org"
+
+	upstreamURL, _ := url.Parse("https://github.com/an-org/a-repo")
+	_, err := ts.Token(upstreamURL)
+	if err == nil {
+		t.Fatal("Token() succeeded against a failing vault login, want an error")
+	}
+	verr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("error type = %T, want *vault.Error", err)
+	}
+	if verr.Denied() {
+		t.Error("Denied() = true for a 500 login failure, want false (not a credential denial)")
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// AuthBackendDecision is the JSON body an external auth backend returns
+// from POST {AuthBackendURL}/authorize, modeled on GitLab workhorse's
+// delegated-auth architecture: workhorse forwards the client request to
+// Rails, and Rails' response decides whether to proceed and with what
+// identity, rather than workhorse making that call itself.
+type AuthBackendDecision struct {
+	// Allowed reports whether the request may proceed. A false value
+	// means HTTPHandler should deny the request with Message (or a
+	// generic denial if Message is empty) instead of running the
+	// smart-HTTP machinery at all.
+	Allowed bool `json:"allowed"`
+	// Message is surfaced to the client when Allowed is false.
+	Message string `json:"message,omitempty"`
+	// RepoPath, if set, overrides the namespace/repo path
+	// URLCanonializer would otherwise derive from the request, the way
+	// Rails can remap a request to a different upstream project than
+	// the one the client asked for (e.g. following a rename).
+	RepoPath string `json:"repo_path,omitempty"`
+	// Token, if set, is used as the upstream fetch's OAuth2 token in
+	// place of whatever TokenSource would have produced, letting the
+	// backend mint a request-scoped credential instead of every request
+	// reusing whatever TokenSource last resolved.
+	Token *oauth2.Token `json:"token,omitempty"`
+	// TempPath, if set, names a directory the request handler should use
+	// for any scratch files this request needs (e.g. a quarantine
+	// directory for an incoming push), instead of a path goblet picks
+	// itself -- mirroring GitLab workhorse's TempPath in its own
+	// pre-authorize response.
+	TempPath string `json:"temp_path,omitempty"`
+	// GitConfigOptions, if set, are passed as additional "-c key=value"
+	// arguments to the git subprocess handling this request, letting the
+	// backend tune per-request git behavior (e.g. uploadpack.allowFilter)
+	// without goblet needing a config knob for every case.
+	GitConfigOptions []string `json:"git_config_options,omitempty"`
+	// ExtraClaims carries backend-specific data the request handler has
+	// no fixed field for -- e.g. a tenant ID or audit annotation -- so a
+	// given deployment's auth backend can pass its own policy decisions
+	// through without every field living in this struct.
+	ExtraClaims map[string]json.RawMessage `json:"extra_claims,omitempty"`
+}
+
+// AuthBackendError reports a non-2xx response from the auth backend
+// itself (as opposed to a network failure constructing or sending the
+// request), carrying the backend's status code and body so the caller
+// can decide how to surface it -- a denial-shaped 401/403/404 can be
+// relayed to the client verbatim, the way a real auth backend would
+// reject a request, while anything else (a 500, a malformed body) is a
+// backend failure the caller should treat as a 502.
+type AuthBackendError struct {
+	// StatusCode is the backend's HTTP response status.
+	StatusCode int
+	// Body is the backend's response body, truncated to a reasonable
+	// size for inclusion in an error message.
+	Body string
+}
+
+func (e *AuthBackendError) Error() string {
+	return fmt.Sprintf("auth backend returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// IsDenial reports whether the backend's status code is one a caller
+// should relay to the client verbatim (401, 403, 404) rather than
+// translate into a generic 502.
+func (e *AuthBackendError) IsDenial() bool {
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return true
+	default:
+		return false
+	}
+}
+
+// maxAuthBackendErrorBody bounds how much of a non-2xx response body
+// AuthBackendError.Body keeps, so a backend that returns an HTML error
+// page doesn't end up entirely inside a log line or error message.
+const maxAuthBackendErrorBody = 4096
+
+// AuthBackend delegates authorization decisions for incoming requests to
+// an external HTTP service, the way GitLab workhorse forwards every Git
+// HTTP request to Rails before running git-http-backend. Set
+// ServerConfig.AuthBackendURL to an AuthBackend's BaseURL to enable it.
+//
+// Calling Authorize from the HTTP handler -- which is not present in
+// this checkout -- is the remaining integration step: HTTPHandler should
+// call it before running the smart-HTTP machinery for every request,
+// deny the request if the decision's Allowed is false, pass RepoPath (if
+// set) to URLCanonializer in place of the request path, and pass Token
+// (if set) to the upstream fetch in place of whatever TokenSource would
+// have produced.
+type AuthBackend struct {
+	// BaseURL is the backend's base address; Authorize POSTs to
+	// BaseURL.String() + "/authorize".
+	BaseURL *url.URL
+	// Client sends the authorize request. Defaults to http.DefaultClient
+	// if nil. Since every request goblet serves calls Authorize once,
+	// callers wiring a custom Client in production should give it a
+	// Transport with connection pooling (http.DefaultTransport's
+	// defaults are fine) rather than building a fresh one per request.
+	Client *http.Client
+	// Timeout bounds how long Authorize waits for the backend before
+	// giving up, so a hung backend fails the request rather than hanging
+	// it indefinitely. Zero means no additional timeout beyond ctx's own
+	// deadline.
+	Timeout time.Duration
+}
+
+// NewAuthBackend returns an AuthBackend that delegates to baseURL.
+func NewAuthBackend(baseURL *url.URL) *AuthBackend {
+	return &AuthBackend{BaseURL: baseURL}
+}
+
+// authBackendRequest is the JSON body Authorize POSTs to the backend,
+// carrying just enough of the inbound request for the backend to decide:
+// GitLab workhorse's Rails endpoint makes the equivalent decision from
+// the same three fields.
+type authBackendRequest struct {
+	Method  string              `json:"method"`
+	Path    string              `json:"path"`
+	Headers map[string][]string `json:"headers"`
+}
+
+// delegatedHeaders lists the request headers forwarded to the auth
+// backend. Authorization and User-Agent are the minimum GitLab
+// workhorse forwards; Git-Protocol lets the backend see the client's
+// requested protocol version if that ever factors into its decision.
+var delegatedHeaders = []string{"Authorization", "User-Agent", "Git-Protocol"}
+
+// Authorize asks the auth backend whether r may proceed, POSTing r's
+// method, path, and delegatedHeaders to {BaseURL}/authorize and decoding
+// the JSON response as an AuthBackendDecision. A non-2xx response
+// returns an *AuthBackendError (see IsDenial); a malformed body or any
+// other failure reaching the backend is returned as a plain error. A
+// caller should fail closed on either -- denying the request -- rather
+// than mistake "backend unreachable" for "permitted".
+func (b *AuthBackend) Authorize(ctx context.Context, r *http.Request) (*AuthBackendDecision, error) {
+	if b.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.Timeout)
+		defer cancel()
+	}
+
+	headers := make(map[string][]string, len(delegatedHeaders))
+	for _, name := range delegatedHeaders {
+		if v := r.Header.Values(name); len(v) > 0 {
+			headers[name] = v
+		}
+	}
+
+	body, err := json.Marshal(authBackendRequest{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Headers: headers,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode auth backend request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.BaseURL.String()+"/authorize", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("cannot construct auth backend request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("auth backend request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxAuthBackendErrorBody))
+		return nil, &AuthBackendError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var decision AuthBackendDecision
+	if err := json.NewDecoder(resp.Body).Decode(&decision); err != nil {
+		return nil, fmt.Errorf("cannot decode auth backend response: %w", err)
+	}
+	return &decision, nil
+}
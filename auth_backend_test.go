@@ -0,0 +1,203 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func newAuthBackendTestServer(t *testing.T, handler http.HandlerFunc) *AuthBackend {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+	return NewAuthBackend(u)
+}
+
+func TestAuthBackendAuthorizeAllowed(t *testing.T) {
+	var gotMethod, gotPath, gotAuth string
+	backend := newAuthBackendTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var req authBackendRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode authorize request: %v", err)
+		}
+		gotMethod = req.Method
+		gotPath = req.Path
+		if v := req.Headers["Authorization"]; len(v) > 0 {
+			gotAuth = v[0]
+		}
+		json.NewEncoder(w).Encode(AuthBackendDecision{Allowed: true})
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/org/repo.git/info/refs", nil)
+	r.Header.Set("Authorization", "Bearer test-token")
+
+	decision, err := backend.Authorize(context.Background(), r)
+	if err != nil {
+		t.Fatalf("Authorize() failed: %v", err)
+	}
+	if !decision.Allowed {
+		t.Error("decision.Allowed = false, want true")
+	}
+	if gotMethod != http.MethodGet {
+		t.Errorf("forwarded method = %q, want %q", gotMethod, http.MethodGet)
+	}
+	if gotPath != "/org/repo.git/info/refs" {
+		t.Errorf("forwarded path = %q, want %q", gotPath, "/org/repo.git/info/refs")
+	}
+	if gotAuth != "Bearer test-token" {
+		t.Errorf("forwarded Authorization = %q, want %q", gotAuth, "Bearer test-token")
+	}
+}
+
+func TestAuthBackendAuthorizeDenied(t *testing.T) {
+	backend := newAuthBackendTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(AuthBackendDecision{Allowed: false, Message: "not permitted"})
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/org/repo.git/info/refs", nil)
+	decision, err := backend.Authorize(context.Background(), r)
+	if err != nil {
+		t.Fatalf("Authorize() failed: %v", err)
+	}
+	if decision.Allowed {
+		t.Error("decision.Allowed = true, want false")
+	}
+	if decision.Message != "not permitted" {
+		t.Errorf("decision.Message = %q, want %q", decision.Message, "not permitted")
+	}
+}
+
+func TestAuthBackendAuthorizeOverridesRepoPathAndToken(t *testing.T) {
+	backend := newAuthBackendTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(AuthBackendDecision{
+			Allowed:  true,
+			RepoPath: "other-org/other-repo",
+			Token:    &oauth2.Token{AccessToken: "delegated-token", TokenType: "Bearer"},
+		})
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/org/repo.git/info/refs", nil)
+	decision, err := backend.Authorize(context.Background(), r)
+	if err != nil {
+		t.Fatalf("Authorize() failed: %v", err)
+	}
+	if decision.RepoPath != "other-org/other-repo" {
+		t.Errorf("decision.RepoPath = %q, want %q", decision.RepoPath, "other-org/other-repo")
+	}
+	if decision.Token == nil || decision.Token.AccessToken != "delegated-token" {
+		t.Errorf("decision.Token = %+v, want AccessToken %q", decision.Token, "delegated-token")
+	}
+}
+
+func TestAuthBackendAuthorizeErrorOnNon2xx(t *testing.T) {
+	backend := newAuthBackendTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/org/repo.git/info/refs", nil)
+	if _, err := backend.Authorize(context.Background(), r); err == nil {
+		t.Error("Authorize() succeeded against a 500 response, want an error")
+	}
+}
+
+func TestAuthBackendAuthorizeNon2xxReturnsAuthBackendError(t *testing.T) {
+	tests := []struct {
+		status     int
+		wantDenial bool
+	}{
+		{http.StatusUnauthorized, true},
+		{http.StatusForbidden, true},
+		{http.StatusNotFound, true},
+		{http.StatusInternalServerError, false},
+	}
+	for _, tt := range tests {
+		backend := newAuthBackendTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte("backend says no"))
+		})
+
+		r := httptest.NewRequest(http.MethodGet, "/org/repo.git/info/refs", nil)
+		_, err := backend.Authorize(context.Background(), r)
+		if err == nil {
+			t.Fatalf("status %d: Authorize() succeeded, want an error", tt.status)
+		}
+		abErr, ok := err.(*AuthBackendError)
+		if !ok {
+			t.Fatalf("status %d: error type = %T, want *AuthBackendError", tt.status, err)
+		}
+		if abErr.StatusCode != tt.status {
+			t.Errorf("status %d: AuthBackendError.StatusCode = %d, want %d", tt.status, abErr.StatusCode, tt.status)
+		}
+		if abErr.IsDenial() != tt.wantDenial {
+			t.Errorf("status %d: IsDenial() = %v, want %v", tt.status, abErr.IsDenial(), tt.wantDenial)
+		}
+	}
+}
+
+func TestAuthBackendAuthorizeTimeout(t *testing.T) {
+	backend := newAuthBackendTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		json.NewEncoder(w).Encode(AuthBackendDecision{Allowed: true})
+	})
+	backend.Timeout = time.Millisecond
+
+	r := httptest.NewRequest(http.MethodGet, "/org/repo.git/info/refs", nil)
+	if _, err := backend.Authorize(context.Background(), r); err == nil {
+		t.Error("Authorize() succeeded against a backend slower than Timeout, want an error")
+	}
+}
+
+func TestAuthBackendAuthorizeDecodesTempPathGitConfigOptionsAndExtraClaims(t *testing.T) {
+	backend := newAuthBackendTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(AuthBackendDecision{
+			Allowed:          true,
+			TempPath:         "/tmp/goblet-quarantine/abc123",
+			GitConfigOptions: []string{"uploadpack.allowFilter=true"},
+			ExtraClaims:      map[string]json.RawMessage{"tenant_id": json.RawMessage(`"tenant-a"`)},
+		})
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/org/repo.git/info/refs", nil)
+	decision, err := backend.Authorize(context.Background(), r)
+	if err != nil {
+		t.Fatalf("Authorize() failed: %v", err)
+	}
+	if decision.TempPath != "/tmp/goblet-quarantine/abc123" {
+		t.Errorf("decision.TempPath = %q, want %q", decision.TempPath, "/tmp/goblet-quarantine/abc123")
+	}
+	if len(decision.GitConfigOptions) != 1 || decision.GitConfigOptions[0] != "uploadpack.allowFilter=true" {
+		t.Errorf("decision.GitConfigOptions = %v, want [uploadpack.allowFilter=true]", decision.GitConfigOptions)
+	}
+	var tenantID string
+	if err := json.Unmarshal(decision.ExtraClaims["tenant_id"], &tenantID); err != nil {
+		t.Fatalf("cannot unmarshal extra_claims.tenant_id: %v", err)
+	}
+	if tenantID != "tenant-a" {
+		t.Errorf("decision.ExtraClaims[tenant_id] = %q, want %q", tenantID, "tenant-a")
+	}
+}
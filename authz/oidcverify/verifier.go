@@ -0,0 +1,61 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oidcverify adapts auth/oidc's ID token verification into a
+// goblet.OIDCVerifier, so IsolationConfig can authenticate a request's
+// bearer token -- issuer, audience, expiry, not-before, and signature
+// against the issuer's JWKS -- before IsolationUser/IsolationTenant
+// trust any claim extracted from it.
+package oidcverify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/goblet"
+	"github.com/google/goblet/auth/oidc"
+)
+
+// Verifier implements goblet.OIDCVerifier against a single OIDC issuer.
+type Verifier struct {
+	verifier *oidc.Verifier
+}
+
+// New fetches issuerURL's discovery document and JWKS (go-oidc caches
+// the key set and refreshes it as needed for later verifications) and
+// returns a Verifier that accepts tokens issued for audience clientID.
+func New(ctx context.Context, issuerURL, clientID string) (*Verifier, error) {
+	v, err := oidc.NewVerifier(ctx, &oidc.Config{IssuerURL: issuerURL, ClientID: clientID})
+	if err != nil {
+		return nil, fmt.Errorf("oidcverify: %w", err)
+	}
+	return &Verifier{verifier: v}, nil
+}
+
+// VerifyBearerToken implements goblet.OIDCVerifier.
+func (v *Verifier) VerifyBearerToken(ctx context.Context, rawToken string) (*goblet.Claims, error) {
+	idToken, err := v.verifier.VerifyIDToken(ctx, rawToken)
+	if err != nil {
+		return nil, fmt.Errorf("oidcverify: %w", err)
+	}
+	claims, err := oidc.GetClaims(idToken)
+	if err != nil {
+		return nil, fmt.Errorf("oidcverify: %w", err)
+	}
+	return &goblet.Claims{
+		Email:   claims.Email,
+		Subject: claims.Subject,
+		Groups:  claims.Groups,
+	}, nil
+}
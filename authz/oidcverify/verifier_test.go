@@ -0,0 +1,168 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidcverify
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testKeyID = "test-key-1"
+
+// newTestOIDCServer stands up a minimal discovery + JWKS endpoint so
+// Verifier can be exercised without a real identity provider.
+func newTestOIDCServer(t *testing.T, key *rsa.PrivateKey) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+
+	var issuer string
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                issuer,
+			"authorization_endpoint":                issuer + "/auth",
+			"token_endpoint":                        issuer + "/token",
+			"jwks_uri":                              issuer + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]interface{}{{
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"kid": testKeyID,
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(bigIntToBytes(key.PublicKey.E)),
+			}},
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	issuer = server.URL
+	return server
+}
+
+func bigIntToBytes(i int) []byte {
+	// Standard RSA public exponent (65537) fits in 3 bytes.
+	return []byte{byte(i >> 16), byte(i >> 8), byte(i)}
+}
+
+func signTestIDToken(t *testing.T, key *rsa.PrivateKey, issuer, audience, subject string, extra map[string]interface{}) string {
+	t.Helper()
+	claims := jwt.MapClaims{
+		"iss": issuer,
+		"aud": audience,
+		"sub": subject,
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Add(-time.Minute).Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("cannot sign test ID token: %v", err)
+	}
+	return signed
+}
+
+func TestVerifier_VerifyBearerToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate test key: %v", err)
+	}
+	server := newTestOIDCServer(t, key)
+	defer server.Close()
+
+	v, err := New(context.Background(), server.URL, "test-client")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	rawToken := signTestIDToken(t, key, server.URL, "test-client", "user-1", map[string]interface{}{
+		"email":  "alice@example.com",
+		"groups": []string{"org:engineering"},
+	})
+
+	claims, err := v.VerifyBearerToken(context.Background(), rawToken)
+	if err != nil {
+		t.Fatalf("VerifyBearerToken() error = %v", err)
+	}
+	if claims.Subject != "user-1" {
+		t.Errorf("Subject = %q, want user-1", claims.Subject)
+	}
+	if claims.Email != "alice@example.com" {
+		t.Errorf("Email = %q, want alice@example.com", claims.Email)
+	}
+	if len(claims.Groups) != 1 || claims.Groups[0] != "org:engineering" {
+		t.Errorf("Groups = %v, want [org:engineering]", claims.Groups)
+	}
+}
+
+func TestVerifier_RejectsWrongAudience(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate test key: %v", err)
+	}
+	server := newTestOIDCServer(t, key)
+	defer server.Close()
+
+	v, err := New(context.Background(), server.URL, "test-client")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	rawToken := signTestIDToken(t, key, server.URL, "some-other-client", "user-1", nil)
+
+	if _, err := v.VerifyBearerToken(context.Background(), rawToken); err == nil {
+		t.Error("VerifyBearerToken() error = nil, want an error for a token issued to a different audience")
+	}
+}
+
+func TestVerifier_RejectsBadSignature(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate test key: %v", err)
+	}
+	server := newTestOIDCServer(t, key)
+	defer server.Close()
+
+	v, err := New(context.Background(), server.URL, "test-client")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	forgedKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate forged key: %v", err)
+	}
+	rawToken := signTestIDToken(t, forgedKey, server.URL, "test-client", "user-1", nil)
+
+	if _, err := v.VerifyBearerToken(context.Background(), rawToken); err == nil {
+		t.Error("VerifyBearerToken() error = nil, want an error for a token signed with an untrusted key")
+	}
+}
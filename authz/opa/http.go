@@ -0,0 +1,79 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// HTTPEvaluator calls an OPA server's REST API
+// (POST {Endpoint}/v1/data/{Package}) to evaluate a decision.
+type HTTPEvaluator struct {
+	// Endpoint is the base URL of the OPA server, e.g.
+	// "http://opa.internal:8181".
+	Endpoint string
+	// Package is the policy package path using slashes, e.g.
+	// "goblet/authz" for the rule data.goblet.authz.decision.
+	Package string
+	Client  *http.Client
+}
+
+type opaRequest struct {
+	Input Input `json:"input"`
+}
+
+type opaResponse struct {
+	Result Decision `json:"result"`
+}
+
+// Eval implements Evaluator.
+func (e *HTTPEvaluator) Eval(ctx context.Context, input Input) (Decision, error) {
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	body, err := json.Marshal(opaRequest{Input: input})
+	if err != nil {
+		return Decision{}, fmt.Errorf("cannot marshal OPA input: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/v1/data/%s/decision", e.Endpoint, e.Package)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Decision{}, fmt.Errorf("cannot build OPA request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Decision{}, fmt.Errorf("OPA request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Decision{}, fmt.Errorf("OPA returned status %d", resp.StatusCode)
+	}
+
+	var out opaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return Decision{}, fmt.Errorf("cannot decode OPA response: %w", err)
+	}
+	return out.Result, nil
+}
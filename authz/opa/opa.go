@@ -0,0 +1,62 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package opa implements goblet.AuthorizationProvider against an Open
+// Policy Agent deployment, either over HTTP or via an embedded Rego
+// evaluator, so IsolationConfig's partition/allow decision can be
+// delegated to a policy an operator already maintains for other systems
+// (the same story the minio STS/OPA integration gives S3).
+package opa
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Input is the document evaluated by the goblet.authz.decision rule. It
+// mirrors goblet.AuthorizationInput field-for-field so callers can build
+// one straight from the other.
+type Input struct {
+	Claims       interface{} `json:"claims"`
+	Method       string      `json:"method"`
+	RepoHost     string      `json:"repo_host"`
+	RepoPath     string      `json:"repo_path"`
+	TenantHeader string      `json:"tenant_header"`
+	RemoteAddr   string      `json:"remote_addr"`
+}
+
+// Decision is the expected shape of data.goblet.authz.decision.
+type Decision struct {
+	Allow     bool   `json:"allow"`
+	Partition string `json:"partition"`
+	Reason    string `json:"reason"`
+}
+
+// Evaluator evaluates an Input against a policy and returns a Decision.
+// HTTPEvaluator and RegoEvaluator are the two implementations; Provider
+// wraps either of them with caching, a circuit breaker, and metrics.
+type Evaluator interface {
+	Eval(ctx context.Context, input Input) (Decision, error)
+}
+
+// inputKey returns a stable cache key for input by marshaling it to
+// canonical JSON; encoding/json sorts map keys, so two semantically
+// identical inputs always produce the same key.
+func inputKey(input Input) (string, error) {
+	bs, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opa
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/goblet"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultCacheTTL is used when Config.CacheTTL is zero.
+const DefaultCacheTTL = 10 * time.Second
+
+// DefaultBreakerThreshold is used when Config.BreakerThreshold is zero.
+const DefaultBreakerThreshold = 5
+
+// DefaultBreakerCooldown is used when Config.BreakerCooldown is zero.
+const DefaultBreakerCooldown = 30 * time.Second
+
+// Config configures Provider.
+type Config struct {
+	Evaluator Evaluator
+
+	// CacheTTL bounds how long a decision is reused for an identical
+	// input. Defaults to DefaultCacheTTL.
+	CacheTTL time.Duration
+
+	// BreakerThreshold is the number of consecutive Evaluator errors
+	// that trip the circuit breaker. Defaults to DefaultBreakerThreshold.
+	BreakerThreshold int
+	// BreakerCooldown is how long the breaker stays open before
+	// allowing a single trial request through. Defaults to
+	// DefaultBreakerCooldown.
+	BreakerCooldown time.Duration
+	// FailOpen controls what Authorize returns when no decision can be
+	// obtained (an Evaluator error, or the breaker is open): false (the
+	// default) fails closed (returns the error), matching a secure
+	// default; set true for single-tenant deployments (IsolationSidecar)
+	// where availability matters more than a false allow.
+	FailOpen bool
+
+	// Registerer, if set, registers goblet_authz_decisions_total.
+	Registerer prometheus.Registerer
+
+	Now func() time.Time
+}
+
+type cacheEntry struct {
+	decision Decision
+	expires  time.Time
+}
+
+// Provider wraps an Evaluator with a decision cache, a circuit breaker
+// for OPA outages, and Prometheus counters, and implements
+// goblet.AuthorizationProvider so it can be plugged directly into
+// IsolationConfig.Authorizer.
+type Provider struct {
+	config Config
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+
+	breakerMu          sync.Mutex
+	consecutiveErrors  int
+	breakerOpenedUntil time.Time
+
+	decisions *prometheus.CounterVec
+}
+
+// NewProvider builds a Provider from config.
+func NewProvider(config Config) (*Provider, error) {
+	if config.Evaluator == nil {
+		return nil, fmt.Errorf("opa: Config.Evaluator is required")
+	}
+	if config.CacheTTL == 0 {
+		config.CacheTTL = DefaultCacheTTL
+	}
+	if config.BreakerThreshold == 0 {
+		config.BreakerThreshold = DefaultBreakerThreshold
+	}
+	if config.BreakerCooldown == 0 {
+		config.BreakerCooldown = DefaultBreakerCooldown
+	}
+	now := config.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	p := &Provider{
+		config: config,
+		now:    now,
+		cache:  make(map[string]cacheEntry),
+	}
+
+	if config.Registerer != nil {
+		p.decisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "goblet_authz_decisions_total",
+			Help: "Total number of OPA authorization decisions by result (allow, deny, error).",
+		}, []string{"result"})
+		if err := config.Registerer.Register(p.decisions); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Authorize implements goblet.AuthorizationProvider.
+func (p *Provider) Authorize(ctx context.Context, input goblet.AuthorizationInput) (goblet.AuthorizationDecision, error) {
+	opaInput := Input{
+		Claims:       input.Claims,
+		Method:       input.Method,
+		RepoHost:     input.RepoHost,
+		RepoPath:     input.RepoPath,
+		TenantHeader: input.TenantHeader,
+		RemoteAddr:   input.RemoteAddr,
+	}
+
+	d, err := p.decide(ctx, opaInput)
+	if err != nil {
+		p.count("error")
+		if p.failOpenNow() {
+			return goblet.AuthorizationDecision{Allow: true, Reason: "opa unavailable, failing open"}, nil
+		}
+		return goblet.AuthorizationDecision{}, err
+	}
+
+	if d.Allow {
+		p.count("allow")
+	} else {
+		p.count("deny")
+	}
+	return goblet.AuthorizationDecision{Allow: d.Allow, Partition: d.Partition, Reason: d.Reason}, nil
+}
+
+func (p *Provider) count(result string) {
+	if p.decisions != nil {
+		p.decisions.WithLabelValues(result).Inc()
+	}
+}
+
+// decide resolves a decision via the cache, falling back to the
+// Evaluator (through the circuit breaker) on a miss or expiry.
+func (p *Provider) decide(ctx context.Context, input Input) (Decision, error) {
+	key, err := inputKey(input)
+	if err != nil {
+		return Decision{}, fmt.Errorf("opa: cannot compute cache key: %w", err)
+	}
+
+	now := p.now()
+	p.mu.Lock()
+	if entry, ok := p.cache[key]; ok && now.Before(entry.expires) {
+		p.mu.Unlock()
+		return entry.decision, nil
+	}
+	p.mu.Unlock()
+
+	if !p.breakerAllows(now) {
+		return Decision{}, fmt.Errorf("opa: circuit breaker open")
+	}
+
+	d, err := p.config.Evaluator.Eval(ctx, input)
+	p.recordBreakerResult(err, now)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	p.mu.Lock()
+	p.cache[key] = cacheEntry{decision: d, expires: now.Add(p.config.CacheTTL)}
+	p.mu.Unlock()
+
+	return d, nil
+}
+
+// breakerAllows reports whether a call to the Evaluator should be
+// attempted: always once the breaker isn't open, or a single trial call
+// once the cooldown has elapsed.
+func (p *Provider) breakerAllows(now time.Time) bool {
+	p.breakerMu.Lock()
+	defer p.breakerMu.Unlock()
+	if p.breakerOpenedUntil.IsZero() {
+		return true
+	}
+	return !now.Before(p.breakerOpenedUntil)
+}
+
+func (p *Provider) recordBreakerResult(err error, now time.Time) {
+	p.breakerMu.Lock()
+	defer p.breakerMu.Unlock()
+	if err == nil {
+		p.consecutiveErrors = 0
+		p.breakerOpenedUntil = time.Time{}
+		return
+	}
+	p.consecutiveErrors++
+	if p.consecutiveErrors >= p.config.BreakerThreshold {
+		p.breakerOpenedUntil = now.Add(p.config.BreakerCooldown)
+	}
+}
+
+func (p *Provider) failOpenNow() bool {
+	return p.config.FailOpen
+}
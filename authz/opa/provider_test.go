@@ -0,0 +1,134 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opa
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/goblet"
+)
+
+type stubEvaluator struct {
+	calls  int32
+	decide Decision
+	err    error
+}
+
+func (s *stubEvaluator) Eval(ctx context.Context, input Input) (Decision, error) {
+	atomic.AddInt32(&s.calls, 1)
+	if s.err != nil {
+		return Decision{}, s.err
+	}
+	return s.decide, nil
+}
+
+func TestProviderCachesDecisions(t *testing.T) {
+	eval := &stubEvaluator{decide: Decision{Allow: true, Partition: "tenant-a"}}
+	p, err := NewProvider(Config{Evaluator: eval, CacheTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	input := goblet.AuthorizationInput{RepoHost: "github.com", RepoPath: "/org/repo"}
+	for i := 0; i < 3; i++ {
+		d, err := p.Authorize(context.Background(), input)
+		if err != nil {
+			t.Fatalf("Authorize() error = %v", err)
+		}
+		if !d.Allow || d.Partition != "tenant-a" {
+			t.Fatalf("Authorize() = %+v, want allow=true partition=tenant-a", d)
+		}
+	}
+	if eval.calls != 1 {
+		t.Errorf("evaluator called %d times, want 1 (cache should absorb the rest)", eval.calls)
+	}
+}
+
+func TestProviderDeniesSurfaceReason(t *testing.T) {
+	eval := &stubEvaluator{decide: Decision{Allow: false, Reason: "not in allowed group"}}
+	p, err := NewProvider(Config{Evaluator: eval})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	d, err := p.Authorize(context.Background(), goblet.AuthorizationInput{})
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	if d.Allow {
+		t.Fatal("Authorize().Allow = true, want false")
+	}
+	if d.Reason != "not in allowed group" {
+		t.Errorf("Reason = %q, want %q", d.Reason, "not in allowed group")
+	}
+}
+
+func TestProviderFailsClosedByDefault(t *testing.T) {
+	eval := &stubEvaluator{err: errors.New("opa unreachable")}
+	p, err := NewProvider(Config{Evaluator: eval, BreakerThreshold: 1})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	if _, err := p.Authorize(context.Background(), goblet.AuthorizationInput{}); err == nil {
+		t.Fatal("expected an error with fail-closed (default) behavior")
+	}
+}
+
+func TestProviderFailsOpenWhenConfigured(t *testing.T) {
+	eval := &stubEvaluator{err: errors.New("opa unreachable")}
+	p, err := NewProvider(Config{Evaluator: eval, BreakerThreshold: 1, FailOpen: true})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	d, err := p.Authorize(context.Background(), goblet.AuthorizationInput{})
+	if err != nil {
+		t.Fatalf("Authorize() error = %v, want nil (fail-open)", err)
+	}
+	if !d.Allow {
+		t.Error("Authorize().Allow = false, want true when failing open")
+	}
+}
+
+func TestProviderCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	eval := &stubEvaluator{err: errors.New("boom")}
+	p, err := NewProvider(Config{Evaluator: eval, BreakerThreshold: 2, BreakerCooldown: time.Hour})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := p.Authorize(context.Background(), goblet.AuthorizationInput{RepoPath: "/a"}); err == nil {
+			t.Fatal("expected an error from the failing evaluator")
+		}
+	}
+	if eval.calls != 2 {
+		t.Fatalf("evaluator called %d times before breaker should open, want 2", eval.calls)
+	}
+
+	// The breaker should now be open: a third distinct input must not
+	// reach the evaluator at all.
+	if _, err := p.Authorize(context.Background(), goblet.AuthorizationInput{RepoPath: "/b"}); err == nil {
+		t.Fatal("expected an error while the breaker is open")
+	}
+	if eval.calls != 2 {
+		t.Errorf("evaluator called %d times, want still 2 (breaker should short-circuit)", eval.calls)
+	}
+}
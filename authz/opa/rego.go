@@ -0,0 +1,75 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// RegoEvaluator evaluates a policy embedded in the goblet process itself
+// (no network hop to a separate OPA server), using the same
+// data.goblet.authz.decision query as HTTPEvaluator.
+type RegoEvaluator struct {
+	prepared rego.PreparedEvalQuery
+}
+
+// NewRegoEvaluator compiles the given Rego module(s) source and prepares
+// the data.goblet.authz.decision query for repeated evaluation.
+func NewRegoEvaluator(ctx context.Context, moduleName, module string) (*RegoEvaluator, error) {
+	prepared, err := rego.New(
+		rego.Query("data.goblet.authz.decision"),
+		rego.Module(moduleName, module),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot prepare Rego policy: %w", err)
+	}
+	return &RegoEvaluator{prepared: prepared}, nil
+}
+
+// Eval implements Evaluator.
+func (e *RegoEvaluator) Eval(ctx context.Context, input Input) (Decision, error) {
+	// rego.EvalInput expects plain JSON-compatible values, so round-trip
+	// through encoding/json rather than passing the typed struct.
+	bs, err := json.Marshal(input)
+	if err != nil {
+		return Decision{}, fmt.Errorf("cannot marshal Rego input: %w", err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(bs, &doc); err != nil {
+		return Decision{}, fmt.Errorf("cannot unmarshal Rego input: %w", err)
+	}
+
+	rs, err := e.prepared.Eval(ctx, rego.EvalInput(doc))
+	if err != nil {
+		return Decision{}, fmt.Errorf("Rego evaluation failed: %w", err)
+	}
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return Decision{}, fmt.Errorf("Rego policy produced no result for data.goblet.authz.decision")
+	}
+
+	bs, err = json.Marshal(rs[0].Expressions[0].Value)
+	if err != nil {
+		return Decision{}, fmt.Errorf("cannot marshal Rego result: %w", err)
+	}
+	var d Decision
+	if err := json.Unmarshal(bs, &d); err != nil {
+		return Decision{}, fmt.Errorf("cannot parse Rego decision: %w", err)
+	}
+	return d, nil
+}
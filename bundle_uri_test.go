@@ -0,0 +1,148 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// fakePresignedProvider implements storage.Provider and
+// storage.PresignedProvider for tests, recording the last PresignedGet
+// call it received.
+type fakePresignedProvider struct {
+	mockStorageProvider
+
+	lastKey       string
+	lastReqParams url.Values
+}
+
+func (f *fakePresignedProvider) PresignedGet(ctx context.Context, key string, ttl time.Duration, reqParams url.Values) (*url.URL, error) {
+	f.lastKey = key
+	f.lastReqParams = reqParams
+	return url.Parse("https://example-bucket.s3.amazonaws.com/" + key + "?X-Amz-Signature=test")
+}
+
+func (f *fakePresignedProvider) PresignedPut(ctx context.Context, key string, ttl time.Duration) (*url.URL, error) {
+	return url.Parse("https://example-bucket.s3.amazonaws.com/" + key + "?X-Amz-Signature=test")
+}
+
+func TestBundleURIHandlerPresignsAndSetsFilename(t *testing.T) {
+	handler, err := NewBundleURIHandler(BundleURIConfig{
+		Provider: &fakePresignedProvider{},
+	})
+	if err != nil {
+		t.Fatalf("NewBundleURIHandler failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/myorg/myrepo/info/refs", nil)
+	repoURL, _ := url.Parse("https://upstream.example.com/myorg/myrepo")
+
+	presigned, err := handler.PresignBundle(req, repoURL, "bundles/myorg/myrepo/abc123.bundle")
+	if err != nil {
+		t.Fatalf("PresignBundle failed: %v", err)
+	}
+	if presigned == nil {
+		t.Fatal("expected a non-nil presigned URL")
+	}
+
+	fake := handler.signer.(*fakePresignedProvider)
+	if fake.lastKey != "bundles/myorg/myrepo/abc123.bundle" {
+		t.Errorf("unexpected key presigned: %q", fake.lastKey)
+	}
+	if got, want := fake.lastReqParams.Get("response-content-disposition"), `attachment; filename="myrepo.bundle"`; got != want {
+		t.Errorf("response-content-disposition = %q, want %q", got, want)
+	}
+}
+
+func TestBundleURIHandlerRejectsNonPresigningProvider(t *testing.T) {
+	_, err := NewBundleURIHandler(BundleURIConfig{
+		Provider: &mockStorageProvider{},
+	})
+	if err == nil {
+		t.Fatal("expected NewBundleURIHandler to reject a provider without PresignedGet/PresignedPut")
+	}
+}
+
+func TestBundleURIHandlerShouldRedirect(t *testing.T) {
+	handler, err := NewBundleURIHandler(BundleURIConfig{
+		Provider:        &fakePresignedProvider{},
+		RedirectEnabled: true,
+		MinRedirectSize: 1024,
+	})
+	if err != nil {
+		t.Fatalf("NewBundleURIHandler failed: %v", err)
+	}
+
+	if handler.ShouldRedirect(1023) {
+		t.Error("ShouldRedirect(1023) = true, want false (below MinRedirectSize)")
+	}
+	if !handler.ShouldRedirect(1024) {
+		t.Error("ShouldRedirect(1024) = false, want true (at MinRedirectSize)")
+	}
+
+	disabled, err := NewBundleURIHandler(BundleURIConfig{Provider: &fakePresignedProvider{}})
+	if err != nil {
+		t.Fatalf("NewBundleURIHandler failed: %v", err)
+	}
+	if disabled.ShouldRedirect(1 << 30) {
+		t.Error("ShouldRedirect() = true for a handler with RedirectEnabled unset, want false")
+	}
+}
+
+func TestBundleURIHandlerWriteRedirect(t *testing.T) {
+	handler, err := NewBundleURIHandler(BundleURIConfig{Provider: &fakePresignedProvider{}})
+	if err != nil {
+		t.Fatalf("NewBundleURIHandler failed: %v", err)
+	}
+
+	presigned, _ := url.Parse("https://example-bucket.s3.amazonaws.com/bundles/myorg/myrepo/abc123.bundle?X-Amz-Signature=test")
+	rec := httptest.NewRecorder()
+	handler.WriteRedirect(rec, presigned)
+
+	if rec.Code != http.StatusFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusFound)
+	}
+	if got := rec.Header().Get("Location"); got != presigned.String() {
+		t.Errorf("Location = %q, want %q", got, presigned.String())
+	}
+}
+
+func TestBundleURIHandlerRateLimitsPerPartition(t *testing.T) {
+	handler, err := NewBundleURIHandler(BundleURIConfig{
+		Provider:  &fakePresignedProvider{},
+		RateLimit: rate.Limit(0.001),
+		RateBurst: 1,
+	})
+	if err != nil {
+		t.Fatalf("NewBundleURIHandler failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/myorg/myrepo/info/refs", nil)
+	repoURL, _ := url.Parse("https://upstream.example.com/myorg/myrepo")
+
+	if _, err := handler.PresignBundle(req, repoURL, "bundles/myorg/myrepo/abc123.bundle"); err != nil {
+		t.Fatalf("first PresignBundle should succeed: %v", err)
+	}
+	if _, err := handler.PresignBundle(req, repoURL, "bundles/myorg/myrepo/abc123.bundle"); err == nil {
+		t.Fatal("expected second PresignBundle to be rate limited")
+	}
+}
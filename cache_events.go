@@ -0,0 +1,98 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/google/goblet/storage"
+)
+
+// NewCacheEventHandler returns a storage.Config.EventHandler that reacts
+// to bucket notifications by warming or evicting the on-disk cache at
+// cacheRoot: an s3:ObjectCreated:* event for a ".bundle" key fetches the
+// bundle and recovers the matching managed repository from it (the same
+// path a manual restore would take), and an s3:ObjectRemoved:* event
+// evicts that repository's in-memory state so the next request re-reads
+// from disk/upstream rather than serving a stale handle.
+//
+// The object key is expected to use the same "<host>/<path...>" layout
+// GetCachePath produces when IsolationConfig.Mode is IsolationNone or
+// IsolationSidecar; keys carrying a user/tenant/OPA partition prefix are
+// not reversed by this handler: they are looked up unchanged under
+// cacheRoot, match no tracked repository, and are not logged.
+func NewCacheEventHandler(cacheRoot string, provider storage.Provider) func(storage.Event) {
+	return func(event storage.Event) {
+		localDiskPath := filepath.Join(cacheRoot, filepath.FromSlash(event.Key))
+
+		switch event.Type {
+		case storage.EventObjectRemoved:
+			evictManagedRepo(localDiskPath)
+
+		case storage.EventObjectCreated:
+			if filepath.Ext(event.Key) != ".bundle" {
+				return
+			}
+			if err := prefetchBundle(provider, event.Key, localDiskPath); err != nil {
+				log.Printf("goblet: failed to prefetch bundle %q: %v", event.Key, err)
+			}
+		}
+	}
+}
+
+// evictManagedRepo drops the in-memory managedRepository for
+// localDiskPath, if any, so the next request re-opens it from disk.
+func evictManagedRepo(localDiskPath string) {
+	managedRepos.Delete(localDiskPath)
+}
+
+// prefetchBundle downloads the bundle object at key and, if a managed
+// repository is already tracking localDiskPath, recovers it from the
+// downloaded bundle so the cache is warm before the next request arrives.
+func prefetchBundle(provider storage.Provider, key, localDiskPath string) error {
+	v, ok := managedRepos.Load(localDiskPath)
+	if !ok {
+		// No local repository is tracking this path yet; nothing to
+		// warm until a request creates one.
+		return nil
+	}
+	repo := v.(*managedRepository)
+
+	reader, err := provider.Reader(context.Background(), key)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	tmp, err := os.CreateTemp("", "goblet-prefetch-*.bundle")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, reader); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return repo.RecoverFromBundle(context.Background(), tmp.Name())
+}
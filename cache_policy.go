@@ -0,0 +1,208 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/goblet/storage"
+)
+
+// CachePolicy bounds how long and how much of a storage.Provider's
+// cached objects goblet keeps around, across any backend: a local cache
+// that's grown unbounded is exactly the operational problem that made
+// S3/GCS-backed deployments attractive in the first place, so Sweep
+// lets the object store enforce its own end of the bargain rather than
+// goblet walking and deleting objects one at a time forever.
+type CachePolicy struct {
+	// MaxAge is how long a cached object may go unused before it's
+	// eligible for expiration. Sweep programs this into the backend's
+	// own lifecycle rules when it implements storage.LifecycleProvider
+	// (S3); otherwise Sweep enforces it itself by walking List and
+	// deleting anything older, which is how TTL applies to the local
+	// disk and GCS providers (neither implements LifecycleProvider).
+	MaxAge time.Duration
+	// MaxSize caps the total bytes Sweep keeps under Prefix; once List
+	// reports more than this, the oldest objects (by ObjectAttrs.Updated,
+	// i.e. LRU) are deleted first until the total is back under budget.
+	// Enforced by Sweep itself on every backend, since no Provider
+	// exposes a native "shrink to N bytes" operation.
+	MaxSize int64
+	// Tier is the storage class objects transition to after MaxAge, for
+	// backends that support tiering (S3's TransitionStorageClass via
+	// LifecycleProvider). Ignored by backends without LifecycleProvider.
+	Tier string
+
+	// Prefix scopes Sweep to a subtree of the cache, e.g. a single
+	// isolation partition. Empty sweeps the whole bucket/cache.
+	Prefix string
+
+	// SweepPeriod is how often Sweeper re-runs Sweep in the background.
+	// Defaults to 1 hour if zero.
+	SweepPeriod time.Duration
+
+	// OnSweepError, if set, is called with the error from any Sweep run
+	// that Sweeper performs in the background, since there's no request
+	// in flight to return it to. Sweeper keeps running on the next tick
+	// regardless.
+	OnSweepError func(error)
+}
+
+// Sweeper runs a CachePolicy's Sweep on its own ticker in the
+// background, mirroring CheckRegistry's approach to periodic work: a
+// cache that's only ever reconciled when someone remembers to call
+// Sweep is one that grows unbounded in practice, so ServerConfig wires
+// a Sweeper up front instead of leaving it to callers.
+type Sweeper struct {
+	policy   *CachePolicy
+	provider storage.Provider
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSweeper creates a Sweeper that reconciles policy against provider
+// on policy.SweepPeriod. Call Start to begin the background loop.
+func NewSweeper(policy *CachePolicy, provider storage.Provider) *Sweeper {
+	return &Sweeper{
+		policy:   policy,
+		provider: provider,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start runs an immediate Sweep and then repeats it every
+// policy.SweepPeriod until Stop is called.
+func (s *Sweeper) Start(ctx context.Context) {
+	period := s.policy.SweepPeriod
+	if period <= 0 {
+		period = time.Hour
+	}
+
+	run := func() {
+		if err := s.policy.Sweep(ctx, s.provider); err != nil && s.policy.OnSweepError != nil {
+			s.policy.OnSweepError(err)
+		}
+	}
+
+	go func() {
+		run()
+
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				run()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the background sweep loop. Safe to call multiple times.
+func (s *Sweeper) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// Sweep reconciles cp against provider: it programs provider's bucket
+// lifecycle rules to match MaxAge/Tier when provider implements
+// storage.LifecycleProvider, then walks provider.List(cp.Prefix) to
+// expire anything older than MaxAge and, if the tree still exceeds
+// MaxSize, evict the oldest remaining objects (LRU) until it's back
+// under budget. The List-driven expiration/eviction runs unconditionally
+// -- even behind a LifecycleProvider, whose rules apply on the
+// backend's own schedule -- since MaxSize eviction has no backend-native
+// equivalent and callers that want an immediate MaxAge sweep (rather
+// than waiting on S3's own lifecycle schedule) need it too.
+func (cp *CachePolicy) Sweep(ctx context.Context, provider storage.Provider) error {
+	if lp, ok := provider.(storage.LifecycleProvider); ok && cp.MaxAge > 0 {
+		rule := storage.LifecycleRule{Prefix: cp.Prefix, ExpireAfter: cp.MaxAge}
+		if cp.Tier != "" {
+			rule.TransitionAfter = cp.MaxAge
+			rule.TransitionStorageClass = cp.Tier
+		}
+		if err := lp.SetLifecycleRules(ctx, []storage.LifecycleRule{rule}); err != nil {
+			return fmt.Errorf("failed to program bucket lifecycle rules: %w", err)
+		}
+	}
+
+	objects, err := cp.expireAndList(ctx, provider)
+	if err != nil {
+		return err
+	}
+	return cp.evictOverBudget(ctx, provider, objects)
+}
+
+// expireAndList walks provider.List(cp.Prefix), deleting any object
+// older than MaxAge as it goes, and returns the attrs of everything that
+// survived (for evictOverBudget to consider).
+func (cp *CachePolicy) expireAndList(ctx context.Context, provider storage.Provider) ([]*storage.ObjectAttrs, error) {
+	iter := provider.List(ctx, cp.Prefix)
+	var remaining []*storage.ObjectAttrs
+	for {
+		attrs, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list cache objects: %w", err)
+		}
+
+		if cp.MaxAge > 0 && time.Since(attrs.Updated) > cp.MaxAge {
+			if err := provider.Delete(ctx, attrs.Name); err != nil {
+				return nil, fmt.Errorf("failed to expire %q: %w", attrs.Name, err)
+			}
+			continue
+		}
+		remaining = append(remaining, attrs)
+	}
+	return remaining, nil
+}
+
+// evictOverBudget deletes the oldest of objects (by Updated) until
+// their total size is back under cp.MaxSize. A non-positive MaxSize
+// disables size-based eviction.
+func (cp *CachePolicy) evictOverBudget(ctx context.Context, provider storage.Provider, objects []*storage.ObjectAttrs) error {
+	if cp.MaxSize <= 0 {
+		return nil
+	}
+
+	var total int64
+	for _, attrs := range objects {
+		total += attrs.Size
+	}
+	if total <= cp.MaxSize {
+		return nil
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Updated.Before(objects[j].Updated) })
+	for _, attrs := range objects {
+		if total <= cp.MaxSize {
+			break
+		}
+		if err := provider.Delete(ctx, attrs.Name); err != nil {
+			return fmt.Errorf("failed to evict %q: %w", attrs.Name, err)
+		}
+		total -= attrs.Size
+	}
+	return nil
+}
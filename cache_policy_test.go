@@ -0,0 +1,181 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/goblet/storage"
+)
+
+// fakeSweepProvider is a storage.Provider backed by an in-memory object
+// list, so CachePolicy.Sweep's List/Delete walk can be exercised without
+// a real backend. Embedding fakeSweepProvider in
+// fakeLifecycleSweepProvider lets the lifecycle-rule path reuse it too.
+type fakeSweepProvider struct {
+	objects []*storage.ObjectAttrs
+	deleted []string
+}
+
+func (p *fakeSweepProvider) Writer(ctx context.Context, path string) (io.WriteCloser, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (p *fakeSweepProvider) Reader(ctx context.Context, path string) (io.ReadCloser, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (p *fakeSweepProvider) Delete(ctx context.Context, path string) error {
+	p.deleted = append(p.deleted, path)
+	return nil
+}
+
+func (p *fakeSweepProvider) List(ctx context.Context, prefix string) storage.ObjectIterator {
+	return &fakeSweepIterator{objects: p.objects}
+}
+
+func (p *fakeSweepProvider) Attrs(ctx context.Context, path string) (*storage.ObjectAttrs, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (p *fakeSweepProvider) Close() error { return nil }
+
+type fakeSweepIterator struct {
+	objects []*storage.ObjectAttrs
+	index   int
+}
+
+func (it *fakeSweepIterator) Next() (*storage.ObjectAttrs, error) {
+	if it.index >= len(it.objects) {
+		return nil, io.EOF
+	}
+	attrs := it.objects[it.index]
+	it.index++
+	return attrs, nil
+}
+
+// fakeLifecycleSweepProvider additionally implements storage.LifecycleProvider,
+// so Sweep exercises the SetLifecycleRules path the way S3Provider would.
+type fakeLifecycleSweepProvider struct {
+	fakeSweepProvider
+	rules []storage.LifecycleRule
+}
+
+func (p *fakeLifecycleSweepProvider) SetLifecycleRules(ctx context.Context, rules []storage.LifecycleRule) error {
+	p.rules = rules
+	return nil
+}
+
+func (p *fakeLifecycleSweepProvider) LifecycleRules(ctx context.Context) ([]storage.LifecycleRule, error) {
+	return p.rules, nil
+}
+
+func TestCachePolicySweepExpiresStaleObjects(t *testing.T) {
+	now := time.Now()
+	provider := &fakeSweepProvider{
+		objects: []*storage.ObjectAttrs{
+			{Name: "fresh", Updated: now, Size: 10},
+			{Name: "stale", Updated: now.Add(-48 * time.Hour), Size: 10},
+		},
+	}
+	cp := &CachePolicy{MaxAge: 24 * time.Hour}
+
+	if err := cp.Sweep(context.Background(), provider); err != nil {
+		t.Fatalf("Sweep() returned error: %v", err)
+	}
+
+	if len(provider.deleted) != 1 || provider.deleted[0] != "stale" {
+		t.Errorf("deleted = %v, want [stale]", provider.deleted)
+	}
+}
+
+func TestCachePolicySweepEvictsOverBudgetLRU(t *testing.T) {
+	now := time.Now()
+	provider := &fakeSweepProvider{
+		objects: []*storage.ObjectAttrs{
+			{Name: "oldest", Updated: now.Add(-3 * time.Hour), Size: 40},
+			{Name: "middle", Updated: now.Add(-2 * time.Hour), Size: 40},
+			{Name: "newest", Updated: now.Add(-1 * time.Hour), Size: 40},
+		},
+	}
+	cp := &CachePolicy{MaxSize: 50}
+
+	if err := cp.Sweep(context.Background(), provider); err != nil {
+		t.Fatalf("Sweep() returned error: %v", err)
+	}
+
+	if len(provider.deleted) != 2 || provider.deleted[0] != "oldest" || provider.deleted[1] != "middle" {
+		t.Errorf("deleted = %v, want [oldest middle]", provider.deleted)
+	}
+}
+
+func TestCachePolicySweepProgramsLifecycleRules(t *testing.T) {
+	provider := &fakeLifecycleSweepProvider{}
+	cp := &CachePolicy{MaxAge: 7 * 24 * time.Hour, Tier: "GLACIER", Prefix: "packs/"}
+
+	if err := cp.Sweep(context.Background(), provider); err != nil {
+		t.Fatalf("Sweep() returned error: %v", err)
+	}
+
+	if len(provider.rules) != 1 {
+		t.Fatalf("len(rules) = %d, want 1", len(provider.rules))
+	}
+	rule := provider.rules[0]
+	if rule.Prefix != "packs/" || rule.ExpireAfter != cp.MaxAge || rule.TransitionStorageClass != "GLACIER" {
+		t.Errorf("rule = %+v, want Prefix=packs/ ExpireAfter=%v TransitionStorageClass=GLACIER", rule, cp.MaxAge)
+	}
+}
+
+func TestSweeperRunsAndStops(t *testing.T) {
+	provider := &fakeSweepProvider{
+		objects: []*storage.ObjectAttrs{{Name: "stale", Updated: time.Now().Add(-time.Hour), Size: 1}},
+	}
+	cp := &CachePolicy{MaxAge: time.Minute, SweepPeriod: time.Millisecond}
+
+	var errs sweepErrRecorder
+	cp.OnSweepError = errs.record
+
+	sweeper := NewSweeper(cp, provider)
+	sweeper.Start(context.Background())
+	defer sweeper.Stop()
+
+	deadline := time.After(time.Second)
+	for {
+		if len(provider.deleted) > 0 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("Sweeper did not delete the stale object in time")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	if err := errs.lastErr(); err != nil {
+		t.Errorf("unexpected sweep error: %v", err)
+	}
+}
+
+// sweepErrRecorder records the last error passed to CachePolicy.OnSweepError.
+type sweepErrRecorder struct {
+	err error
+}
+
+func (r *sweepErrRecorder) record(err error) { r.err = err }
+func (r *sweepErrRecorder) lastErr() error   { return r.err }
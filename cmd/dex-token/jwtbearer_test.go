@@ -0,0 +1,171 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestKeyFile generates an RSA key pair and returns the private key
+// alongside the path of a service-account key file holding it PEM-encoded.
+func writeTestKeyFile(t *testing.T, dir, tokenURI string) (*rsa.PrivateKey, string) {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate RSA key: %v", err)
+	}
+	der := x509.MarshalPKCS1PrivateKey(priv)
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: der})
+
+	key := serviceAccountKey{
+		ClientEmail:  "svc@goblet.example.com",
+		PrivateKey:   string(pemBytes),
+		PrivateKeyID: "key-1",
+		TokenURI:     tokenURI,
+	}
+	bs, err := json.Marshal(key)
+	if err != nil {
+		t.Fatalf("cannot marshal key file: %v", err)
+	}
+	path := filepath.Join(dir, "key.json")
+	if err := os.WriteFile(path, bs, 0600); err != nil {
+		t.Fatalf("cannot write key file: %v", err)
+	}
+	return priv, path
+}
+
+func decodeJWTPart(t *testing.T, part string) map[string]interface{} {
+	t.Helper()
+	bs, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		t.Fatalf("cannot base64-decode JWT part: %v", err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(bs, &out); err != nil {
+		t.Fatalf("cannot parse JWT part as JSON: %v", err)
+	}
+	return out
+}
+
+func TestRunJWTBearerFlowSignsAndExchangesAssertion(t *testing.T) {
+	dir := t.TempDir()
+
+	var mux *http.ServeMux
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	priv, keyPath := writeTestKeyFile(t, dir, server.URL+"/token")
+
+	mux = http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("cannot parse token request form: %v", err)
+		}
+		if got := r.Form.Get("grant_type"); got != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			t.Errorf("grant_type = %q, want jwt-bearer urn", got)
+		}
+
+		assertion := r.Form.Get("assertion")
+		parts := strings.Split(assertion, ".")
+		if len(parts) != 3 {
+			t.Fatalf("assertion has %d parts, want 3 (header.claims.signature)", len(parts))
+		}
+
+		header := decodeJWTPart(t, parts[0])
+		if header["alg"] != "RS256" {
+			t.Errorf("alg = %v, want RS256", header["alg"])
+		}
+
+		claims := decodeJWTPart(t, parts[1])
+		if claims["iss"] != "svc@goblet.example.com" {
+			t.Errorf("iss = %v, want svc@goblet.example.com", claims["iss"])
+		}
+		if claims["sub"] != "svc@goblet.example.com" {
+			t.Errorf("sub = %v, want svc@goblet.example.com", claims["sub"])
+		}
+		if claims["aud"] != server.URL+"/token" {
+			t.Errorf("aud = %v, want %s", claims["aud"], server.URL+"/token")
+		}
+		if claims["scope"] != "openid profile" {
+			t.Errorf("scope = %v, want %q", claims["scope"], "openid profile")
+		}
+		iat, _ := claims["iat"].(float64)
+		exp, _ := claims["exp"].(float64)
+		if exp <= iat {
+			t.Errorf("exp (%v) must be after iat (%v)", exp, iat)
+		}
+		if exp-iat > 3600 {
+			t.Errorf("assertion lifetime %v seconds exceeds 1 hour", exp-iat)
+		}
+
+		signedPart := parts[0] + "." + parts[1]
+		sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			t.Fatalf("cannot decode signature: %v", err)
+		}
+		hashed := sha256Sum(signedPart)
+		if err := rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, hashed, sig); err != nil {
+			t.Errorf("assertion signature does not verify against the service-account key: %v", err)
+		}
+
+		fmt.Fprintf(w, `{"access_token":"jwt-bearer-token","token_type":"Bearer","expires_in":`+strconv.Itoa(3600)+`}`)
+	})
+
+	token, err := runJWTBearerFlow(context.Background(), keyPath, "http://unused", []string{"openid", "profile"})
+	if err != nil {
+		t.Fatalf("runJWTBearerFlow() error = %v", err)
+	}
+	if token.AccessToken != "jwt-bearer-token" {
+		t.Errorf("AccessToken = %q, want jwt-bearer-token", token.AccessToken)
+	}
+	if !token.Expiry.After(time.Now()) {
+		t.Errorf("Expiry = %v, want a time in the future", token.Expiry)
+	}
+}
+
+func TestLoadJWTBearerConfigRequiresClientEmail(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "key.json")
+	if err := os.WriteFile(path, []byte(`{"private_key":"x"}`), 0600); err != nil {
+		t.Fatalf("cannot write key file: %v", err)
+	}
+	if _, err := loadJWTBearerConfig(path, "http://dex", nil); err == nil {
+		t.Fatal("expected an error for a key file missing client_email")
+	}
+}
+
+func sha256Sum(s string) []byte {
+	h := crypto.SHA256.New()
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
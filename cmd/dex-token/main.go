@@ -37,6 +37,9 @@ var (
 	redirectURL  = flag.String("redirect-url", "http://localhost:5555/callback", "OAuth2 redirect URL")
 	outputFile   = flag.String("output", "./tokens/token.json", "Output file for token")
 	listen       = flag.String("listen", ":5555", "Address to listen for OAuth2 callback")
+	deviceFlow   = flag.Bool("device", false, "Use the OAuth2 device authorization grant instead of the browser redirect flow (for headless/CI hosts)")
+	authFlow     = flag.String("flow", "interactive", "Authentication flow to use: \"interactive\" or \"jwt-bearer\"")
+	keyFile      = flag.String("key-file", "", "Path to a service-account JSON key file (required for -flow=jwt-bearer)")
 )
 
 // TokenResponse represents the token data.
@@ -66,6 +69,36 @@ func main() {
 		Scopes: []string{"openid", "profile", "email", "groups"},
 	}
 
+	if *authFlow == "jwt-bearer" {
+		if *keyFile == "" {
+			log.Fatal("-key-file is required for -flow=jwt-bearer")
+		}
+		token, err := runJWTBearerFlow(ctx, *keyFile, *dexURL, config.Scopes)
+		if err != nil {
+			log.Fatalf("jwt-bearer authentication failed: %v", err)
+		}
+		if err := saveToken(token); err != nil {
+			log.Fatalf("Failed to save token: %v", err)
+		}
+		fmt.Println("Authentication successful!")
+		fmt.Printf("Token saved to: %s\n", *outputFile)
+		return
+	}
+
+	if *deviceFlow {
+		token, err := runDeviceFlow(ctx, config)
+		if err != nil {
+			log.Fatalf("Device authorization failed: %v", err)
+		}
+		if err := saveToken(token); err != nil {
+			log.Fatalf("Failed to save token: %v", err)
+		}
+		fmt.Println()
+		fmt.Println("Authentication successful!")
+		fmt.Printf("Token saved to: %s\n", *outputFile)
+		return
+	}
+
 	// Generate authorization URL
 	state := "random-state-string"
 	authURL := config.AuthCodeURL(state, oauth2.AccessTypeOffline)
@@ -157,6 +190,32 @@ func main() {
 	}
 }
 
+// runDeviceFlow performs the OAuth2 device authorization grant (RFC 8628):
+// it asks Dex for a device and user code, tells the operator to approve it
+// on another device, then polls the token endpoint until the user
+// completes the approval (or the device code expires).
+func runDeviceFlow(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	resp, err := config.DeviceAuth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot start device authorization: %w", err)
+	}
+
+	fmt.Println("Goblet Authentication (device flow)")
+	fmt.Println("====================================")
+	fmt.Println()
+	if resp.VerificationURIComplete != "" {
+		fmt.Println("Open the following URL to approve this device:")
+		fmt.Println()
+		fmt.Println(resp.VerificationURIComplete)
+	} else {
+		fmt.Printf("Open %s and enter code: %s\n", resp.VerificationURI, resp.UserCode)
+	}
+	fmt.Println()
+	fmt.Println("Waiting for approval...")
+
+	return config.DeviceAccessToken(ctx, resp)
+}
+
 func saveToken(token *oauth2.Token) error {
 	// Create directory if it doesn't exist
 	outputDir := *outputFile
@@ -0,0 +1,50 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/oauth2"
+)
+
+const keyringService = "goblet-auth"
+
+func keychainAvailable() bool { return true }
+
+func saveToKeychain(account string, tok *oauth2.Token) error {
+	bs, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	if err := keyring.Set(keyringService, account, string(bs)); err != nil {
+		return fmt.Errorf("cannot write to macOS Keychain: %w", err)
+	}
+	return nil
+}
+
+func loadFromKeychain(account string) (*oauth2.Token, error) {
+	s, err := keyring.Get(keyringService, account)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read from macOS Keychain: %w", err)
+	}
+	var tok oauth2.Token
+	if err := json.Unmarshal([]byte(s), &tok); err != nil {
+		return nil, fmt.Errorf("cannot parse cached Keychain token: %w", err)
+	}
+	return &tok, nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !darwin
+
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/oauth2"
+)
+
+func keychainAvailable() bool { return false }
+
+func saveToKeychain(account string, tok *oauth2.Token) error {
+	return fmt.Errorf("keychain storage is only supported on macOS")
+}
+
+func loadFromKeychain(account string) (*oauth2.Token, error) {
+	return nil, fmt.Errorf("keychain storage is only supported on macOS")
+}
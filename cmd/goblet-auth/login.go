@@ -0,0 +1,146 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// runLogin implements `goblet-auth login <issuer-url>`: a PKCE
+// authorization-code flow against a localhost redirect listener, in
+// the shape of Databricks' `bricks auth login`.
+func runLogin(args []string) {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	clientID := fs.String("client-id", "goblet-cli", "OAuth2 client ID registered with the issuer")
+	scopes := fs.String("scopes", "openid profile email groups offline_access", "space-separated OAuth2 scopes to request")
+	configPath := fs.String("config", "", "path to the token cache file (default: $XDG_CONFIG_HOME/goblet/tokens.json)")
+	useKeychain := fs.Bool("keychain", defaultUseKeychain(), "store the token in the OS keychain instead of the cache file (macOS only)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		log.Fatal("usage: goblet-auth login <issuer-url>")
+	}
+	issuerURL := fs.Arg(0)
+
+	ctx := context.Background()
+	provider, err := oidc.NewProvider(ctx, issuerURL)
+	if err != nil {
+		log.Fatalf("cannot discover OIDC endpoints at %s: %v", issuerURL, err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatalf("cannot open a local redirect listener: %v", err)
+	}
+	redirectURL := fmt.Sprintf("http://127.0.0.1:%d/callback", listener.Addr().(*net.TCPAddr).Port)
+
+	config := &oauth2.Config{
+		ClientID:    *clientID,
+		RedirectURL: redirectURL,
+		Endpoint:    provider.Endpoint(),
+		Scopes:      strings.Fields(*scopes),
+	}
+
+	verifier, challenge, err := newPKCEPair()
+	if err != nil {
+		log.Fatalf("cannot generate a PKCE code verifier: %v", err)
+	}
+	state, err := randomURLSafeString(16)
+	if err != nil {
+		log.Fatalf("cannot generate a state nonce: %v", err)
+	}
+
+	authURL := config.AuthCodeURL(state,
+		oauth2.AccessTypeOffline,
+		oauth2.SetAuthURLParam("code_challenge", challenge),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+
+	tokenChan := make(chan *oauth2.Token, 1)
+	errChan := make(chan error, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		if errParam := r.URL.Query().Get("error"); errParam != "" {
+			http.Error(w, errParam, http.StatusBadRequest)
+			errChan <- fmt.Errorf("authorization server returned error: %s", errParam)
+			return
+		}
+		if got := r.URL.Query().Get("state"); got != state {
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			errChan <- fmt.Errorf("callback state %q did not match the expected nonce", got)
+			return
+		}
+		tok, err := config.Exchange(r.Context(), r.URL.Query().Get("code"), oauth2.SetAuthURLParam("code_verifier", verifier))
+		if err != nil {
+			http.Error(w, "token exchange failed", http.StatusInternalServerError)
+			errChan <- fmt.Errorf("cannot exchange the authorization code: %w", err)
+			return
+		}
+		fmt.Fprint(w, "<!DOCTYPE html><html><body><h1>Authentication successful</h1>"+
+			"<p>You can close this window and return to the terminal.</p></body></html>")
+		tokenChan <- tok
+	})
+	server := &http.Server{Handler: mux}
+	defer server.Close()
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			errChan <- err
+		}
+	}()
+
+	fmt.Println("Open the following URL to sign in:")
+	fmt.Println()
+	fmt.Println(authURL)
+	fmt.Println()
+	if err := openBrowser(authURL); err != nil {
+		fmt.Printf("(could not open a browser automatically: %v)\n", err)
+	}
+	fmt.Println("Waiting for authentication...")
+
+	var tok *oauth2.Token
+	select {
+	case tok = <-tokenChan:
+	case err := <-errChan:
+		log.Fatalf("login failed: %v", err)
+	case <-time.After(5 * time.Minute):
+		log.Fatal("login timed out")
+	}
+
+	path, err := tokenCachePath(*configPath)
+	if err != nil {
+		log.Fatalf("cannot determine the token cache path: %v", err)
+	}
+	if err := saveCachedToken(path, *useKeychain, tok); err != nil {
+		log.Fatalf("cannot save the token: %v", err)
+	}
+
+	fmt.Println()
+	if *useKeychain {
+		fmt.Println("Login successful. Token cached in the macOS Keychain.")
+	} else {
+		fmt.Println("Login successful. Token cached at", path)
+	}
+}
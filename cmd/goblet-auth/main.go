@@ -0,0 +1,46 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main implements goblet-auth, an interactive OIDC PKCE login
+// helper for local development and CI bootstrap, so developers running
+// Terraform or git locally against a goblet proxy don't have to
+// hand-craft a TokenSource closure themselves.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "login":
+		runLogin(os.Args[2:])
+	case "token":
+		runToken(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: goblet-auth login <issuer-url> [flags]")
+	fmt.Fprintln(os.Stderr, "       goblet-auth token [flags]")
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+)
+
+func TestNewPKCEPair(t *testing.T) {
+	verifier, challenge, err := newPKCEPair()
+	if err != nil {
+		t.Fatalf("newPKCEPair() error = %v", err)
+	}
+	if verifier == "" || challenge == "" {
+		t.Fatalf("newPKCEPair() returned empty verifier/challenge")
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	want := base64.RawURLEncoding.EncodeToString(sum[:])
+	if challenge != want {
+		t.Errorf("challenge = %q, want S256(verifier) = %q", challenge, want)
+	}
+
+	verifier2, _, err := newPKCEPair()
+	if err != nil {
+		t.Fatalf("newPKCEPair() error = %v", err)
+	}
+	if verifier == verifier2 {
+		t.Error("newPKCEPair() returned the same verifier twice")
+	}
+}
+
+func TestRandomURLSafeString(t *testing.T) {
+	s, err := randomURLSafeString(16)
+	if err != nil {
+		t.Fatalf("randomURLSafeString() error = %v", err)
+	}
+	if _, err := base64.RawURLEncoding.DecodeString(s); err != nil {
+		t.Errorf("randomURLSafeString() = %q is not valid base64url: %v", s, err)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// runToken implements `goblet-auth token`: prints the current bearer
+// token from the cache written by `login`, refreshing it first via
+// -issuer if it's near expiry.
+func runToken(args []string) {
+	fs := flag.NewFlagSet("token", flag.ExitOnError)
+	issuerURL := fs.String("issuer", "", "issuer URL, used to refresh the cached token if it's near expiry (optional)")
+	clientID := fs.String("client-id", "goblet-cli", "OAuth2 client ID, used only when refreshing")
+	configPath := fs.String("config", "", "path to the token cache file (default: $XDG_CONFIG_HOME/goblet/tokens.json)")
+	useKeychain := fs.Bool("keychain", defaultUseKeychain(), "read the token from the OS keychain instead of the cache file (macOS only)")
+	fs.Parse(args)
+
+	path, err := tokenCachePath(*configPath)
+	if err != nil {
+		log.Fatalf("cannot determine the token cache path: %v", err)
+	}
+
+	tok, err := loadCachedToken(path, *useKeychain)
+	if err != nil {
+		log.Fatalf("cannot read the cached token; run `goblet-auth login <issuer-url>` first: %v", err)
+	}
+
+	if tok.Valid() || *issuerURL == "" {
+		fmt.Println(tok.AccessToken)
+		return
+	}
+
+	ctx := context.Background()
+	provider, err := oidc.NewProvider(ctx, *issuerURL)
+	if err != nil {
+		log.Fatalf("cannot discover OIDC endpoints at %s: %v", *issuerURL, err)
+	}
+	config := &oauth2.Config{ClientID: *clientID, Endpoint: provider.Endpoint()}
+
+	refreshed, err := config.TokenSource(ctx, tok).Token()
+	if err != nil {
+		log.Fatalf("cannot refresh the cached token: %v", err)
+	}
+	if refreshed.AccessToken != tok.AccessToken {
+		if err := saveCachedToken(path, *useKeychain, refreshed); err != nil {
+			log.Printf("warning: cannot persist the refreshed token: %v", err)
+		}
+	}
+	fmt.Println(refreshed.AccessToken)
+}
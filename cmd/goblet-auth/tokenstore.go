@@ -0,0 +1,69 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"golang.org/x/oauth2"
+
+	"github.com/google/goblet/auth/tokensource"
+)
+
+// keychainAccount identifies the cached token within the OS keychain.
+// There's one goblet-auth identity per machine, so a fixed account name
+// is enough to find it back.
+const keychainAccount = "default"
+
+// tokenCachePath returns override if set, else
+// $XDG_CONFIG_HOME/goblet/tokens.json (os.UserConfigDir honors
+// XDG_CONFIG_HOME on Linux and gives the platform-appropriate
+// equivalent elsewhere).
+func tokenCachePath(override string) (string, error) {
+	if override != "" {
+		return override, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goblet", "tokens.json"), nil
+}
+
+// saveCachedToken persists tok to the keychain when useKeychain is true
+// (macOS only), otherwise to path.
+func saveCachedToken(path string, useKeychain bool, tok *oauth2.Token) error {
+	if useKeychain {
+		return saveToKeychain(keychainAccount, tok)
+	}
+	return tokensource.SaveToken(path, tok)
+}
+
+// loadCachedToken is the read-side counterpart of saveCachedToken.
+func loadCachedToken(path string, useKeychain bool) (*oauth2.Token, error) {
+	if useKeychain {
+		return loadFromKeychain(keychainAccount)
+	}
+	return tokensource.Peek(path)
+}
+
+// defaultUseKeychain is the -keychain flag's default: macOS developers
+// get Keychain-backed storage out of the box, everyone else gets the
+// plain token-cache file.
+func defaultUseKeychain() bool {
+	return runtime.GOOS == "darwin"
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"net/http"
+	"strings"
+)
+
+// corsAllowHeaders and corsAllowMethods are fixed for every CORS
+// response goblet sends: browser git clients (isomorphic-git and
+// similar) always send this header set against one of the smart-HTTP
+// endpoints, and there's no per-deployment reason to vary them.
+const (
+	corsAllowHeaders = "Content-Type, Authorization, User-Agent, Git-Protocol"
+	corsAllowMethods = "GET, POST, OPTIONS"
+)
+
+// CORSConfig configures CORSHandler.
+type CORSConfig struct {
+	// AccessControlAllowOrigin is the value echoed as
+	// Access-Control-Allow-Origin. Two values are handled specially:
+	// "*" allows any origin, and "null" matches only a request whose
+	// Origin header is empty or the literal string "null" (what
+	// browsers send for a sandboxed or file:// origin). Any other
+	// value is matched against the request's Origin header exactly.
+	// Empty sends no CORS headers at all, so upgrading goblet doesn't
+	// start sending them to an existing deployment without an explicit
+	// opt-in: ApplyHeaders does nothing, and HandlePreflight still
+	// answers a smart-HTTP preflight with a bare 204 and reports true.
+	AccessControlAllowOrigin string
+}
+
+// CORSHandler adds the CORS behavior browser-based git clients need --
+// Gitea and Gogs do the same thing -- to goblet's smart-HTTP endpoints:
+// answering an OPTIONS preflight directly, and echoing
+// Access-Control-Allow-Origin onto the real response that follows so
+// the browser's fetch/XHR is actually allowed to read it.
+//
+// Calling HandlePreflight and ApplyHeaders from the HTTP handler --
+// which is not present in this checkout -- is the remaining
+// integration step: ServeHTTP should call HandlePreflight first and
+// return immediately if it reports true, and otherwise call
+// ApplyHeaders before writing any smart-HTTP response.
+type CORSHandler struct {
+	config CORSConfig
+}
+
+// NewCORSHandler returns a CORSHandler for config.
+func NewCORSHandler(config CORSConfig) *CORSHandler {
+	return &CORSHandler{config: config}
+}
+
+// HandlePreflight answers an OPTIONS preflight for one of the
+// smart-HTTP endpoints goblet serves (paths ending in /info/refs,
+// /git-upload-pack, or /git-receive-pack) and reports whether it did,
+// so the caller can skip its normal dispatch for the request. Requests
+// outside the scope of CORS preflighting -- non-OPTIONS methods, or
+// paths HandlePreflight doesn't recognize -- are left entirely alone
+// and it returns false.
+func (h *CORSHandler) HandlePreflight(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodOptions || !isCORSPath(r.URL.Path) {
+		return false
+	}
+
+	if allow, ok := h.allowOrigin(r.Header.Get("Origin")); ok {
+		header := w.Header()
+		header.Set("Access-Control-Allow-Origin", allow)
+		header.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+		header.Set("Access-Control-Allow-Methods", corsAllowMethods)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// ApplyHeaders echoes Access-Control-Allow-Origin onto a normal
+// smart-HTTP response when r's Origin matches config, so a browser
+// that already sent its preflight is allowed to read the actual
+// response too. Call it before writing any response body.
+func (h *CORSHandler) ApplyHeaders(w http.ResponseWriter, r *http.Request) {
+	if allow, ok := h.allowOrigin(r.Header.Get("Origin")); ok {
+		w.Header().Set("Access-Control-Allow-Origin", allow)
+	}
+}
+
+// allowOrigin reports the Access-Control-Allow-Origin value to send for
+// a request whose Origin header is origin, and whether CORS applies to
+// it at all under config.AccessControlAllowOrigin.
+func (h *CORSHandler) allowOrigin(origin string) (allow string, ok bool) {
+	switch h.config.AccessControlAllowOrigin {
+	case "":
+		return "", false
+	case "*":
+		return "*", true
+	case "null":
+		if origin == "" || origin == "null" {
+			return "null", true
+		}
+		return "", false
+	default:
+		if origin != "" && origin == h.config.AccessControlAllowOrigin {
+			return origin, true
+		}
+		return "", false
+	}
+}
+
+// isCORSPath reports whether path is one of the smart-HTTP endpoints a
+// browser git client preflights.
+func isCORSPath(path string) bool {
+	return strings.HasSuffix(path, "/info/refs") ||
+		strings.HasSuffix(path, "/git-upload-pack") ||
+		strings.HasSuffix(path, "/git-receive-pack")
+}
@@ -0,0 +1,163 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSHandlerHandlePreflight(t *testing.T) {
+	tests := []struct {
+		name        string
+		allow       string
+		method      string
+		path        string
+		origin      string
+		wantHandled bool
+		wantOrigin  string
+	}{
+		{
+			name:        "preflight on info/refs allows a wildcard origin",
+			allow:       "*",
+			method:      http.MethodOptions,
+			path:        "/foo/bar.git/info/refs",
+			origin:      "https://example.com",
+			wantHandled: true,
+			wantOrigin:  "*",
+		},
+		{
+			name:        "preflight on git-upload-pack",
+			allow:       "https://example.com",
+			method:      http.MethodOptions,
+			path:        "/foo/bar.git/git-upload-pack",
+			origin:      "https://example.com",
+			wantHandled: true,
+			wantOrigin:  "https://example.com",
+		},
+		{
+			name:        "preflight with mismatched origin gets no CORS headers but is still handled",
+			allow:       "https://example.com",
+			method:      http.MethodOptions,
+			path:        "/foo/bar.git/git-receive-pack",
+			origin:      "https://evil.example",
+			wantHandled: true,
+			wantOrigin:  "",
+		},
+		{
+			name:        "non-OPTIONS request is left alone",
+			allow:       "*",
+			method:      http.MethodGet,
+			path:        "/foo/bar.git/info/refs",
+			origin:      "https://example.com",
+			wantHandled: false,
+		},
+		{
+			name:        "OPTIONS on an unrelated path is left alone",
+			allow:       "*",
+			method:      http.MethodOptions,
+			path:        "/foo/bar.git/unknown",
+			origin:      "https://example.com",
+			wantHandled: false,
+		},
+		{
+			name:        "CORS disabled by empty config",
+			allow:       "",
+			method:      http.MethodOptions,
+			path:        "/foo/bar.git/info/refs",
+			origin:      "https://example.com",
+			wantHandled: true,
+			wantOrigin:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewCORSHandler(CORSConfig{AccessControlAllowOrigin: tt.allow})
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+
+			handled := h.HandlePreflight(rec, req)
+			if handled != tt.wantHandled {
+				t.Errorf("HandlePreflight() = %v, want %v", handled, tt.wantHandled)
+			}
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if tt.wantHandled && tt.wantOrigin != "" {
+				if got := rec.Header().Get("Access-Control-Allow-Headers"); got != corsAllowHeaders {
+					t.Errorf("Access-Control-Allow-Headers = %q, want %q", got, corsAllowHeaders)
+				}
+				if got := rec.Header().Get("Access-Control-Allow-Methods"); got != corsAllowMethods {
+					t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, corsAllowMethods)
+				}
+			}
+		})
+	}
+}
+
+func TestCORSHandlerAllowOriginNull(t *testing.T) {
+	h := NewCORSHandler(CORSConfig{AccessControlAllowOrigin: "null"})
+
+	req := httptest.NewRequest(http.MethodOptions, "/foo/bar.git/info/refs", nil)
+	// No Origin header set, mirroring a sandboxed/file:// origin.
+	rec := httptest.NewRecorder()
+
+	if !h.HandlePreflight(rec, req) {
+		t.Fatal("HandlePreflight() = false, want true")
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "null" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, "null")
+	}
+}
+
+func TestCORSHandlerApplyHeaders(t *testing.T) {
+	h := NewCORSHandler(CORSConfig{AccessControlAllowOrigin: "https://example.com"})
+
+	req := httptest.NewRequest(http.MethodGet, "/foo/bar.git/info/refs?service=git-upload-pack", nil)
+	req.Header.Set("Origin", "https://example.com")
+	rec := httptest.NewRecorder()
+
+	h.ApplyHeaders(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, "https://example.com")
+	}
+	// ApplyHeaders only sets Allow-Origin; Allow-Headers/Methods are
+	// preflight-only per the CORS spec.
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "" {
+		t.Errorf("Access-Control-Allow-Headers = %q, want empty", got)
+	}
+}
+
+func TestCORSHandlerApplyHeadersMismatchedOrigin(t *testing.T) {
+	h := NewCORSHandler(CORSConfig{AccessControlAllowOrigin: "https://example.com"})
+
+	req := httptest.NewRequest(http.MethodGet, "/foo/bar.git/info/refs", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	rec := httptest.NewRecorder()
+
+	h.ApplyHeaders(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want empty", got)
+	}
+}
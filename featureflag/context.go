@@ -0,0 +1,76 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featureflag
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// HeaderName is the HTTP header clients and internal callers use to
+// override a flag for a single request, e.g.
+// "X-Goblet-Feature-Flag: lfs_proxy_enabled=false". Multiple flags can
+// be overridden by repeating the header or comma-separating entries.
+const HeaderName = "X-Goblet-Feature-Flag"
+
+type ctxKey struct{ name string }
+
+// WithValue returns a context in which flag is forced to the given
+// enabled value,
+// taking precedence over any Set override or DefaultOn for every
+// IsEnabled call against that context. Tests should prefer this to
+// mutating a shared Set or global config: it scopes the override to
+// exactly the request or call tree under test.
+func WithValue(ctx context.Context, flag Flag, enabled bool) context.Context {
+	return context.WithValue(ctx, ctxKey{flag.Name}, enabled)
+}
+
+func valueFromContext(ctx context.Context, name string) (bool, bool) {
+	v, ok := ctx.Value(ctxKey{name}).(bool)
+	return v, ok
+}
+
+// ContextFromRequest parses r's X-Goblet-Feature-Flag header(s) and
+// returns ctx with a WithValue override applied for each entry that
+// names a registered Flag. Unrecognized flag names are ignored rather
+// than rejected, so a client one release ahead of the server (or a
+// typo) doesn't turn into a proxy error for an otherwise normal
+// request.
+func ContextFromRequest(ctx context.Context, r *http.Request) context.Context {
+	for _, header := range r.Header.Values(HeaderName) {
+		for _, entry := range strings.Split(header, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(entry, "=")
+			if !ok {
+				continue
+			}
+			flag, ok := Lookup(strings.TrimSpace(name))
+			if !ok {
+				continue
+			}
+			enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				continue
+			}
+			ctx = WithValue(ctx, flag, enabled)
+		}
+	}
+	return ctx
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featureflag
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextFromRequestAppliesKnownFlags(t *testing.T) {
+	flag := Register(Flag{Name: "test_header_flag", DefaultOn: true})
+	s := NewSet()
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(HeaderName, "test_header_flag=false, test_unregistered_flag=true")
+
+	ctx := ContextFromRequest(context.Background(), r)
+	if s.IsEnabled(ctx, flag) {
+		t.Errorf("header override was not applied: IsEnabled() = true, want false")
+	}
+}
+
+func TestContextFromRequestIgnoresUnrecognizedAndMalformed(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(HeaderName, "not_a_valid_entry, also=not=valid=bool")
+
+	// Should not panic, and should not add any usable override for an
+	// unregistered flag.
+	ctx := ContextFromRequest(context.Background(), r)
+	if _, ok := valueFromContext(ctx, "not_a_valid_entry"); ok {
+		t.Errorf("unregistered flag name should not produce a context override")
+	}
+}
+
+func TestWithValueRoundTrip(t *testing.T) {
+	flag := Register(Flag{Name: "test_withvalue_flag", DefaultOn: false})
+	ctx := WithValue(context.Background(), flag, true)
+
+	v, ok := valueFromContext(ctx, flag.Name)
+	if !ok || !v {
+		t.Errorf("valueFromContext() = %v, %v, want true, true", v, ok)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featureflag
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DurationFlag is a feature flag whose effective value is a
+// time.Duration rather than a boolean, for gating thresholds like
+// StaleCacheWarningThreshold rather than on/off behavior. It shares
+// Flag's registration, environment-override, and admin-endpoint story,
+// just with a duration-typed default and override instead of a
+// boolean one.
+type DurationFlag struct {
+	// Name identifies the flag, with the same conventions as Flag.Name.
+	Name string
+	// Default is the duration RegisterDuration's Set returns absent any
+	// override.
+	Default time.Duration
+	// Description explains what the duration controls.
+	Description string
+}
+
+var (
+	durationRegistryMu sync.RWMutex
+	durationRegistry   = map[string]DurationFlag{}
+)
+
+// RegisterDuration adds f to the duration flag registry and returns it
+// unchanged, for use in a var initializer the same way Register is used
+// for boolean Flags.
+func RegisterDuration(f DurationFlag) DurationFlag {
+	if f.Name == "" {
+		panic("featureflag: RegisterDuration called with an empty Name")
+	}
+	durationRegistryMu.Lock()
+	defer durationRegistryMu.Unlock()
+	if _, ok := durationRegistry[f.Name]; ok {
+		panic("featureflag: duration flag " + f.Name + " registered more than once")
+	}
+	durationRegistry[f.Name] = f
+	return f
+}
+
+// Duration returns flag's effective value on s: an override previously
+// set with SetDuration, an environment variable override
+// (GOBLET_FEATURE_<NAME>, parsed with time.ParseDuration) read at
+// NewSet time, or flag.Default.
+func (s *Set) Duration(flag DurationFlag) time.Duration {
+	s.durationMu.RLock()
+	defer s.durationMu.RUnlock()
+	if v, ok := s.durationOverrides[flag.Name]; ok {
+		return v
+	}
+	return flag.Default
+}
+
+// SetDuration overrides name's effective duration on s. It returns an
+// error if name hasn't been registered with RegisterDuration.
+func (s *Set) SetDuration(name string, d time.Duration) error {
+	durationRegistryMu.RLock()
+	_, ok := durationRegistry[name]
+	durationRegistryMu.RUnlock()
+	if !ok {
+		return &unknownFlagError{name}
+	}
+	s.durationMu.Lock()
+	defer s.durationMu.Unlock()
+	if s.durationOverrides == nil {
+		s.durationOverrides = map[string]time.Duration{}
+	}
+	s.durationOverrides[name] = d
+	return nil
+}
+
+func loadDurationOverrides() map[string]time.Duration {
+	out := map[string]time.Duration{}
+	durationRegistryMu.RLock()
+	defer durationRegistryMu.RUnlock()
+	for _, f := range durationRegistry {
+		raw, ok := os.LookupEnv(envPrefix + strings.ToUpper(f.Name))
+		if !ok {
+			continue
+		}
+		if d, err := time.ParseDuration(raw); err == nil {
+			out[f.Name] = d
+		}
+	}
+	return out
+}
+
+type unknownFlagError struct{ name string }
+
+func (e *unknownFlagError) Error() string {
+	return "featureflag: unknown duration flag \"" + e.name + "\""
+}
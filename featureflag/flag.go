@@ -0,0 +1,95 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package featureflag provides a general mechanism for gating
+// in-development or risky behavior behind a named, independently
+// toggleable flag, modeled on Gitaly's feature-flag package: flags are
+// registered once at init time, given a default, and can be overridden
+// per-deployment (an environment variable), at runtime (the admin HTTP
+// endpoint), or per-request (a context.Context value set from the
+// X-Goblet-Feature-Flag header).
+package featureflag
+
+import (
+	"sort"
+	"sync"
+)
+
+// Flag is a single boolean feature flag. Flags are created with
+// Register, which is meant to be called from a package-level var
+// initializer so every flag goblet understands is known before main
+// runs, the same way Go's standard flag package expects Var calls
+// during init.
+type Flag struct {
+	// Name identifies the flag in the environment variable override,
+	// the admin endpoint, and the X-Goblet-Feature-Flag header. It
+	// should be lower_snake_case, matching the flags already wired in
+	// flags.go.
+	Name string
+	// DefaultOn is the value IsEnabled returns absent any override.
+	DefaultOn bool
+	// Description explains what the flag gates, surfaced verbatim by
+	// the admin endpoint.
+	Description string
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Flag{}
+)
+
+// Register adds f to the package-wide flag registry and returns it
+// unchanged, so it can be called directly from a var initializer:
+//
+//	var MyFlag = featureflag.Register(featureflag.Flag{
+//		Name:      "my_flag",
+//		DefaultOn: false,
+//	})
+//
+// Register panics if Name is empty or already registered: both
+// indicate a programming error (a flag defined twice, or forgotten to
+// be named) that should fail at init time rather than silently merge
+// two unrelated flags.
+func Register(f Flag) Flag {
+	if f.Name == "" {
+		panic("featureflag: Register called with an empty Name")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[f.Name]; ok {
+		panic("featureflag: flag " + f.Name + " registered more than once")
+	}
+	registry[f.Name] = f
+	return f
+}
+
+// Lookup returns the registered Flag with the given name, if any.
+func Lookup(name string) (Flag, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	f, ok := registry[name]
+	return f, ok
+}
+
+// All returns every registered Flag, sorted by Name.
+func All() []Flag {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]Flag, 0, len(registry))
+	for _, f := range registry {
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
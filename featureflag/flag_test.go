@@ -0,0 +1,60 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featureflag
+
+import "testing"
+
+func TestRegisterPanicsOnDuplicate(t *testing.T) {
+	Register(Flag{Name: "test_dup_flag"})
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected Register to panic on a duplicate name")
+		}
+	}()
+	Register(Flag{Name: "test_dup_flag"})
+}
+
+func TestRegisterPanicsOnEmptyName(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected Register to panic on an empty name")
+		}
+	}()
+	Register(Flag{Name: ""})
+}
+
+func TestLookupAndAll(t *testing.T) {
+	f := Register(Flag{Name: "test_lookup_flag", DefaultOn: true, Description: "for TestLookupAndAll"})
+
+	got, ok := Lookup("test_lookup_flag")
+	if !ok || got != f {
+		t.Fatalf("Lookup() = %+v, %v, want %+v, true", got, ok, f)
+	}
+
+	if _, ok := Lookup("test_nonexistent_flag"); ok {
+		t.Errorf("Lookup() of an unregistered name reported found")
+	}
+
+	found := false
+	for _, flag := range All() {
+		if flag.Name == "test_lookup_flag" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("All() did not include a just-registered flag")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featureflag
+
+import "time"
+
+// OfflineLsRefsFallback gates serving an ls-refs response entirely from
+// the local cache when the upstream is unreachable or
+// ServerConfig.UpstreamEnabled is false, instead of failing the
+// request. On by default: this is the behavior the offline integration
+// tests already exercise.
+var OfflineLsRefsFallback = Register(Flag{
+	Name:        "offline_ls_refs_fallback",
+	DefaultOn:   true,
+	Description: "Serve ls-refs from the local cache when the upstream is unreachable, instead of failing the request.",
+})
+
+// LFSProxyEnabled gates whether the LFS Handler's Upstream proxy path
+// (see lfs.UpstreamConfig) is wired up at all. Off by default: LFS
+// proxying is new enough that an operator should opt in deployment by
+// deployment.
+var LFSProxyEnabled = Register(Flag{
+	Name:        "lfs_proxy_enabled",
+	DefaultOn:   false,
+	Description: "Serve the Git LFS Batch API proxy and object cache.",
+})
+
+// HealthReadinessIncludesUpstream gates whether the /readyz probe's
+// overall status is degraded by a failing upstream-reachability check,
+// versus only reporting it informationally under Components. Off by
+// default: an upstream outage shouldn't take a proxy serving entirely
+// from cache out of its load balancer's rotation.
+var HealthReadinessIncludesUpstream = Register(Flag{
+	Name:        "health_readiness_includes_upstream",
+	DefaultOn:   false,
+	Description: "Let an unreachable upstream degrade the /readyz result, not just the informational health component.",
+})
+
+// StaleCacheWarningThreshold is how long a cached object may go
+// unrefreshed before HealthChecker reports the cache component as
+// degraded rather than healthy. 24 hours by default: a repo that
+// genuinely sees no pushes or fetches for a day is unusual enough to be
+// worth a DEGRADED nudge without paging anyone.
+var StaleCacheWarningThreshold = RegisterDuration(DurationFlag{
+	Name:        "stale_cache_warning_threshold",
+	Default:     24 * time.Hour,
+	Description: "How long a cache entry may go unrefreshed before the cache health component reports degraded.",
+})
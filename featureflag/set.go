@@ -0,0 +1,176 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featureflag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// envPrefix is prepended to a Flag's upper-cased Name to form the
+// environment variable Set reads its startup override from, e.g.
+// "offline_ls_refs_fallback" -> "GOBLET_FEATURE_OFFLINE_LS_REFS_FALLBACK".
+const envPrefix = "GOBLET_FEATURE_"
+
+// Set holds the effective on/off value for every registered Flag for
+// one goblet process: a startup snapshot of environment variable
+// overrides, mutable afterward through SetEnabled (wired to the admin
+// endpoint's PUT handler).
+type Set struct {
+	mu        sync.RWMutex
+	overrides map[string]bool
+
+	durationMu        sync.RWMutex
+	durationOverrides map[string]time.Duration
+}
+
+// NewSet creates a Set seeded from the environment: for every flag
+// registered with Register, GOBLET_FEATURE_<NAME> is read (if set) and
+// parsed with strconv.ParseBool, overriding that flag's DefaultOn. An
+// unparsable value is ignored, leaving the flag at its default, since a
+// health check gating on a misconfigured env var is worse than one that
+// silently falls back.
+func NewSet() *Set {
+	s := &Set{
+		overrides:         map[string]bool{},
+		durationOverrides: loadDurationOverrides(),
+	}
+	for _, f := range All() {
+		raw, ok := os.LookupEnv(envPrefix + strings.ToUpper(f.Name))
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseBool(raw); err == nil {
+			s.overrides[f.Name] = v
+		}
+	}
+	return s
+}
+
+// IsEnabled reports whether flag is enabled: a context override set by
+// WithValue takes precedence, then an override set on s (startup
+// environment variable or a later SetEnabled call), then flag.DefaultOn.
+func (s *Set) IsEnabled(ctx context.Context, flag Flag) bool {
+	if v, ok := valueFromContext(ctx, flag.Name); ok {
+		return v
+	}
+	return s.isEnabledLocked(flag)
+}
+
+func (s *Set) isEnabledLocked(flag Flag) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if v, ok := s.overrides[flag.Name]; ok {
+		return v
+	}
+	return flag.DefaultOn
+}
+
+// SetEnabled overrides name's effective value on s until the next
+// SetEnabled call or process restart. It returns an error if name
+// hasn't been registered with Register.
+func (s *Set) SetEnabled(name string, enabled bool) error {
+	if _, ok := Lookup(name); !ok {
+		return fmt.Errorf("featureflag: unknown flag %q", name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[name] = enabled
+	return nil
+}
+
+// effectiveEntry is one flag's reported state in the Effective snapshot
+// and the admin endpoint's GET response.
+type effectiveEntry struct {
+	Name        string `json:"name"`
+	Enabled     bool   `json:"enabled"`
+	DefaultOn   bool   `json:"default_on"`
+	Overridden  bool   `json:"overridden"`
+	Description string `json:"description,omitempty"`
+}
+
+// Effective returns the current on/off value of every registered flag,
+// ignoring any per-request context override, as a name -> enabled map
+// suitable for embedding in a health check or log line.
+func (s *Set) Effective() map[string]bool {
+	out := map[string]bool{}
+	for _, f := range All() {
+		out[f.Name] = s.isEnabledLocked(f)
+	}
+	return out
+}
+
+func (s *Set) entries() []effectiveEntry {
+	flags := All()
+	out := make([]effectiveEntry, 0, len(flags))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, f := range flags {
+		override, overridden := s.overrides[f.Name]
+		enabled := f.DefaultOn
+		if overridden {
+			enabled = override
+		}
+		out = append(out, effectiveEntry{
+			Name:        f.Name,
+			Enabled:     enabled,
+			DefaultOn:   f.DefaultOn,
+			Overridden:  overridden,
+			Description: f.Description,
+		})
+	}
+	return out
+}
+
+// AdminHandler serves GET and PUT on an "/admin/flags" endpoint: GET
+// returns every registered flag's current state as a JSON array, and
+// PUT applies a single override from a body of the form
+// {"name": "lfs_proxy_enabled", "enabled": false}.
+func AdminHandler(s *Set) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(s.entries())
+
+		case http.MethodPut:
+			var body struct {
+				Name    string `json:"name"`
+				Enabled bool   `json:"enabled"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := s.SetEnabled(body.Name, body.Enabled); err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(s.entries())
+
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featureflag
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetDefaultsAndOverride(t *testing.T) {
+	flag := Register(Flag{Name: "test_set_flag", DefaultOn: false})
+	s := NewSet()
+
+	if s.IsEnabled(context.Background(), flag) {
+		t.Errorf("IsEnabled() = true before any override, want DefaultOn (false)")
+	}
+
+	if err := s.SetEnabled(flag.Name, true); err != nil {
+		t.Fatalf("SetEnabled() error: %v", err)
+	}
+	if !s.IsEnabled(context.Background(), flag) {
+		t.Errorf("IsEnabled() = false after SetEnabled(true)")
+	}
+}
+
+func TestSetEnabledUnknownFlag(t *testing.T) {
+	s := NewSet()
+	if err := s.SetEnabled("test_never_registered", true); err == nil {
+		t.Errorf("SetEnabled() on an unregistered flag did not error")
+	}
+}
+
+func TestSetEnvOverride(t *testing.T) {
+	flag := Register(Flag{Name: "test_env_flag", DefaultOn: false})
+	t.Setenv(envPrefix+"TEST_ENV_FLAG", "true")
+
+	s := NewSet()
+	if !s.IsEnabled(context.Background(), flag) {
+		t.Errorf("IsEnabled() = false, want the env override to win over DefaultOn")
+	}
+}
+
+func TestSetDurationDefaultsAndOverride(t *testing.T) {
+	flag := RegisterDuration(DurationFlag{Name: "test_set_duration", Default: time.Minute})
+	s := NewSet()
+
+	if got := s.Duration(flag); got != time.Minute {
+		t.Errorf("Duration() = %v, want default %v", got, time.Minute)
+	}
+
+	if err := s.SetDuration(flag.Name, time.Hour); err != nil {
+		t.Fatalf("SetDuration() error: %v", err)
+	}
+	if got := s.Duration(flag); got != time.Hour {
+		t.Errorf("Duration() = %v after override, want %v", got, time.Hour)
+	}
+}
+
+func TestSetDurationEnvOverride(t *testing.T) {
+	flag := RegisterDuration(DurationFlag{Name: "test_env_duration", Default: time.Minute})
+	t.Setenv(envPrefix+"TEST_ENV_DURATION", "5m")
+
+	s := NewSet()
+	if got := s.Duration(flag); got != 5*time.Minute {
+		t.Errorf("Duration() = %v, want the env override 5m", got)
+	}
+}
+
+func TestContextOverrideWinsOverSet(t *testing.T) {
+	flag := Register(Flag{Name: "test_ctx_flag", DefaultOn: false})
+	s := NewSet()
+	if err := s.SetEnabled(flag.Name, true); err != nil {
+		t.Fatalf("SetEnabled() error: %v", err)
+	}
+
+	ctx := WithValue(context.Background(), flag, false)
+	if s.IsEnabled(ctx, flag) {
+		t.Errorf("context override did not take precedence over the Set override")
+	}
+}
+
+func TestAdminHandlerGetAndPut(t *testing.T) {
+	flag := Register(Flag{Name: "test_admin_flag", DefaultOn: false, Description: "admin handler test"})
+	s := NewSet()
+	h := AdminHandler(s)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/flags", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, want 200", w.Code)
+	}
+	var before []effectiveEntry
+	if err := json.NewDecoder(w.Body).Decode(&before); err != nil {
+		t.Fatalf("decoding GET body: %v", err)
+	}
+	if !containsDisabled(before, flag.Name) {
+		t.Fatalf("GET response did not report %s as disabled: %+v", flag.Name, before)
+	}
+
+	body := strings.NewReader(`{"name":"` + flag.Name + `","enabled":true}`)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/admin/flags", body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want 200, body: %s", w.Code, w.Body.String())
+	}
+
+	if !s.IsEnabled(context.Background(), flag) {
+		t.Errorf("PUT did not persist the override onto the Set")
+	}
+}
+
+func TestAdminHandlerPutUnknownFlag(t *testing.T) {
+	s := NewSet()
+	h := AdminHandler(s)
+
+	body := strings.NewReader(`{"name":"test_never_registered_admin","enabled":true}`)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/admin/flags", body))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("PUT status = %d, want 404 for an unregistered flag", w.Code)
+	}
+}
+
+func containsDisabled(entries []effectiveEntry, name string) bool {
+	for _, e := range entries {
+		if e.Name == name {
+			return !e.Enabled
+		}
+	}
+	return false
+}
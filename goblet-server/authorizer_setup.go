@@ -0,0 +1,78 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/goblet/auth"
+	"github.com/google/goblet/auth/mtls"
+	"github.com/google/goblet/auth/oidc"
+	googlehook "github.com/google/goblet/google"
+	"golang.org/x/oauth2"
+)
+
+// setupAuthorizer builds the goblet.ServerConfig.RequestAuthorizer
+// selected by authorizerKind ("google", "oidc", "mtls", or "none"), so
+// goblet-server isn't hard-wired to googlehook's Google-only hook. ts is
+// only used by "google"; the oidc.* parameters are only used by "oidc".
+// oidcAllowDevTokens must be left false in any production deployment --
+// it maps directly onto oidc.Authorizer.AllowDevTokens, which accepts
+// any "dev-token-*" bearer token without verification. For "mtls", the
+// caller is still responsible for configuring the *http.Server's
+// TLSConfig (ClientCAs from the CA bundle that signs client
+// certificates, ClientAuth set to tls.RequireAndVerifyClientCert) and
+// serving with ListenAndServeTLS -- see main.go's *authorizerKind ==
+// "mtls" handling -- this only builds the Authorizer that inspects the
+// already-verified client certificate.
+func setupAuthorizer(ctx context.Context, authorizerKind string, ts oauth2.TokenSource, oidcIssuer, oidcAudience, oidcRequiredClaim string, oidcAllowDevTokens bool) (func(*http.Request) error, error) {
+	switch authorizerKind {
+	case "", "google":
+		authorizer, err := googlehook.NewRequestAuthorizer(ts)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create a Google request authorizer: %v", err)
+		}
+		return authorizer, nil
+
+	case "oidc":
+		if oidcIssuer == "" {
+			return nil, fmt.Errorf("-authorizer=oidc requires -oidc_issuer")
+		}
+		verifier, err := oidc.NewVerifier(ctx, oidc.Config{
+			IssuerURL: oidcIssuer,
+			ClientID:  oidcAudience,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot create an OIDC verifier: %v", err)
+		}
+		authorizer := oidc.NewAuthorizer(verifier)
+		if oidcRequiredClaim != "" {
+			authorizer.AllowedGroups = []string{oidcRequiredClaim}
+		}
+		authorizer.AllowDevTokens = oidcAllowDevTokens
+		return auth.RequestAuthorizerFunc(authorizer), nil
+
+	case "mtls":
+		return auth.RequestAuthorizerFunc(mtls.NewRequestAuthorizer()), nil
+
+	case "none":
+		return auth.RequestAuthorizerFunc(auth.None), nil
+
+	default:
+		return nil, fmt.Errorf("unknown -authorizer %q: want google, oidc, mtls, or none", authorizerKind)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/goblet/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// FileConfig is the schema for the file -config points at: everything
+// main() otherwise builds from flags, plus a list of named storage
+// backends and backup jobs flags have no way to express more than one
+// of. A zero FileConfig (e.g. one loaded from an empty file) leaves
+// every section unset. configManager.Reload reads CacheRoot, Authorizer,
+// Backends and BackupJobs; an empty Authorizer.Kind selects the "google"
+// authorizer, the same as the -authorizer default.
+type FileConfig struct {
+	Listen ListenFileConfig `json:"listen,omitempty" yaml:"listen,omitempty"`
+
+	// CacheRoot mirrors -cache_root. A reload that would change it from
+	// the value the process started with is rejected: the local disk
+	// cache layout and any in-flight clone streams are keyed to it.
+	CacheRoot string `json:"cache_root,omitempty" yaml:"cache_root,omitempty"`
+
+	Authorizer AuthorizerFileConfig `json:"authorizer,omitempty" yaml:"authorizer,omitempty"`
+
+	// Backends maps a backend name (referenced by BackupJobFileConfig.Backend)
+	// to its storage configuration.
+	Backends map[string]BackendFileConfig `json:"backends,omitempty" yaml:"backends,omitempty"`
+
+	// BackupJobs is the set of backup goroutines reload should converge
+	// the running process to: one per entry, identified by Name.
+	BackupJobs []BackupJobFileConfig `json:"backup_jobs,omitempty" yaml:"backup_jobs,omitempty"`
+}
+
+// ListenFileConfig mirrors the -port/-shutdown_timeout flags.
+type ListenFileConfig struct {
+	Port            int           `json:"port,omitempty" yaml:"port,omitempty"`
+	ShutdownTimeout time.Duration `json:"shutdown_timeout,omitempty" yaml:"shutdown_timeout,omitempty"`
+}
+
+// AuthorizerFileConfig mirrors the -authorizer/-oidc_* flags.
+type AuthorizerFileConfig struct {
+	Kind               string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	OIDCIssuer         string `json:"oidc_issuer,omitempty" yaml:"oidc_issuer,omitempty"`
+	OIDCAudience       string `json:"oidc_audience,omitempty" yaml:"oidc_audience,omitempty"`
+	OIDCRequiredClaim  string `json:"oidc_required_claim,omitempty" yaml:"oidc_required_claim,omitempty"`
+	// OIDCAllowDevTokens must be left false (the default) in any
+	// production deployment -- it maps directly onto
+	// oidc.Authorizer.AllowDevTokens, which accepts any "dev-token-*"
+	// bearer token without verification.
+	OIDCAllowDevTokens bool `json:"oidc_allow_dev_tokens,omitempty" yaml:"oidc_allow_dev_tokens,omitempty"`
+}
+
+// BackendFileConfig mirrors the subset of storage.Config a backup job's
+// backend needs; toStorageConfig fills in the rest of storage.Config
+// from it.
+type BackendFileConfig struct {
+	Provider string `json:"provider" yaml:"provider"`
+
+	GCSBucket string `json:"gcs_bucket,omitempty" yaml:"gcs_bucket,omitempty"`
+
+	S3Endpoint        string `json:"s3_endpoint,omitempty" yaml:"s3_endpoint,omitempty"`
+	S3Bucket          string `json:"s3_bucket,omitempty" yaml:"s3_bucket,omitempty"`
+	S3AccessKeyID     string `json:"s3_access_key,omitempty" yaml:"s3_access_key,omitempty"`
+	S3SecretAccessKey string `json:"s3_secret_key,omitempty" yaml:"s3_secret_key,omitempty"`
+	S3Region          string `json:"s3_region,omitempty" yaml:"s3_region,omitempty"`
+	S3UseSSL          bool   `json:"s3_use_ssl,omitempty" yaml:"s3_use_ssl,omitempty"`
+	S3Credentials     string `json:"s3_credentials,omitempty" yaml:"s3_credentials,omitempty"`
+	S3STSEndpoint     string `json:"s3_sts_endpoint,omitempty" yaml:"s3_sts_endpoint,omitempty"`
+	S3RoleARN         string `json:"s3_role_arn,omitempty" yaml:"s3_role_arn,omitempty"`
+	S3ExternalID      string `json:"s3_external_id,omitempty" yaml:"s3_external_id,omitempty"`
+	S3SessionName     string `json:"s3_session_name,omitempty" yaml:"s3_session_name,omitempty"`
+	S3UserAgent       string `json:"s3_user_agent,omitempty" yaml:"s3_user_agent,omitempty"`
+
+	AzureConnectionString string `json:"azure_connection_string,omitempty" yaml:"azure_connection_string,omitempty"`
+	AzureAccountName      string `json:"azure_account_name,omitempty" yaml:"azure_account_name,omitempty"`
+	AzureAccountKey       string `json:"azure_account_key,omitempty" yaml:"azure_account_key,omitempty"`
+	AzureContainer        string `json:"azure_container,omitempty" yaml:"azure_container,omitempty"`
+	AzureEndpoint         string `json:"azure_endpoint,omitempty" yaml:"azure_endpoint,omitempty"`
+
+	LocalPath string `json:"local_path,omitempty" yaml:"local_path,omitempty"`
+}
+
+// BackupJobFileConfig is one entry in FileConfig.BackupJobs.
+type BackupJobFileConfig struct {
+	// Name identifies this job across reloads: reload matches running
+	// jobs to FileConfig.BackupJobs entries by Name, not by position.
+	Name string `json:"name" yaml:"name"`
+	// Backend names an entry in FileConfig.Backends.
+	Backend string `json:"backend" yaml:"backend"`
+	// ManifestName is passed through to googlehook.RunBackupProcess.
+	ManifestName string `json:"manifest_name" yaml:"manifest_name"`
+	// Schedule is an operator-facing note on how often this job's
+	// backend is expected to see new backup writes; googlehook.RunBackupProcess
+	// (not present in this checkout) owns the actual backup cadence, so
+	// this field is informational only until that wiring exists.
+	Schedule string `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+}
+
+// loadFileConfig reads and parses path as YAML (.yaml/.yml) or JSON
+// (anything else).
+func loadFileConfig(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file %q: %w", path, err)
+	}
+	var fc FileConfig
+	if strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
+		if err := yaml.Unmarshal(data, &fc); err != nil {
+			return nil, fmt.Errorf("cannot parse config file %q as YAML: %w", path, err)
+		}
+	} else {
+		if err := json.Unmarshal(data, &fc); err != nil {
+			return nil, fmt.Errorf("cannot parse config file %q as JSON: %w", path, err)
+		}
+	}
+	return &fc, nil
+}
+
+// toStorageConfig builds the storage.Config for a named backend.
+func (b BackendFileConfig) toStorageConfig() *storage.Config {
+	return &storage.Config{
+		Provider:              b.Provider,
+		GCSBucket:             b.GCSBucket,
+		S3Endpoint:            b.S3Endpoint,
+		S3Bucket:              b.S3Bucket,
+		S3AccessKeyID:         b.S3AccessKeyID,
+		S3SecretAccessKey:     b.S3SecretAccessKey,
+		S3Region:              b.S3Region,
+		S3UseSSL:              b.S3UseSSL,
+		S3CredentialsMode:     s3CredentialsModeFlagToConfig(b.S3Credentials),
+		S3STSEndpoint:         b.S3STSEndpoint,
+		S3RoleARN:             b.S3RoleARN,
+		S3ExternalID:          b.S3ExternalID,
+		S3RoleSessionName:     b.S3SessionName,
+		S3UserAgent:           b.S3UserAgent,
+		AzureConnectionString: b.AzureConnectionString,
+		AzureAccountName:      b.AzureAccountName,
+		AzureAccountKey:       b.AzureAccountKey,
+		AzureContainer:        b.AzureContainer,
+		AzureEndpoint:         b.AzureEndpoint,
+		LocalPath:             b.LocalPath,
+	}
+}
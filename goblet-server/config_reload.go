@@ -0,0 +1,184 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/goblet"
+	googlehook "github.com/google/goblet/google"
+	"github.com/google/goblet/storage"
+	"golang.org/x/oauth2"
+)
+
+// configManager holds the parts of a running goblet-server that -config's
+// SIGHUP reload can change without a restart: the request authorizer and
+// the set of backup-job goroutines. FileConfig.Listen is not read at
+// all; the listener keeps the -port and -shutdown_timeout it started
+// with. A backend's own settings only take effect for jobs started
+// after the reload, since a storage.Provider already handed to
+// googlehook.RunBackupProcess can't be reconfigured in place.
+//
+// The zero value is not usable; create one with newConfigManager.
+type configManager struct {
+	ts         oauth2.TokenSource
+	serverConf *goblet.ServerConfig
+	backupLog  *log.Logger
+	cacheRoot  string       // refuse reloads that would change this
+	authorizer atomic.Value // func(*http.Request) error
+
+	mu   sync.Mutex
+	jobs map[string]*runningBackupJob // keyed by BackupJobFileConfig.Name
+}
+
+// runningBackupJob is one backup goroutine configManager started, kept
+// around so a later reload can recognize it's still wanted (skip) or no
+// longer wanted (stop).
+type runningBackupJob struct {
+	config   BackupJobFileConfig
+	cancel   context.CancelFunc
+	provider storage.Provider
+}
+
+// newConfigManager creates a configManager whose initial authorizer is
+// fallback and whose cacheRoot is fixed for the process lifetime: a
+// reload that tries to change it is rejected rather than applied.
+func newConfigManager(fallback func(*http.Request) error, ts oauth2.TokenSource, serverConf *goblet.ServerConfig, backupLog *log.Logger, cacheRoot string) *configManager {
+	cm := &configManager{
+		ts:         ts,
+		serverConf: serverConf,
+		backupLog:  backupLog,
+		cacheRoot:  cacheRoot,
+		jobs:       map[string]*runningBackupJob{},
+	}
+	cm.authorizer.Store(fallback)
+	return cm
+}
+
+// RequestAuthorizer is the func(*http.Request) error to install as
+// goblet.ServerConfig.RequestAuthorizer; it reads the atomic.Value set by
+// the most recent successful Reload, so a SIGHUP takes effect for every
+// request after it without restarting the listener.
+func (cm *configManager) RequestAuthorizer(r *http.Request) error {
+	return cm.authorizer.Load().(func(*http.Request) error)(r)
+}
+
+// Reload converges the running process to fc: it swaps the authorizer
+// (after building the new one fully succeeds, so a bad config never
+// leaves requests unauthenticated), then starts a runningBackupJob for
+// every fc.BackupJobs entry not already running under that name and
+// stops every running job no longer listed. fc.CacheRoot, if non-empty
+// and different from the cacheRoot newConfigManager was created with, is
+// rejected without applying anything else from fc.
+func (cm *configManager) Reload(ctx context.Context, fc *FileConfig) error {
+	if fc.CacheRoot != "" && fc.CacheRoot != cm.cacheRoot {
+		return fmt.Errorf("refusing reload: cache_root changed from %q to %q; restart the process to change it", cm.cacheRoot, fc.CacheRoot)
+	}
+	if fc.Authorizer.Kind == "mtls" {
+		// mtls only works behind the TLS listener main.go starts at
+		// process startup (ClientCAs/ClientAuth on the *http.Server's
+		// TLSConfig, served with ListenAndServeTLS); a SIGHUP reload
+		// can't retroactively start TLS on an already-listening plain
+		// HTTP server, so switching to it here would silently fail
+		// every request's client-certificate check instead of
+		// authorizing anything.
+		return fmt.Errorf("refusing reload: authorizer.kind \"mtls\" requires restarting the process with -authorizer=mtls, not a config reload")
+	}
+
+	authorizer, err := setupAuthorizer(ctx, fc.Authorizer.Kind, cm.ts, fc.Authorizer.OIDCIssuer, fc.Authorizer.OIDCAudience, fc.Authorizer.OIDCRequiredClaim, fc.Authorizer.OIDCAllowDevTokens)
+	if err != nil {
+		return fmt.Errorf("refusing reload: %v", err)
+	}
+	cm.authorizer.Store(authorizer)
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	wanted := map[string]BackupJobFileConfig{}
+	for _, job := range fc.BackupJobs {
+		wanted[job.Name] = job
+	}
+
+	for name, running := range cm.jobs {
+		if _, ok := wanted[name]; !ok {
+			log.Printf("config reload: stopping backup job %q", name)
+			running.cancel()
+			running.provider.Close()
+			delete(cm.jobs, name)
+		}
+	}
+
+	for name, job := range wanted {
+		if _, ok := cm.jobs[name]; ok {
+			// Already running; backend/manifest changes to an existing
+			// job only take effect on a later restart, since the
+			// provider googlehook.RunBackupProcess is already using
+			// can't be swapped out from under it.
+			continue
+		}
+		backend, ok := fc.Backends[job.Backend]
+		if !ok {
+			log.Printf("config reload: backup job %q references unknown backend %q, skipping", name, job.Backend)
+			continue
+		}
+		running, err := cm.startBackupJob(ctx, job, backend)
+		if err != nil {
+			log.Printf("config reload: cannot start backup job %q: %v", name, err)
+			continue
+		}
+		cm.jobs[name] = running
+		log.Printf("config reload: started backup job %q", name)
+	}
+	return nil
+}
+
+// startBackupJob creates the storage.Provider job.Backend describes and
+// runs googlehook.RunBackupProcess against it in a goroutine. jobCtx is
+// canceled by the returned job's cancel so a future Reload dropping this
+// job at least stops new storage operations from starting, even though
+// RunBackupProcess itself (not present in this checkout) may not observe
+// ctx cancellation mid-operation; closing provider is the hard stop.
+func (cm *configManager) startBackupJob(ctx context.Context, job BackupJobFileConfig, backend BackendFileConfig) (*runningBackupJob, error) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	provider, err := storage.NewProvider(jobCtx, backend.toStorageConfig())
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if provider == nil {
+		cancel()
+		return nil, fmt.Errorf("backend %q has no provider configured", job.Backend)
+	}
+	go func() {
+		googlehook.RunBackupProcess(cm.serverConf, provider, job.ManifestName, cm.backupLog)
+	}()
+	return &runningBackupJob{config: job, cancel: cancel, provider: provider}, nil
+}
+
+// Close stops every running backup job, e.g. during process shutdown.
+func (cm *configManager) Close() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	for name, running := range cm.jobs {
+		running.cancel()
+		running.provider.Close()
+		delete(cm.jobs, name)
+	}
+}
@@ -16,6 +16,8 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"flag"
 	"fmt"
 	"io"
@@ -24,17 +26,18 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"cloud.google.com/go/errorreporting"
 	"cloud.google.com/go/logging"
-	"contrib.go.opencensus.io/exporter/stackdriver"
 	"github.com/google/goblet"
 	googlehook "github.com/google/goblet/google"
 	"github.com/google/goblet/storage"
 	"github.com/google/uuid"
-	"go.opencensus.io/stats/view"
-	"go.opencensus.io/tag"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"golang.org/x/oauth2/google"
 
 	logpb "google.golang.org/genproto/googleapis/logging/v2"
@@ -46,14 +49,32 @@ const (
 )
 
 var (
-	port      = flag.Int("port", 8080, "port to listen to")
-	cacheRoot = flag.String("cache_root", "", "Root directory of cached repositories")
+	port            = flag.Int("port", 8080, "port to listen to")
+	cacheRoot       = flag.String("cache_root", "", "Root directory of cached repositories")
+	shutdownTimeout = flag.Duration("shutdown_timeout", 30*time.Second, "How long to wait for in-flight requests to drain on SIGINT/SIGTERM before forcibly closing them")
+	configFile      = flag.String("config", "", "Path to a YAML or JSON config file describing storage backends, backup jobs, and the authorizer choice; re-read on SIGHUP. Flag-only configuration continues to work when unset.")
 
 	stackdriverProject      = flag.String("stackdriver_project", "", "GCP project ID used for the Stackdriver integration")
 	stackdriverLoggingLogID = flag.String("stackdriver_logging_log_id", "", "Stackdriver logging Log ID")
 
+	metricsExporter       = flag.String("metrics_exporter", "stackdriver", "Where to publish the inbound/outbound/upstream-fetch metrics: 'stackdriver', 'prometheus', 'otlp', or 'none'")
+	otlpCollectorEndpoint = flag.String("otlp_collector_endpoint", "", "OTLP/gRPC collector endpoint, e.g. 'otel-collector:4317' (metrics_exporter=otlp only)")
+
+	authorizerKind     = flag.String("authorizer", "google", "How to authorize incoming Git smart-HTTP requests: 'google' (the googlehook OAuth2 hook), 'oidc', 'mtls', or 'none'")
+	oidcIssuer         = flag.String("oidc_issuer", "", "OIDC issuer URL (authorizer=oidc only)")
+	oidcAudience       = flag.String("oidc_audience", "", "Expected OIDC audience/client ID (authorizer=oidc only)")
+	oidcRequiredClaim  = flag.String("oidc_required_claim", "", "If set, the OIDC group claim a token must carry to be authorized (authorizer=oidc only)")
+	oidcAllowDevTokens = flag.Bool("oidc_allow_dev_tokens", false, "DANGEROUS: accept any \"dev-token-*\" bearer token without verification (authorizer=oidc only). Never set this on a production listener.")
+
+	// TLS/mTLS configuration. authorizer=mtls requires all three: a
+	// server certificate/key to terminate TLS with, and the CA bundle
+	// that signs the client certificates it's meant to verify.
+	tlsCertFile        = flag.String("tls_cert_file", "", "Path to the server's TLS certificate, PEM-encoded (required for authorizer=mtls)")
+	tlsKeyFile         = flag.String("tls_key_file", "", "Path to the server's TLS private key, PEM-encoded (required for authorizer=mtls)")
+	mtlsClientCABundle = flag.String("mtls_client_ca_bundle", "", "PEM bundle of CA certificates trusted to sign client certificates (required for authorizer=mtls)")
+
 	// Storage provider configuration.
-	storageProvider = flag.String("storage_provider", "", "Storage provider: 'gcs' or 's3'")
+	storageProvider = flag.String("storage_provider", "", "Storage provider: 'gcs', 's3', 'azure', 'local', or 'memory'")
 
 	// GCS configuration.
 	backupBucketName   = flag.String("backup_bucket_name", "", "Name of the GCS bucket for backed-up repositories (GCS only)")
@@ -67,82 +88,84 @@ var (
 	s3Region          = flag.String("s3_region", "us-east-1", "S3 region")
 	s3UseSSL          = flag.Bool("s3_use_ssl", false, "Use SSL for S3 connections")
 
-	latencyDistributionAggregation = view.Distribution(
-		100,
-		200,
-		400,
-		800,
-		1000, // 1s
-		2000,
-		4000,
-		8000,
-		10000, // 10s
-		20000,
-		40000,
-		80000,
-		100000, // 100s
-		200000,
-		400000,
-		800000,
-		1000000, // 1000s
-		2000000,
-		4000000,
-		8000000,
-	)
-	views = []*view.View{
-		{
-			Name:        "github.com/google/goblet/inbound-command-count",
-			Description: "Inbound command count",
-			TagKeys:     []tag.Key{goblet.CommandTypeKey, goblet.CommandCanonicalStatusKey, goblet.CommandCacheStateKey},
-			Measure:     goblet.InboundCommandCount,
-			Aggregation: view.Count(),
-		},
-		{
-			Name:        "github.com/google/goblet/inbound-command-latency",
-			Description: "Inbound command latency",
-			TagKeys:     []tag.Key{goblet.CommandTypeKey, goblet.CommandCanonicalStatusKey, goblet.CommandCacheStateKey},
-			Measure:     goblet.InboundCommandProcessingTime,
-			Aggregation: latencyDistributionAggregation,
-		},
-		{
-			Name:        "github.com/google/goblet/outbound-command-count",
-			Description: "Outbound command count",
-			TagKeys:     []tag.Key{goblet.CommandTypeKey, goblet.CommandCanonicalStatusKey},
-			Measure:     goblet.OutboundCommandCount,
-			Aggregation: view.Count(),
-		},
-		{
-			Name:        "github.com/google/goblet/outbound-command-latency",
-			Description: "Outbound command latency",
-			TagKeys:     []tag.Key{goblet.CommandTypeKey, goblet.CommandCanonicalStatusKey},
-			Measure:     goblet.OutboundCommandProcessingTime,
-			Aggregation: latencyDistributionAggregation,
-		},
-		{
-			Name:        "github.com/google/goblet/upstream-fetch-blocking-time",
-			Description: "Duration that requests are waiting for git-fetch from the upstream",
-			Measure:     goblet.UpstreamFetchWaitingTime,
-			Aggregation: latencyDistributionAggregation,
-		},
+	s3Credentials          = flag.String("s3_credentials", "static", "How to obtain S3 credentials: 'static' (s3_access_key/s3_secret_key), 'env' (AWS_* env vars), 'instance' (EC2/ECS instance metadata), 'irsa' (Kubernetes IRSA web-identity token), or 'assume_role' (STS AssumeRole on top of s3_access_key/s3_secret_key)")
+	s3STSEndpoint          = flag.String("s3_sts_endpoint", "", "STS (or IAM metadata) endpoint used by s3_credentials modes other than 'static'/'env'")
+	s3RoleARN              = flag.String("s3_role_arn", "", "IAM role ARN to assume (s3_credentials=irsa or assume_role)")
+	s3ExternalID           = flag.String("s3_external_id", "", "STS AssumeRole external ID (s3_credentials=assume_role only)")
+	s3SessionName          = flag.String("s3_session_name", "goblet", "STS session name (s3_credentials=irsa or assume_role)")
+	s3WebIdentityTokenFile = flag.String("s3_web_identity_token_file", os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), "Path to the OIDC/JWT web-identity token file (s3_credentials=irsa only); defaults to $AWS_WEB_IDENTITY_TOKEN_FILE")
+	s3UserAgentFlag        = flag.String("s3_user_agent", "", "Extra User-Agent string appended to outgoing S3 requests, so goblet's traffic is identifiable in S3/Minio access logs")
+
+	// Azure Blob Storage configuration.
+	azureConnectionString = flag.String("azure_connection_string", "", "Azure Storage connection string (azure only; takes precedence over azure_account_name/azure_account_key)")
+	azureAccountName      = flag.String("azure_account_name", "", "Azure Storage account name (azure only)")
+	azureAccountKey       = flag.String("azure_account_key", "", "Azure Storage account key (azure only)")
+	azureContainer        = flag.String("azure_container", "", "Azure Blob Storage container name (azure only)")
+	azureEndpoint         = flag.String("azure_endpoint", "", "Azure Blob service endpoint override, e.g. for the Azurite emulator (azure only)")
+
+	// Local filesystem configuration.
+	localPath = flag.String("local_path", "", "Root directory for the local filesystem storage provider (local only)")
+
+	// latencyHistogramBoundaries are the explicit bucket boundaries (in
+	// milliseconds) applied to every goblet command-latency histogram
+	// below, carried over unchanged from the OpenCensus Distribution
+	// aggregation this replaces.
+	latencyHistogramBoundaries = []float64{
+		100, 200, 400, 800,
+		1000, 2000, 4000, 8000, // 1s .. 8s
+		10000, 20000, 40000, 80000, // 10s .. 80s
+		100000, 200000, 400000, 800000, // 100s .. 800s
+		1000000, 2000000, 4000000, 8000000, // 1000s ..
+	}
+
+	// latencyViews pins the bucket boundaries above onto each of
+	// goblet's command-latency histograms by instrument name; everything
+	// else (which attributes get exported, at what cardinality) is left
+	// to the SDK's defaults.
+	latencyViews = []sdkmetric.View{
+		sdkmetric.NewView(
+			sdkmetric.Instrument{Name: "github.com/google/goblet/inbound-command-latency"},
+			sdkmetric.Stream{Aggregation: sdkmetric.AggregationExplicitBucketHistogram{Boundaries: latencyHistogramBoundaries}},
+		),
+		sdkmetric.NewView(
+			sdkmetric.Instrument{Name: "github.com/google/goblet/outbound-command-latency"},
+			sdkmetric.Stream{Aggregation: sdkmetric.AggregationExplicitBucketHistogram{Boundaries: latencyHistogramBoundaries}},
+		),
+		sdkmetric.NewView(
+			sdkmetric.Instrument{Name: "github.com/google/goblet/upstream-fetch-blocking-time"},
+			sdkmetric.Stream{Aggregation: sdkmetric.AggregationExplicitBucketHistogram{Boundaries: latencyHistogramBoundaries}},
+		),
 	}
 )
 
 func main() {
 	flag.Parse()
 
+	// ctx is canceled on SIGINT/SIGTERM instead of letting the default
+	// handler kill the process outright, so storage.NewProvider's
+	// backup-upload goroutine (and any other ctx-aware work started
+	// below) gets a chance to finish its current object and write a
+	// final manifest during the drain below, rather than being killed
+	// mid-upload.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	ts, err := google.DefaultTokenSource(context.Background(), scopeCloudPlatform, scopeUserInfoEmail)
 	if err != nil {
 		log.Fatalf("Cannot initialize the OAuth2 token source: %v", err)
 	}
-	authorizer, err := googlehook.NewRequestAuthorizer(ts)
+	authorizer, err := setupAuthorizer(context.Background(), *authorizerKind, ts, *oidcIssuer, *oidcAudience, *oidcRequiredClaim, *oidcAllowDevTokens)
 	if err != nil {
 		log.Fatalf("Cannot create a request authorizer: %v", err)
 	}
-	if err := view.Register(views...); err != nil {
-		log.Fatal(err)
-	}
 
-	var er func(*http.Request, error)
+	// er and rl default to a plain-stderr fallback, so the proxy reports
+	// errors and logs requests end-to-end without any Stackdriver
+	// configuration; the *stackdriverProject block below replaces both
+	// with Stackdriver-backed implementations when it's set.
+	var er func(*http.Request, error) = func(r *http.Request, err error) {
+		log.Printf("Error while processing a request: %v", err)
+	}
 	var rl func(r *http.Request, status int, requestSize, responseSize int64, latency time.Duration) = func(r *http.Request, status int, requestSize, responseSize int64, latency time.Duration) {
 		dump, err := httputil.DumpRequest(r, false)
 		if err != nil {
@@ -225,18 +248,17 @@ func main() {
 			// Backup logger
 			backupLogger = sdLogger.StandardLogger(logging.Warning)
 		}
+	}
 
-		// OpenCensus view exporters.
-		exporter, err := stackdriver.NewExporter(stackdriver.Options{
-			ProjectID: *stackdriverProject,
-		})
-		if err != nil {
-			log.Fatal(err)
-		}
-		if err = exporter.StartMetricsExporter(); err != nil {
-			log.Fatal(err)
-		}
+	metricsShutdown, err := setupMeterProvider(context.Background(), *metricsExporter, *stackdriverProject, *otlpCollectorEndpoint, http.DefaultServeMux)
+	if err != nil {
+		log.Fatalf("Cannot set up the %s metrics exporter: %v", *metricsExporter, err)
 	}
+	defer func() {
+		if err := metricsShutdown(context.Background()); err != nil {
+			log.Printf("Failed to shut down the metrics exporter: %v", err)
+		}
+	}()
 
 	config := &goblet.ServerConfig{
 		LocalDiskCacheRoot:         *cacheRoot,
@@ -248,33 +270,95 @@ func main() {
 		LongRunningOperationLogger: lrol,
 	}
 
-	if *storageProvider != "" && *backupManifestName != "" {
+	// cm is non-nil only when -config is set: it owns the backup jobs
+	// and authorizer a SIGHUP reload can change, in place of the
+	// single flag-derived backup job and authorizer below.
+	var cm *configManager
+	if *configFile != "" {
+		cm = newConfigManager(authorizer, ts, config, backupLogger, *cacheRoot)
+		config.RequestAuthorizer = cm.RequestAuthorizer
+
+		fc, err := loadFileConfig(*configFile)
+		if err != nil {
+			log.Fatalf("Cannot load -config: %v", err)
+		}
+		if err := cm.Reload(ctx, fc); err != nil {
+			log.Fatalf("Cannot apply -config: %v", err)
+		}
+		defer cm.Close()
+
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				fc, err := loadFileConfig(*configFile)
+				if err != nil {
+					log.Printf("SIGHUP: cannot load -config: %v", err)
+					continue
+				}
+				if err := cm.Reload(ctx, fc); err != nil {
+					log.Printf("SIGHUP: cannot apply -config: %v", err)
+					continue
+				}
+				log.Printf("SIGHUP: reloaded %s", *configFile)
+			}
+		}()
+	}
+
+	if cm == nil && *storageProvider != "" && *backupManifestName != "" {
 		storageConfig := &storage.Config{
-			Provider:          *storageProvider,
-			GCSBucket:         *backupBucketName,
-			S3Endpoint:        *s3Endpoint,
-			S3Bucket:          *s3Bucket,
-			S3AccessKeyID:     *s3AccessKeyID,
-			S3SecretAccessKey: *s3SecretAccessKey,
-			S3Region:          *s3Region,
-			S3UseSSL:          *s3UseSSL,
+			Provider:               *storageProvider,
+			GCSBucket:              *backupBucketName,
+			S3Endpoint:             *s3Endpoint,
+			S3Bucket:               *s3Bucket,
+			S3AccessKeyID:          *s3AccessKeyID,
+			S3SecretAccessKey:      *s3SecretAccessKey,
+			S3Region:               *s3Region,
+			S3UseSSL:               *s3UseSSL,
+			S3CredentialsMode:      s3CredentialsModeFlagToConfig(*s3Credentials),
+			S3STSEndpoint:          *s3STSEndpoint,
+			S3RoleARN:              *s3RoleARN,
+			S3ExternalID:           *s3ExternalID,
+			S3RoleSessionName:      *s3SessionName,
+			S3WebIdentityTokenFile: *s3WebIdentityTokenFile,
+			S3UserAgent:            *s3UserAgentFlag,
+			AzureConnectionString:  *azureConnectionString,
+			AzureAccountName:       *azureAccountName,
+			AzureAccountKey:        *azureAccountKey,
+			AzureContainer:         *azureContainer,
+			AzureEndpoint:          *azureEndpoint,
+			LocalPath:              *localPath,
 		}
 
-		provider, err := storage.NewProvider(context.Background(), storageConfig)
+		provider, err := storage.NewProvider(ctx, storageConfig)
 		if err != nil {
 			log.Fatalf("Cannot create storage provider: %v", err)
 		}
 		if provider != nil {
 			defer provider.Close()
+			// RunBackupProcess's own ctx-aware draining (finish the
+			// in-flight object, write a final manifest instead of
+			// aborting mid-upload) lives in the google package, which
+			// is not present in this checkout; ctx is threaded through
+			// here so that wiring is a one-line change once it is.
 			googlehook.RunBackupProcess(config, provider, *backupManifestName, backupLogger)
 		}
 	}
 
+	// draining is flipped to true as soon as shutdown begins, so
+	// /healthz fails immediately and a load balancer stops routing new
+	// traffic here before in-flight requests are even given the chance
+	// to finish.
+	var draining atomic.Bool
 	http.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		if draining.Load() {
+			http.Error(w, "shutting down\n", http.StatusServiceUnavailable)
+			return
+		}
 		w.Header().Set("Content-Type", "text/plain")
 		_, _ = io.WriteString(w, "ok\n")
 	})
-	http.Handle("/", goblet.HTTPHandler(config))
+	http.Handle("/", drainingHandler(&draining, goblet.HTTPHandler(config)))
 
 	// Create server with timeouts to prevent resource exhaustion
 	server := &http.Server{
@@ -283,7 +367,92 @@ func main() {
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
-	log.Fatal(server.ListenAndServe())
+
+	// -authorizer=mtls only rejects requests lacking an acceptable
+	// client certificate; the TLS listener is what actually collects
+	// and verifies one. Without this, mtls.RequestAuthorizer would never
+	// see r.TLS populated and every request would fail with "no client
+	// certificate presented" -- so mtls requires serving TLS directly
+	// here rather than the plain server.ListenAndServe() below.
+	var tlsConfig *tls.Config
+	if *authorizerKind == "mtls" {
+		if *tlsCertFile == "" || *tlsKeyFile == "" || *mtlsClientCABundle == "" {
+			log.Fatal("-authorizer=mtls requires -tls_cert_file, -tls_key_file, and -mtls_client_ca_bundle")
+		}
+		caBundle, err := os.ReadFile(*mtlsClientCABundle)
+		if err != nil {
+			log.Fatalf("Cannot read -mtls_client_ca_bundle: %v", err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caBundle) {
+			log.Fatalf("No certificates found in -mtls_client_ca_bundle %s", *mtlsClientCABundle)
+		}
+		tlsConfig = &tls.Config{
+			ClientCAs:  clientCAs,
+			ClientAuth: tls.RequireAndVerifyClientCert,
+		}
+		server.TLSConfig = tlsConfig
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if tlsConfig != nil {
+			serveErr <- server.ListenAndServeTLS(*tlsCertFile, *tlsKeyFile)
+		} else {
+			serveErr <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		log.Fatal(err)
+	case <-ctx.Done():
+		stop()
+		log.Printf("Received shutdown signal, draining for up to %v", *shutdownTimeout)
+		draining.Store(true)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error during graceful shutdown: %v", err)
+		}
+	}
+}
+
+// drainingHandler wraps next so that once draining reports true, every
+// request gets a 503 with Retry-After instead of reaching next -- used
+// for the main git-proxy route during the shutdown drain, where
+// /healthz already failing tells the load balancer to stop sending new
+// requests, but any already in flight (or racing the LB's next check)
+// still need an explicit, retryable rejection rather than being served
+// by a handler whose dependencies may already be tearing down.
+func drainingHandler(draining *atomic.Bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if draining.Load() {
+			w.Header().Set("Retry-After", "5")
+			http.Error(w, "server is shutting down\n", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// s3CredentialsModeFlagToConfig translates -s3_credentials' operator-facing
+// mode names into storage.Config.S3CredentialsMode's, which name the
+// underlying STS call instead of the AWS deployment pattern that calls
+// it: "instance" (EC2/ECS instance metadata) is storage's "iam", and
+// "irsa" (Kubernetes' web-identity-token convention) is storage's
+// "web-identity". "static", "env", and "assume_role" pass through
+// unchanged.
+func s3CredentialsModeFlagToConfig(mode string) string {
+	switch mode {
+	case "instance":
+		return "iam"
+	case "irsa":
+		return "web-identity"
+	default:
+		return mode
+	}
 }
 
 type LongRunningOperation struct {
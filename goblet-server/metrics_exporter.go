@@ -0,0 +1,91 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	mexporter "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/metric"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// setupMeterProvider builds and installs the otel.MeterProvider selected by
+// exporterKind ("stackdriver", "prometheus", "otlp", or "none"), so the three
+// latencyViews-pinned histograms stay the same regardless of where they end
+// up: goblet's storage layer already supports non-GCP backends (S3, Azure,
+// local disk), and requiring Stackdriver for metrics shouldn't be a
+// precondition for using them. The "prometheus" mode registers a "/metrics"
+// handler on mux; the others don't use mux at all. It returns a shutdown
+// func to flush/close the exporter on process exit, and is a no-op
+// (shutdown does nothing) for "none".
+func setupMeterProvider(ctx context.Context, exporterKind, stackdriverProject, otlpEndpoint string, mux *http.ServeMux) (shutdown func(context.Context) error, err error) {
+	noopShutdown := func(context.Context) error { return nil }
+
+	switch exporterKind {
+	case "", "none":
+		return noopShutdown, nil
+
+	case "stackdriver":
+		if stackdriverProject == "" {
+			return nil, fmt.Errorf("-metrics_exporter=stackdriver requires -stackdriver_project")
+		}
+		metricExporter, err := mexporter.New(mexporter.WithProjectID(stackdriverProject))
+		if err != nil {
+			return nil, fmt.Errorf("cannot create the Stackdriver metric exporter: %v", err)
+		}
+		reader := sdkmetric.NewPeriodicReader(metricExporter)
+		otel.SetMeterProvider(sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(reader),
+			sdkmetric.WithView(latencyViews...),
+		))
+		return reader.Shutdown, nil
+
+	case "prometheus":
+		reader, err := otelprometheus.New()
+		if err != nil {
+			return nil, fmt.Errorf("cannot create the Prometheus metric exporter: %v", err)
+		}
+		otel.SetMeterProvider(sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(reader),
+			sdkmetric.WithView(latencyViews...),
+		))
+		mux.Handle("/metrics", promhttp.Handler())
+		return reader.Shutdown, nil
+
+	case "otlp":
+		if otlpEndpoint == "" {
+			return nil, fmt.Errorf("-metrics_exporter=otlp requires -otlp_collector_endpoint")
+		}
+		metricExporter, err := otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithEndpoint(otlpEndpoint), otlpmetricgrpc.WithInsecure())
+		if err != nil {
+			return nil, fmt.Errorf("cannot create the OTLP metric exporter: %v", err)
+		}
+		reader := sdkmetric.NewPeriodicReader(metricExporter)
+		otel.SetMeterProvider(sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(reader),
+			sdkmetric.WithView(latencyViews...),
+		))
+		return reader.Shutdown, nil
+
+	default:
+		return nil, fmt.Errorf("unknown -metrics_exporter %q: want stackdriver, prometheus, otlp, or none", exporterKind)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// decodeGzipRequestBody reports whether r carries a gzip-compressed
+// body (a real `git` client sends `Content-Encoding: gzip` on
+// git-upload-pack/git-receive-pack POSTs, the same as Gitea and Gogs
+// handle), and if so replaces r.Body with a gzip.Reader over the
+// original body and removes the Content-Encoding header, so a caller
+// that reads r.Body afterwards -- such as the v2 protocol parser that
+// feeds serveFetchLocal -- never has to know the request was
+// compressed. ok is false, and r is left untouched, when there's
+// nothing to decode.
+//
+// The returned io.Closer closes the gzip reader (not the underlying
+// body, which the caller owns as usual via r.Body.Close); call it once
+// decoding is done. Calling HTTPHandler -- not present in this checkout
+// -- should call decodeGzipRequestBody right after reading
+// Content-Encoding and before passing r.Body to gitprotocolio, and defer
+// closing the returned io.Closer alongside r.Body.Close.
+//
+// decodeGzipRequestBody itself is covered directly in
+// gzip_request_test.go (decode, no-op, and invalid-gzip cases). The
+// originally-requested "testing/TestServer sends a gzipped upload-pack
+// request" integration test is not addable in this checkout: unlike
+// receive_pack.go's receivePackHandler (a raw byte relay that only
+// needed this function plus an io.Copy to the upstream), an
+// upload-pack handler also needs to turn r.Body into
+// []*gitprotocolio.ProtocolV2RequestChunk the way serveFetchLocal's
+// callers expect, and that HTTP-to-protocol-v2 parsing bridge lives in
+// httpProxyServer, which this checkout doesn't have. Building it just to
+// host this one test would be reconstructing a large piece of the
+// missing handler rather than testing the gzip decoding this file
+// actually adds, so it's left as the same "remaining integration step"
+// as the rest of this file.
+func decodeGzipRequestBody(r *http.Request) (closer io.Closer, ok bool, err error) {
+	if r.Header.Get("Content-Encoding") != "gzip" {
+		return nil, false, nil
+	}
+
+	gz, err := gzip.NewReader(r.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot decode gzip request body: %w", err)
+	}
+
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{gz, r.Body}
+	r.Header.Del("Content-Encoding")
+	r.ContentLength = -1
+
+	return gz, true, nil
+}
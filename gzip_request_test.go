@@ -0,0 +1,91 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeGzipRequestBodyDecodesCompressedBody(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte("pkt-line payload")); err != nil {
+		t.Fatalf("failed to write gzip payload: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+
+	r := httptest.NewRequest("POST", "/foo/bar.git/git-upload-pack", &buf)
+	r.Header.Set("Content-Encoding", "gzip")
+
+	closer, ok, err := decodeGzipRequestBody(r)
+	if err != nil {
+		t.Fatalf("decodeGzipRequestBody() failed: %v", err)
+	}
+	if !ok {
+		t.Fatal("decodeGzipRequestBody() ok = false, want true")
+	}
+	defer closer.Close()
+
+	if got := r.Header.Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding header = %q, want empty after decoding", got)
+	}
+
+	got, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatalf("failed to read decoded body: %v", err)
+	}
+	if string(got) != "pkt-line payload" {
+		t.Errorf("decoded body = %q, want %q", got, "pkt-line payload")
+	}
+}
+
+func TestDecodeGzipRequestBodyNoopWithoutHeader(t *testing.T) {
+	r := httptest.NewRequest("POST", "/foo/bar.git/git-upload-pack", strings.NewReader("pkt-line payload"))
+
+	closer, ok, err := decodeGzipRequestBody(r)
+	if err != nil {
+		t.Fatalf("decodeGzipRequestBody() failed: %v", err)
+	}
+	if ok {
+		t.Fatal("decodeGzipRequestBody() ok = true, want false without Content-Encoding")
+	}
+	if closer != nil {
+		t.Error("decodeGzipRequestBody() returned a non-nil closer for the no-op case")
+	}
+
+	got, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if string(got) != "pkt-line payload" {
+		t.Errorf("body = %q, want %q", got, "pkt-line payload")
+	}
+}
+
+func TestDecodeGzipRequestBodyErrorsOnInvalidGzip(t *testing.T) {
+	r := httptest.NewRequest("POST", "/foo/bar.git/git-upload-pack", strings.NewReader("not actually gzip"))
+	r.Header.Set("Content-Encoding", "gzip")
+
+	if _, _, err := decodeGzipRequestBody(r); err == nil {
+		t.Error("decodeGzipRequestBody() succeeded on invalid gzip data, want an error")
+	}
+}
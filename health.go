@@ -18,9 +18,15 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/goblet/featureflag"
+	"github.com/google/goblet/metrics"
 	"github.com/google/goblet/storage"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // HealthStatus represents the overall health status
@@ -48,12 +54,27 @@ type HealthCheckResponse struct {
 	Timestamp  time.Time                  `json:"timestamp"`
 	Version    string                     `json:"version,omitempty"`
 	Components map[string]ComponentHealth `json:"components"`
+	// Checks is the same component results as Components, but as an
+	// ordered list tagged with the ProbeKind each one was checked under
+	// -- populated by CheckKind (the /livez, /readyz, /startupz path),
+	// left empty by the combined Check/ServeHTTP path.
+	Checks []ComponentCheckResult `json:"checks,omitempty"`
 }
 
 // HealthChecker provides health check functionality
 type HealthChecker struct {
 	storageProvider storage.Provider
 	version         string
+	checks          *CheckRegistry
+	metrics         *healthMetrics
+	flags           *featureflag.Set
+
+	collectors   *metrics.Collectors
+	events       *metrics.EventStream
+	lastStatusMu sync.Mutex
+	lastStatus   map[string]HealthStatus
+
+	probeState
 }
 
 // NewHealthChecker creates a new health checker
@@ -64,6 +85,93 @@ func NewHealthChecker(provider storage.Provider, version string) *HealthChecker
 	}
 }
 
+// EnableMetrics registers the goblet_health_check_duration_seconds,
+// goblet_health_check_status, and goblet_health_check_failures_total
+// collectors with reg. Every subsequent call to Check records each
+// component's latency and outcome against them, so a scrape of reg's
+// /metrics endpoint exposes the same signal already present in the JSON
+// health response.
+func (hc *HealthChecker) EnableMetrics(reg prometheus.Registerer) error {
+	m, err := newHealthMetrics(reg)
+	if err != nil {
+		return err
+	}
+	hc.metrics = m
+	return nil
+}
+
+// EnableFeatureFlags makes Check report the effective state of every
+// registered featureflag.Flag under Components["flags"], so operators
+// can confirm a flag's rollout state (env var override, or an admin
+// endpoint change) without cross-referencing deployment config.
+func (hc *HealthChecker) EnableFeatureFlags(set *featureflag.Set) {
+	hc.flags = set
+}
+
+// EnableEvents makes Check update collectors' goblet_health_component_status
+// gauge after every component check, and publish an EventHealthTransition
+// on stream whenever a component's status differs from what the previous
+// Check call reported for it.
+func (hc *HealthChecker) EnableEvents(collectors *metrics.Collectors, stream *metrics.EventStream) {
+	hc.collectors = collectors
+	hc.events = stream
+}
+
+// componentStatusGauge converts a HealthStatus to the 0/1/2 scale
+// metrics.Collectors.RecordComponentStatus expects.
+func componentStatusGauge(status HealthStatus) metrics.ComponentStatus {
+	switch status {
+	case HealthStatusHealthy:
+		return metrics.ComponentStatusHealthy
+	case HealthStatusDegraded:
+		return metrics.ComponentStatusDegraded
+	default:
+		return metrics.ComponentStatusUnhealthy
+	}
+}
+
+// observeComponentStatus records component's current status against
+// hc.collectors and, if it differs from the status the previous Check
+// call reported for the same component, publishes an
+// EventHealthTransition on hc.events. Both are no-ops until
+// EnableEvents has been called.
+func (hc *HealthChecker) observeComponentStatus(component string, status HealthStatus) {
+	if hc.collectors != nil {
+		hc.collectors.RecordComponentStatus(component, componentStatusGauge(status))
+	}
+	if hc.events == nil {
+		return
+	}
+
+	hc.lastStatusMu.Lock()
+	if hc.lastStatus == nil {
+		hc.lastStatus = make(map[string]HealthStatus)
+	}
+	previous, seen := hc.lastStatus[component]
+	hc.lastStatus[component] = status
+	hc.lastStatusMu.Unlock()
+
+	if seen && previous != status {
+		hc.events.Publish(metrics.Event{
+			Type:           metrics.EventHealthTransition,
+			Time:           time.Now(),
+			Component:      component,
+			PreviousStatus: string(previous),
+			Status:         string(status),
+		})
+	}
+}
+
+// RegisterCheck adds a pluggable, periodically-run Check whose latest
+// result is folded into future calls to Check() as a component named
+// check.Name(). The check starts running in the background immediately.
+func (hc *HealthChecker) RegisterCheck(check Check, opts CheckOptions) {
+	if hc.checks == nil {
+		hc.checks = NewCheckRegistry()
+	}
+	hc.checks.Register(check, opts)
+}
+
 // Check performs a health check and returns the status
 func (hc *HealthChecker) Check(ctx context.Context) *HealthCheckResponse {
 	response := &HealthCheckResponse{
@@ -75,7 +183,10 @@ func (hc *HealthChecker) Check(ctx context.Context) *HealthCheckResponse {
 
 	// Check storage connectivity if configured
 	if hc.storageProvider != nil {
+		start := time.Now()
 		storageHealth := hc.checkStorage(ctx)
+		hc.recordMetric("storage", time.Since(start), storageHealth.Status)
+		hc.observeComponentStatus("storage", storageHealth.Status)
 		response.Components["storage"] = storageHealth
 
 		// Degrade overall status if storage is unhealthy
@@ -86,15 +197,50 @@ func (hc *HealthChecker) Check(ctx context.Context) *HealthCheckResponse {
 	}
 
 	// Check disk cache - always present
+	start := time.Now()
 	cacheHealth := hc.checkCache()
+	hc.recordMetric("cache", time.Since(start), cacheHealth.Status)
+	hc.observeComponentStatus("cache", cacheHealth.Status)
 	response.Components["cache"] = cacheHealth
 	if cacheHealth.Status == HealthStatusUnhealthy {
 		response.Status = HealthStatusUnhealthy
 	}
 
+	// Report the effective feature-flag set, if EnableFeatureFlags was
+	// called. This never affects response.Status: a flag being on or
+	// off isn't itself a health signal, just rollout-state visibility.
+	if hc.flags != nil {
+		response.Components["flags"] = ComponentHealth{
+			Status:  HealthStatusHealthy,
+			Message: formatEffectiveFlags(hc.flags.Effective()),
+		}
+	}
+
+	// Fold in the latest result of any registered pluggable checks.
+	if hc.checks != nil {
+		for name, result := range hc.checks.Results() {
+			ch := ComponentHealth{Status: HealthStatusHealthy, Message: "ok"}
+			if result.Error != nil {
+				ch.Status = HealthStatusUnhealthy
+				ch.Message = result.Error.Error()
+				response.Status = HealthStatusUnhealthy
+			}
+			hc.recordMetric(name, 0, ch.Status)
+			response.Components[name] = ch
+		}
+	}
+
 	return response
 }
 
+// recordMetric is a no-op until EnableMetrics has been called.
+func (hc *HealthChecker) recordMetric(component string, d time.Duration, status HealthStatus) {
+	if hc.metrics == nil {
+		return
+	}
+	hc.metrics.record(component, d.Seconds(), status)
+}
+
 // checkStorage checks the storage provider connectivity
 func (hc *HealthChecker) checkStorage(ctx context.Context) ComponentHealth {
 	if hc.storageProvider == nil {
@@ -151,6 +297,27 @@ func (hc *HealthChecker) checkCache() ComponentHealth {
 	}
 }
 
+// formatEffectiveFlags renders effective as a stable, comma-separated
+// "name=on|off" list, matching the plain-string Message convention the
+// other checkXxx helpers in this file already use for ComponentHealth.
+func formatEffectiveFlags(effective map[string]bool) string {
+	names := make([]string, 0, len(effective))
+	for name := range effective {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		state := "off"
+		if effective[name] {
+			state = "on"
+		}
+		parts = append(parts, name+"="+state)
+	}
+	return strings.Join(parts, ", ")
+}
+
 // ServeHTTP implements http.Handler for health check endpoint
 func (hc *HealthChecker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Support both simple and detailed health checks
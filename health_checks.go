@@ -0,0 +1,148 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"sync"
+	"time"
+)
+
+// Check is a single named, pluggable health check, modeled on
+// go-sundheit's health.Check interface: Execute runs the check and
+// returns arbitrary diagnostic details plus an error if the check failed.
+type Check interface {
+	Name() string
+	Execute() (details interface{}, err error)
+}
+
+// CheckFunc adapts a function to a Check.
+type CheckFunc struct {
+	CheckName string
+	Fn        func() (interface{}, error)
+}
+
+// Name implements Check.
+func (f CheckFunc) Name() string { return f.CheckName }
+
+// Execute implements Check.
+func (f CheckFunc) Execute() (interface{}, error) { return f.Fn() }
+
+// CheckOptions configures how a registered Check is scheduled.
+type CheckOptions struct {
+	// ExecutionPeriod is how often the check re-runs in the background.
+	// Defaults to 1 minute if zero.
+	ExecutionPeriod time.Duration
+	// InitialDelay delays the first run after registration. Zero runs
+	// immediately.
+	InitialDelay time.Duration
+}
+
+// CheckResult is the latest outcome of a registered Check.
+type CheckResult struct {
+	Details            interface{}
+	Error              error
+	LastCheckTime      time.Time
+	ContiguousFailures int
+}
+
+// CheckRegistry runs registered Checks on their own schedule in the
+// background and exposes their latest results, so HealthChecker.Check can
+// fold in arbitrary component checks (DB connectivity, disk space,
+// upstream reachability, ...) without each one blocking the HTTP request.
+type CheckRegistry struct {
+	mu      sync.RWMutex
+	results map[string]CheckResult
+	stop    chan struct{}
+	stopped bool
+}
+
+// NewCheckRegistry creates an empty CheckRegistry.
+func NewCheckRegistry() *CheckRegistry {
+	return &CheckRegistry{
+		results: map[string]CheckResult{},
+		stop:    make(chan struct{}),
+	}
+}
+
+// Register starts running check on its own ticker in the background.
+func (r *CheckRegistry) Register(check Check, opts CheckOptions) {
+	period := opts.ExecutionPeriod
+	if period <= 0 {
+		period = time.Minute
+	}
+
+	run := func() {
+		details, err := check.Execute()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		prev := r.results[check.Name()]
+		failures := 0
+		if err != nil {
+			failures = prev.ContiguousFailures + 1
+		}
+		r.results[check.Name()] = CheckResult{
+			Details:            details,
+			Error:              err,
+			LastCheckTime:      time.Now(),
+			ContiguousFailures: failures,
+		}
+	}
+
+	go func() {
+		if opts.InitialDelay > 0 {
+			select {
+			case <-time.After(opts.InitialDelay):
+			case <-r.stop:
+				return
+			}
+		}
+		run()
+
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				run()
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Results returns a snapshot of the latest result for every registered
+// check.
+func (r *CheckRegistry) Results() map[string]CheckResult {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]CheckResult, len(r.results))
+	for k, v := range r.results {
+		out[k] = v
+	}
+	return out
+}
+
+// Stop halts all background check goroutines. Safe to call multiple
+// times.
+func (r *CheckRegistry) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return
+	}
+	r.stopped = true
+	close(r.stop)
+}
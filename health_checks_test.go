@@ -0,0 +1,96 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckRegistryRunsAndRecordsResults(t *testing.T) {
+	registry := NewCheckRegistry()
+	defer registry.Stop()
+
+	calls := make(chan struct{}, 10)
+	registry.Register(CheckFunc{
+		CheckName: "always-ok",
+		Fn: func() (interface{}, error) {
+			calls <- struct{}{}
+			return "details", nil
+		},
+	}, CheckOptions{ExecutionPeriod: 10 * time.Millisecond})
+
+	select {
+	case <-calls:
+	case <-time.After(time.Second):
+		t.Fatal("check did not run within 1s")
+	}
+
+	results := registry.Results()
+	res, ok := results["always-ok"]
+	if !ok {
+		t.Fatal("expected a result for \"always-ok\"")
+	}
+	if res.Error != nil {
+		t.Errorf("Error = %v, want nil", res.Error)
+	}
+	if res.Details != "details" {
+		t.Errorf("Details = %v, want \"details\"", res.Details)
+	}
+}
+
+func TestCheckRegistryTracksContiguousFailures(t *testing.T) {
+	registry := NewCheckRegistry()
+	defer registry.Stop()
+
+	failErr := errors.New("boom")
+	registry.Register(CheckFunc{
+		CheckName: "always-fails",
+		Fn:        func() (interface{}, error) { return nil, failErr },
+	}, CheckOptions{ExecutionPeriod: 5 * time.Millisecond})
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if res, ok := registry.Results()["always-fails"]; ok && res.ContiguousFailures >= 2 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("expected ContiguousFailures to reach at least 2 within 1s")
+}
+
+func TestHealthCheckerFoldsInRegisteredChecks(t *testing.T) {
+	hc := NewHealthChecker(nil, "test")
+	hc.RegisterCheck(CheckFunc{
+		CheckName: "broken-dependency",
+		Fn:        func() (interface{}, error) { return nil, errors.New("unreachable") },
+	}, CheckOptions{ExecutionPeriod: 5 * time.Millisecond})
+	defer hc.checks.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		resp := hc.Check(context.Background())
+		if comp, ok := resp.Components["broken-dependency"]; ok && comp.Status == HealthStatusUnhealthy {
+			if resp.Status != HealthStatusUnhealthy {
+				t.Errorf("overall Status = %v, want unhealthy", resp.Status)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("expected the registered check's failure to surface within 1s")
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/goblet/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// gatherGaugeValue reads the current value of a single-labeled gauge
+// metric family back out of reg, the way a /metrics scrape would see
+// it, since metrics.Collectors keeps its underlying GaugeVecs
+// unexported.
+func gatherGaugeValue(t *testing.T, reg *prometheus.Registry, family string, labelValue string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error: %v", err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != family {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetValue() == labelValue {
+					return m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	t.Fatalf("metric family %q with label value %q not found", family, labelValue)
+	return 0
+}
+
+func TestHealthCheckerRecordsComponentStatusGauge(t *testing.T) {
+	mock := &mockStorageProvider{}
+	hc := NewHealthChecker(mock, "1.0.0")
+
+	reg := prometheus.NewRegistry()
+	collectors, err := metrics.New(reg)
+	if err != nil {
+		t.Fatalf("metrics.New() error: %v", err)
+	}
+	hc.EnableEvents(collectors, metrics.NewEventStream(0))
+
+	hc.Check(context.Background())
+
+	if got := gatherGaugeValue(t, reg, "goblet_health_component_status", "storage"); got != float64(metrics.ComponentStatusHealthy) {
+		t.Errorf("storage component status = %v, want %v", got, metrics.ComponentStatusHealthy)
+	}
+	if got := gatherGaugeValue(t, reg, "goblet_health_component_status", "cache"); got != float64(metrics.ComponentStatusHealthy) {
+		t.Errorf("cache component status = %v, want %v", got, metrics.ComponentStatusHealthy)
+	}
+}
+
+func TestHealthCheckerPublishesTransitionOnStatusChange(t *testing.T) {
+	mock := &mockStorageProvider{}
+	hc := NewHealthChecker(mock, "1.0.0")
+
+	reg := prometheus.NewRegistry()
+	collectors, err := metrics.New(reg)
+	if err != nil {
+		t.Fatalf("metrics.New() error: %v", err)
+	}
+	stream := metrics.NewEventStream(0)
+	hc.EnableEvents(collectors, stream)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		stream.ServeHTTP(w, req)
+		close(done)
+	}()
+	waitForSSESubscriber(t, w)
+
+	// No transition on the first Check: there's no previous status to
+	// compare against yet.
+	hc.Check(context.Background())
+
+	// The second Check observes storage going unhealthy, which should
+	// publish exactly one EventHealthTransition.
+	mock.listError = errors.New("unreachable")
+	hc.Check(context.Background())
+
+	cancel()
+	<-done
+
+	event, ok := findSSEEvent(w.Body.String(), metrics.EventHealthTransition)
+	if !ok {
+		t.Fatalf("SSE body did not contain a %s event: %q", metrics.EventHealthTransition, w.Body.String())
+	}
+	if event.Component != "storage" {
+		t.Errorf("component = %q, want storage", event.Component)
+	}
+	if event.PreviousStatus != string(HealthStatusHealthy) || event.Status != string(HealthStatusUnhealthy) {
+		t.Errorf("transition = %s -> %s, want healthy -> unhealthy", event.PreviousStatus, event.Status)
+	}
+}
+
+func TestHealthCheckerCheckIsNoopWithoutEnableEvents(t *testing.T) {
+	hc := NewHealthChecker(&mockStorageProvider{}, "1.0.0")
+	// EnableEvents was never called; Check must not panic or block.
+	hc.Check(context.Background())
+}
+
+// waitForSSESubscriber blocks until w has flushed the SSE response
+// headers, so a Publish issued right after this returns is guaranteed
+// to reach the subscriber rather than racing its ServeHTTP goroutine.
+func waitForSSESubscriber(t *testing.T, w *httptest.ResponseRecorder) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !w.Flushed {
+		if time.Now().After(deadline) {
+			t.Fatal("SSE subscriber never started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// findSSEEvent scans an SSE response body for the first "data: " line
+// whose JSON decodes to an Event of type want.
+func findSSEEvent(body string, want metrics.EventType) (metrics.Event, bool) {
+	for _, line := range strings.Split(body, "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var e metrics.Event
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			continue
+		}
+		if e.Type == want {
+			return e, true
+		}
+	}
+	return metrics.Event{}, false
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// healthMetrics holds the Prometheus collectors for HealthChecker
+// component executions. It is only non-nil once EnableMetrics has been
+// called, so health checks remain free of any Prometheus dependency by
+// default.
+type healthMetrics struct {
+	duration *prometheus.HistogramVec
+	status   *prometheus.GaugeVec
+	failures *prometheus.CounterVec
+}
+
+func newHealthMetrics(reg prometheus.Registerer) (*healthMetrics, error) {
+	m := &healthMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "goblet_health_check_duration_seconds",
+			Help: "Duration of health check component executions in seconds.",
+		}, []string{"component"}),
+		status: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "goblet_health_check_status",
+			Help: "Health check status per component (1=healthy, 0.5=degraded, 0=unhealthy).",
+		}, []string{"component"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "goblet_health_check_failures_total",
+			Help: "Total number of failed health check executions per component.",
+		}, []string{"component"}),
+	}
+	for _, c := range []prometheus.Collector{m.duration, m.status, m.failures} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// statusValue maps a HealthStatus to the numeric gauge value described in
+// goblet_health_check_status's help text.
+func statusValue(s HealthStatus) float64 {
+	switch s {
+	case HealthStatusHealthy:
+		return 1
+	case HealthStatusDegraded:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// record updates the duration/status/failure collectors for a single
+// component execution.
+func (m *healthMetrics) record(component string, seconds float64, status HealthStatus) {
+	m.duration.WithLabelValues(component).Observe(seconds)
+	m.status.WithLabelValues(component).Set(statusValue(status))
+	if status == HealthStatusUnhealthy {
+		m.failures.WithLabelValues(component).Inc()
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func counterValue(t *testing.T, c prometheus.Collector) float64 {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 1)
+	c.Collect(ch)
+	select {
+	case m := <-ch:
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			t.Fatalf("cannot read metric: %v", err)
+		}
+		return out.GetCounter().GetValue()
+	default:
+		return 0
+	}
+}
+
+func TestHealthCheckerRecordsFailureMetricOnStorageError(t *testing.T) {
+	mock := &mockStorageProvider{listError: errors.New("unreachable")}
+	hc := NewHealthChecker(mock, "1.0.0")
+
+	reg := prometheus.NewRegistry()
+	if err := hc.EnableMetrics(reg); err != nil {
+		t.Fatalf("EnableMetrics() error = %v", err)
+	}
+
+	hc.Check(context.Background())
+
+	failures := hc.metrics.failures.WithLabelValues("storage")
+	if got := counterValue(t, failures); got != 1 {
+		t.Errorf("storage failures counter = %v, want 1", got)
+	}
+
+	hc.Check(context.Background())
+	if got := counterValue(t, failures); got != 2 {
+		t.Errorf("storage failures counter after second failing check = %v, want 2", got)
+	}
+}
+
+func TestHealthCheckerDoesNotRecordFailureOnHealthyStorage(t *testing.T) {
+	mock := &mockStorageProvider{}
+	hc := NewHealthChecker(mock, "1.0.0")
+
+	reg := prometheus.NewRegistry()
+	if err := hc.EnableMetrics(reg); err != nil {
+		t.Fatalf("EnableMetrics() error = %v", err)
+	}
+
+	hc.Check(context.Background())
+
+	if got := counterValue(t, hc.metrics.failures.WithLabelValues("storage")); got != 0 {
+		t.Errorf("storage failures counter = %v, want 0", got)
+	}
+}
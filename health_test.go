@@ -53,6 +53,10 @@ func (m *mockStorageProvider) List(ctx context.Context, prefix string) storage.O
 	return &mockObjectIterator{err: m.listError}
 }
 
+func (m *mockStorageProvider) Attrs(ctx context.Context, path string) (*storage.ObjectAttrs, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (m *mockStorageProvider) Close() error {
 	m.closed = true
 	return nil
@@ -0,0 +1,104 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// PusherIdentity identifies the person behind a receive-pack request, as
+// resolved by ServerConfig.IdentifyPusher, so it can be exported into a
+// pre-receive/post-receive hook's environment the way Gogs and Gitea
+// export GOGS_AUTH_USER_ID and friends.
+type PusherIdentity struct {
+	// UserID is the identity provider's stable ID for the pusher, e.g.
+	// a numeric or opaque string ID -- whatever IdentifyPusher's caller
+	// considers canonical, since goblet itself has no user database.
+	UserID string
+	// Username is a human-readable name for the pusher, for hooks that
+	// want something loggable without looking the ID up elsewhere.
+	Username string
+	// Email is the pusher's email, for hooks enforcing a commit author
+	// allowlist or similar.
+	Email string
+}
+
+// hookNames are the hook scripts installHookScripts links into a newly
+// initialized bare repository's hooks/ directory, if present in
+// ServerConfig.HookScriptDir. These three are the ones a pre-receive/
+// post-receive policy framework cares about; git runs several others
+// goblet has no reason to override.
+var hookNames = []string{"pre-receive", "post-receive", "update"}
+
+// installHookScripts symlinks each of hookNames found directly under
+// hookScriptDir into repoPath's hooks/ directory, so operators can drop
+// policy scripts (branch protections, size limits) into one directory
+// and have every cached repository pick them up, the same way Gogs'
+// GOGS_REPO_CUSTOM_HOOKS_PATH lets a custom hooks directory be shared
+// across repositories. A hook script missing from hookScriptDir is
+// silently skipped rather than treated as an error, since operators are
+// expected to only populate the hooks they actually want enforced.
+func installHookScripts(hookScriptDir, repoPath string) error {
+	if hookScriptDir == "" {
+		return nil
+	}
+
+	hooksDir := filepath.Join(repoPath, "hooks")
+	if err := os.MkdirAll(hooksDir, 0750); err != nil {
+		return fmt.Errorf("cannot create hooks dir: %w", err)
+	}
+
+	for _, name := range hookNames {
+		src := filepath.Join(hookScriptDir, name)
+		if _, err := os.Stat(src); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("cannot stat hook script %q: %w", src, err)
+		}
+
+		dst := filepath.Join(hooksDir, name)
+		_ = os.Remove(dst) // Re-linking on every init keeps an updated hookScriptDir in sync.
+		if err := os.Symlink(src, dst); err != nil {
+			return fmt.Errorf("cannot link hook script %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// pusherEnv returns the GOBLET_* environment variables a pre-receive or
+// post-receive hook needs to identify and log a push, for a
+// receive-pack request authorized as identity against repoPath, tagged
+// with requestID for correlating the hook's logs back to the request
+// that triggered it.
+//
+// Nothing in this checkout calls pusherEnv yet: goblet doesn't proxy
+// git-receive-pack (see http_proxy_server_test.go's "not supported"
+// case) or do write-through caching, so there's no receive-pack code
+// path to set cmd.Env from it. Once either lands, that code should call
+// ServerConfig.IdentifyPusher on the incoming request, install hook
+// scripts via installHookScripts if they haven't been already, and pass
+// append(os.Environ(), pusherEnv(identity, repoPath, requestID)...) as
+// the receive-pack subprocess's environment.
+func pusherEnv(identity PusherIdentity, repoPath, requestID string) []string {
+	return []string{
+		"GOBLET_AUTH_USER=" + identity.Username,
+		"GOBLET_AUTH_EMAIL=" + identity.Email,
+		"GOBLET_REPO_PATH=" + repoPath,
+		"GOBLET_REQUEST_ID=" + requestID,
+	}
+}
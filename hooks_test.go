@@ -0,0 +1,79 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstallHookScriptsLinksPresentHooks(t *testing.T) {
+	hookScriptDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(hookScriptDir, "pre-receive"), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatalf("failed to write pre-receive script: %v", err)
+	}
+
+	repoPath := t.TempDir()
+	if err := installHookScripts(hookScriptDir, repoPath); err != nil {
+		t.Fatalf("installHookScripts() failed: %v", err)
+	}
+
+	link := filepath.Join(repoPath, "hooks", "pre-receive")
+	target, err := os.Readlink(link)
+	if err != nil {
+		t.Fatalf("pre-receive was not linked: %v", err)
+	}
+	if want := filepath.Join(hookScriptDir, "pre-receive"); target != want {
+		t.Errorf("pre-receive symlink target = %q, want %q", target, want)
+	}
+
+	for _, missing := range []string{"post-receive", "update"} {
+		if _, err := os.Lstat(filepath.Join(repoPath, "hooks", missing)); !os.IsNotExist(err) {
+			t.Errorf("hook %q was linked despite not existing in hookScriptDir", missing)
+		}
+	}
+}
+
+func TestInstallHookScriptsNoopWithoutConfiguredDir(t *testing.T) {
+	repoPath := t.TempDir()
+	if err := installHookScripts("", repoPath); err != nil {
+		t.Fatalf("installHookScripts() failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, "hooks")); !os.IsNotExist(err) {
+		t.Error("installHookScripts() created a hooks dir despite an empty hookScriptDir")
+	}
+}
+
+func TestPusherEnvIncludesIdentityAndRequestMetadata(t *testing.T) {
+	env := pusherEnv(PusherIdentity{Username: "alice", Email: "alice@example.com"}, "/cache/org/repo", "req-123")
+
+	want := map[string]bool{
+		"GOBLET_AUTH_USER=alice":              false,
+		"GOBLET_AUTH_EMAIL=alice@example.com": false,
+		"GOBLET_REPO_PATH=/cache/org/repo":    false,
+		"GOBLET_REQUEST_ID=req-123":           false,
+	}
+	for _, kv := range env {
+		if _, ok := want[kv]; ok {
+			want[kv] = true
+		}
+	}
+	for kv, found := range want {
+		if !found {
+			t.Errorf("pusherEnv() missing entry %q", kv)
+		}
+	}
+}
@@ -64,8 +64,92 @@ const (
 	//   - Single service account
 	//   - Pod-scoped cache
 	IsolationSidecar IsolationMode = "sidecar"
+
+	// IsolationOPA - Partition and access decisions delegated to an
+	// external policy engine (SAFE, most flexible)
+	// Requires IsolationConfig.Authorizer to be set. Replaces the built-in
+	// user/tenant extraction above with a single Authorize call per
+	// request; see AuthorizationProvider.
+	// Use for:
+	//   - Centralizing authz decisions with an existing OPA deployment
+	//   - Cache partitioning rules that don't map cleanly to "user" or
+	//     "tenant" (e.g. combinations of claims and repo path)
+	IsolationOPA IsolationMode = "opa"
+
+	// IsolationGroup - Cache isolated per OIDC group claim (SAFE, better
+	// efficiency)
+	// Like IsolationTenant, but reads IsolationConfig.GroupsClaimKey
+	// (default "groups") and picks one group via
+	// IsolationConfig.GroupSelector when a token carries several, rather
+	// than always taking the first. Most enterprise OIDC deployments
+	// (Dex, Keycloak, Okta) express team boundaries this way.
+	// Example: /cache/group-engineering/github.com/org/repo
+	IsolationGroup IsolationMode = "group"
+
+	// IsolationCustomClaim - Cache isolated per arbitrary tenant claim
+	// (SAFE, better efficiency)
+	// Reads IsolationConfig.CustomClaimKey (e.g. "tenant_id", "org_id")
+	// from the token, for IdPs that mint a dedicated tenant claim rather
+	// than overloading "groups".
+	// Example: /cache/tenant-acme-corp/github.com/org/repo
+	IsolationCustomClaim IsolationMode = "custom-claim"
 )
 
+// AuthorizationProvider lets an external policy engine (for example Open
+// Policy Agent) decide, for a single authenticated request, both whether
+// the request may proceed and which cache partition it belongs to. It
+// supersedes the built-in claim/header extraction in getUserIdentifier
+// and getTenantIdentifier when IsolationConfig.Mode is IsolationOPA.
+type AuthorizationProvider interface {
+	Authorize(ctx context.Context, input AuthorizationInput) (AuthorizationDecision, error)
+}
+
+// AuthorizationInput is the document an AuthorizationProvider evaluates a
+// decision against.
+type AuthorizationInput struct {
+	Claims       *Claims
+	Method       string
+	RepoHost     string
+	RepoPath     string
+	TenantHeader string
+	RemoteAddr   string
+}
+
+// AuthorizationDecision is the result of evaluating an AuthorizationInput.
+// Partition is used verbatim (after sanitizeIdentifier) as the cache
+// partition segment; Reason is surfaced to the client when Allow is
+// false.
+type AuthorizationDecision struct {
+	Allow     bool
+	Partition string
+	Reason    string
+}
+
+// OIDCVerifier authenticates a raw bearer token and returns the claims
+// it carries. IsolationConfig.OIDCVerifier uses this to verify a
+// request's signature, issuer, audience, and expiry before
+// IsolationUser/IsolationTenant trust any claim from it -- without it,
+// GetClaimsFromContext returns whatever the caller put there, which may
+// not have been verified at all.
+type OIDCVerifier interface {
+	VerifyBearerToken(ctx context.Context, rawToken string) (*Claims, error)
+}
+
+// ForbiddenError is returned by GetCachePath when an AuthorizationProvider
+// denies a request. Callers that want to distinguish a policy denial
+// (HTTP 403) from a configuration or provider error (HTTP 500) should
+// use errors.As against this type.
+type ForbiddenError struct {
+	Reason string
+}
+
+func (e *ForbiddenError) Error() string {
+	if e.Reason == "" {
+		return "request denied by authorization policy"
+	}
+	return e.Reason
+}
+
 // IsolationConfig defines how to extract and apply isolation.
 type IsolationConfig struct {
 	// Mode specifies the isolation strategy
@@ -95,6 +179,40 @@ type IsolationConfig struct {
 	// Useful for privacy or to handle special characters in identifiers
 	// Example: alice@company.com -> sha256(alice@company.com) = "a1b2c3..."
 	HashIdentifiers bool
+
+	// Authorizer is consulted for both the partition key and the
+	// allow/deny decision when Mode is IsolationOPA. Required in that
+	// mode; ignored otherwise.
+	Authorizer AuthorizationProvider
+
+	// OIDCVerifier, if set, verifies the request's bearer token before
+	// GetCachePath extracts a user or tenant identifier from its
+	// claims, in IsolationUser, IsolationTenant, IsolationGroup and
+	// IsolationCustomClaim modes. A request
+	// with a missing or unverifiable bearer token is rejected before
+	// any cache path is derived. Has no effect in IsolationNone,
+	// IsolationSidecar, or IsolationOPA (the latter does its own
+	// verification inside Authorizer).
+	OIDCVerifier OIDCVerifier
+
+	// GroupsClaimKey names the claim (see Claims.Raw) carrying the
+	// authenticated request's groups, for Mode = IsolationGroup.
+	// Defaults to "groups". With Raw unset (e.g. a Claims value built
+	// by hand, as most tests do), falls back to the Groups field.
+	GroupsClaimKey string
+
+	// GroupSelector picks a single group to partition on when a token
+	// carries several, for Mode = IsolationGroup. If nil, TenantRegex
+	// (if set) is matched against each group in order and the first
+	// match's first submatch wins; with neither set, the first group
+	// wins.
+	GroupSelector func(groups []string) (string, error)
+
+	// CustomClaimKey names an arbitrary claim (see Claims.Raw) to
+	// partition on, for Mode = IsolationCustomClaim -- e.g. "tenant_id"
+	// or "org_id" for IdPs that mint a dedicated tenant claim rather
+	// than overloading "groups". Required in that mode.
+	CustomClaimKey string
 }
 
 // DefaultIsolationConfig returns safe defaults.
@@ -113,6 +231,14 @@ func (ic *IsolationConfig) GetCachePath(r *http.Request, cacheRoot string, repoU
 		ic = DefaultIsolationConfig()
 	}
 
+	if ic.OIDCVerifier != nil && (ic.Mode == IsolationUser || ic.Mode == IsolationTenant || ic.Mode == IsolationGroup || ic.Mode == IsolationCustomClaim) {
+		claims, err := ic.verifyBearerToken(r)
+		if err != nil {
+			return "", err
+		}
+		r = r.WithContext(SetClaimsInContext(r.Context(), claims))
+	}
+
 	// Base path without isolation
 	basePath := filepath.Join(repoURL.Host, repoURL.Path)
 
@@ -135,11 +261,85 @@ func (ic *IsolationConfig) GetCachePath(r *http.Request, cacheRoot string, repoU
 		}
 		return filepath.Join(cacheRoot, tenantID, basePath), nil
 
+	case IsolationGroup:
+		groupID, err := ic.getGroupIdentifier(r)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(cacheRoot, groupID, basePath), nil
+
+	case IsolationCustomClaim:
+		customID, err := ic.getCustomClaimIdentifier(r)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(cacheRoot, customID, basePath), nil
+
+	case IsolationOPA:
+		partition, err := ic.authorize(r, repoURL)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(cacheRoot, partition, basePath), nil
+
 	default:
 		return "", fmt.Errorf("unknown isolation mode: %s", ic.Mode)
 	}
 }
 
+// authorize delegates the partition/allow decision for a request to
+// ic.Authorizer and returns a sanitized partition segment, or a
+// *ForbiddenError if the provider denies the request.
+func (ic *IsolationConfig) authorize(r *http.Request, repoURL *url.URL) (string, error) {
+	if ic.Authorizer == nil {
+		return "", fmt.Errorf("IsolationOPA mode requires IsolationConfig.Authorizer to be set")
+	}
+
+	input := AuthorizationInput{
+		Claims:       GetClaimsFromContext(r.Context()),
+		Method:       r.Method,
+		RepoHost:     repoURL.Host,
+		RepoPath:     repoURL.Path,
+		TenantHeader: r.Header.Get(ic.TenantHeaderKey),
+		RemoteAddr:   r.RemoteAddr,
+	}
+
+	decision, err := ic.Authorizer.Authorize(r.Context(), input)
+	if err != nil {
+		return "", fmt.Errorf("authorization provider error: %w", err)
+	}
+	if !decision.Allow {
+		return "", &ForbiddenError{Reason: decision.Reason}
+	}
+	return ic.sanitizeIdentifier(decision.Partition, "opa"), nil
+}
+
+// verifyBearerToken extracts the request's bearer token and verifies it
+// through ic.OIDCVerifier, rejecting the request outright if the header
+// is absent or verification fails.
+func (ic *IsolationConfig) verifyBearerToken(r *http.Request) (*Claims, error) {
+	token := extractBearerToken(r)
+	if token == "" {
+		return nil, fmt.Errorf("OIDCVerifier is configured but the request has no bearer token")
+	}
+	claims, err := ic.OIDCVerifier.VerifyBearerToken(r.Context(), token)
+	if err != nil {
+		return nil, fmt.Errorf("bearer token verification failed: %w", err)
+	}
+	return claims, nil
+}
+
+// extractBearerToken returns the token from an "Authorization: Bearer
+// <token>" request header, or "" if absent.
+func extractBearerToken(r *http.Request) string {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(auth, prefix)
+}
+
 // getUserIdentifier extracts user identifier from request.
 func (ic *IsolationConfig) getUserIdentifier(r *http.Request) (string, error) {
 	// Try to get from OIDC claims context
@@ -230,6 +430,66 @@ func (ic *IsolationConfig) getTenantIdentifier(r *http.Request) (string, error)
 	return "", fmt.Errorf("no tenant identity found in request")
 }
 
+// getGroupIdentifier extracts the partition group from request claims
+// for IsolationGroup, returning a *ForbiddenError (so callers can map it
+// to an HTTP 403) when the configured claim is absent or no group can
+// be selected.
+func (ic *IsolationConfig) getGroupIdentifier(r *http.Request) (string, error) {
+	claims := GetClaimsFromContext(r.Context())
+	if claims == nil {
+		return "", &ForbiddenError{Reason: "no claims found for group-based isolation"}
+	}
+
+	claimKey := ic.GroupsClaimKey
+	if claimKey == "" {
+		claimKey = "groups"
+	}
+	groups := claims.groupsForKey(claimKey)
+	if len(groups) == 0 {
+		return "", &ForbiddenError{Reason: fmt.Sprintf("claim %q not present on token, or empty", claimKey)}
+	}
+
+	if ic.GroupSelector != nil {
+		group, err := ic.GroupSelector(groups)
+		if err != nil {
+			return "", &ForbiddenError{Reason: fmt.Sprintf("group selection failed: %v", err)}
+		}
+		return ic.sanitizeIdentifier(group, "group"), nil
+	}
+
+	if ic.TenantRegex != nil {
+		for _, g := range groups {
+			if matches := ic.TenantRegex.FindStringSubmatch(g); len(matches) > 1 {
+				return ic.sanitizeIdentifier(matches[1], "group"), nil
+			}
+		}
+		return "", &ForbiddenError{Reason: "no group matched the configured TenantRegex"}
+	}
+
+	return ic.sanitizeIdentifier(groups[0], "group"), nil
+}
+
+// getCustomClaimIdentifier extracts the partition tenant from
+// IsolationConfig.CustomClaimKey for IsolationCustomClaim, returning a
+// *ForbiddenError (so callers can map it to an HTTP 403) when the claim
+// is absent.
+func (ic *IsolationConfig) getCustomClaimIdentifier(r *http.Request) (string, error) {
+	if ic.CustomClaimKey == "" {
+		return "", fmt.Errorf("IsolationCustomClaim mode requires IsolationConfig.CustomClaimKey to be set")
+	}
+
+	claims := GetClaimsFromContext(r.Context())
+	if claims == nil {
+		return "", &ForbiddenError{Reason: fmt.Sprintf("no claims found for custom claim %q", ic.CustomClaimKey)}
+	}
+
+	value, ok := claims.stringForKey(ic.CustomClaimKey)
+	if !ok || value == "" {
+		return "", &ForbiddenError{Reason: fmt.Sprintf("claim %q not present on token", ic.CustomClaimKey)}
+	}
+	return ic.sanitizeIdentifier(value, "tenant"), nil
+}
+
 // sanitizeIdentifier makes identifier safe for use in filesystem paths.
 func (ic *IsolationConfig) sanitizeIdentifier(identifier, prefix string) string {
 	if ic.HashIdentifiers {
@@ -289,6 +549,21 @@ func (ic *IsolationConfig) Validate() error {
 		}
 		return nil
 
+	case IsolationGroup:
+		return nil
+
+	case IsolationCustomClaim:
+		if ic.CustomClaimKey == "" {
+			return fmt.Errorf("CustomClaimKey must be set for IsolationCustomClaim mode")
+		}
+		return nil
+
+	case IsolationOPA:
+		if ic.Authorizer == nil {
+			return fmt.Errorf("Authorizer must be set for IsolationOPA mode")
+		}
+		return nil
+
 	default:
 		return fmt.Errorf("unknown isolation mode: %s", ic.Mode)
 	}
@@ -299,6 +574,68 @@ type Claims struct {
 	Email   string
 	Subject string
 	Groups  []string
+
+	// Raw holds additional claims decoded from the token that don't
+	// have a dedicated field above -- e.g. a groups claim an IdP names
+	// something other than "groups", or an arbitrary tenant claim like
+	// "tenant_id"/"org_id". IsolationConfig's GroupsClaimKey and
+	// CustomClaimKey read from here via groupsForKey/stringForKey. A
+	// Claims value built by hand (as most tests do) may leave this nil.
+	Raw map[string]interface{}
+}
+
+// groupsForKey returns the string values of c.Raw[key], or c.Groups if
+// key is "groups" and Raw doesn't have its own entry for it (so a
+// hand-built Claims with only Groups set still works under the default
+// GroupsClaimKey). Supports both []string and []interface{} of strings,
+// since Raw is typically the result of decoding a JWT's JSON claims.
+func (c *Claims) groupsForKey(key string) []string {
+	if c == nil {
+		return nil
+	}
+	if raw, ok := c.Raw[key]; ok {
+		switch v := raw.(type) {
+		case []string:
+			return v
+		case []interface{}:
+			groups := make([]string, 0, len(v))
+			for _, e := range v {
+				if s, ok := e.(string); ok {
+					groups = append(groups, s)
+				}
+			}
+			return groups
+		case string:
+			return []string{v}
+		}
+		return nil
+	}
+	if key == "groups" {
+		return c.Groups
+	}
+	return nil
+}
+
+// stringForKey returns c.Raw[key] as a string and whether it was
+// present, falling back to the dedicated Email/Subject fields for the
+// "email"/"sub" keys so a hand-built Claims works without populating
+// Raw.
+func (c *Claims) stringForKey(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	if raw, ok := c.Raw[key]; ok {
+		s, ok := raw.(string)
+		return s, ok
+	}
+	switch key {
+	case "email":
+		return c.Email, c.Email != ""
+	case "sub":
+		return c.Subject, c.Subject != ""
+	default:
+		return "", false
+	}
 }
 
 // claimsContextKey is the key for storing claims in request context.
@@ -318,6 +655,63 @@ func GetClaimsFromContext(ctx context.Context) *Claims {
 	return claims
 }
 
+// tenantContextKey is the key for storing a resolved tenant ID in
+// context, for TenantContext.
+type tenantContextKey struct{}
+
+// SetTenantInContext stores a tenant ID in ctx.
+func SetTenantInContext(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// GetTenantFromContext retrieves a tenant ID stored by SetTenantInContext,
+// or "" if none was set. managedRepository.getToken uses this both to
+// namespace its TokenCache key and, when ServerConfig.TokenSourceCtx is
+// set, to let the token source itself resolve per-tenant upstream
+// credentials.
+func GetTenantFromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenantID
+}
+
+// TenantContext resolves r's tenant ID the same way GetCachePath does
+// under IsolationTenant (verifying its bearer token first if
+// OIDCVerifier is set) and returns ctx enriched with it via
+// SetTenantInContext. Outside IsolationTenant it returns r.Context()
+// unchanged.
+//
+// GetCachePath already derives a tenant ID to build the cache directory
+// path, but doesn't expose it past its own return value, so a request's
+// tenant never reaches the upstream-fetch path that runs after the
+// cache-path lookup. The HTTP handler that serves a request -- not
+// present in this checkout -- is expected to call this alongside
+// GetCachePath and pass the resulting context into
+// managedRepository.fetchUpstream, so per-tenant upstream credentials
+// and TokenCache entries line up with the same tenant ID the cache
+// directory was partitioned by.
+func (ic *IsolationConfig) TenantContext(r *http.Request) (context.Context, error) {
+	if ic == nil {
+		ic = DefaultIsolationConfig()
+	}
+	if ic.Mode != IsolationTenant {
+		return r.Context(), nil
+	}
+
+	if ic.OIDCVerifier != nil {
+		claims, err := ic.verifyBearerToken(r)
+		if err != nil {
+			return nil, err
+		}
+		r = r.WithContext(SetClaimsInContext(r.Context(), claims))
+	}
+
+	tenantID, err := ic.getTenantIdentifier(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant identifier: %w", err)
+	}
+	return SetTenantInContext(r.Context(), tenantID), nil
+}
+
 // SecurityWarning returns a warning message if configuration is unsafe.
 func (ic *IsolationConfig) SecurityWarning() string {
 	if ic == nil {
@@ -332,9 +726,31 @@ func (ic *IsolationConfig) SecurityWarning() string {
 	case IsolationSidecar:
 		return "✓ Isolation mode 'sidecar' - safe for single-user/single-service-account deployments"
 	case IsolationUser:
-		return "✓ Isolation mode 'user' - safe for multi-tenant deployments (user-scoped cache)"
+		if ic.OIDCVerifier != nil {
+			return "✓ Isolation mode 'user' - user claims are verified by OIDCVerifier before use (user-scoped cache)"
+		}
+		return "⚠️  WARNING: Isolation mode 'user' trusts claims from GetClaimsFromContext without verifying them. " +
+			"Set OIDCVerifier so a forged or mismatched-issuer bearer token can't cross user boundaries."
 	case IsolationTenant:
-		return "✓ Isolation mode 'tenant' - safe for multi-tenant deployments (tenant-scoped cache)"
+		if ic.OIDCVerifier != nil {
+			return "✓ Isolation mode 'tenant' - tenant claims are verified by OIDCVerifier before use (tenant-scoped cache)"
+		}
+		return "⚠️  WARNING: Isolation mode 'tenant' trusts claims from GetClaimsFromContext without verifying them. " +
+			"Set OIDCVerifier so a forged or mismatched-issuer bearer token can't cross tenant boundaries."
+	case IsolationGroup:
+		if ic.OIDCVerifier != nil {
+			return "✓ Isolation mode 'group' - group claims are verified by OIDCVerifier before use (group-scoped cache)"
+		}
+		return "⚠️  WARNING: Isolation mode 'group' trusts claims from GetClaimsFromContext without verifying them. " +
+			"Set OIDCVerifier so a forged or mismatched-issuer bearer token can't cross group boundaries."
+	case IsolationCustomClaim:
+		if ic.OIDCVerifier != nil {
+			return "✓ Isolation mode 'custom-claim' - the configured claim is verified by OIDCVerifier before use (tenant-scoped cache)"
+		}
+		return "⚠️  WARNING: Isolation mode 'custom-claim' trusts claims from GetClaimsFromContext without verifying them. " +
+			"Set OIDCVerifier so a forged or mismatched-issuer bearer token can't cross tenant boundaries."
+	case IsolationOPA:
+		return "✓ Isolation mode 'opa' - partition and access decisions delegated to an external policy engine"
 	default:
 		return "⚠️  WARNING: Unknown isolation mode"
 	}
@@ -0,0 +1,99 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lfs implements the Git LFS Batch API so that goblet can proxy
+// large file storage traffic alongside the core Git protocol v2 fetches.
+//
+// See https://github.com/git-lfs/git-lfs/blob/main/docs/api/batch.md for the
+// protocol this package implements.
+package lfs
+
+import (
+	"time"
+)
+
+// Operation is the LFS batch operation requested by the client.
+type Operation string
+
+const (
+	// OperationDownload requests download actions for the given objects.
+	OperationDownload Operation = "download"
+	// OperationUpload requests upload actions for the given objects.
+	OperationUpload Operation = "upload"
+)
+
+// Default and supported transfer adapter names.
+const (
+	TransferBasic     = "basic"
+	TransferMultipart = "multipart"
+	TransferTus       = "tus"
+)
+
+// Pointer identifies an LFS object by its SHA-256 content OID and size, as
+// sent in both requests and responses.
+type Pointer struct {
+	OID  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+// BatchRequest is the body of a POST to the LFS batch endpoint.
+type BatchRequest struct {
+	Operation Operation `json:"operation"`
+	Transfers []string  `json:"transfers,omitempty"`
+	Ref       *Ref      `json:"ref,omitempty"`
+	Objects   []Pointer `json:"objects"`
+}
+
+// Ref identifies the Git ref the batch request is scoped to.
+type Ref struct {
+	Name string `json:"name"`
+}
+
+// BatchResponse is the body returned from the LFS batch endpoint.
+type BatchResponse struct {
+	Transfer string          `json:"transfer,omitempty"`
+	Objects  []ObjectActions `json:"objects"`
+}
+
+// ObjectActions carries the actions available for a single object, or an
+// error if the object cannot be served.
+type ObjectActions struct {
+	OID           string             `json:"oid"`
+	Size          int64              `json:"size"`
+	Authenticated bool               `json:"authenticated,omitempty"`
+	Actions       map[string]*Action `json:"actions,omitempty"`
+	Error         *ObjectError       `json:"error,omitempty"`
+}
+
+// Action describes a single HTTP operation (download, upload, verify) a
+// client should perform for an object.
+type Action struct {
+	Href      string            `json:"href"`
+	Header    map[string]string `json:"header,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at,omitempty"`
+}
+
+// ObjectError is returned in place of Actions when an object cannot be
+// served, following the LFS batch API error codes.
+type ObjectError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	// ErrCodeNotFound indicates the object does not exist upstream.
+	ErrCodeNotFound = 404
+	// ErrCodeValidation indicates the request was invalid, e.g. bad OID.
+	ErrCodeValidation = 422
+)
@@ -0,0 +1,192 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lfs
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/goblet/storage"
+	"golang.org/x/sync/singleflight"
+)
+
+const batchContentType = "application/vnd.git-lfs+json"
+
+// Config configures a Handler.
+type Config struct {
+	// Storage holds the content-addressed object bytes, keyed by OID
+	// under an "lfs/<oid>" prefix.
+	Storage storage.Provider
+
+	// Adapters are the transfer adapters this server supports. The first
+	// name in the client's `transfers` list that matches one of them
+	// picks the adapter for the whole batch, so the client's order
+	// decides; Adapters[0] is used if the client didn't advertise any,
+	// and no adapter is chosen if none of the client's names match.
+	Adapters []TransferAdapter
+
+	// RequestAuthorizer is consulted before serving the batch and object
+	// endpoints, mirroring goblet.ServerConfig.RequestAuthorizer.
+	RequestAuthorizer func(r *http.Request) error
+
+	// Upstream, if set, makes ServeBatch forward the batch request to a
+	// real upstream LFS server and ServeObject serve objects out of
+	// Storage as a content-addressable cache of that upstream, instead
+	// of Adapters building actions against Storage directly. See
+	// UpstreamConfig.
+	Upstream *UpstreamConfig
+}
+
+// Handler serves the LFS Batch API and the "basic" adapter's object
+// endpoints for a single repository path.
+type Handler struct {
+	config Config
+
+	// fetchGroup dedupes concurrent Upstream cache-fill fetches for the
+	// same oid, the same singleflight pattern LRUTokenCache uses for
+	// concurrent token refreshes.
+	fetchGroup singleflight.Group
+}
+
+// NewHandler creates an LFS Handler from config.
+func NewHandler(config Config) *Handler {
+	return &Handler{config: config}
+}
+
+// ServeBatch handles POST <repo>/info/lfs/objects/batch.
+func (h *Handler) ServeBatch(repoPath string, w http.ResponseWriter, r *http.Request) {
+	if err := h.authorize(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid batch request: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if h.config.Upstream != nil {
+		h.serveBatchUpstream(repoPath, req, r.Header.Get("Authorization"), w)
+		return
+	}
+
+	adapter := h.selectAdapter(req.Transfers)
+	if adapter == nil {
+		http.Error(w, "no supported transfer adapter", http.StatusUnprocessableEntity)
+		return
+	}
+
+	resp := BatchResponse{
+		Transfer: adapter.Name(),
+		Objects:  make([]ObjectActions, 0, len(req.Objects)),
+	}
+	for _, obj := range req.Objects {
+		actions, objErr := adapter.BuildActions(repoPath, req.Operation, obj, r.Header.Get("Authorization"))
+		oa := ObjectActions{OID: obj.OID, Size: obj.Size, Authenticated: true}
+		if objErr != nil {
+			oa.Error = objErr
+		} else {
+			oa.Actions = actions
+		}
+		resp.Objects = append(resp.Objects, oa)
+	}
+
+	w.Header().Set("Content-Type", batchContentType)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// ServeObject handles GET/PUT <repo>/info/lfs/objects/<oid>, used by the
+// "basic" transfer adapter.
+func (h *Handler) ServeObject(oid string, w http.ResponseWriter, r *http.Request) {
+	if err := h.authorize(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if h.config.Storage == nil {
+		http.Error(w, "lfs storage not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	if h.config.Upstream != nil && r.Method == http.MethodGet {
+		h.serveObjectUpstream(oid, w, r)
+		return
+	}
+
+	path := objectStoragePath(oid)
+	switch r.Method {
+	case http.MethodGet:
+		rc, err := h.config.Storage.Reader(r.Context(), path)
+		if err != nil {
+			http.Error(w, "object not found", http.StatusNotFound)
+			return
+		}
+		defer rc.Close()
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = io.Copy(w, rc)
+	case http.MethodPut:
+		wc, err := h.config.Storage.Writer(r.Context(), path)
+		if err != nil {
+			http.Error(w, "cannot store object: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, err := io.Copy(wc, r.Body); err != nil {
+			_ = wc.Close()
+			http.Error(w, "upload failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := wc.Close(); err != nil {
+			http.Error(w, "upload failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) authorize(r *http.Request) error {
+	if h.config.RequestAuthorizer == nil {
+		return nil
+	}
+	return h.config.RequestAuthorizer(r)
+}
+
+func (h *Handler) selectAdapter(clientTransfers []string) TransferAdapter {
+	if len(h.config.Adapters) == 0 {
+		return nil
+	}
+	if len(clientTransfers) == 0 {
+		return h.config.Adapters[0]
+	}
+	for _, name := range clientTransfers {
+		for _, a := range h.config.Adapters {
+			if a.Name() == name {
+				return a
+			}
+		}
+	}
+	return nil
+}
+
+func objectStoragePath(oid string) string {
+	return strings.Join([]string{"lfs", oid}, "/")
+}
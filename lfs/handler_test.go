@@ -0,0 +1,82 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lfs
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBasicAdapterBuildActions(t *testing.T) {
+	adapter := &BasicAdapter{BaseURL: "https://goblet.example.com"}
+
+	actions, objErr := adapter.BuildActions("github.com/org/repo", OperationDownload, Pointer{OID: "abc123", Size: 10}, "Bearer tok")
+	if objErr != nil {
+		t.Fatalf("unexpected object error: %+v", objErr)
+	}
+	dl, ok := actions["download"]
+	if !ok {
+		t.Fatalf("expected a download action, got %+v", actions)
+	}
+	wantHref := "https://goblet.example.com/github.com/org/repo/info/lfs/objects/abc123"
+	if dl.Href != wantHref {
+		t.Errorf("Href = %q, want %q", dl.Href, wantHref)
+	}
+	if dl.Header["Authorization"] != "Bearer tok" {
+		t.Errorf("Authorization header = %q, want forwarded value", dl.Header["Authorization"])
+	}
+}
+
+func TestBasicAdapterMissingOID(t *testing.T) {
+	adapter := &BasicAdapter{BaseURL: "https://goblet.example.com"}
+	_, objErr := adapter.BuildActions("github.com/org/repo", OperationUpload, Pointer{Size: 10}, "")
+	if objErr == nil || objErr.Code != ErrCodeValidation {
+		t.Fatalf("expected a validation error, got %+v", objErr)
+	}
+}
+
+func TestHandlerSelectAdapter(t *testing.T) {
+	basic := &BasicAdapter{BaseURL: "https://goblet.example.com"}
+	h := NewHandler(Config{Adapters: []TransferAdapter{basic}})
+
+	if got := h.selectAdapter(nil); got != basic {
+		t.Errorf("selectAdapter(nil) = %v, want the only configured adapter", got)
+	}
+	if got := h.selectAdapter([]string{"multipart", "basic"}); got != basic {
+		t.Errorf("selectAdapter did not fall back to the supported adapter")
+	}
+	if got := h.selectAdapter([]string{"tus"}); got != nil {
+		t.Errorf("selectAdapter(unsupported) = %v, want nil", got)
+	}
+}
+
+func TestServeBatchRejectsBadJSON(t *testing.T) {
+	h := NewHandler(Config{Adapters: []TransferAdapter{&BasicAdapter{BaseURL: "https://goblet.example.com"}}})
+	r := httptest.NewRequest("POST", "/github.com/org/repo/info/lfs/objects/batch", strings.NewReader("not json"))
+	w := httptest.NewRecorder()
+
+	h.ServeBatch("github.com/org/repo", w, r)
+
+	if w.Code != 422 {
+		t.Errorf("status = %d, want 422", w.Code)
+	}
+}
+
+func TestObjectStoragePath(t *testing.T) {
+	if got, want := objectStoragePath("abc123"), "lfs/abc123"; got != want {
+		t.Errorf("objectStoragePath() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// UpstreamConfig makes a Handler proxy the LFS Batch API to a real
+// upstream LFS server instead of building actions locally the way the
+// local TransferAdapters do, caching fetched object bytes in
+// Config.Storage so repeated downloads of the same oid don't hit
+// upstream twice. Cached objects accumulate under the "sha256:" prefix
+// (see cacheObjectPath) like any other goblet cache entry; bound their
+// growth the same way, by pointing a goblet.CachePolicy{Prefix:
+// "sha256:"} and goblet.Sweeper at Config.Storage.
+type UpstreamConfig struct {
+	// BaseURL is the upstream repo's LFS root, as resolved by
+	// auth/oidc.CanonicalizeLFSURL. ServeBatch POSTs to BaseURL +
+	// "/info/lfs/objects/batch", and a cache-miss object fetch GETs
+	// BaseURL + "/info/lfs/objects/<oid>".
+	BaseURL *url.URL
+
+	// SelfBaseURL is goblet's own externally reachable base URL, used to
+	// rewrite each object's download href to point back at this Handler
+	// (SelfBaseURL + "/" + repoPath + "/info/lfs/objects/" + oid) so the
+	// client re-fetches through the cache instead of going directly to
+	// BaseURL.
+	SelfBaseURL string
+
+	// Client sends the upstream batch and object requests. Defaults to
+	// http.DefaultClient if nil.
+	Client *http.Client
+
+	// TokenSource, if set, mints the Authorization sent upstream on both
+	// the batch forward and the object fetch, mirroring
+	// goblet.ServerConfig.TokenSource. It replaces whatever Authorization
+	// the client itself sent rather than forwarding it, so the client's
+	// own bearer never reaches upstream and the upstream's LFS
+	// credentials never leak back to the client. RepoURL is passed to it
+	// unchanged on every call. When TokenSource is nil, the client's
+	// Authorization header is forwarded upstream as-is.
+	TokenSource func(upstream *url.URL) (*oauth2.Token, error)
+
+	// RepoURL is the upstream git repository URL (not its LFS root),
+	// passed to TokenSource the same way goblet's own fetchUpstream does.
+	// Required when TokenSource is set.
+	RepoURL *url.URL
+}
+
+func (c *UpstreamConfig) client() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return http.DefaultClient
+}
+
+// upstreamAuthorization returns the Authorization header to send
+// upstream: a freshly minted token from TokenSource if one is
+// configured, otherwise clientAuthHeader forwarded unchanged.
+func (c *UpstreamConfig) upstreamAuthorization(clientAuthHeader string) (string, error) {
+	if c.TokenSource == nil {
+		return clientAuthHeader, nil
+	}
+	tok, err := c.TokenSource(c.RepoURL)
+	if err != nil {
+		return "", fmt.Errorf("cannot mint upstream LFS token: %w", err)
+	}
+	if tok.AccessToken == "" {
+		return "", nil
+	}
+	return tok.Type() + " " + tok.AccessToken, nil
+}
+
+// cacheObjectPath is the storage key an upstream-proxied object's bytes
+// are cached under. It's namespaced by digest algorithm, unlike the
+// local adapters' plain "lfs/<oid>" objectStoragePath, since a future
+// transfer adapter might address objects by something other than a
+// SHA-256 OID.
+func cacheObjectPath(oid string) string {
+	return "sha256:" + oid
+}
+
+// serveBatchUpstream forwards req to h.config.Upstream and rewrites each
+// returned download action to point back at this Handler. Verify
+// actions are passed through unchanged: a client verifies a checksum
+// directly against upstream, and goblet has nothing useful to add to
+// that round trip.
+func (h *Handler) serveBatchUpstream(repoPath string, req BatchRequest, authHeader string, w http.ResponseWriter) {
+	up := h.config.Upstream
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		http.Error(w, "cannot encode upstream batch request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	upstreamURL := strings.TrimSuffix(up.BaseURL.String(), "/") + "/info/lfs/objects/batch"
+	upstreamReq, err := http.NewRequest(http.MethodPost, upstreamURL, bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, "cannot build upstream batch request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	upstreamReq.Header.Set("Content-Type", batchContentType)
+	upstreamReq.Header.Set("Accept", batchContentType)
+	auth, err := up.upstreamAuthorization(authHeader)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if auth != "" {
+		upstreamReq.Header.Set("Authorization", auth)
+	}
+
+	resp, err := up.client().Do(upstreamReq)
+	if err != nil {
+		http.Error(w, "upstream LFS batch request failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		http.Error(w, fmt.Sprintf("upstream LFS server returned %d", resp.StatusCode), http.StatusBadGateway)
+		return
+	}
+
+	var upstreamResp BatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&upstreamResp); err != nil {
+		http.Error(w, "invalid upstream batch response: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	selfBase := strings.TrimSuffix(up.SelfBaseURL, "/")
+	for i := range upstreamResp.Objects {
+		obj := &upstreamResp.Objects[i]
+		dl, ok := obj.Actions["download"]
+		if !ok {
+			continue
+		}
+		dl.Href = fmt.Sprintf("%s/%s/info/lfs/objects/%s", selfBase, repoPath, obj.OID)
+		dl.Header = nil
+	}
+
+	w.Header().Set("Content-Type", batchContentType)
+	_ = json.NewEncoder(w).Encode(upstreamResp)
+}
+
+// serveObjectUpstream serves oid's bytes out of Config.Storage, treating
+// it as a content-addressable cache keyed by cacheObjectPath: a cache
+// hit is served directly (honoring a Range header for resumable
+// downloads), and a miss triggers fetchAndCache before serving.
+func (h *Handler) serveObjectUpstream(oid string, w http.ResponseWriter, r *http.Request) {
+	path := cacheObjectPath(oid)
+
+	if _, err := h.config.Storage.Attrs(r.Context(), path); err != nil {
+		if err := h.fetchAndCache(r.Context(), oid, r.Header.Get("Authorization")); err != nil {
+			http.Error(w, "upstream fetch failed: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+
+	attrs, err := h.config.Storage.Attrs(r.Context(), path)
+	if err != nil {
+		http.Error(w, "object not found", http.StatusNotFound)
+		return
+	}
+	rc, err := h.config.Storage.Reader(r.Context(), path)
+	if err != nil {
+		http.Error(w, "object not found", http.StatusNotFound)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	start, end, partial, err := parseRangeHeader(r.Header.Get("Range"), attrs.Size)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	if !partial {
+		w.Header().Set("Content-Length", strconv.FormatInt(attrs.Size, 10))
+		_, _ = io.Copy(w, rc)
+		return
+	}
+	if _, err := io.CopyN(io.Discard, rc, start); err != nil {
+		http.Error(w, "range read failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, attrs.Size))
+	w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	w.WriteHeader(http.StatusPartialContent)
+	_, _ = io.CopyN(w, rc, end-start+1)
+}
+
+// fetchAndCache fetches oid from the upstream LFS server and, once the
+// bytes have been verified to hash to oid (an LFS OID is itself the
+// object's SHA-256 digest), writes it into Config.Storage at
+// cacheObjectPath(oid). The fetch first lands under a staging key
+// invisible to serveObjectUpstream's cache-hit check, so a concurrent
+// request for the same oid can never observe a truncated or substituted
+// upstream response before it's verified. Concurrent callers for the
+// same oid collapse into a single upstream fetch via fetchGroup, the
+// same singleflight pattern LRUTokenCache uses to dedupe concurrent
+// token refreshes. authHeader is forwarded for this fetch only, unless
+// Config.Upstream.TokenSource overrides it; neither is ever persisted
+// alongside the cached bytes.
+func (h *Handler) fetchAndCache(ctx context.Context, oid, authHeader string) error {
+	up := h.config.Upstream
+	_, err, _ := h.fetchGroup.Do(oid, func() (interface{}, error) {
+		objURL := strings.TrimSuffix(up.BaseURL.String(), "/") + "/info/lfs/objects/" + oid
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, objURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		auth, err := up.upstreamAuthorization(authHeader)
+		if err != nil {
+			return nil, err
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+
+		resp, err := up.client().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("upstream object fetch returned %d", resp.StatusCode)
+		}
+
+		stagingPath := cacheObjectPath(oid) + ".staging-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+		wc, err := h.config.Storage.Writer(ctx, stagingPath)
+		if err != nil {
+			return nil, err
+		}
+		hasher := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(wc, hasher), resp.Body); err != nil {
+			_ = wc.Close()
+			_ = h.config.Storage.Delete(ctx, stagingPath)
+			return nil, err
+		}
+		if err := wc.Close(); err != nil {
+			_ = h.config.Storage.Delete(ctx, stagingPath)
+			return nil, err
+		}
+		if got := hex.EncodeToString(hasher.Sum(nil)); got != oid {
+			_ = h.config.Storage.Delete(ctx, stagingPath)
+			return nil, fmt.Errorf("upstream object digest mismatch: want %s, got %s", oid, got)
+		}
+
+		if err := h.promoteStagedObject(ctx, stagingPath, cacheObjectPath(oid)); err != nil {
+			_ = h.config.Storage.Delete(ctx, stagingPath)
+			return nil, err
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// promoteStagedObject copies a verified object from its staging path to
+// its final cache key, since storage.Provider has no rename operation.
+// Called only after digest verification, so the final key never becomes
+// visible with unverified contents.
+func (h *Handler) promoteStagedObject(ctx context.Context, stagingPath, finalPath string) error {
+	rc, err := h.config.Storage.Reader(ctx, stagingPath)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	wc, err := h.config.Storage.Writer(ctx, finalPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(wc, rc); err != nil {
+		_ = wc.Close()
+		_ = h.config.Storage.Delete(ctx, finalPath)
+		return err
+	}
+	if err := wc.Close(); err != nil {
+		_ = h.config.Storage.Delete(ctx, finalPath)
+		return err
+	}
+	return h.config.Storage.Delete(ctx, stagingPath)
+}
+
+// parseRangeHeader parses a single-range "bytes=start-end" Range header,
+// the only form LFS clients send when resuming a download. It reports
+// partial=false for an empty header, meaning the whole object should be
+// served.
+func parseRangeHeader(header string, size int64) (start, end int64, partial bool, err error) {
+	if header == "" {
+		return 0, 0, false, nil
+	}
+	spec := strings.TrimPrefix(header, "bytes=")
+	if spec == header {
+		return 0, 0, false, fmt.Errorf("unsupported range unit")
+	}
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, false, fmt.Errorf("malformed range")
+	}
+	start, err = strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("malformed range start")
+	}
+	if parts[1] == "" {
+		end = size - 1
+	} else if end, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+		return 0, 0, false, fmt.Errorf("malformed range end")
+	}
+	if start < 0 || end < start || end >= size {
+		return 0, 0, false, fmt.Errorf("range out of bounds")
+	}
+	return start, end, true, nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lfs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/goblet/storage"
+	"golang.org/x/oauth2"
+)
+
+func TestCacheObjectPath(t *testing.T) {
+	if got, want := cacheObjectPath("abc123"), "sha256:abc123"; got != want {
+		t.Errorf("cacheObjectPath() = %q, want %q", got, want)
+	}
+}
+
+func TestServeBatchUpstreamRewritesDownloadHref(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Authorization"), "Bearer tok"; got != want {
+			t.Errorf("upstream Authorization = %q, want %q", got, want)
+		}
+		w.Header().Set("Content-Type", batchContentType)
+		w.Write([]byte(`{"objects":[{"oid":"abc123","size":10,"actions":{"download":{"href":"https://upstream.example.com/objects/abc123","header":{"Authorization":"upstream-secret"}}}}]}`))
+	}))
+	defer upstream.Close()
+
+	upstreamURL, _ := url.Parse(upstream.URL)
+	h := NewHandler(Config{
+		Storage: storage.NewMemoryProvider(),
+		Upstream: &UpstreamConfig{
+			BaseURL:     upstreamURL,
+			SelfBaseURL: "https://goblet.example.com",
+		},
+	})
+
+	r := httptest.NewRequest("POST", "/github.com/org/repo/info/lfs/objects/batch", strings.NewReader(`{"operation":"download","objects":[{"oid":"abc123","size":10}]}`))
+	r.Header.Set("Authorization", "Bearer tok")
+	w := httptest.NewRecorder()
+
+	h.ServeBatch("github.com/org/repo", w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200, body: %s", w.Code, w.Body.String())
+	}
+	wantHref := "https://goblet.example.com/github.com/org/repo/info/lfs/objects/abc123"
+	if got := w.Body.String(); !strings.Contains(got, wantHref) {
+		t.Errorf("response %s does not contain rewritten href %q", got, wantHref)
+	}
+	if strings.Contains(w.Body.String(), "upstream-secret") {
+		t.Errorf("response leaked upstream's download header: %s", w.Body.String())
+	}
+}
+
+// objectBytesOID is the SHA-256 hex digest of "object bytes", i.e. the
+// OID an LFS client would use to request it.
+const objectBytesOID = "e6aab7ae6c1426434801a72c67fe0735eeff70a85f1a476a37d958fdeec6a6e3"
+
+func TestServeObjectUpstreamFetchesAndCaches(t *testing.T) {
+	fetches := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Write([]byte("object bytes"))
+	}))
+	defer upstream.Close()
+
+	upstreamURL, _ := url.Parse(upstream.URL)
+	mem := storage.NewMemoryProvider()
+	h := NewHandler(Config{
+		Storage: mem,
+		Upstream: &UpstreamConfig{
+			BaseURL:     upstreamURL,
+			SelfBaseURL: "https://goblet.example.com",
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest("GET", "/github.com/org/repo/info/lfs/objects/"+objectBytesOID, nil)
+		w := httptest.NewRecorder()
+		h.ServeObject(objectBytesOID, w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200, body: %s", w.Code, w.Body.String())
+		}
+		if got, want := w.Body.String(), "object bytes"; got != want {
+			t.Errorf("body = %q, want %q", got, want)
+		}
+	}
+
+	if fetches != 1 {
+		t.Errorf("upstream fetched %d times, want 1 (second request should hit the cache)", fetches)
+	}
+
+	if _, err := mem.Attrs(context.Background(), "sha256:"+objectBytesOID); err != nil {
+		t.Errorf("expected object cached at sha256:%s: %v", objectBytesOID, err)
+	}
+}
+
+func TestServeObjectUpstreamRejectsDigestMismatch(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not the right bytes"))
+	}))
+	defer upstream.Close()
+
+	upstreamURL, _ := url.Parse(upstream.URL)
+	mem := storage.NewMemoryProvider()
+	h := NewHandler(Config{
+		Storage: mem,
+		Upstream: &UpstreamConfig{
+			BaseURL:     upstreamURL,
+			SelfBaseURL: "https://goblet.example.com",
+		},
+	})
+
+	r := httptest.NewRequest("GET", "/github.com/org/repo/info/lfs/objects/abc123", nil)
+	w := httptest.NewRecorder()
+	h.ServeObject("abc123", w, r)
+
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502 on digest mismatch", w.Code)
+	}
+	if _, err := mem.Attrs(context.Background(), "sha256:abc123"); err == nil {
+		t.Error("mismatched object should not remain cached")
+	}
+}
+
+func TestUpstreamTokenSourceReplacesClientAuthorization(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Authorization"), "Bearer minted-token"; got != want {
+			t.Errorf("upstream Authorization = %q, want %q", got, want)
+		}
+		w.Header().Set("Content-Type", batchContentType)
+		w.Write([]byte(`{"objects":[]}`))
+	}))
+	defer upstream.Close()
+
+	upstreamURL, _ := url.Parse(upstream.URL)
+	repoURL, _ := url.Parse("https://github.com/org/repo")
+	h := NewHandler(Config{
+		Storage: storage.NewMemoryProvider(),
+		Upstream: &UpstreamConfig{
+			BaseURL:     upstreamURL,
+			SelfBaseURL: "https://goblet.example.com",
+			RepoURL:     repoURL,
+			TokenSource: func(u *url.URL) (*oauth2.Token, error) {
+				if u != repoURL {
+					t.Errorf("TokenSource called with %v, want %v", u, repoURL)
+				}
+				return &oauth2.Token{AccessToken: "minted-token", TokenType: "Bearer"}, nil
+			},
+		},
+	})
+
+	r := httptest.NewRequest("POST", "/github.com/org/repo/info/lfs/objects/batch", strings.NewReader(`{"operation":"download","objects":[]}`))
+	r.Header.Set("Authorization", "Bearer client-token")
+	w := httptest.NewRecorder()
+
+	h.ServeBatch("github.com/org/repo", w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200, body: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestParseRangeHeader(t *testing.T) {
+	cases := []struct {
+		header      string
+		size        int64
+		wantStart   int64
+		wantEnd     int64
+		wantPartial bool
+		wantErr     bool
+	}{
+		{header: "", size: 10, wantPartial: false},
+		{header: "bytes=0-4", size: 10, wantStart: 0, wantEnd: 4, wantPartial: true},
+		{header: "bytes=5-", size: 10, wantStart: 5, wantEnd: 9, wantPartial: true},
+		{header: "bytes=0-20", size: 10, wantErr: true},
+		{header: "items=0-4", size: 10, wantErr: true},
+		{header: "bytes=bad", size: 10, wantErr: true},
+	}
+	for _, c := range cases {
+		start, end, partial, err := parseRangeHeader(c.header, c.size)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parseRangeHeader(%q, %d) = nil error, want error", c.header, c.size)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRangeHeader(%q, %d) unexpected error: %v", c.header, c.size, err)
+			continue
+		}
+		if start != c.wantStart || end != c.wantEnd || partial != c.wantPartial {
+			t.Errorf("parseRangeHeader(%q, %d) = (%d, %d, %v), want (%d, %d, %v)",
+				c.header, c.size, start, end, partial, c.wantStart, c.wantEnd, c.wantPartial)
+		}
+	}
+}
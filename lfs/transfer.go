@@ -0,0 +1,90 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lfs
+
+import (
+	"fmt"
+	"time"
+)
+
+// TransferAdapter builds the actions for a single object for a given
+// operation. Adapters are selected by name from the `transfers` array the
+// client advertises in the batch request.
+type TransferAdapter interface {
+	// Name is the adapter identifier advertised in BatchResponse.Transfer,
+	// e.g. "basic".
+	Name() string
+
+	// BuildActions returns the actions for the given object, or an
+	// ObjectError if the object cannot be handled by this adapter.
+	BuildActions(repoPath string, op Operation, obj Pointer, authHeader string) (map[string]*Action, *ObjectError)
+}
+
+// BasicAdapter implements the "basic" LFS transfer adapter: direct HTTP
+// GET/PUT against href URLs rooted at BaseURL, forwarding the caller's
+// Authorization header so the upstream object store can authorize the
+// request the same way it authorizes Git traffic.
+type BasicAdapter struct {
+	// BaseURL is the externally reachable base, e.g.
+	// "https://goblet.example.com". Object hrefs are
+	// BaseURL + "/<repoPath>/info/lfs/objects/<oid>".
+	BaseURL string
+
+	// Expiry is how long the returned href is valid for. Defaults to 1
+	// hour if zero.
+	Expiry time.Duration
+
+	// Now returns the current time; overridable for tests.
+	Now func() time.Time
+}
+
+// Name implements TransferAdapter.
+func (a *BasicAdapter) Name() string { return TransferBasic }
+
+// BuildActions implements TransferAdapter.
+func (a *BasicAdapter) BuildActions(repoPath string, op Operation, obj Pointer, authHeader string) (map[string]*Action, *ObjectError) {
+	if obj.OID == "" {
+		return nil, &ObjectError{Code: ErrCodeValidation, Message: "missing oid"}
+	}
+
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	expiry := a.Expiry
+	if expiry == 0 {
+		expiry = time.Hour
+	}
+
+	href := fmt.Sprintf("%s/%s/info/lfs/objects/%s", a.BaseURL, repoPath, obj.OID)
+	header := map[string]string{}
+	if authHeader != "" {
+		header["Authorization"] = authHeader
+	}
+	action := &Action{
+		Href:      href,
+		Header:    header,
+		ExpiresAt: now().Add(expiry),
+	}
+
+	actions := map[string]*Action{}
+	switch op {
+	case OperationDownload:
+		actions["download"] = action
+	case OperationUpload:
+		actions["upload"] = action
+	}
+	return actions, nil
+}
@@ -32,8 +32,7 @@ import (
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/google/gitprotocolio"
-	"go.opencensus.io/stats"
-	"go.opencensus.io/tag"
+	"go.opentelemetry.io/otel/metric"
 	"golang.org/x/oauth2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -90,14 +89,19 @@ func openManagedRepository(config *ServerConfig, u *url.URL) (*managedRepository
 		}
 
 		op := noopOperation{}
-		_ = runGit(op, localDiskPath, "init", "--bare")
-		_ = runGit(op, localDiskPath, "config", "protocol.version", "2")
-		_ = runGit(op, localDiskPath, "config", "uploadpack.allowfilter", "1")
-		_ = runGit(op, localDiskPath, "config", "uploadpack.allowrefinwant", "1")
-		_ = runGit(op, localDiskPath, "config", "repack.writebitmaps", "1")
+		ctx := context.Background()
+		_ = runGit(ctx, op, localDiskPath, "init", "--bare")
+		_ = runGit(ctx, op, localDiskPath, "config", "protocol.version", "2")
+		_ = runGit(ctx, op, localDiskPath, "config", "uploadpack.allowfilter", "1")
+		_ = runGit(ctx, op, localDiskPath, "config", "uploadpack.allowrefinwant", "1")
+		_ = runGit(ctx, op, localDiskPath, "config", "repack.writebitmaps", "1")
 		// It seems there's a bug in libcurl and HTTP/2 doens't work.
-		_ = runGit(op, localDiskPath, "config", "http.version", "HTTP/1.1")
-		_ = runGit(op, localDiskPath, "remote", "add", "--mirror=fetch", "origin", u.String())
+		_ = runGit(ctx, op, localDiskPath, "config", "http.version", "HTTP/1.1")
+		_ = runGit(ctx, op, localDiskPath, "remote", "add", "--mirror=fetch", "origin", u.String())
+
+		if err := installHookScripts(config.HookScriptDir, localDiskPath); err != nil {
+			return nil, status.Errorf(codes.Internal, "cannot install hook scripts: %v", err)
+		}
 	}
 
 	return m, nil
@@ -108,14 +112,12 @@ func logStats(command string, startTime time.Time, err error) {
 	if st, ok := status.FromError(err); ok {
 		code = st.Code()
 	}
-	_ = stats.RecordWithTags(context.Background(),
-		[]tag.Mutator{
-			tag.Insert(CommandTypeKey, command),
-			tag.Insert(CommandCanonicalStatusKey, code.String()),
-		},
-		OutboundCommandCount.M(1),
-		OutboundCommandProcessingTime.M(int64(time.Since(startTime)/time.Millisecond)),
+	attrs := metric.WithAttributes(
+		CommandTypeKey.String(command),
+		CommandCanonicalStatusKey.String(code.String()),
 	)
+	OutboundCommandCount.Add(context.Background(), 1, attrs)
+	OutboundCommandProcessingTime.Record(context.Background(), float64(time.Since(startTime)/time.Millisecond), attrs)
 }
 
 type managedRepository struct {
@@ -126,17 +128,72 @@ type managedRepository struct {
 	mu            sync.RWMutex
 }
 
-func (r *managedRepository) lsRefsUpstream(command []*gitprotocolio.ProtocolV2RequestChunk) ([]*gitprotocolio.ProtocolV2ResponseChunk, error) {
-	req, err := http.NewRequest("POST", r.upstreamURL.String()+"/git-upload-pack", newGitRequest(command))
+// withUpstreamTimeout bounds ctx by config.UpstreamFetchTimeout when ctx
+// doesn't already carry its own deadline, so a request whose caller
+// never set one (or a background fetch with context.Background()) can't
+// hang on an upstream indefinitely; the caller's own deadline, if any,
+// is always honored as-is. The returned cancel must be called once the
+// bounded ctx is no longer needed, same as context.WithTimeout's.
+func withUpstreamTimeout(ctx context.Context, config *ServerConfig) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || config.UpstreamFetchTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, config.UpstreamFetchTimeout)
+}
+
+// getToken obtains an OAuth2 token for the upstream, through
+// config.TokenCache if one is configured so repeated calls (e.g. one
+// per fetchUpstream) don't hit config.TokenSource, and therefore the
+// identity provider, every time. If config.TokenSourceCtx is set, it's
+// preferred over TokenSource and receives ctx, so a tenant ID placed
+// there by IsolationConfig.TenantContext can resolve different upstream
+// credentials per tenant instead of every tenant sharing whatever
+// TokenSource last resolved for the upstream URL alone; the same tenant
+// ID also namespaces the TokenCache entry via TokenCacheKey.TenantID, so
+// distinct tenants never collide on or evict each other's cached token.
+// forceRefresh evicts any cached token first, for retrying after the
+// upstream has rejected one as stale.
+func (r *managedRepository) getToken(ctx context.Context, forceRefresh bool) (*oauth2.Token, error) {
+	fetch := func() (*oauth2.Token, error) {
+		if r.config.TokenSourceCtx != nil {
+			return r.config.TokenSourceCtx(ctx, r.upstreamURL)
+		}
+		return r.config.TokenSource(r.upstreamURL)
+	}
+	if r.config.TokenCache == nil {
+		return fetch()
+	}
+	key := TokenCacheKey{UpstreamURL: r.upstreamURL.String(), TenantID: GetTenantFromContext(ctx)}
+	if forceRefresh {
+		r.config.TokenCache.Invalidate(key)
+	}
+	return r.config.TokenCache.Token(key, fetch)
+}
+
+// postUpstreamSmartHTTPRequest POSTs body to the upstream's
+// /<endpoint> smart-HTTP service (e.g. "git-upload-pack" or
+// "git-receive-pack"), setting the Content-Type/Accept pair smart-HTTP
+// expects for that endpoint and authenticating with a (force-refreshed,
+// if forceRefresh) token for the tenant carried in ctx (see
+// IsolationConfig.TenantContext). It returns a *UpstreamAuthError if the
+// upstream responds 401/403. metricLabel is passed to logStats, since
+// the two endpoints report under different command names.
+//
+// Shared by postUpstreamGitRequest (git-upload-pack) and
+// PushUpstream (git-receive-pack, see
+// receive_pack.go), which differ only in endpoint, request body, and
+// forceRefresh support.
+func (r *managedRepository) postUpstreamSmartHTTPRequest(ctx context.Context, endpoint string, body io.Reader, forceRefresh bool, metricLabel string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", r.upstreamURL.String()+"/"+endpoint, body)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "cannot construct a request object: %v", err)
 	}
-	t, err := r.config.TokenSource(r.upstreamURL)
+	t, err := r.getToken(ctx, forceRefresh)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "cannot obtain an OAuth2 access token for the server: %v", err)
 	}
-	req.Header.Add("Content-Type", "application/x-git-upload-pack-request")
-	req.Header.Add("Accept", "application/x-git-upload-pack-result")
+	req.Header.Add("Content-Type", "application/x-"+endpoint+"-request")
+	req.Header.Add("Accept", "application/x-"+endpoint+"-result")
 	req.Header.Add("Git-Protocol", "version=2")
 	// Only set auth header if we have a valid token
 	if t.AccessToken != "" {
@@ -145,12 +202,19 @@ func (r *managedRepository) lsRefsUpstream(command []*gitprotocolio.ProtocolV2Re
 
 	startTime := time.Now()
 	resp, err := http.DefaultClient.Do(req)
-	logStats("ls-refs", startTime, err)
+	logStats(metricLabel, startTime, err)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "cannot send a request to the upstream: %v", err)
 	}
-	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		resp.Body.Close()
+		authErr := &UpstreamAuthError{Upstream: r.upstreamURL, StatusCode: resp.StatusCode}
+		r.reportUpstreamAuthError(req, authErr)
+		return nil, authErr
+	}
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
 		errMessage := ""
 		if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
 			bs, err := io.ReadAll(resp.Body)
@@ -160,6 +224,32 @@ func (r *managedRepository) lsRefsUpstream(command []*gitprotocolio.ProtocolV2Re
 		}
 		return nil, fmt.Errorf("got a non-OK response from the upstream: %v %s", resp.StatusCode, errMessage)
 	}
+	return resp, nil
+}
+
+// postUpstreamGitRequest POSTs command to the upstream's git-upload-pack
+// endpoint, authenticated with a (force-refreshed, if forceRefresh)
+// token for the tenant carried in ctx (see
+// IsolationConfig.TenantContext), and returns a *UpstreamAuthError if
+// the upstream responds 401/403.
+func (r *managedRepository) postUpstreamGitRequest(ctx context.Context, command []*gitprotocolio.ProtocolV2RequestChunk, forceRefresh bool) (*http.Response, error) {
+	return r.postUpstreamSmartHTTPRequest(ctx, "git-upload-pack", newGitRequest(command), forceRefresh, "ls-refs")
+}
+
+func (r *managedRepository) lsRefsUpstream(ctx context.Context, command []*gitprotocolio.ProtocolV2RequestChunk) ([]*gitprotocolio.ProtocolV2ResponseChunk, error) {
+	ctx, cancel := withUpstreamTimeout(ctx, r.config)
+	defer cancel()
+
+	resp, err := r.postUpstreamGitRequest(ctx, command, false)
+	if isUpstreamAuthError(err) {
+		// The cached token was rejected; force a fresh one and retry
+		// exactly once.
+		resp, err = r.postUpstreamGitRequest(ctx, command, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
 	chunks := []*gitprotocolio.ProtocolV2ResponseChunk{}
 	v2Resp := gitprotocolio.NewProtocolV2Response(resp.Body)
@@ -300,7 +390,13 @@ func (r *managedRepository) lsRefsLocal(command []*gitprotocolio.ProtocolV2Reque
 	return chunks, nil
 }
 
-func (r *managedRepository) fetchUpstream() (err error) {
+// fetchUpstream fetches the upstream into the local cache, authenticated
+// with a token for the tenant carried in ctx, if any (see
+// IsolationConfig.TenantContext and ServerConfig.TokenSourceCtx).
+func (r *managedRepository) fetchUpstream(ctx context.Context) (err error) {
+	ctx, cancel := withUpstreamTimeout(ctx, r.config)
+	defer cancel()
+
 	op := r.startOperation("FetchUpstream")
 	defer func() {
 		op.Done(err)
@@ -319,34 +415,15 @@ func (r *managedRepository) fetchUpstream() (err error) {
 		splitGitFetch = true
 	}
 
-	var t *oauth2.Token
 	startTime := time.Now()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if splitGitFetch {
 		// Fetch heads and changes first.
-		t, err = r.config.TokenSource(r.upstreamURL)
-		if err != nil {
-			err = status.Errorf(codes.Internal, "cannot obtain an OAuth2 access token for the server: %v", err)
-			return err
-		}
-		if t.AccessToken != "" {
-			err = runGit(op, r.localDiskPath, "-c", "http.extraHeader=Authorization: "+t.Type()+" "+t.AccessToken, "fetch", "--progress", "-f", "-n", "origin", "refs/heads/*:refs/heads/*", "refs/changes/*:refs/changes/*")
-		} else {
-			err = runGit(op, r.localDiskPath, "fetch", "--progress", "-f", "-n", "origin", "refs/heads/*:refs/heads/*", "refs/changes/*:refs/changes/*")
-		}
+		err = r.runGitFetchWithAuthRetry(ctx, op, "fetch", "--progress", "-f", "-n", "origin", "refs/heads/*:refs/heads/*", "refs/changes/*:refs/changes/*")
 	}
 	if err == nil {
-		t, err = r.config.TokenSource(r.upstreamURL)
-		if err != nil {
-			err = status.Errorf(codes.Internal, "cannot obtain an OAuth2 access token for the server: %v", err)
-			return err
-		}
-		if t.AccessToken != "" {
-			err = runGit(op, r.localDiskPath, "-c", "http.extraHeader=Authorization: "+t.Type()+" "+t.AccessToken, "fetch", "--progress", "-f", "origin")
-		} else {
-			err = runGit(op, r.localDiskPath, "fetch", "--progress", "-f", "origin")
-		}
+		err = r.runGitFetchWithAuthRetry(ctx, op, "fetch", "--progress", "-f", "origin")
 	}
 	logStats("fetch", startTime, err)
 	if err == nil {
@@ -366,7 +443,7 @@ func (r *managedRepository) LastUpdateTime() time.Time {
 	return r.lastUpdate
 }
 
-func (r *managedRepository) RecoverFromBundle(bundlePath string) (err error) {
+func (r *managedRepository) RecoverFromBundle(ctx context.Context, bundlePath string) (err error) {
 	op := r.startOperation("ReadBundle")
 	defer func() {
 		op.Done(err)
@@ -374,16 +451,16 @@ func (r *managedRepository) RecoverFromBundle(bundlePath string) (err error) {
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	err = runGit(op, r.localDiskPath, "fetch", "--progress", "-f", bundlePath, "refs/*:refs/*")
+	err = runGit(ctx, op, r.localDiskPath, "fetch", "--progress", "-f", bundlePath, "refs/*:refs/*")
 	return
 }
 
-func (r *managedRepository) WriteBundle(w io.Writer) (err error) {
+func (r *managedRepository) WriteBundle(ctx context.Context, w io.Writer) (err error) {
 	op := r.startOperation("CreateBundle")
 	defer func() {
 		op.Done(err)
 	}()
-	err = runGitWithStdOut(op, w, r.localDiskPath, "bundle", "create", "-", "--all")
+	err = runGitWithStdOut(ctx, op, w, r.localDiskPath, "bundle", "create", "-", "--all")
 	return
 }
 
@@ -431,11 +508,11 @@ func (r *managedRepository) hasAllWants(hashes []plumbing.Hash, refs []string) (
 	return true, nil
 }
 
-func (r *managedRepository) serveFetchLocal(command []*gitprotocolio.ProtocolV2RequestChunk, w io.Writer) error {
+func (r *managedRepository) serveFetchLocal(ctx context.Context, command []*gitprotocolio.ProtocolV2RequestChunk, w io.Writer) error {
 	// If fetch-upstream is running, it's possible that Git returns
 	// incomplete set of objects when the refs being fetched is updated and
 	// it uses ref-in-want.
-	cmd := exec.Command(gitBinary, "upload-pack", "--stateless-rpc", r.localDiskPath)
+	cmd := exec.CommandContext(ctx, gitBinary, "upload-pack", "--stateless-rpc", r.localDiskPath)
 	cmd.Env = []string{"GIT_PROTOCOL=version=2"}
 	cmd.Dir = r.localDiskPath
 	cmd.Stdin = newGitRequest(command)
@@ -451,8 +528,8 @@ func (r *managedRepository) startOperation(op string) RunningOperation {
 	return noopOperation{}
 }
 
-func runGit(op RunningOperation, gitDir string, arg ...string) error {
-	cmd := exec.Command(gitBinary, arg...)
+func runGit(ctx context.Context, op RunningOperation, gitDir string, arg ...string) error {
+	cmd := exec.CommandContext(ctx, gitBinary, arg...)
 	cmd.Env = []string{}
 	cmd.Dir = gitDir
 	cmd.Stderr = &operationWriter{op}
@@ -463,8 +540,8 @@ func runGit(op RunningOperation, gitDir string, arg ...string) error {
 	return nil
 }
 
-func runGitWithStdOut(op RunningOperation, w io.Writer, gitDir string, arg ...string) error {
-	cmd := exec.Command(gitBinary, arg...)
+func runGitWithStdOut(ctx context.Context, op RunningOperation, w io.Writer, gitDir string, arg ...string) error {
+	cmd := exec.CommandContext(ctx, gitBinary, arg...)
 	cmd.Env = []string{}
 	cmd.Dir = gitDir
 	cmd.Stdout = w
@@ -15,6 +15,7 @@
 package goblet
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -23,6 +24,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/google/gitprotocolio"
 	"golang.org/x/oauth2"
 )
 
@@ -63,7 +65,7 @@ func TestManagedRepository_TokenSourceCalled(t *testing.T) {
 
 	// Trigger a fetch to invoke TokenSource
 	// Note: This will likely fail without a real upstream, but TokenSource will still be called
-	_ = repo.fetchUpstream()
+	_ = repo.fetchUpstream(context.Background())
 
 	// Verify the URL was captured
 	mu.Lock()
@@ -156,7 +158,7 @@ func TestManagedRepository_DifferentTokenTypes(t *testing.T) {
 
 			// Force an upstream fetch to trigger token usage
 			// Note: This will fail but we're just testing that the auth header is set
-			_ = repo.fetchUpstream()
+			_ = repo.fetchUpstream(context.Background())
 
 			mu.Lock()
 			authHeader := capturedAuthHeader
@@ -218,7 +220,7 @@ func TestManagedRepository_EmptyToken(t *testing.T) {
 	}
 
 	// Trigger upstream operation
-	_ = repo.fetchUpstream()
+	_ = repo.fetchUpstream(context.Background())
 
 	mu.Lock()
 	defer mu.Unlock()
@@ -254,7 +256,7 @@ func TestManagedRepository_TokenSourceError(t *testing.T) {
 	}
 
 	// Attempt to fetch - should fail with token error
-	err = repo.fetchUpstream()
+	err = repo.fetchUpstream(context.Background())
 	if err == nil {
 		t.Error("Expected error when TokenSource fails, got nil")
 	}
@@ -310,7 +312,7 @@ func TestManagedRepository_MultipleTokenCalls(t *testing.T) {
 
 	// Make multiple fetch attempts
 	for i := 0; i < 3; i++ {
-		_ = repo.fetchUpstream()
+		_ = repo.fetchUpstream(context.Background())
 	}
 
 	mu.Lock()
@@ -323,6 +325,62 @@ func TestManagedRepository_MultipleTokenCalls(t *testing.T) {
 	t.Logf("TokenSource called %d times for token refresh", callCount)
 }
 
+// TestManagedRepository_TokenCacheDedupesConcurrentCalls verifies that,
+// with a TokenCache configured, concurrent fetchUpstream calls collapse
+// into a single TokenSource call instead of hammering the identity
+// provider once per fetch.
+func TestManagedRepository_TokenCacheDedupesConcurrentCalls(t *testing.T) {
+	var callCount int32
+	var mu sync.Mutex
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("0000"))
+	}))
+	defer upstreamServer.Close()
+
+	upstreamURL, _ := url.Parse(upstreamServer.URL + "/repo")
+
+	config := &ServerConfig{
+		LocalDiskCacheRoot: t.TempDir(),
+		URLCanonializer: func(u *url.URL) (*url.URL, error) {
+			return upstreamURL, nil
+		},
+		RequestAuthorizer: func(r *http.Request) error {
+			return nil
+		},
+		TokenCache: &LRUTokenCache{},
+		TokenSource: func(u *url.URL) (*oauth2.Token, error) {
+			mu.Lock()
+			callCount++
+			mu.Unlock()
+			return &oauth2.Token{AccessToken: "cached-token", TokenType: "Bearer"}, nil
+		},
+	}
+
+	repo, err := openManagedRepository(config, upstreamURL)
+	if err != nil {
+		t.Fatalf("Failed to open managed repository: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = repo.fetchUpstream(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if callCount != 1 {
+		t.Errorf("TokenSource called %d times, want 1 (TokenCache should dedupe and reuse the cached token)", callCount)
+	}
+}
+
 // TestManagedRepository_URLPassedToTokenSource verifies that the exact
 // upstream URL is passed to TokenSource, including host, path, etc.
 func TestManagedRepository_URLPassedToTokenSource(t *testing.T) {
@@ -381,7 +439,7 @@ func TestManagedRepository_URLPassedToTokenSource(t *testing.T) {
 			}
 
 			// Trigger a fetch to invoke TokenSource
-			_ = repo.fetchUpstream()
+			_ = repo.fetchUpstream(context.Background())
 
 			mu.Lock()
 			defer mu.Unlock()
@@ -458,7 +516,7 @@ func TestManagedRepository_ConcurrentTokenRequests(t *testing.T) {
 	for i := 0; i < numGoroutines; i++ {
 		go func() {
 			defer wg.Done()
-			_ = repo.fetchUpstream()
+			_ = repo.fetchUpstream(context.Background())
 		}()
 	}
 
@@ -473,3 +531,164 @@ func TestManagedRepository_ConcurrentTokenRequests(t *testing.T) {
 
 	t.Logf("TokenSource handled %d concurrent calls successfully", tokenCallCount)
 }
+
+// TestManagedRepository_LsRefsRetriesOnUpstreamAuthError verifies that
+// lsRefsUpstream, on a 401 from the upstream, invalidates the cached
+// token, fetches a fresh one, and retries exactly once -- and that the
+// rejected attempt is reported through ErrorReporter as an
+// *UpstreamAuthError.
+func TestManagedRepository_LsRefsRetriesOnUpstreamAuthError(t *testing.T) {
+	var mu sync.Mutex
+	var requestCount, tokenCallCount int
+	var reportedErr error
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestCount++
+		first := requestCount == 1
+		mu.Unlock()
+
+		if first {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("0000"))
+	}))
+	defer upstreamServer.Close()
+
+	upstreamURL, _ := url.Parse(upstreamServer.URL + "/repo")
+
+	config := &ServerConfig{
+		LocalDiskCacheRoot: t.TempDir(),
+		URLCanonializer: func(u *url.URL) (*url.URL, error) {
+			return upstreamURL, nil
+		},
+		RequestAuthorizer: func(r *http.Request) error {
+			return nil
+		},
+		TokenCache: &LRUTokenCache{},
+		TokenSource: func(u *url.URL) (*oauth2.Token, error) {
+			mu.Lock()
+			tokenCallCount++
+			token := fmt.Sprintf("token-%d", tokenCallCount)
+			mu.Unlock()
+			return &oauth2.Token{AccessToken: token, TokenType: "Bearer"}, nil
+		},
+		ErrorReporter: func(r *http.Request, err error) {
+			mu.Lock()
+			reportedErr = err
+			mu.Unlock()
+		},
+	}
+
+	repo, err := openManagedRepository(config, upstreamURL)
+	if err != nil {
+		t.Fatalf("Failed to open managed repository: %v", err)
+	}
+
+	if _, err := repo.lsRefsUpstream(context.Background(), []*gitprotocolio.ProtocolV2RequestChunk{}); err != nil {
+		t.Fatalf("lsRefsUpstream() error = %v, want nil after retry", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if requestCount != 2 {
+		t.Errorf("upstream received %d requests, want 2 (one rejected, one retried)", requestCount)
+	}
+	if tokenCallCount != 2 {
+		t.Errorf("TokenSource called %d times, want 2 (cached token rejected, fresh one fetched)", tokenCallCount)
+	}
+	if reportedErr == nil {
+		t.Fatal("ErrorReporter was never called")
+	}
+	if !isUpstreamAuthError(reportedErr) {
+		t.Errorf("ErrorReporter got %v (%T), want an *UpstreamAuthError", reportedErr, reportedErr)
+	}
+}
+
+// TestManagedRepository_TenantScopedTokenSource verifies that, with
+// TokenSourceCtx configured, two requests to the same upstream URL under
+// different tenants (as set by SetTenantInContext, mirroring what
+// IsolationConfig.TenantContext puts in a real request's context)
+// produce distinct Authorization headers and distinct TokenCache
+// entries, instead of every tenant sharing (and evicting) whatever
+// token the other last resolved for the shared upstream URL.
+func TestManagedRepository_TenantScopedTokenSource(t *testing.T) {
+	var mu sync.Mutex
+	var authHeaders []string
+	var tokenCallCount int
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("0000"))
+	}))
+	defer upstreamServer.Close()
+
+	upstreamURL, _ := url.Parse(upstreamServer.URL + "/repo")
+
+	config := &ServerConfig{
+		LocalDiskCacheRoot: t.TempDir(),
+		URLCanonializer: func(u *url.URL) (*url.URL, error) {
+			return upstreamURL, nil
+		},
+		RequestAuthorizer: func(r *http.Request) error {
+			return nil
+		},
+		TokenCache: &LRUTokenCache{},
+		TokenSourceCtx: func(ctx context.Context, u *url.URL) (*oauth2.Token, error) {
+			mu.Lock()
+			tokenCallCount++
+			mu.Unlock()
+			return &oauth2.Token{
+				AccessToken: "token-for-" + GetTenantFromContext(ctx),
+				TokenType:   "Bearer",
+			}, nil
+		},
+	}
+
+	repo, err := openManagedRepository(config, upstreamURL)
+	if err != nil {
+		t.Fatalf("Failed to open managed repository: %v", err)
+	}
+
+	tenantACtx := SetTenantInContext(context.Background(), "tenant-a")
+	tenantBCtx := SetTenantInContext(context.Background(), "tenant-b")
+
+	// Two requests for tenant A: the second should hit the TokenCache
+	// instead of calling TokenSourceCtx again.
+	if _, err := repo.lsRefsUpstream(tenantACtx, []*gitprotocolio.ProtocolV2RequestChunk{}); err != nil {
+		t.Fatalf("lsRefsUpstream(tenant-a) error = %v", err)
+	}
+	if _, err := repo.lsRefsUpstream(tenantACtx, []*gitprotocolio.ProtocolV2RequestChunk{}); err != nil {
+		t.Fatalf("lsRefsUpstream(tenant-a) error = %v", err)
+	}
+	// A request for tenant B: a cache miss under its own TokenCacheKey,
+	// not tenant A's.
+	if _, err := repo.lsRefsUpstream(tenantBCtx, []*gitprotocolio.ProtocolV2RequestChunk{}); err != nil {
+		t.Fatalf("lsRefsUpstream(tenant-b) error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if tokenCallCount != 2 {
+		t.Errorf("TokenSourceCtx called %d times, want 2 (one per tenant; tenant-a's second request reused its cached token)", tokenCallCount)
+	}
+	if len(authHeaders) != 3 {
+		t.Fatalf("upstream received %d requests, want 3", len(authHeaders))
+	}
+	if authHeaders[0] != authHeaders[1] {
+		t.Errorf("tenant-a's two requests carried different Authorization headers: %q vs %q", authHeaders[0], authHeaders[1])
+	}
+	if authHeaders[0] == authHeaders[2] {
+		t.Errorf("tenant-a and tenant-b requests carried the same Authorization header %q, want distinct tokens per tenant", authHeaders[0])
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithUpstreamTimeoutAddsFallbackDeadline(t *testing.T) {
+	config := &ServerConfig{UpstreamFetchTimeout: time.Minute}
+
+	ctx, cancel := withUpstreamTimeout(context.Background(), config)
+	defer cancel()
+
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("withUpstreamTimeout() did not add a deadline for a context without one")
+	}
+}
+
+func TestWithUpstreamTimeoutLeavesExistingDeadlineAlone(t *testing.T) {
+	config := &ServerConfig{UpstreamFetchTimeout: time.Minute}
+
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Hour)
+	defer parentCancel()
+
+	ctx, cancel := withUpstreamTimeout(parent, config)
+	defer cancel()
+
+	parentDeadline, _ := parent.Deadline()
+	ctxDeadline, ok := ctx.Deadline()
+	if !ok || !ctxDeadline.Equal(parentDeadline) {
+		t.Errorf("withUpstreamTimeout() overrode the caller's own deadline: got %v, want %v", ctxDeadline, parentDeadline)
+	}
+}
+
+func TestWithUpstreamTimeoutNoopWithoutConfiguredTimeout(t *testing.T) {
+	config := &ServerConfig{}
+
+	ctx, cancel := withUpstreamTimeout(context.Background(), config)
+	defer cancel()
+
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("withUpstreamTimeout() added a deadline despite UpstreamFetchTimeout being unset")
+	}
+}
+
+// TestFetchUpstreamRespectsCanceledContext verifies that fetchUpstream
+// bails out on an already-canceled context instead of still shelling
+// out to git -- the point of threading ctx through runGit in the first
+// place.
+func TestFetchUpstreamRespectsCanceledContext(t *testing.T) {
+	repo := openArchiveTestRepo(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := repo.fetchUpstream(ctx); err == nil {
+		t.Error("fetchUpstream() with a canceled context succeeded, want an error")
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics exposes Prometheus collectors and a companion
+// Server-Sent Events stream for the signals goblet's ad hoc logging
+// previously only wrote to stdout: a repo falling back to the local
+// cache because the upstream is unreachable or disabled, a cached repo
+// going stale past a warning threshold, and a health component
+// transitioning between healthy/degraded/unhealthy. Together they let a
+// dashboard or a CI job react to these events instead of polling
+// /healthz?detailed=true.
+//
+// Collectors intentionally does not redeclare
+// goblet_health_check_duration_seconds: HealthChecker.EnableMetrics
+// already registers that histogram (see health_metrics.go).
+// HealthChecker.EnableEvents wires RecordComponentStatus and
+// EventHealthTransition into HealthChecker.Check. RecordCacheUpdate and
+// RecordUpstreamFallback still await their own integration step: the
+// managed-repository fetch loop and the ls-refs upstream/local fallback
+// decision both live in the HTTP/upload-pack handler, which (like the
+// caller UpstreamPolicy's doc comment describes for itself) is not
+// present in this checkout. This package is usable standalone via
+// Record* in the meantime.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ComponentStatus is the three-value health scale
+// goblet_health_component_status reports, distinct from the
+// goblet_health_check_status gauge's 1/0.5/0 scale: 0/1/2 matches the
+// convention dashboards built against Kubernetes-style probes already
+// expect.
+type ComponentStatus int
+
+const (
+	ComponentStatusUnhealthy ComponentStatus = 0
+	ComponentStatusDegraded  ComponentStatus = 1
+	ComponentStatusHealthy   ComponentStatus = 2
+)
+
+// Collectors holds the Prometheus collectors this package registers.
+// The zero value is not usable; create one with New.
+type Collectors struct {
+	cacheLastUpdate  *prometheus.GaugeVec
+	upstreamFallback *prometheus.CounterVec
+	componentStatus  *prometheus.GaugeVec
+}
+
+// New creates Collectors and registers them against reg.
+func New(reg prometheus.Registerer) (*Collectors, error) {
+	c := &Collectors{
+		cacheLastUpdate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "goblet_cache_last_update_seconds",
+			Help: "Unix timestamp, in seconds, of the last successful cache update for a repository.",
+		}, []string{"repo"}),
+		upstreamFallback: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "goblet_upstream_fallback_total",
+			Help: "Total number of requests served from the local cache because the upstream was unreachable or disabled, by reason.",
+		}, []string{"repo", "reason"}),
+		componentStatus: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "goblet_health_component_status",
+			Help: "Health status per component (2=healthy, 1=degraded, 0=unhealthy).",
+		}, []string{"component"}),
+	}
+	for _, coll := range []prometheus.Collector{c.cacheLastUpdate, c.upstreamFallback, c.componentStatus} {
+		if err := reg.Register(coll); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// RecordCacheUpdate sets repo's last-update gauge to t, normally called
+// right after a managed repository finishes a successful fetch from
+// upstream.
+func (c *Collectors) RecordCacheUpdate(repo string, t time.Time) {
+	c.cacheLastUpdate.WithLabelValues(repo).Set(float64(t.Unix()))
+}
+
+// RecordUpstreamFallback increments the fallback counter for repo and
+// reason (e.g. "upstream_disabled", "upstream_unreachable"), normally
+// called from the same code path that decides to serve lsRefsLocal
+// instead of lsRefsUpstream.
+func (c *Collectors) RecordUpstreamFallback(repo, reason string) {
+	c.upstreamFallback.WithLabelValues(repo, reason).Inc()
+}
+
+// RecordComponentStatus sets component's health gauge to status.
+func (c *Collectors) RecordComponentStatus(component string, status ComponentStatus) {
+	c.componentStatus.WithLabelValues(component).Set(float64(status))
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func gaugeValue(t *testing.T, c prometheus.Collector) float64 {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 1)
+	c.Collect(ch)
+	m := <-ch
+	var out dto.Metric
+	if err := m.Write(&out); err != nil {
+		t.Fatalf("cannot read metric: %v", err)
+	}
+	return out.GetGauge().GetValue()
+}
+
+func counterValue(t *testing.T, c prometheus.Collector) float64 {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 1)
+	c.Collect(ch)
+	m := <-ch
+	var out dto.Metric
+	if err := m.Write(&out); err != nil {
+		t.Fatalf("cannot read metric: %v", err)
+	}
+	return out.GetCounter().GetValue()
+}
+
+func TestRecordCacheUpdate(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c, err := New(reg)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	c.RecordCacheUpdate("github.com/owner/repo", now)
+
+	got := gaugeValue(t, c.cacheLastUpdate.WithLabelValues("github.com/owner/repo"))
+	if got != float64(now.Unix()) {
+		t.Errorf("cacheLastUpdate = %v, want %v", got, now.Unix())
+	}
+}
+
+func TestRecordUpstreamFallback(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c, err := New(reg)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	c.RecordUpstreamFallback("github.com/owner/repo", "upstream_disabled")
+	c.RecordUpstreamFallback("github.com/owner/repo", "upstream_disabled")
+
+	got := counterValue(t, c.upstreamFallback.WithLabelValues("github.com/owner/repo", "upstream_disabled"))
+	if got != 2 {
+		t.Errorf("upstreamFallback = %v, want 2", got)
+	}
+}
+
+func TestRecordComponentStatus(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c, err := New(reg)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	c.RecordComponentStatus("storage", ComponentStatusDegraded)
+	if got := gaugeValue(t, c.componentStatus.WithLabelValues("storage")); got != float64(ComponentStatusDegraded) {
+		t.Errorf("componentStatus = %v, want %v", got, ComponentStatusDegraded)
+	}
+}
+
+func TestNewRejectsDuplicateRegistration(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if _, err := New(reg); err != nil {
+		t.Fatalf("first New() error: %v", err)
+	}
+	if _, err := New(reg); err == nil {
+		t.Errorf("second New() against the same registry should fail on duplicate collectors")
+	}
+}
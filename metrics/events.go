@@ -0,0 +1,163 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// EventType identifies what kind of Event a record describes.
+type EventType string
+
+const (
+	// EventUpstreamFallback fires whenever a request is served from the
+	// local cache instead of the upstream.
+	EventUpstreamFallback EventType = "upstream_fallback"
+	// EventCacheStale fires when a repository's cache age crosses
+	// featureflag.StaleCacheWarningThreshold.
+	EventCacheStale EventType = "cache_stale"
+	// EventHealthTransition fires when a health component's status
+	// changes from its previously reported value.
+	EventHealthTransition EventType = "health_transition"
+)
+
+// Event is one JSON record streamed over an EventStream's SSE endpoint.
+type Event struct {
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+
+	// Repo is set for EventUpstreamFallback and EventCacheStale.
+	Repo string `json:"repo,omitempty"`
+	// Reason is set for EventUpstreamFallback (e.g.
+	// "upstream_disabled") and EventCacheStale (the age that tripped
+	// the threshold, formatted as a duration string).
+	Reason string `json:"reason,omitempty"`
+
+	// Component, PreviousStatus, and Status are set for
+	// EventHealthTransition.
+	Component      string `json:"component,omitempty"`
+	PreviousStatus string `json:"previous_status,omitempty"`
+	Status         string `json:"status,omitempty"`
+}
+
+// defaultSubscriberBuffer bounds how many Events a slow SSE consumer can
+// fall behind by before Publish starts dropping its oldest queued
+// event to make room for the newest one.
+const defaultSubscriberBuffer = 64
+
+// EventStream fans Events out to any number of concurrent SSE
+// subscribers. Each subscriber has its own fixed-size ring buffer, so
+// one slow consumer drops its own oldest events instead of blocking
+// Publish or starving the other subscribers.
+type EventStream struct {
+	bufferSize int
+
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewEventStream creates an EventStream whose subscriber buffers hold
+// bufferSize events each. A bufferSize <= 0 uses
+// defaultSubscriberBuffer.
+func NewEventStream(bufferSize int) *EventStream {
+	if bufferSize <= 0 {
+		bufferSize = defaultSubscriberBuffer
+	}
+	return &EventStream{
+		bufferSize:  bufferSize,
+		subscribers: map[chan Event]struct{}{},
+	}
+}
+
+// Publish delivers e to every current subscriber. A subscriber whose
+// buffer is full has its oldest queued event dropped to make room,
+// rather than blocking this call.
+func (s *EventStream) Publish(e Event) {
+	s.mu.Lock()
+	chans := make([]chan Event, 0, len(s.subscribers))
+	for ch := range s.subscribers {
+		chans = append(chans, ch)
+	}
+	s.mu.Unlock()
+
+	for _, ch := range chans {
+		select {
+		case ch <- e:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- e:
+			default:
+			}
+		}
+	}
+}
+
+func (s *EventStream) subscribe() chan Event {
+	ch := make(chan Event, s.bufferSize)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+func (s *EventStream) unsubscribe(ch chan Event) {
+	s.mu.Lock()
+	delete(s.subscribers, ch)
+	s.mu.Unlock()
+}
+
+// ServeHTTP streams Events to the client as Server-Sent Events until
+// the request's context is done. Mount it at "/events" so dashboards
+// and CI systems can watch fallback/staleness/health-transition
+// activity live instead of polling /healthz?detailed=true.
+func (s *EventStream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := s.subscribe()
+	defer s.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-ch:
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventStreamPublishDropsOldestOnFullBuffer(t *testing.T) {
+	s := NewEventStream(2)
+	ch := s.subscribe()
+	defer s.unsubscribe(ch)
+
+	s.Publish(Event{Type: EventCacheStale, Repo: "first"})
+	s.Publish(Event{Type: EventCacheStale, Repo: "second"})
+	s.Publish(Event{Type: EventCacheStale, Repo: "third"})
+
+	got := []string{(<-ch).Repo, (<-ch).Repo}
+	want := []string{"second", "third"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %q, want %q (oldest should have been dropped)", i, got[i], want[i])
+		}
+	}
+}
+
+func TestEventStreamPublishIgnoresUnsubscribed(t *testing.T) {
+	s := NewEventStream(1)
+	// No subscribers: Publish must not block or panic.
+	s.Publish(Event{Type: EventUpstreamFallback, Repo: "github.com/owner/repo"})
+}
+
+func TestEventStreamServeHTTPStreamsEvents(t *testing.T) {
+	s := NewEventStream(4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		s.ServeHTTP(w, req)
+		close(done)
+	}()
+
+	// Give ServeHTTP time to subscribe before publishing.
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mu.Lock()
+		n := len(s.subscribers)
+		s.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("ServeHTTP never subscribed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	s.Publish(Event{Type: EventHealthTransition, Component: "storage", Status: "degraded"})
+	cancel()
+	<-done
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(w.Body.String()))
+	var sawData bool
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data: ") && strings.Contains(line, `"degraded"`) {
+			sawData = true
+		}
+	}
+	if !sawData {
+		t.Errorf("SSE body did not contain the published event: %q", w.Body.String())
+	}
+}
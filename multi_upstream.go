@@ -0,0 +1,268 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/oauth2"
+	"gopkg.in/yaml.v3"
+)
+
+// MultiUpstreamRule routes requests whose path starts with PathPrefix to
+// UpstreamURL, authenticating them with TokenSource, so a single goblet
+// instance can front several independently-authenticated upstreams (for
+// example GitHub, GitLab, and an on-prem Gitea) instead of the one
+// ServerConfig.TokenSource every repository otherwise shares.
+type MultiUpstreamRule struct {
+	// Name identifies this rule in logs and as the "rule" label of the
+	// goblet_multi_upstream_requests_total Prometheus counter; it does
+	// not need to match PathPrefix.
+	Name string
+	// PathPrefix is matched against the incoming request path. The first
+	// rule (in order) whose PathPrefix prefixes the path wins; the
+	// matched prefix is stripped before the remainder is appended to
+	// UpstreamURL.Path.
+	PathPrefix string
+	// UpstreamURL is the base URL requests matching PathPrefix are
+	// rewritten to.
+	UpstreamURL *url.URL
+	// TokenSource mints the upstream credential for this rule, in the
+	// same func(*url.URL) (*oauth2.Token, error) shape as
+	// ServerConfig.TokenSource. Nil means requests to this upstream carry
+	// no token, the way ServerConfig.TokenSource being nil does today.
+	TokenSource func(*url.URL) (*oauth2.Token, error)
+}
+
+// MultiUpstream holds an ordered set of MultiUpstreamRule and produces a
+// URLCanonializer and a TokenSource, both consulting the same rule table,
+// for ServerConfig to use in place of a single static upstream.
+type MultiUpstream struct {
+	Rules []MultiUpstreamRule
+
+	metrics *multiUpstreamMetrics
+}
+
+// NewMultiUpstream returns a MultiUpstream serving rules in the given
+// order; earlier rules take priority when more than one PathPrefix
+// matches a request.
+func NewMultiUpstream(rules []MultiUpstreamRule) *MultiUpstream {
+	return &MultiUpstream{Rules: rules}
+}
+
+// match returns the first rule whose PathPrefix prefixes path at a "/"
+// boundary, along with the remainder of path after the prefix is
+// stripped. A boundary check (rather than a bare strings.HasPrefix)
+// keeps a rule configured with PathPrefix "/gh" from also matching
+// "/ghenterprise/..." or "/gh-other/...", which would route those
+// requests -- and the upstream credential that comes with them -- to
+// the wrong rule.
+func (m *MultiUpstream) match(path string) (rule *MultiUpstreamRule, rest string, ok bool) {
+	for i := range m.Rules {
+		r := &m.Rules[i]
+		if pathHasPrefixBoundary(path, r.PathPrefix) {
+			return r, strings.TrimPrefix(path, r.PathPrefix), true
+		}
+	}
+	return nil, "", false
+}
+
+// pathHasPrefixBoundary reports whether path starts with prefix and
+// either is exactly prefix or continues with a "/", so "/gh" matches
+// "/gh" and "/gh/repo" but not "/ghenterprise".
+func pathHasPrefixBoundary(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/")
+}
+
+// CanonicalizeURL implements the ServerConfig.URLCanonializer signature:
+// it finds the rule whose PathPrefix matches u, strips that prefix, and
+// rewrites scheme, host, and path to UpstreamURL's.
+func (m *MultiUpstream) CanonicalizeURL(u *url.URL) (*url.URL, error) {
+	rule, rest, ok := m.match(u.Path)
+	if !ok {
+		err := fmt.Errorf("multiupstream: no rule matches path %q", u.Path)
+		m.observe("", "route", err)
+		return nil, err
+	}
+	out := *rule.UpstreamURL
+	out.Path = joinUpstreamPath(rule.UpstreamURL.Path, rest)
+	m.observe(rule.Name, "route", nil)
+	return &out, nil
+}
+
+// TokenSource implements the ServerConfig.TokenSource signature: it finds
+// the rule whose UpstreamURL.Host matches u -- the same URL
+// CanonicalizeURL just produced -- and delegates to that rule's
+// TokenSource.
+func (m *MultiUpstream) TokenSource(u *url.URL) (*oauth2.Token, error) {
+	rule := m.ruleForUpstream(u)
+	if rule == nil {
+		err := fmt.Errorf("multiupstream: no rule's upstream matches %s", u)
+		m.observe("", "token", err)
+		return nil, err
+	}
+	if rule.TokenSource == nil {
+		return nil, nil
+	}
+	tok, err := rule.TokenSource(u)
+	m.observe(rule.Name, "token", err)
+	return tok, err
+}
+
+// ruleForUpstream finds the rule whose canonicalized URL u came from, so
+// TokenSource can recover which rule applies without threading the rule
+// through managed_repository's TokenSource call. Matching on Host alone
+// would conflate two rules that front the same upstream host (e.g. one
+// org carved out with its own credentials and a narrower PathPrefix than
+// a general-purpose rule for the same host), so this also requires
+// UpstreamURL.Path to prefix u.Path, and prefers the longest such path
+// match (CanonicalizeURL, by contrast, takes the first rule in order
+// whose PathPrefix matches).
+func (m *MultiUpstream) ruleForUpstream(u *url.URL) *MultiUpstreamRule {
+	var best *MultiUpstreamRule
+	for i := range m.Rules {
+		r := &m.Rules[i]
+		if r.UpstreamURL.Host != u.Host {
+			continue
+		}
+		if !strings.HasPrefix(u.Path, r.UpstreamURL.Path) {
+			continue
+		}
+		if best == nil || len(r.UpstreamURL.Path) > len(best.UpstreamURL.Path) {
+			best = r
+		}
+	}
+	return best
+}
+
+// joinUpstreamPath appends rest to base the way net/http/httputil's
+// ReverseProxy joins a stripped prefix back onto its target, avoiding a
+// doubled or missing slash at the seam.
+func joinUpstreamPath(base, rest string) string {
+	if rest == "" {
+		return base
+	}
+	baseSlash := strings.HasSuffix(base, "/")
+	restSlash := strings.HasPrefix(rest, "/")
+	switch {
+	case baseSlash && restSlash:
+		return base + rest[1:]
+	case !baseSlash && !restSlash:
+		return base + "/" + rest
+	default:
+		return base + rest
+	}
+}
+
+// multiUpstreamMetrics holds the Prometheus collector counting rule
+// outcomes. It is only non-nil once EnableMetrics has been called, so
+// MultiUpstream stays free of any Prometheus dependency by default --
+// the same convention HealthChecker.EnableMetrics uses for healthMetrics.
+type multiUpstreamMetrics struct {
+	outcomes *prometheus.CounterVec
+}
+
+// EnableMetrics registers the goblet_multi_upstream_requests_total
+// counter with reg, labeled by rule name, operation ("route" or
+// "token"), and outcome ("ok" or "error").
+func (m *MultiUpstream) EnableMetrics(reg prometheus.Registerer) error {
+	outcomes := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "goblet_multi_upstream_requests_total",
+		Help: "Number of MultiUpstream routing and token decisions, by rule, operation, and outcome.",
+	}, []string{"rule", "operation", "outcome"})
+	if err := reg.Register(outcomes); err != nil {
+		return err
+	}
+	m.metrics = &multiUpstreamMetrics{outcomes: outcomes}
+	return nil
+}
+
+// observe is a no-op until EnableMetrics has been called.
+func (m *MultiUpstream) observe(rule, operation string, err error) {
+	if m.metrics == nil {
+		return
+	}
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	m.metrics.outcomes.WithLabelValues(rule, operation, outcome).Inc()
+}
+
+// MultiUpstreamRuleConfig is one entry of a MultiUpstreamFileConfig,
+// the subset of MultiUpstreamRule that can be expressed as YAML/JSON;
+// TokenSource has no serializable form, so BuildRules pairs each entry
+// with a TokenSource supplied by the caller instead.
+type MultiUpstreamRuleConfig struct {
+	Name        string `json:"name" yaml:"name"`
+	PathPrefix  string `json:"path_prefix" yaml:"path_prefix"`
+	UpstreamURL string `json:"upstream_url" yaml:"upstream_url"`
+}
+
+// MultiUpstreamFileConfig is the schema for a MultiUpstream rule file, so
+// operators can front GitHub, GitLab, and an on-prem Gitea through one
+// goblet instance by editing a config file rather than recompiling.
+type MultiUpstreamFileConfig struct {
+	Rules []MultiUpstreamRuleConfig `json:"rules" yaml:"rules"`
+}
+
+// LoadMultiUpstreamConfig reads and parses path as YAML (.yaml/.yml) or
+// JSON (anything else), mirroring goblet-server's loadFileConfig.
+func LoadMultiUpstreamConfig(path string) (*MultiUpstreamFileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read multi-upstream config %q: %w", path, err)
+	}
+	var fc MultiUpstreamFileConfig
+	if strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
+		if err := yaml.Unmarshal(data, &fc); err != nil {
+			return nil, fmt.Errorf("cannot parse multi-upstream config %q as YAML: %w", path, err)
+		}
+	} else {
+		if err := json.Unmarshal(data, &fc); err != nil {
+			return nil, fmt.Errorf("cannot parse multi-upstream config %q as JSON: %w", path, err)
+		}
+	}
+	return &fc, nil
+}
+
+// BuildRules resolves fc's entries into MultiUpstreamRule values ready
+// for NewMultiUpstream, pairing each entry with tokenSources[Name]. A
+// name with no entry in tokenSources gets a nil TokenSource, the same as
+// leaving ServerConfig.TokenSource unset.
+func (fc *MultiUpstreamFileConfig) BuildRules(tokenSources map[string]func(*url.URL) (*oauth2.Token, error)) ([]MultiUpstreamRule, error) {
+	rules := make([]MultiUpstreamRule, 0, len(fc.Rules))
+	for _, rc := range fc.Rules {
+		u, err := url.Parse(rc.UpstreamURL)
+		if err != nil {
+			return nil, fmt.Errorf("multi-upstream rule %q: invalid upstream_url %q: %w", rc.Name, rc.UpstreamURL, err)
+		}
+		rules = append(rules, MultiUpstreamRule{
+			Name:        rc.Name,
+			PathPrefix:  rc.PathPrefix,
+			UpstreamURL: u,
+			TokenSource: tokenSources[rc.Name],
+		})
+	}
+	return rules, nil
+}
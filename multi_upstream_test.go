@@ -0,0 +1,281 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/oauth2"
+)
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("cannot parse %q: %v", raw, err)
+	}
+	return u
+}
+
+func TestMultiUpstreamCanonicalizeURLRewritesPrefix(t *testing.T) {
+	multi := NewMultiUpstream([]MultiUpstreamRule{
+		{Name: "github", PathPrefix: "/gh", UpstreamURL: mustParseURL(t, "https://github.com")},
+		{Name: "gitlab", PathPrefix: "/gl", UpstreamURL: mustParseURL(t, "https://gitlab.com")},
+	})
+
+	got, err := multi.CanonicalizeURL(mustParseURL(t, "/gh/org/repo/info/refs"))
+	if err != nil {
+		t.Fatalf("CanonicalizeURL() error = %v", err)
+	}
+	if want := "https://github.com/org/repo/info/refs"; got.String() != want {
+		t.Errorf("CanonicalizeURL() = %q, want %q", got.String(), want)
+	}
+}
+
+func TestMultiUpstreamCanonicalizeURLFirstMatchWins(t *testing.T) {
+	multi := NewMultiUpstream([]MultiUpstreamRule{
+		{Name: "specific", PathPrefix: "/gh/internal", UpstreamURL: mustParseURL(t, "https://internal.example.com")},
+		{Name: "general", PathPrefix: "/gh", UpstreamURL: mustParseURL(t, "https://github.com")},
+	})
+
+	got, err := multi.CanonicalizeURL(mustParseURL(t, "/gh/internal/repo"))
+	if err != nil {
+		t.Fatalf("CanonicalizeURL() error = %v", err)
+	}
+	if want := "https://internal.example.com/repo"; got.String() != want {
+		t.Errorf("CanonicalizeURL() = %q, want %q (ordered rules should let a narrower prefix win)", got.String(), want)
+	}
+}
+
+func TestMultiUpstreamCanonicalizeURLDoesNotMatchAdjacentPrefix(t *testing.T) {
+	multi := NewMultiUpstream([]MultiUpstreamRule{
+		{Name: "github", PathPrefix: "/gh", UpstreamURL: mustParseURL(t, "https://github.com")},
+	})
+
+	if _, err := multi.CanonicalizeURL(mustParseURL(t, "/ghenterprise/org/repo/info/refs")); err == nil {
+		t.Error("CanonicalizeURL() matched PathPrefix \"/gh\" against \"/ghenterprise/...\", want no match since \"/gh\" isn't a path-segment prefix of it")
+	}
+}
+
+func TestMultiUpstreamCanonicalizeURLNoMatch(t *testing.T) {
+	multi := NewMultiUpstream([]MultiUpstreamRule{
+		{Name: "github", PathPrefix: "/gh", UpstreamURL: mustParseURL(t, "https://github.com")},
+	})
+
+	if _, err := multi.CanonicalizeURL(mustParseURL(t, "/unknown/org/repo")); err == nil {
+		t.Error("CanonicalizeURL() succeeded for an unmatched prefix, want an error")
+	}
+}
+
+func TestMultiUpstreamTokenSourceDispatchesByRule(t *testing.T) {
+	var sawGitHub, sawGitLab bool
+	multi := NewMultiUpstream([]MultiUpstreamRule{
+		{
+			Name:        "github",
+			PathPrefix:  "/gh",
+			UpstreamURL: mustParseURL(t, "https://github.com"),
+			TokenSource: func(u *url.URL) (*oauth2.Token, error) {
+				sawGitHub = true
+				return &oauth2.Token{AccessToken: "github-token"}, nil
+			},
+		},
+		{
+			Name:        "gitlab",
+			PathPrefix:  "/gl",
+			UpstreamURL: mustParseURL(t, "https://gitlab.com"),
+			TokenSource: func(u *url.URL) (*oauth2.Token, error) {
+				sawGitLab = true
+				return &oauth2.Token{AccessToken: "gitlab-token"}, nil
+			},
+		},
+	})
+
+	upstream, err := multi.CanonicalizeURL(mustParseURL(t, "/gh/org/repo"))
+	if err != nil {
+		t.Fatalf("CanonicalizeURL() error = %v", err)
+	}
+	tok, err := multi.TokenSource(upstream)
+	if err != nil {
+		t.Fatalf("TokenSource() error = %v", err)
+	}
+	if tok.AccessToken != "github-token" {
+		t.Errorf("AccessToken = %q, want github-token", tok.AccessToken)
+	}
+	if !sawGitHub || sawGitLab {
+		t.Errorf("sawGitHub=%v sawGitLab=%v, want only sawGitHub", sawGitHub, sawGitLab)
+	}
+}
+
+func TestMultiUpstreamTokenSourceDisambiguatesSharedHost(t *testing.T) {
+	var sawInternal, sawGeneral bool
+	multi := NewMultiUpstream([]MultiUpstreamRule{
+		{
+			Name:        "internal",
+			PathPrefix:  "/gh/internal",
+			UpstreamURL: mustParseURL(t, "https://github.com/internal-org"),
+			TokenSource: func(u *url.URL) (*oauth2.Token, error) {
+				sawInternal = true
+				return &oauth2.Token{AccessToken: "internal-token"}, nil
+			},
+		},
+		{
+			Name:        "general",
+			PathPrefix:  "/gh",
+			UpstreamURL: mustParseURL(t, "https://github.com"),
+			TokenSource: func(u *url.URL) (*oauth2.Token, error) {
+				sawGeneral = true
+				return &oauth2.Token{AccessToken: "general-token"}, nil
+			},
+		},
+	})
+
+	upstream, err := multi.CanonicalizeURL(mustParseURL(t, "/gh/internal/repo"))
+	if err != nil {
+		t.Fatalf("CanonicalizeURL() error = %v", err)
+	}
+	tok, err := multi.TokenSource(upstream)
+	if err != nil {
+		t.Fatalf("TokenSource() error = %v", err)
+	}
+	if tok.AccessToken != "internal-token" {
+		t.Errorf("AccessToken = %q, want internal-token (the narrower rule sharing github.com's host)", tok.AccessToken)
+	}
+	if !sawInternal || sawGeneral {
+		t.Errorf("sawInternal=%v sawGeneral=%v, want only sawInternal for two rules sharing a host", sawInternal, sawGeneral)
+	}
+}
+
+func TestMultiUpstreamTokenSourceNilIsOptional(t *testing.T) {
+	multi := NewMultiUpstream([]MultiUpstreamRule{
+		{Name: "github", PathPrefix: "/gh", UpstreamURL: mustParseURL(t, "https://github.com")},
+	})
+
+	upstream, err := multi.CanonicalizeURL(mustParseURL(t, "/gh/org/repo"))
+	if err != nil {
+		t.Fatalf("CanonicalizeURL() error = %v", err)
+	}
+	tok, err := multi.TokenSource(upstream)
+	if err != nil {
+		t.Fatalf("TokenSource() error = %v", err)
+	}
+	if tok != nil {
+		t.Errorf("TokenSource() = %+v, want nil for a rule with no TokenSource", tok)
+	}
+}
+
+func TestMultiUpstreamTokenSourceNoMatchingRule(t *testing.T) {
+	multi := NewMultiUpstream([]MultiUpstreamRule{
+		{Name: "github", PathPrefix: "/gh", UpstreamURL: mustParseURL(t, "https://github.com")},
+	})
+
+	if _, err := multi.TokenSource(mustParseURL(t, "https://gitlab.com/org/repo")); err == nil {
+		t.Error("TokenSource() succeeded for an upstream with no matching rule, want an error")
+	}
+}
+
+func TestMultiUpstreamEnableMetricsCountsOutcomes(t *testing.T) {
+	multi := NewMultiUpstream([]MultiUpstreamRule{
+		{Name: "github", PathPrefix: "/gh", UpstreamURL: mustParseURL(t, "https://github.com")},
+	})
+	reg := prometheus.NewRegistry()
+	if err := multi.EnableMetrics(reg); err != nil {
+		t.Fatalf("EnableMetrics() error = %v", err)
+	}
+
+	if _, err := multi.CanonicalizeURL(mustParseURL(t, "/gh/org/repo")); err != nil {
+		t.Fatalf("CanonicalizeURL() error = %v", err)
+	}
+	if _, err := multi.CanonicalizeURL(mustParseURL(t, "/unmatched")); err == nil {
+		t.Fatal("CanonicalizeURL() succeeded for an unmatched prefix, want an error")
+	}
+
+	got, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	var found bool
+	for _, mf := range got {
+		if mf.GetName() == "goblet_multi_upstream_requests_total" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("goblet_multi_upstream_requests_total was not registered")
+	}
+}
+
+func TestLoadMultiUpstreamConfigJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.json")
+	const contents = `{"rules":[
+		{"name":"github","path_prefix":"/gh","upstream_url":"https://github.com"},
+		{"name":"gitlab","path_prefix":"/gl","upstream_url":"https://gitlab.com"}
+	]}`
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("cannot write config: %v", err)
+	}
+
+	fc, err := LoadMultiUpstreamConfig(path)
+	if err != nil {
+		t.Fatalf("LoadMultiUpstreamConfig() error = %v", err)
+	}
+	if len(fc.Rules) != 2 {
+		t.Fatalf("len(Rules) = %d, want 2", len(fc.Rules))
+	}
+
+	tokenSources := map[string]func(*url.URL) (*oauth2.Token, error){
+		"github": func(u *url.URL) (*oauth2.Token, error) { return &oauth2.Token{AccessToken: "gh"}, nil },
+	}
+	rules, err := fc.BuildRules(tokenSources)
+	if err != nil {
+		t.Fatalf("BuildRules() error = %v", err)
+	}
+	if rules[0].TokenSource == nil {
+		t.Error("rules[0].TokenSource = nil, want the github token source")
+	}
+	if rules[1].TokenSource != nil {
+		t.Error("rules[1].TokenSource != nil, want nil for a name with no configured source")
+	}
+}
+
+func TestLoadMultiUpstreamConfigYAML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.yaml")
+	const contents = "rules:\n  - name: gitea\n    path_prefix: /gitea\n    upstream_url: https://gitea.example.com\n"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("cannot write config: %v", err)
+	}
+
+	fc, err := LoadMultiUpstreamConfig(path)
+	if err != nil {
+		t.Fatalf("LoadMultiUpstreamConfig() error = %v", err)
+	}
+	if len(fc.Rules) != 1 || fc.Rules[0].Name != "gitea" {
+		t.Fatalf("Rules = %+v, want a single gitea rule", fc.Rules)
+	}
+}
+
+func TestMultiUpstreamConfigBuildRulesRejectsInvalidURL(t *testing.T) {
+	fc := &MultiUpstreamFileConfig{Rules: []MultiUpstreamRuleConfig{
+		{Name: "broken", PathPrefix: "/x", UpstreamURL: "://not-a-url"},
+	}}
+	if _, err := fc.BuildRules(nil); err == nil {
+		t.Error("BuildRules() succeeded for an invalid upstream_url, want an error")
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ProbeKind identifies which Kubernetes-style probe a registered
+// component feeds. Liveness only verifies the process is still running
+// (no external calls: a wedged dependency should never make Kubernetes
+// restart a process that's otherwise fine), readiness aggregates
+// whatever's needed to decide if the process should receive traffic,
+// and startup stays unhealthy until the process has finished its
+// initial warm-up.
+type ProbeKind string
+
+const (
+	ProbeKindLiveness  ProbeKind = "liveness"
+	ProbeKindReadiness ProbeKind = "readiness"
+	ProbeKindStartup   ProbeKind = "startup"
+)
+
+// ComponentCheckResult is one component's outcome within a
+// HealthCheckResponse, tagged with the probe it was registered under so
+// a caller inspecting Checks can tell a readiness failure from a
+// startup one without cross-referencing RegisterComponent calls.
+type ComponentCheckResult struct {
+	Name   string          `json:"name"`
+	Kind   ProbeKind       `json:"kind"`
+	Health ComponentHealth `json:"health"`
+}
+
+// ComponentOptions configures a component registered with
+// RegisterComponent.
+type ComponentOptions struct {
+	// Timeout bounds how long the component's check function may run
+	// before it's treated as unhealthy. Defaults to 5 seconds if zero.
+	Timeout time.Duration
+}
+
+// registeredComponent is a component registered through
+// RegisterComponent, run synchronously (unlike Check's CheckRegistry,
+// which polls on its own schedule) whenever its probe endpoint is hit,
+// since readiness/startup are expected to reflect current state rather
+// than a stale background sample.
+type registeredComponent struct {
+	name string
+	kind ProbeKind
+	fn   func(ctx context.Context) ComponentHealth
+	opts ComponentOptions
+}
+
+// RegisterComponent adds a component check that CheckKind folds into
+// the probe response for kind, run with its own timeout (opts.Timeout)
+// each time that probe is queried. Registering a second component under
+// the same name replaces the first.
+func (hc *HealthChecker) RegisterComponent(name string, kind ProbeKind, fn func(ctx context.Context) ComponentHealth, opts ComponentOptions) {
+	hc.componentsMu.Lock()
+	defer hc.componentsMu.Unlock()
+	if hc.components == nil {
+		hc.components = map[string]*registeredComponent{}
+	}
+	hc.components[name] = &registeredComponent{name: name, kind: kind, fn: fn, opts: opts}
+}
+
+// MarkStartupComplete records that the process has finished its
+// initial warm-up (e.g. the first successful cache fill), so
+// CheckKind(ctx, ProbeKindStartup) reports healthy from this point on.
+// Before the first call, startup reports unhealthy.
+func (hc *HealthChecker) MarkStartupComplete() {
+	hc.startupMu.Lock()
+	defer hc.startupMu.Unlock()
+	hc.startupWarm = true
+}
+
+// SetReadinessDegradedThreshold configures how many registered
+// readiness components may report unhealthy before /readyz itself
+// reports unhealthy rather than merely degraded. Defaults to 0: any
+// unhealthy readiness component makes the whole probe unhealthy.
+func (hc *HealthChecker) SetReadinessDegradedThreshold(n int) {
+	hc.readinessDegradedThreshold = n
+}
+
+// CheckKind runs the checks relevant to kind and returns the aggregate
+// HealthCheckResponse: liveness has no built-in checks of its own (see
+// ProbeKind's doc comment), readiness folds in storage and disk-cache
+// the same way Check does, and startup reports healthy only once
+// MarkStartupComplete has been called. Any components registered
+// through RegisterComponent for kind are folded in afterward, each
+// bounded by its own ComponentOptions.Timeout.
+func (hc *HealthChecker) CheckKind(ctx context.Context, kind ProbeKind) *HealthCheckResponse {
+	response := &HealthCheckResponse{
+		Status:     HealthStatusHealthy,
+		Timestamp:  time.Now(),
+		Version:    hc.version,
+		Components: make(map[string]ComponentHealth),
+	}
+
+	addBuiltin := func(name string, health ComponentHealth) {
+		response.Components[name] = health
+		response.Checks = append(response.Checks, ComponentCheckResult{Name: name, Kind: kind, Health: health})
+	}
+
+	switch kind {
+	case ProbeKindStartup:
+		hc.startupMu.Lock()
+		warm := hc.startupWarm
+		hc.startupMu.Unlock()
+
+		health := ComponentHealth{Status: HealthStatusUnhealthy, Message: "cache not yet warmed"}
+		if warm {
+			health = ComponentHealth{Status: HealthStatusHealthy, Message: "cache warmed"}
+		}
+		addBuiltin("startup", health)
+		if !warm {
+			response.Status = HealthStatusUnhealthy
+		}
+
+	case ProbeKindReadiness:
+		if hc.storageProvider != nil {
+			start := time.Now()
+			storageHealth := hc.checkStorage(ctx)
+			hc.recordMetric("storage", time.Since(start), storageHealth.Status)
+			addBuiltin("storage", storageHealth)
+			if storageHealth.Status == HealthStatusUnhealthy {
+				response.Status = HealthStatusDegraded
+			}
+		}
+
+		start := time.Now()
+		cacheHealth := hc.checkCache()
+		hc.recordMetric("cache", time.Since(start), cacheHealth.Status)
+		addBuiltin("cache", cacheHealth)
+		if cacheHealth.Status == HealthStatusUnhealthy {
+			response.Status = HealthStatusUnhealthy
+		}
+	}
+
+	hc.componentsMu.RLock()
+	components := make([]*registeredComponent, 0, len(hc.components))
+	for _, c := range hc.components {
+		if c.kind == kind {
+			components = append(components, c)
+		}
+	}
+	hc.componentsMu.RUnlock()
+
+	unhealthy := 0
+	for _, c := range components {
+		result := hc.runComponent(ctx, c)
+		response.Components[c.name] = result.Health
+		response.Checks = append(response.Checks, result)
+		if result.Health.Status == HealthStatusUnhealthy {
+			unhealthy++
+		}
+	}
+
+	if unhealthy > 0 {
+		if kind == ProbeKindReadiness && unhealthy <= hc.readinessDegradedThreshold {
+			if response.Status == HealthStatusHealthy {
+				response.Status = HealthStatusDegraded
+			}
+		} else {
+			response.Status = HealthStatusUnhealthy
+		}
+	}
+
+	return response
+}
+
+// runComponent executes c.fn bounded by its configured timeout (5
+// seconds if unset), treating a timeout the same as an unhealthy result
+// rather than letting a wedged component hang the probe request
+// forever.
+func (hc *HealthChecker) runComponent(ctx context.Context, c *registeredComponent) ComponentCheckResult {
+	timeout := c.opts.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	resultCh := make(chan ComponentHealth, 1)
+	go func() { resultCh <- c.fn(cctx) }()
+
+	var health ComponentHealth
+	select {
+	case health = <-resultCh:
+	case <-cctx.Done():
+		health = ComponentHealth{Status: HealthStatusUnhealthy, Message: "timed out"}
+	}
+
+	hc.recordMetric(c.name, time.Since(start), health.Status)
+	return ComponentCheckResult{Name: c.name, Kind: c.kind, Health: health}
+}
+
+// ServeLivez answers a Kubernetes liveness probe. It only ever reflects
+// whether the process can run this handler at all -- never a dependency
+// failure -- since liveness exists to let Kubernetes restart a wedged
+// process, not to reflect the health of things readiness already covers.
+func (hc *HealthChecker) ServeLivez(w http.ResponseWriter, r *http.Request) {
+	hc.serveProbe(w, r, ProbeKindLiveness)
+}
+
+// ServeReadyz answers a Kubernetes readiness probe, aggregating storage,
+// disk-cache, and any readiness components registered through
+// RegisterComponent.
+func (hc *HealthChecker) ServeReadyz(w http.ResponseWriter, r *http.Request) {
+	hc.serveProbe(w, r, ProbeKindReadiness)
+}
+
+// ServeStartupz answers a Kubernetes startup probe, reporting unhealthy
+// until MarkStartupComplete has been called.
+func (hc *HealthChecker) ServeStartupz(w http.ResponseWriter, r *http.Request) {
+	hc.serveProbe(w, r, ProbeKindStartup)
+}
+
+func (hc *HealthChecker) serveProbe(w http.ResponseWriter, r *http.Request, kind ProbeKind) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	health := hc.CheckKind(ctx, kind)
+
+	status := http.StatusOK
+	if health.Status == HealthStatusUnhealthy {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(health)
+}
+
+// probeState is embedded in HealthChecker (see health.go) to back
+// RegisterComponent, MarkStartupComplete, and
+// SetReadinessDegradedThreshold without cluttering HealthChecker's
+// original fields.
+type probeState struct {
+	componentsMu sync.RWMutex
+	components   map[string]*registeredComponent
+
+	startupMu   sync.Mutex
+	startupWarm bool
+
+	readinessDegradedThreshold int
+}
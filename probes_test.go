@@ -0,0 +1,161 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckKindLivenessHasNoBuiltinChecks(t *testing.T) {
+	hc := NewHealthChecker(nil, "1.0.0")
+
+	resp := hc.CheckKind(context.Background(), ProbeKindLiveness)
+
+	if resp.Status != HealthStatusHealthy {
+		t.Errorf("Status = %s, want %s", resp.Status, HealthStatusHealthy)
+	}
+	if len(resp.Checks) != 0 {
+		t.Errorf("Checks = %+v, want empty for liveness with no registered components", resp.Checks)
+	}
+}
+
+func TestCheckKindStartupUnhealthyUntilMarked(t *testing.T) {
+	hc := NewHealthChecker(nil, "1.0.0")
+
+	resp := hc.CheckKind(context.Background(), ProbeKindStartup)
+	if resp.Status != HealthStatusUnhealthy {
+		t.Errorf("Status before MarkStartupComplete = %s, want %s", resp.Status, HealthStatusUnhealthy)
+	}
+
+	hc.MarkStartupComplete()
+
+	resp = hc.CheckKind(context.Background(), ProbeKindStartup)
+	if resp.Status != HealthStatusHealthy {
+		t.Errorf("Status after MarkStartupComplete = %s, want %s", resp.Status, HealthStatusHealthy)
+	}
+}
+
+func TestCheckKindReadinessAggregatesBuiltinComponents(t *testing.T) {
+	hc := NewHealthChecker(&mockStorageProvider{}, "1.0.0")
+
+	resp := hc.CheckKind(context.Background(), ProbeKindReadiness)
+	if resp.Status != HealthStatusHealthy {
+		t.Errorf("Status = %s, want %s", resp.Status, HealthStatusHealthy)
+	}
+
+	names := map[string]bool{}
+	for _, c := range resp.Checks {
+		if c.Kind != ProbeKindReadiness {
+			t.Errorf("Checks entry %q has Kind %q, want %q", c.Name, c.Kind, ProbeKindReadiness)
+		}
+		names[c.Name] = true
+	}
+	for _, want := range []string{"storage", "cache"} {
+		if !names[want] {
+			t.Errorf("Checks missing entry %q", want)
+		}
+	}
+}
+
+func TestRegisterComponentFeedsItsKindOnly(t *testing.T) {
+	hc := NewHealthChecker(nil, "1.0.0")
+	hc.RegisterComponent("upstream", ProbeKindReadiness, func(ctx context.Context) ComponentHealth {
+		return ComponentHealth{Status: HealthStatusHealthy, Message: "reachable"}
+	}, ComponentOptions{})
+
+	readyResp := hc.CheckKind(context.Background(), ProbeKindReadiness)
+	if readyResp.Components["upstream"].Status != HealthStatusHealthy {
+		t.Errorf("readiness upstream component = %+v, want healthy", readyResp.Components["upstream"])
+	}
+
+	liveResp := hc.CheckKind(context.Background(), ProbeKindLiveness)
+	if _, ok := liveResp.Components["upstream"]; ok {
+		t.Error("liveness response unexpectedly included a readiness-only component")
+	}
+}
+
+func TestRegisterComponentUnhealthyMakesReadinessUnhealthy(t *testing.T) {
+	hc := NewHealthChecker(nil, "1.0.0")
+	hc.RegisterComponent("upstream", ProbeKindReadiness, func(ctx context.Context) ComponentHealth {
+		return ComponentHealth{Status: HealthStatusUnhealthy, Message: "unreachable"}
+	}, ComponentOptions{})
+
+	resp := hc.CheckKind(context.Background(), ProbeKindReadiness)
+	if resp.Status != HealthStatusUnhealthy {
+		t.Errorf("Status = %s, want %s", resp.Status, HealthStatusUnhealthy)
+	}
+}
+
+func TestRegisterComponentWithinDegradedThresholdStaysReady(t *testing.T) {
+	hc := NewHealthChecker(nil, "1.0.0")
+	hc.SetReadinessDegradedThreshold(1)
+	hc.RegisterComponent("flaky", ProbeKindReadiness, func(ctx context.Context) ComponentHealth {
+		return ComponentHealth{Status: HealthStatusUnhealthy, Message: "flaky"}
+	}, ComponentOptions{})
+
+	resp := hc.CheckKind(context.Background(), ProbeKindReadiness)
+	if resp.Status != HealthStatusDegraded {
+		t.Errorf("Status = %s, want %s", resp.Status, HealthStatusDegraded)
+	}
+}
+
+func TestRunComponentTimesOut(t *testing.T) {
+	hc := NewHealthChecker(nil, "1.0.0")
+	hc.RegisterComponent("slow", ProbeKindReadiness, func(ctx context.Context) ComponentHealth {
+		<-ctx.Done()
+		return ComponentHealth{Status: HealthStatusHealthy}
+	}, ComponentOptions{Timeout: 10 * time.Millisecond})
+
+	resp := hc.CheckKind(context.Background(), ProbeKindReadiness)
+	if resp.Components["slow"].Status != HealthStatusUnhealthy {
+		t.Errorf("slow component status = %s, want %s", resp.Components["slow"].Status, HealthStatusUnhealthy)
+	}
+}
+
+func TestServeLivezReadyzStartupzHandlers(t *testing.T) {
+	hc := NewHealthChecker(&mockStorageProvider{}, "1.0.0")
+
+	for _, tt := range []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"livez", hc.ServeLivez},
+		{"readyz", hc.ServeReadyz},
+		{"startupz", hc.ServeStartupz},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/"+tt.name, nil)
+			rec := httptest.NewRecorder()
+			tt.handler(rec, req)
+
+			if tt.name == "startupz" {
+				if rec.Code != http.StatusServiceUnavailable {
+					t.Errorf("status = %d, want %d before MarkStartupComplete", rec.Code, http.StatusServiceUnavailable)
+				}
+				return
+			}
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+		})
+	}
+}
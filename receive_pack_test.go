@@ -0,0 +1,335 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// readRefs returns the output of `git for-each-ref` against gitDir, used
+// to confirm a local mirror's state is byte-for-byte unchanged across a
+// failed push.
+func readRefs(t *testing.T, gitDir string) string {
+	t.Helper()
+	cmd := exec.Command(gitBinary, "for-each-ref")
+	cmd.Dir = gitDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git for-each-ref failed: %v\n%s", err, out)
+	}
+	return string(out)
+}
+
+func openReceivePackTestRepo(t *testing.T, upstreamURL *url.URL, errorReporter func(*http.Request, error)) *managedRepository {
+	t.Helper()
+
+	testToken := &oauth2.Token{AccessToken: "test-token", TokenType: "Bearer"}
+	config := &ServerConfig{
+		LocalDiskCacheRoot: t.TempDir(),
+		URLCanonializer:    func(u *url.URL) (*url.URL, error) { return upstreamURL, nil },
+		RequestAuthorizer:  func(r *http.Request) error { return nil },
+		TokenSource:        func(u *url.URL) (*oauth2.Token, error) { return testToken, nil },
+		ErrorReporter:      errorReporter,
+	}
+
+	repo, err := openManagedRepository(config, upstreamURL)
+	if err != nil {
+		t.Fatalf("openManagedRepository() failed: %v", err)
+	}
+	return repo
+}
+
+// TestManagedRepositoryPushUpstreamRelaysReportStatus verifies that
+// PushUpstream forwards the client's request body to the upstream's
+// git-receive-pack endpoint and copies the upstream's report-status
+// response back unmodified.
+func TestManagedRepositoryPushUpstreamRelaysReportStatus(t *testing.T) {
+	var gotBody, gotContentType, gotAuth string
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repo/git-receive-pack" {
+			t.Errorf("request path = %q, want .../git-receive-pack", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		gotContentType = r.Header.Get("Content-Type")
+		gotAuth = r.Header.Get("Authorization")
+
+		w.Header().Set("Content-Type", "application/x-git-receive-pack-result")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("000eunpack ok\n0019ok refs/heads/main\n0000"))
+	}))
+	defer upstreamServer.Close()
+
+	upstreamURL, _ := url.Parse(upstreamServer.URL + "/repo")
+	repo := openReceivePackTestRepo(t, upstreamURL, nil)
+
+	var out bytes.Buffer
+	if err := repo.PushUpstream(context.Background(), strings.NewReader("0032command-list-and-pack-data"), &out); err != nil {
+		t.Fatalf("PushUpstream() failed: %v", err)
+	}
+
+	if gotBody != "0032command-list-and-pack-data" {
+		t.Errorf("upstream received body %q, want the client's request verbatim", gotBody)
+	}
+	if gotContentType != "application/x-git-receive-pack-request" {
+		t.Errorf("Content-Type sent upstream = %q, want application/x-git-receive-pack-request", gotContentType)
+	}
+	if gotAuth != "Bearer test-token" {
+		t.Errorf("Authorization sent upstream = %q, want Bearer test-token", gotAuth)
+	}
+	if out.String() != "000eunpack ok\n0019ok refs/heads/main\n0000" {
+		t.Errorf("relayed report-status = %q, want the upstream's response verbatim", out.String())
+	}
+}
+
+// TestManagedRepositoryPushUpstreamDenial verifies that a 401/403 from
+// the upstream surfaces as an *UpstreamAuthError rather than a generic
+// error, the same as postUpstreamGitRequest's upload-pack path.
+func TestManagedRepositoryPushUpstreamDenial(t *testing.T) {
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer upstreamServer.Close()
+
+	upstreamURL, _ := url.Parse(upstreamServer.URL + "/repo")
+	repo := openReceivePackTestRepo(t, upstreamURL, nil)
+
+	var out bytes.Buffer
+	err := repo.PushUpstream(context.Background(), strings.NewReader("0000"), &out)
+	if err == nil {
+		t.Fatal("PushUpstream() succeeded against a 403 upstream, want an error")
+	}
+	if !isUpstreamAuthError(err) {
+		t.Errorf("PushUpstream() error = %v (%T), want an *UpstreamAuthError", err, err)
+	}
+}
+
+// TestManagedRepositoryPushUpstreamSchedulesRefsRefresh verifies that a
+// successful push triggers a background fetchUpstream, rather than
+// requiring the caller to explicitly refresh the local mirror.
+func TestManagedRepositoryPushUpstreamSchedulesRefsRefresh(t *testing.T) {
+	var mu sync.Mutex
+	var receivePackRequests, infoRefsRequests int
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if strings.HasSuffix(r.URL.Path, "/git-receive-pack") {
+			receivePackRequests++
+		} else {
+			infoRefsRequests++
+		}
+		mu.Unlock()
+
+		if strings.HasSuffix(r.URL.Path, "/git-receive-pack") {
+			w.Header().Set("Content-Type", "application/x-git-receive-pack-result")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("0000"))
+			return
+		}
+		// fetchUpstream shells out to `git fetch`, which this fake HTTP
+		// upstream can't actually serve -- returning an error response
+		// is enough to observe that a fetch was attempted at all.
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer upstreamServer.Close()
+
+	upstreamURL, _ := url.Parse(upstreamServer.URL + "/repo")
+
+	refreshDone := make(chan struct{}, 1)
+	repo := openReceivePackTestRepo(t, upstreamURL, func(r *http.Request, err error) {
+		select {
+		case refreshDone <- struct{}{}:
+		default:
+		}
+	})
+
+	var out bytes.Buffer
+	if err := repo.PushUpstream(context.Background(), strings.NewReader("0000"), &out); err != nil {
+		t.Fatalf("PushUpstream() failed: %v", err)
+	}
+
+	select {
+	case <-refreshDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ScheduleRefsRefresh did not trigger a background fetch within 5s")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if receivePackRequests != 1 {
+		t.Errorf("upstream received %d git-receive-pack requests, want 1", receivePackRequests)
+	}
+	if infoRefsRequests == 0 {
+		t.Error("upstream received no requests from the background refresh after a successful push")
+	}
+}
+
+// TestReceivePackHandlerDisabledReturns501 verifies that receivePackHandler
+// refuses every request with a 501 unless cfg.EnableReceivePack is set,
+// matching goblet's read-only-by-default behavior.
+func TestReceivePackHandlerDisabledReturns501(t *testing.T) {
+	upstreamURL, _ := url.Parse("http://upstream.invalid/repo")
+	repo := openReceivePackTestRepo(t, upstreamURL, nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/repo/git-receive-pack", strings.NewReader("0000"))
+
+	receivePackHandler(ReceivePackHandlerConfig{}, repo, w, r)
+
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotImplemented)
+	}
+}
+
+// TestReceivePackHandlerAuthorizerRejection verifies that a non-nil
+// ReceivePackAuthorizer error 403s the request without ever contacting
+// the upstream.
+func TestReceivePackHandlerAuthorizerRejection(t *testing.T) {
+	var upstreamRequests int32
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamRequests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamServer.Close()
+
+	upstreamURL, _ := url.Parse(upstreamServer.URL + "/repo")
+	repo := openReceivePackTestRepo(t, upstreamURL, nil)
+
+	cfg := ReceivePackHandlerConfig{
+		EnableReceivePack: true,
+		ReceivePackAuthorizer: func(r *http.Request) error {
+			return pushDeniedError{}
+		},
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/repo/git-receive-pack", strings.NewReader("0000"))
+
+	receivePackHandler(cfg, repo, w, r)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if upstreamRequests != 0 {
+		t.Errorf("upstream received %d requests, want 0 (a rejected push should never reach the upstream)", upstreamRequests)
+	}
+}
+
+// pushDeniedError is a minimal error implementation for
+// TestReceivePackHandlerAuthorizerRejection; the handler only inspects
+// whether ReceivePackAuthorizer returned non-nil, not any particular
+// error type.
+type pushDeniedError struct{}
+
+func (pushDeniedError) Error() string { return "not authorized to push" }
+
+// TestReceivePackHandlerSuccessfulRoundtrip verifies the full handler
+// path end to end: a gzip-compressed request body is decoded, relayed
+// to the upstream, and the upstream's report-status response is copied
+// back to the client verbatim.
+func TestReceivePackHandlerSuccessfulRoundtrip(t *testing.T) {
+	var gotBody string
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.Header().Set("Content-Type", "application/x-git-receive-pack-result")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("000eunpack ok\n0019ok refs/heads/main\n0000"))
+	}))
+	defer upstreamServer.Close()
+
+	upstreamURL, _ := url.Parse(upstreamServer.URL + "/repo")
+	repo := openReceivePackTestRepo(t, upstreamURL, nil)
+
+	cfg := ReceivePackHandlerConfig{
+		EnableReceivePack:     true,
+		ReceivePackAuthorizer: func(r *http.Request) error { return nil },
+	}
+
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	if _, err := gz.Write([]byte("0032command-list-and-pack-data")); err != nil {
+		t.Fatalf("gzip.Write() failed: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip.Close() failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/repo/git-receive-pack", &gzipped)
+	r.Header.Set("Content-Encoding", "gzip")
+
+	receivePackHandler(cfg, repo, w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200, body: %s", w.Code, w.Body.String())
+	}
+	if gotBody != "0032command-list-and-pack-data" {
+		t.Errorf("upstream received body %q, want the client's decompressed request verbatim", gotBody)
+	}
+	if want := "000eunpack ok\n0019ok refs/heads/main\n0000"; w.Body.String() != want {
+		t.Errorf("response body = %q, want %q", w.Body.String(), want)
+	}
+}
+
+// TestReceivePackHandlerFailedUpstreamPushDoesNotCorruptCache verifies
+// that a push the upstream rejects leaves the local mirror's refs
+// exactly as they were -- receivePackHandler never touches the local
+// disk cache directly, and a failed PushUpstream doesn't call
+// ScheduleRefsRefresh, so there's nothing for a failed push to corrupt.
+func TestReceivePackHandlerFailedUpstreamPushDoesNotCorruptCache(t *testing.T) {
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer upstreamServer.Close()
+
+	upstreamURL, _ := url.Parse(upstreamServer.URL + "/repo")
+	repo := openReceivePackTestRepo(t, upstreamURL, nil)
+
+	refsBefore := readRefs(t, repo.localDiskPath)
+
+	cfg := ReceivePackHandlerConfig{
+		EnableReceivePack:     true,
+		ReceivePackAuthorizer: func(r *http.Request) error { return nil },
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/repo/git-receive-pack", strings.NewReader("0000"))
+
+	receivePackHandler(cfg, repo, w, r)
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadGateway)
+	}
+
+	refsAfter := readRefs(t, repo.localDiskPath)
+	if refsBefore != refsAfter {
+		t.Errorf("local mirror's refs changed after a failed push:\nbefore: %q\nafter:  %q", refsBefore, refsAfter)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultXSendfileHeader is the response header goblet writes to offload
+// a cached file to the frontend, and the header name negotiated against
+// when ServerConfig.XSendfileType is empty. Set ServerConfig.XSendfile to
+// enable the feature; ServerConfig.XSendfileType overrides the header
+// name for frontends (e.g. Apache's mod_xsendfile) that expect something
+// other than nginx's "X-Sendfile".
+const DefaultXSendfileHeader = "X-Sendfile"
+
+// ErrSendfilePathEscapesRoot is returned by writeXSendfileResponse when
+// path resolves outside cacheRoot, so a bug upstream in path handling
+// can't be turned into an instruction for the frontend to sendfile(2) an
+// arbitrary file on the host.
+var ErrSendfilePathEscapesRoot = errors.New("sendfile: path escapes the cache root")
+
+// wantsXSendfile reports whether r negotiated sendfile offload via the
+// "X-Sendfile-Type" request header, the same negotiation nginx's
+// ngx_http_xsendfile and other reverse proxies that support X-Sendfile
+// already perform: the frontend advertises the header name it
+// understands, and only offloads responses that carry it back.
+// headerName is the value to match against, DefaultXSendfileHeader if
+// empty (i.e. ServerConfig.XSendfileType unset).
+//
+// Wiring this into httpProxyServer.ServeHTTP -- calling wantsXSendfile
+// once per request, and only handing a response to
+// writeXSendfileResponse when it corresponds to a complete, on-disk file
+// (a finished packfile, a cached archive, or a cached info/refs
+// advertisement) rather than a live, streamed upload-pack negotiation --
+// is the remaining integration step, in httpProxyServer, which is not
+// present in this checkout.
+func wantsXSendfile(r *http.Request, headerName string) bool {
+	if headerName == "" {
+		headerName = DefaultXSendfileHeader
+	}
+	return r.Header.Get("X-Sendfile-Type") == headerName
+}
+
+// resolveXSendfilePath absolute-izes path and verifies it lies inside
+// cacheRoot, returning ErrSendfilePathEscapesRoot otherwise.
+func resolveXSendfilePath(cacheRoot, path string) (string, error) {
+	absRoot, err := filepath.Abs(cacheRoot)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve cache root: %w", err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve sendfile path: %w", err)
+	}
+	rel, err := filepath.Rel(absRoot, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrSendfilePathEscapesRoot
+	}
+	return abs, nil
+}
+
+// verifyNoSymlinkEscape resolves any symlinks in path and cacheRoot and
+// confirms the resolved target still lies inside the resolved root, so a
+// symlink placed inside cacheRoot but pointing outside it can't be used
+// to make writeXSendfileResponse offload an arbitrary file on the host
+// -- resolveXSendfilePath's containment check alone is purely lexical
+// and a symlink would sail through it. path must already exist.
+func verifyNoSymlinkEscape(cacheRoot, path string) error {
+	realRoot, err := filepath.EvalSymlinks(cacheRoot)
+	if err != nil {
+		return fmt.Errorf("cannot resolve cache root: %w", err)
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("cannot resolve sendfile path: %w", err)
+	}
+	if _, err := resolveXSendfilePath(realRoot, realPath); err != nil {
+		return err
+	}
+	return nil
+}
+
+// writeXSendfileResponse serves the fully-materialized file at path
+// (which must resolve inside cacheRoot -- ServerConfig.LocalDiskCacheRoot
+// in production) by writing a zero-length 200 response carrying
+// headerName (DefaultXSendfileHeader if empty) set to path's absolute
+// form, instead of streaming the file's bytes through this process, the
+// way nginx's X-Sendfile support expects. contentType and filename (used
+// for Content-Disposition if non-empty) are set the same as a streamed
+// response would set them.
+//
+// It stats path before writing any header, so the returned responseSize
+// reflects the file's on-disk size -- the size a streamed response would
+// have reported to RequestLogger -- even though this response's actual
+// body is empty.
+func writeXSendfileResponse(w http.ResponseWriter, headerName, cacheRoot, path, contentType, filename string) (responseSize int64, err error) {
+	abs, err := resolveXSendfilePath(cacheRoot, path)
+	if err != nil {
+		return 0, err
+	}
+	if err := verifyNoSymlinkEscape(cacheRoot, abs); err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return 0, fmt.Errorf("cannot stat sendfile target: %w", err)
+	}
+	if headerName == "" {
+		headerName = DefaultXSendfileHeader
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if filename != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+	w.Header().Set(headerName, abs)
+	w.WriteHeader(http.StatusOK)
+	return info.Size(), nil
+}
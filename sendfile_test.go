@@ -0,0 +1,172 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWantsXSendfile(t *testing.T) {
+	tests := []struct {
+		name       string
+		headerVal  string
+		headerName string
+		want       bool
+	}{
+		{"default header name matches", "X-Sendfile", "", true},
+		{"default header name, no request header", "", "", false},
+		{"configured header name matches", "X-Accel-Redirect", "X-Accel-Redirect", true},
+		{"configured header name, request sends default instead", "X-Sendfile", "X-Accel-Redirect", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/org/repo.git/info/refs", nil)
+			if tt.headerVal != "" {
+				r.Header.Set("X-Sendfile-Type", tt.headerVal)
+			}
+			if got := wantsXSendfile(r, tt.headerName); got != tt.want {
+				t.Errorf("wantsXSendfile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWantsXSendfileStreamingRequestNotOffloaded(t *testing.T) {
+	// A live upload-pack negotiation request carries no X-Sendfile-Type
+	// header, since the frontend can't know in advance whether the
+	// response will be a fully-materialized file or a streamed
+	// negotiation -- so it must fall through to the normal streaming
+	// path rather than being offloaded.
+	r := httptest.NewRequest("POST", "/org/repo.git/git-upload-pack", nil)
+	if wantsXSendfile(r, "") {
+		t.Error("wantsXSendfile() = true for a request with no X-Sendfile-Type header, want false")
+	}
+}
+
+func TestWriteXSendfileResponse(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "pack-deadbeef.pack")
+	if err := os.WriteFile(path, []byte("pack contents"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	size, err := writeXSendfileResponse(w, "", root, path, "application/x-git-packed-objects", "pack-deadbeef.pack")
+	if err != nil {
+		t.Fatalf("writeXSendfileResponse() failed: %v", err)
+	}
+	if size != int64(len("pack contents")) {
+		t.Errorf("responseSize = %d, want %d", size, len("pack contents"))
+	}
+	if w.Code != 200 {
+		t.Errorf("status = %d, want 200", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("body length = %d, want 0", w.Body.Len())
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		t.Fatalf("filepath.Abs() failed: %v", err)
+	}
+	if got := w.Header().Get(DefaultXSendfileHeader); got != absPath {
+		t.Errorf("%s header = %q, want %q", DefaultXSendfileHeader, got, absPath)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/x-git-packed-objects" {
+		t.Errorf("Content-Type = %q, want %q", got, "application/x-git-packed-objects")
+	}
+	if got := w.Header().Get("Content-Disposition"); got == "" {
+		t.Error("Content-Disposition header not set")
+	}
+}
+
+func TestWriteXSendfileResponseCustomHeaderName(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "archive.zip")
+	if err := os.WriteFile(path, []byte("zip contents"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	if _, err := writeXSendfileResponse(w, "X-Accel-Redirect", root, path, "application/zip", ""); err != nil {
+		t.Fatalf("writeXSendfileResponse() failed: %v", err)
+	}
+	if w.Header().Get("X-Accel-Redirect") == "" {
+		t.Error("X-Accel-Redirect header not set")
+	}
+	if w.Header().Get(DefaultXSendfileHeader) != "" {
+		t.Errorf("%s header set, want only the configured header name used", DefaultXSendfileHeader)
+	}
+}
+
+func TestWriteXSendfileResponseRejectsPathOutsideCacheRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	path := filepath.Join(outside, "secret")
+	if err := os.WriteFile(path, []byte("should not be served"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	if _, err := writeXSendfileResponse(w, "", root, path, "application/octet-stream", ""); err == nil {
+		t.Error("writeXSendfileResponse() succeeded for a path outside the cache root, want an error")
+	}
+	if w.Header().Get(DefaultXSendfileHeader) != "" {
+		t.Error("X-Sendfile header set despite a rejected path")
+	}
+}
+
+func TestWriteXSendfileResponseRejectsSymlinkEscapingRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret")
+	if err := os.WriteFile(secret, []byte("should not be served"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	link := filepath.Join(root, "escape")
+	if err := os.Symlink(secret, link); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	if _, err := writeXSendfileResponse(w, "", root, link, "application/octet-stream", ""); err == nil {
+		t.Error("writeXSendfileResponse() succeeded for a symlink escaping the cache root, want an error")
+	}
+	if w.Header().Get(DefaultXSendfileHeader) != "" {
+		t.Error("X-Sendfile header set despite a rejected symlink")
+	}
+}
+
+func TestResolveXSendfilePathRejectsTraversal(t *testing.T) {
+	root := t.TempDir()
+	if _, err := resolveXSendfilePath(root, filepath.Join(root, "..", "escaped")); err != ErrSendfilePathEscapesRoot {
+		t.Errorf("resolveXSendfilePath() error = %v, want %v", err, ErrSendfilePathEscapesRoot)
+	}
+}
+
+func TestResolveXSendfilePathAllowsNestedPath(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "ab", "cd", "pack-deadbeef.pack")
+	abs, err := resolveXSendfilePath(root, nested)
+	if err != nil {
+		t.Fatalf("resolveXSendfilePath() failed: %v", err)
+	}
+	if !filepath.IsAbs(abs) {
+		t.Errorf("resolveXSendfilePath() = %q, want an absolute path", abs)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+)
+
+// AzureProvider implements Provider for Azure Blob Storage.
+type AzureProvider struct {
+	client    *azblob.Client
+	container string
+}
+
+// NewAzureProvider creates a new Azure Blob Storage provider from config.
+// Authentication follows whichever of config.AzureConnectionString or
+// config.AzureAccountName/AzureAccountKey is set, mirroring the
+// static-credentials-by-default convention NewS3Provider uses; a bare
+// AzureAccountName with no key falls back to Azure's default credential
+// chain (managed identity, environment, CLI), analogous to S3's "iam"
+// mode.
+func NewAzureProvider(ctx context.Context, config *Config) (*AzureProvider, error) {
+	client, err := newAzureClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := azureContainerExists(ctx, client, config.AzureContainer)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if _, err := client.CreateContainer(ctx, config.AzureContainer, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return &AzureProvider{
+		client:    client,
+		container: config.AzureContainer,
+	}, nil
+}
+
+func newAzureClient(config *Config) (*azblob.Client, error) {
+	if config.AzureConnectionString != "" {
+		return azblob.NewClientFromConnectionString(config.AzureConnectionString, nil)
+	}
+
+	serviceURL := "https://" + config.AzureAccountName + ".blob.core.windows.net/"
+	if config.AzureEndpoint != "" {
+		serviceURL = config.AzureEndpoint
+	}
+
+	if config.AzureAccountKey != "" {
+		cred, err := azblob.NewSharedKeyCredential(config.AzureAccountName, config.AzureAccountKey)
+		if err != nil {
+			return nil, err
+		}
+		return azblob.NewClientWithSharedKeyCredential(serviceURL, cred, nil)
+	}
+
+	cred, err := azcore.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, err
+	}
+	return azblob.NewClient(serviceURL, cred, nil)
+}
+
+// azureContainerExists reports whether containerName already exists,
+// mirroring S3Provider's BucketExists/MakeBucket dance so the provider
+// can be pointed at a container that doesn't exist yet.
+func azureContainerExists(ctx context.Context, client *azblob.Client, containerName string) (bool, error) {
+	pager := client.ServiceClient().NewListContainersPager(&service.ListContainersOptions{
+		Prefix: to.Ptr(containerName),
+	})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return false, err
+		}
+		for _, c := range page.ContainerItems {
+			if c.Name != nil && *c.Name == containerName {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Writer returns a writer for the given object path. Azure's SDK has no
+// streaming append-write primitive comparable to GCS/S3's resumable
+// uploads, so the writer buffers in memory and uploads on Close.
+func (a *AzureProvider) Writer(ctx context.Context, path string) (io.WriteCloser, error) {
+	return &azureWriter{ctx: ctx, provider: a, path: path}, nil
+}
+
+// Reader returns a reader for the given object path.
+func (a *AzureProvider) Reader(ctx context.Context, path string) (io.ReadCloser, error) {
+	resp, err := a.client.DownloadStream(ctx, a.container, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes an object at the given path.
+func (a *AzureProvider) Delete(ctx context.Context, path string) error {
+	_, err := a.client.DeleteBlob(ctx, a.container, path, nil)
+	return err
+}
+
+// Attrs returns metadata for the object at path.
+func (a *AzureProvider) Attrs(ctx context.Context, path string) (*ObjectAttrs, error) {
+	props, err := a.client.ServiceClient().NewContainerClient(a.container).NewBlobClient(path).GetProperties(ctx, nil)
+	if err != nil {
+		return nil, WrapError("Attrs", path, err)
+	}
+
+	attrs := &ObjectAttrs{Name: path}
+	if props.CreationTime != nil {
+		attrs.Created = *props.CreationTime
+	}
+	if props.LastModified != nil {
+		attrs.Updated = *props.LastModified
+	}
+	if props.ContentLength != nil {
+		attrs.Size = *props.ContentLength
+	}
+	if props.ETag != nil {
+		attrs.ETag = string(*props.ETag)
+	}
+	if props.ContentMD5 != nil {
+		attrs.MD5 = base64.StdEncoding.EncodeToString(props.ContentMD5)
+	}
+	if props.ContentType != nil {
+		attrs.ContentType = *props.ContentType
+	}
+	if props.AccessTier != nil {
+		attrs.StorageClass = *props.AccessTier
+	}
+	if props.Metadata != nil {
+		attrs.Metadata = make(map[string]string, len(props.Metadata))
+		for k, v := range props.Metadata {
+			if v != nil {
+				attrs.Metadata[k] = *v
+			}
+		}
+	}
+	return attrs, nil
+}
+
+// List returns an iterator for objects with the given prefix.
+func (a *AzureProvider) List(ctx context.Context, prefix string) ObjectIterator {
+	pager := a.client.NewListBlobsFlatPager(a.container, &container.ListBlobsFlatOptions{
+		Prefix: to.Ptr(prefix),
+	})
+	return &azureIterator{ctx: ctx, pager: pager}
+}
+
+// Close is a no-op: the Azure SDK client holds no connection to release.
+func (a *AzureProvider) Close() error {
+	return nil
+}
+
+// azureWriter buffers writes and uploads the accumulated bytes as a
+// single block blob on Close.
+type azureWriter struct {
+	ctx      context.Context
+	provider *AzureProvider
+	path     string
+	buf      []byte
+}
+
+func (w *azureWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+func (w *azureWriter) Close() error {
+	_, err := w.provider.client.UploadBuffer(w.ctx, w.provider.container, w.path, w.buf, nil)
+	return err
+}
+
+// azureIterator wraps the Azure SDK's flat-blob-listing pager.
+type azureIterator struct {
+	ctx   context.Context
+	pager *runtime.Pager[azblob.ListBlobsFlatResponse]
+	items []*container.BlobItem
+	index int
+}
+
+// Next returns the next object attributes.
+func (i *azureIterator) Next() (*ObjectAttrs, error) {
+	for i.index >= len(i.items) {
+		if !i.pager.More() {
+			return nil, io.EOF
+		}
+		page, err := i.pager.NextPage(i.ctx)
+		if err != nil {
+			return nil, err
+		}
+		i.items = page.Segment.BlobItems
+		i.index = 0
+	}
+
+	item := i.items[i.index]
+	i.index++
+
+	attrs := &ObjectAttrs{Name: *item.Name}
+	if item.Properties != nil {
+		if item.Properties.CreationTime != nil {
+			attrs.Created = *item.Properties.CreationTime
+		}
+		if item.Properties.LastModified != nil {
+			attrs.Updated = *item.Properties.LastModified
+		}
+		if item.Properties.ContentLength != nil {
+			attrs.Size = *item.Properties.ContentLength
+		}
+	}
+	return attrs, nil
+}
+
+func init() {
+	RegisterProvider("azure", func(ctx context.Context, config *Config) (Provider, error) {
+		return NewAzureProvider(ctx, config)
+	})
+}
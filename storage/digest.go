@@ -0,0 +1,179 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// DigestAlgorithm identifies a supported content hash function.
+type DigestAlgorithm string
+
+// DigestSHA256 is the only DigestAlgorithm WriteContentAddressed/
+// ReadVerified currently support; it's named explicitly (rather than
+// assumed) so a future second algorithm doesn't silently change what
+// existing keys mean.
+const DigestSHA256 DigestAlgorithm = "sha256"
+
+func newHash(algo DigestAlgorithm) (hash.Hash, error) {
+	switch algo {
+	case DigestSHA256:
+		return sha256.New(), nil
+	default:
+		return nil, fmt.Errorf("storage: unsupported digest algorithm %q", algo)
+	}
+}
+
+// DigestProvider wraps a Provider with content-addressed writes and
+// digest-verified reads, so a bundle (or any other object) can be keyed
+// and later fetched by its own hash instead of by a caller-chosen path,
+// with corruption caught at read time rather than discovered as a bad
+// git object well downstream.
+type DigestProvider struct {
+	Provider
+}
+
+// NewDigestProvider wraps provider with content-addressing support.
+func NewDigestProvider(provider Provider) *DigestProvider {
+	return &DigestProvider{Provider: provider}
+}
+
+// ContentKey returns the object path WriteContentAddressed would use for
+// a digest, following the same fan-out layout git uses for loose
+// objects (first byte as a directory, to keep any one directory from
+// accumulating unbounded entries): "<prefix>/<algo>/<aa>/<digest>".
+func ContentKey(prefix string, algo DigestAlgorithm, digest string) string {
+	dir := digest
+	if len(digest) >= 2 {
+		dir = digest[:2]
+	}
+	if prefix == "" {
+		return fmt.Sprintf("%s/%s/%s", algo, dir, digest)
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", prefix, algo, dir, digest)
+}
+
+// WriteContentAddressed hashes r's entire content with algo and writes
+// it to the key ContentKey(prefix, algo, digest) would return, returning
+// that key and the hex-encoded digest. Because the key can't be chosen
+// until the whole content has been hashed, r is buffered in memory
+// first; this is meant for bundle-sized objects, not arbitrarily large
+// streams.
+func (d *DigestProvider) WriteContentAddressed(ctx context.Context, prefix string, algo DigestAlgorithm, r io.Reader) (key, digest string, err error) {
+	h, err := newHash(algo)
+	if err != nil {
+		return "", "", err
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(io.MultiWriter(&buf, h), r); err != nil {
+		return "", "", WrapError("WriteContentAddressed", "", err)
+	}
+	digest = hex.EncodeToString(h.Sum(nil))
+	key = ContentKey(prefix, algo, digest)
+
+	w, err := d.Provider.Writer(ctx, key)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		w.Close()
+		return "", "", WrapError("WriteContentAddressed", key, err)
+	}
+	if err := w.Close(); err != nil {
+		return "", "", WrapError("WriteContentAddressed", key, err)
+	}
+
+	return key, digest, nil
+}
+
+// ReadVerified returns a reader for key that fails with an
+// ErrCodeDigestMismatch *Error from the Read that reaches EOF if the
+// content doesn't hash to wantDigest, or from Close if the reader was
+// closed before reaching EOF (so a caller that reads a truncated prefix
+// doesn't mistake "no error yet" for "verified").
+func (d *DigestProvider) ReadVerified(ctx context.Context, key string, algo DigestAlgorithm, wantDigest string) (io.ReadCloser, error) {
+	h, err := newHash(algo)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := d.Provider.Reader(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &verifyingReadCloser{
+		reader:     r,
+		hash:       h,
+		key:        key,
+		wantDigest: wantDigest,
+	}, nil
+}
+
+// verifyingReadCloser hashes bytes as they're read and checks the result
+// against wantDigest once the underlying reader reports EOF.
+type verifyingReadCloser struct {
+	reader     io.ReadCloser
+	hash       hash.Hash
+	key        string
+	wantDigest string
+	verified   bool
+}
+
+func (v *verifyingReadCloser) Read(p []byte) (int, error) {
+	n, err := v.reader.Read(p)
+	if n > 0 {
+		v.hash.Write(p[:n])
+	}
+	if err == io.EOF {
+		if verifyErr := v.verify(); verifyErr != nil {
+			return n, verifyErr
+		}
+	}
+	return n, err
+}
+
+func (v *verifyingReadCloser) Close() error {
+	closeErr := v.reader.Close()
+	if verifyErr := v.verify(); verifyErr != nil {
+		return verifyErr
+	}
+	return closeErr
+}
+
+func (v *verifyingReadCloser) verify() error {
+	if v.verified {
+		return nil
+	}
+	v.verified = true
+
+	got := hex.EncodeToString(v.hash.Sum(nil))
+	if got != v.wantDigest {
+		return &Error{
+			Code: ErrCodeDigestMismatch,
+			Op:   "ReadVerified",
+			Path: v.key,
+			Err:  fmt.Errorf("digest mismatch: want %s, got %s", v.wantDigest, got),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDigestProvider_WriteContentAddressedAndReadVerified(t *testing.T) {
+	ctx := context.Background()
+
+	for name, provider := range providerFactories(t) {
+		t.Run(name, func(t *testing.T) {
+			d := NewDigestProvider(provider)
+
+			key, digest, err := d.WriteContentAddressed(ctx, "bundles", DigestSHA256, strings.NewReader("hello world"))
+			if err != nil {
+				t.Fatalf("WriteContentAddressed failed: %v", err)
+			}
+			if want := ContentKey("bundles", DigestSHA256, digest); key != want {
+				t.Errorf("key = %q, want %q", key, want)
+			}
+
+			r, err := d.ReadVerified(ctx, key, DigestSHA256, digest)
+			if err != nil {
+				t.Fatalf("ReadVerified failed: %v", err)
+			}
+			data, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("ReadAll failed: %v", err)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+			if string(data) != "hello world" {
+				t.Errorf("data = %q, want %q", data, "hello world")
+			}
+		})
+	}
+}
+
+func TestDigestProvider_ReadVerifiedMismatch(t *testing.T) {
+	ctx := context.Background()
+	d := NewDigestProvider(NewMemoryProvider())
+
+	key, _, err := d.WriteContentAddressed(ctx, "bundles", DigestSHA256, strings.NewReader("hello world"))
+	if err != nil {
+		t.Fatalf("WriteContentAddressed failed: %v", err)
+	}
+
+	r, err := d.ReadVerified(ctx, key, DigestSHA256, strings.Repeat("0", 64))
+	if err != nil {
+		t.Fatalf("ReadVerified failed: %v", err)
+	}
+	defer r.Close()
+
+	_, err = io.ReadAll(r)
+	if !IsDigestMismatch(err) {
+		t.Errorf("ReadAll error = %v, want ErrCodeDigestMismatch", err)
+	}
+}
+
+func TestDigestProvider_WriteContentAddressedEmptyPrefix(t *testing.T) {
+	ctx := context.Background()
+	d := NewDigestProvider(NewMemoryProvider())
+
+	key, digest, err := d.WriteContentAddressed(ctx, "", DigestSHA256, bytes.NewReader(nil))
+	if err != nil {
+		t.Fatalf("WriteContentAddressed failed: %v", err)
+	}
+	if want := ContentKey("", DigestSHA256, digest); key != want {
+		t.Errorf("key = %q, want %q", key, want)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/minio/minio-go/v7"
+	"google.golang.org/api/googleapi"
+)
+
+// ErrorCode categorizes a storage error independent of which backend
+// produced it, so callers (metrics, health checks, retry logic) can
+// branch on the category instead of pattern-matching the backend's own
+// error type or, worse, its error string.
+type ErrorCode string
+
+const (
+	// ErrCodeNotFound means the requested object, bucket, or container
+	// doesn't exist.
+	ErrCodeNotFound ErrorCode = "not_found"
+	// ErrCodeAlreadyExists means a create/write conflicted with an
+	// existing object, e.g. a conditional write precondition failure.
+	ErrCodeAlreadyExists ErrorCode = "already_exists"
+	// ErrCodePermissionDenied means the backend rejected the request on
+	// authorization grounds.
+	ErrCodePermissionDenied ErrorCode = "permission_denied"
+	// ErrCodeTimeout means the request's context deadline was exceeded.
+	ErrCodeTimeout ErrorCode = "timeout"
+	// ErrCodeCanceled means the request's context was canceled.
+	ErrCodeCanceled ErrorCode = "canceled"
+	// ErrCodeInvalidArgument means the backend rejected the request as
+	// malformed, e.g. an invalid object key or out-of-range parameter.
+	ErrCodeInvalidArgument ErrorCode = "invalid_argument"
+	// ErrCodeUnavailable means the backend (or the network path to it)
+	// is unreachable.
+	ErrCodeUnavailable ErrorCode = "unavailable"
+	// ErrCodeDigestMismatch means content read back from the backend
+	// didn't hash to the digest it was written (or expected to be
+	// found) under; see DigestProvider in digest.go.
+	ErrCodeDigestMismatch ErrorCode = "digest_mismatch"
+	// ErrCodeUnknown is the fallback for errors ClassifyError doesn't
+	// recognize.
+	ErrCodeUnknown ErrorCode = "unknown"
+)
+
+// Error is the structured error Provider implementations should wrap
+// backend errors in, so callers can type-assert or use errors.As instead
+// of string-matching err.Error().
+type Error struct {
+	// Code categorizes the failure.
+	Code ErrorCode
+	// Op is the Provider method that failed, e.g. "Reader" or "Delete".
+	Op string
+	// Path is the object path the operation targeted.
+	Path string
+	// Err is the underlying backend error, preserved for logging and
+	// for errors.Is/As against backend-specific sentinel errors.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Path != "" {
+		return fmt.Sprintf("storage: %s %q: %s: %v", e.Op, e.Path, e.Code, e.Err)
+	}
+	return fmt.Sprintf("storage: %s: %s: %v", e.Op, e.Code, e.Err)
+}
+
+// Unwrap returns the underlying backend error, so errors.Is/As see
+// through to it.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewError wraps err as a *Error with the given code, op, and path. If
+// err is nil, NewError returns nil, so call sites can write
+// `return NewError(...)` directly in place of `return err`.
+func NewError(code ErrorCode, op, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Op: op, Path: path, Err: err}
+}
+
+// WrapError wraps err as a *Error, classifying its code from the
+// backend error itself via ClassifyError. Providers that haven't been
+// taught the specific failure mode for a call site can use this instead
+// of picking a code by hand.
+func WrapError(op, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: ClassifyError(err), Op: op, Path: path, Err: err}
+}
+
+// ClassifyError maps a backend error (GCS, S3/Minio, Azure Blob, or a
+// plain os/context error from the local/memory providers) to an
+// ErrorCode. It replaces the old approach of matching substrings like
+// "not found" or "denied" against err.Error(), which broke silently
+// whenever a backend reworded a message.
+func ClassifyError(err error) ErrorCode {
+	if err == nil {
+		return ErrCodeUnknown
+	}
+
+	var storageErr *Error
+	if errors.As(err, &storageErr) {
+		return storageErr.Code
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrCodeCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrCodeTimeout
+	case errors.Is(err, os.ErrNotExist):
+		return ErrCodeNotFound
+	case errors.Is(err, os.ErrPermission):
+		return ErrCodePermissionDenied
+	case errors.Is(err, storage.ErrObjectNotExist), errors.Is(err, storage.ErrBucketNotExist):
+		return ErrCodeNotFound
+	}
+
+	if code, ok := classifyMinioError(err); ok {
+		return code
+	}
+	if code, ok := classifyGoogleAPIError(err); ok {
+		return code
+	}
+	if code, ok := classifyAzureError(err); ok {
+		return code
+	}
+
+	return ErrCodeUnknown
+}
+
+// classifyMinioError inspects a minio-go error response for the S3/Minio
+// provider.
+func classifyMinioError(err error) (ErrorCode, bool) {
+	resp := minio.ToErrorResponse(err)
+	if resp.Code == "" {
+		return "", false
+	}
+	switch resp.Code {
+	case "NoSuchKey", "NoSuchBucket", "NoSuchUpload", "NoSuchVersion", "NotFound":
+		return ErrCodeNotFound, true
+	case "AccessDenied":
+		return ErrCodePermissionDenied, true
+	case "InvalidArgument", "InvalidRequest", "MalformedXML":
+		return ErrCodeInvalidArgument, true
+	case "BucketAlreadyExists", "BucketAlreadyOwnedByYou", "PreconditionFailed":
+		return ErrCodeAlreadyExists, true
+	default:
+		return ErrCodeUnknown, true
+	}
+}
+
+// classifyGoogleAPIError inspects a googleapi.Error for the GCS
+// provider.
+func classifyGoogleAPIError(err error) (ErrorCode, bool) {
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) {
+		return "", false
+	}
+	switch gerr.Code {
+	case 404:
+		return ErrCodeNotFound, true
+	case 403, 401:
+		return ErrCodePermissionDenied, true
+	case 409, 412:
+		return ErrCodeAlreadyExists, true
+	case 400:
+		return ErrCodeInvalidArgument, true
+	case 503, 429:
+		return ErrCodeUnavailable, true
+	default:
+		return ErrCodeUnknown, true
+	}
+}
+
+// classifyAzureError inspects an Azure Blob Storage error code for the
+// Azure provider.
+func classifyAzureError(err error) (ErrorCode, bool) {
+	switch {
+	case bloberror.HasCode(err, bloberror.BlobNotFound, bloberror.ContainerNotFound, bloberror.ResourceNotFound):
+		return ErrCodeNotFound, true
+	case bloberror.HasCode(err, bloberror.AuthorizationFailure, bloberror.InsufficientAccountPermissions):
+		return ErrCodePermissionDenied, true
+	case bloberror.HasCode(err, bloberror.BlobAlreadyExists, bloberror.ContainerAlreadyExists, bloberror.ConditionNotMet):
+		return ErrCodeAlreadyExists, true
+	case bloberror.HasCode(err, bloberror.InvalidInput, bloberror.InvalidBlobOrBlock):
+		return ErrCodeInvalidArgument, true
+	}
+	return "", false
+}
+
+// IsNotFound reports whether err (classified via ClassifyError) denotes
+// a missing object, bucket, or container.
+func IsNotFound(err error) bool {
+	return ClassifyError(err) == ErrCodeNotFound
+}
+
+// IsPermissionDenied reports whether err (classified via ClassifyError)
+// denotes an authorization failure.
+func IsPermissionDenied(err error) bool {
+	return ClassifyError(err) == ErrCodePermissionDenied
+}
+
+// IsDigestMismatch reports whether err (classified via ClassifyError)
+// denotes content that didn't hash to its expected digest; see
+// DigestProvider in digest.go.
+func IsDigestMismatch(err error) bool {
+	return ClassifyError(err) == ErrCodeDigestMismatch
+}
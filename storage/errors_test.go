@@ -0,0 +1,74 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCode
+	}{
+		{"nil-like unknown", errors.New("boom"), ErrCodeUnknown},
+		{"context canceled", context.Canceled, ErrCodeCanceled},
+		{"context deadline exceeded", context.DeadlineExceeded, ErrCodeTimeout},
+		{"os not exist", os.ErrNotExist, ErrCodeNotFound},
+		{"os permission", os.ErrPermission, ErrCodePermissionDenied},
+		{"wrapped context canceled", &Error{Code: ErrCodeCanceled, Err: context.Canceled}, ErrCodeCanceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestError_UnwrapAndMessage(t *testing.T) {
+	underlying := os.ErrNotExist
+	err := NewError(ErrCodeNotFound, "Reader", "bundles/a.bundle", underlying)
+
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Error("expected errors.Is to see through to the underlying error")
+	}
+	if !IsNotFound(err) {
+		t.Error("expected IsNotFound to be true")
+	}
+	if IsPermissionDenied(err) {
+		t.Error("expected IsPermissionDenied to be false")
+	}
+
+	var se *Error
+	if !errors.As(err, &se) {
+		t.Fatal("expected errors.As to find *Error")
+	}
+	if se.Op != "Reader" || se.Path != "bundles/a.bundle" {
+		t.Errorf("Op/Path = %q/%q, want Reader/bundles/a.bundle", se.Op, se.Path)
+	}
+}
+
+func TestNewError_NilPassthrough(t *testing.T) {
+	if err := NewError(ErrCodeNotFound, "Reader", "x", nil); err != nil {
+		t.Errorf("NewError(..., nil) = %v, want nil", err)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// EventType categorizes a bucket notification.
+type EventType string
+
+const (
+	// EventObjectCreated fires for s3:ObjectCreated:* notifications,
+	// e.g. a bundle uploaded by another replica or an out-of-band
+	// backup job.
+	EventObjectCreated EventType = "created"
+	// EventObjectRemoved fires for s3:ObjectRemoved:* notifications.
+	EventObjectRemoved EventType = "removed"
+)
+
+// Event is a single bucket notification, already translated from the
+// backend-specific wire format (Minio's ListenBucketNotification, or in
+// principle SQS/SNS for AWS).
+type Event struct {
+	Type   EventType
+	Bucket string
+	Key    string
+	Size   int64
+	Time   time.Time
+}
+
+// eventSourceBackoff bounds the reconnect backoff for the notification
+// event loop.
+const (
+	eventSourceMinBackoff = time.Second
+	eventSourceMaxBackoff = 30 * time.Second
+)
+
+// startEventSource launches the background goroutine that subscribes to
+// the bucket's S3 notifications and invokes config.EventHandler for each
+// one, reconnecting with exponential backoff if the subscription drops.
+// It returns a stop function to terminate the goroutine, to be called
+// from Provider.Close.
+func startEventSource(client *minio.Client, config *Config) func() {
+	if !config.EventsEnabled || config.EventHandler == nil {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	go runEventLoop(stop, client, config)
+	return func() { close(stop) }
+}
+
+func runEventLoop(stop chan struct{}, client *minio.Client, config *Config) {
+	backoff := eventSourceMinBackoff
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		events := client.ListenBucketNotification(ctx, config.S3Bucket, config.EventsPrefix, config.EventsSuffix,
+			[]string{"s3:ObjectCreated:*", "s3:ObjectRemoved:*"})
+
+		connected := false
+	drain:
+		for {
+			select {
+			case <-stop:
+				cancel()
+				return
+			case notification, ok := <-events:
+				if !ok {
+					break drain
+				}
+				connected = true
+				backoff = eventSourceMinBackoff
+				for _, record := range notification.Records {
+					if event, ok := translateRecord(record); ok {
+						config.EventHandler(event)
+					}
+				}
+				if notification.Err != nil {
+					log.Printf("goblet: bucket notification error: %v", notification.Err)
+				}
+			}
+		}
+		cancel()
+
+		if !connected {
+			// The subscription never delivered anything before
+			// closing; back off before retrying so a
+			// misconfigured endpoint doesn't spin.
+			select {
+			case <-stop:
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > eventSourceMaxBackoff {
+				backoff = eventSourceMaxBackoff
+			}
+		}
+	}
+}
+
+func translateRecord(record minio.NotificationEvent) (Event, bool) {
+	key := record.S3.Object.Key
+	if key == "" {
+		return Event{}, false
+	}
+
+	var typ EventType
+	switch {
+	case strings.HasPrefix(record.EventName, "s3:ObjectCreated:"):
+		typ = EventObjectCreated
+	case strings.HasPrefix(record.EventName, "s3:ObjectRemoved:"):
+		typ = EventObjectRemoved
+	default:
+		return Event{}, false
+	}
+
+	t, _ := time.Parse(time.RFC3339, record.EventTime)
+	return Event{
+		Type:   typ,
+		Bucket: record.S3.Bucket.Name,
+		Key:    key,
+		Size:   record.S3.Object.Size,
+		Time:   t,
+	}, true
+}
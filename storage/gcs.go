@@ -16,7 +16,13 @@ package storage
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
 	"io"
+	"os"
+	"time"
 
 	"cloud.google.com/go/storage"
 	"google.golang.org/api/iterator"
@@ -26,19 +32,70 @@ import (
 type GCSProvider struct {
 	client *storage.Client
 	bucket *storage.BucketHandle
+
+	signedURLGoogleAccessID string
+	signedURLPrivateKey     []byte
+	signedURLDefaultTTL     time.Duration
+	signedURLMaxTTL         time.Duration
 }
 
 // NewGCSProvider creates a new GCS storage provider
-func NewGCSProvider(ctx context.Context, bucketName string) (*GCSProvider, error) {
+func NewGCSProvider(ctx context.Context, config *Config) (*GCSProvider, error) {
 	client, err := storage.NewClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return &GCSProvider{
-		client: client,
-		bucket: client.Bucket(bucketName),
-	}, nil
+	g := &GCSProvider{
+		client:              client,
+		bucket:              client.Bucket(config.GCSBucket),
+		signedURLDefaultTTL: config.GCSSignedURLDefaultTTL,
+		signedURLMaxTTL:     config.GCSSignedURLMaxTTL,
+	}
+	if g.signedURLDefaultTTL <= 0 {
+		g.signedURLDefaultTTL = DefaultPresignedURLTTL
+	}
+	if g.signedURLMaxTTL <= 0 {
+		g.signedURLMaxTTL = MaxPresignedURLTTL
+	}
+
+	if config.GCSSignedURLServiceAccountKeyFile != "" {
+		accessID, privateKey, err := readGCSServiceAccountKey(config.GCSSignedURLServiceAccountKeyFile)
+		if err != nil {
+			client.Close()
+			return nil, fmt.Errorf("loading GCSSignedURLServiceAccountKeyFile: %w", err)
+		}
+		g.signedURLGoogleAccessID = accessID
+		g.signedURLPrivateKey = privateKey
+	}
+
+	return g, nil
+}
+
+// gcsServiceAccountKey models the fields NewGCSProvider needs out of a
+// service account JSON key file, ignoring the rest (project_id,
+// token_uri, ...).
+type gcsServiceAccountKey struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+}
+
+// readGCSServiceAccountKey extracts the client email and PEM private key
+// from a service account JSON key file, as required by
+// storage.SignedURLOptions for V4 signing.
+func readGCSServiceAccountKey(path string) (accessID string, privateKey []byte, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	var key gcsServiceAccountKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return "", nil, fmt.Errorf("parsing service account key: %w", err)
+	}
+	if key.ClientEmail == "" || key.PrivateKey == "" {
+		return "", nil, fmt.Errorf("service account key is missing client_email or private_key")
+	}
+	return key.ClientEmail, []byte(key.PrivateKey), nil
 }
 
 // Writer returns a writer for the given object path
@@ -46,16 +103,99 @@ func (g *GCSProvider) Writer(ctx context.Context, path string) (io.WriteCloser,
 	return g.bucket.Object(path).NewWriter(ctx), nil
 }
 
+// WriterWithOptions implements ConditionalProvider, applying opts as GCS
+// write preconditions (DoesNotExist for IfNoneMatch, Conditions.
+// GenerationMatch for IfGenerationMatch); a precondition failure surfaces
+// from the returned writer's Close as an ErrCodeAlreadyExists *Error.
+func (g *GCSProvider) WriterWithOptions(ctx context.Context, path string, opts WriteOptions) (io.WriteCloser, error) {
+	obj := g.bucket.Object(path)
+	switch {
+	case opts.IfNoneMatch:
+		obj = obj.If(storage.Conditions{DoesNotExist: true})
+	case opts.IfGenerationMatch != nil:
+		obj = obj.If(storage.Conditions{GenerationMatch: *opts.IfGenerationMatch})
+	}
+	return &gcsConditionalWriter{w: obj.NewWriter(ctx), path: path}, nil
+}
+
+// gcsConditionalWriter wraps a *storage.Writer so a precondition
+// violation, which the GCS client surfaces as a googleapi.Error on
+// Close, comes back as the package's own *Error instead.
+type gcsConditionalWriter struct {
+	w    *storage.Writer
+	path string
+}
+
+func (w *gcsConditionalWriter) Write(p []byte) (int, error) {
+	return w.w.Write(p)
+}
+
+func (w *gcsConditionalWriter) Close() error {
+	if err := w.w.Close(); err != nil {
+		return WrapError("WriterWithOptions", w.path, err)
+	}
+	return nil
+}
+
 // Reader returns a reader for the given object path
 func (g *GCSProvider) Reader(ctx context.Context, path string) (io.ReadCloser, error) {
 	return g.bucket.Object(path).NewReader(ctx)
 }
 
+// ReaderAt implements RangeReaderProvider using GCS's NewRangeReader,
+// which already treats a negative length as "read to the end of the
+// object".
+func (g *GCSProvider) ReaderAt(ctx context.Context, path string, offset, length int64) (io.ReadCloser, error) {
+	r, err := g.bucket.Object(path).NewRangeReader(ctx, offset, length)
+	if err != nil {
+		return nil, WrapError("ReaderAt", path, err)
+	}
+	return r, nil
+}
+
 // Delete removes an object at the given path
 func (g *GCSProvider) Delete(ctx context.Context, path string) error {
 	return g.bucket.Object(path).Delete(ctx)
 }
 
+// Attrs returns metadata for the object at path.
+func (g *GCSProvider) Attrs(ctx context.Context, path string) (*ObjectAttrs, error) {
+	attrs, err := g.bucket.Object(path).Attrs(ctx)
+	if err != nil {
+		return nil, WrapError("Attrs", path, err)
+	}
+	return gcsObjectAttrs(attrs), nil
+}
+
+// gcsObjectAttrs translates a *storage.ObjectAttrs into the package's
+// backend-agnostic ObjectAttrs.
+func gcsObjectAttrs(attrs *storage.ObjectAttrs) *ObjectAttrs {
+	return &ObjectAttrs{
+		Name:         attrs.Name,
+		Prefix:       attrs.Prefix,
+		Created:      attrs.Created,
+		Updated:      attrs.Updated,
+		Size:         attrs.Size,
+		ETag:         attrs.Etag,
+		MD5:          base64.StdEncoding.EncodeToString(attrs.MD5),
+		CRC32C:       encodeCRC32C(attrs.CRC32C),
+		ContentType:  attrs.ContentType,
+		StorageClass: attrs.StorageClass,
+		Metadata:     attrs.Metadata,
+	}
+}
+
+// encodeCRC32C base64-encodes a CRC32C checksum the way GCS reports it
+// over its JSON API, or "" if no checksum was computed.
+func encodeCRC32C(crc uint32) string {
+	if crc == 0 {
+		return ""
+	}
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], crc)
+	return base64.StdEncoding.EncodeToString(b[:])
+}
+
 // List returns an iterator for objects with the given prefix
 func (g *GCSProvider) List(ctx context.Context, prefix string) ObjectIterator {
 	query := &storage.Query{
@@ -87,11 +227,11 @@ func (i *gcsIterator) Next() (*ObjectAttrs, error) {
 		return nil, err
 	}
 
-	return &ObjectAttrs{
-		Name:    attrs.Name,
-		Prefix:  attrs.Prefix,
-		Created: attrs.Created,
-		Updated: attrs.Updated,
-		Size:    attrs.Size,
-	}, nil
+	return gcsObjectAttrs(attrs), nil
+}
+
+func init() {
+	RegisterProvider("gcs", func(ctx context.Context, config *Config) (Provider, error) {
+		return NewGCSProvider(ctx, config)
+	})
 }
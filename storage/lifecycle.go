@@ -0,0 +1,53 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// LifecycleRule describes one object-lifecycle rule to apply to objects
+// under Prefix: expire them after ExpireAfter, transition them to
+// TransitionStorageClass after TransitionAfter, and/or abort orphaned
+// multipart uploads after AbortIncompleteUploadsAfter. A zero duration
+// field disables that part of the rule.
+type LifecycleRule struct {
+	Prefix string
+
+	ExpireAfter time.Duration
+
+	TransitionAfter        time.Duration
+	TransitionStorageClass string
+
+	AbortIncompleteUploadsAfter time.Duration
+}
+
+// LifecycleProvider is an optional capability implemented by storage
+// backends that can enforce object-lifecycle rules natively (e.g. S3's
+// bucket lifecycle configuration), so goblet can let the object store
+// itself expire/transition stale packs instead of walking List and
+// deleting objects one at a time. Not every Provider implementation
+// supports this; callers should type-assert, and fall back to walking
+// List/Attrs/Delete themselves for the ones that don't (see
+// CachePolicy.Sweep).
+type LifecycleProvider interface {
+	// SetLifecycleRules replaces the backend's current lifecycle
+	// configuration with rules.
+	SetLifecycleRules(ctx context.Context, rules []LifecycleRule) error
+	// LifecycleRules returns the backend's currently configured
+	// lifecycle rules.
+	LifecycleRules(ctx context.Context) ([]LifecycleRule, error)
+}
@@ -0,0 +1,188 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalProvider implements Provider against a directory on the local
+// filesystem. It exists for single-node deployments and development,
+// where running a GCS/S3-compatible backend just to exercise the backup
+// path is unnecessary overhead.
+type LocalProvider struct {
+	root string
+}
+
+// NewLocalProvider creates a new filesystem-backed storage provider
+// rooted at dir. dir is created if it doesn't already exist.
+func NewLocalProvider(dir string) (*LocalProvider, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalProvider{root: dir}, nil
+}
+
+// resolve maps an object path to a filesystem path rooted at p.root,
+// rejecting any path that would escape it.
+func (p *LocalProvider) resolve(path string) (string, error) {
+	full := filepath.Join(p.root, filepath.FromSlash(path))
+	if full != p.root && !strings.HasPrefix(full, p.root+string(filepath.Separator)) {
+		return "", os.ErrInvalid
+	}
+	return full, nil
+}
+
+// Writer returns a writer for the given object path.
+func (p *LocalProvider) Writer(ctx context.Context, path string) (io.WriteCloser, error) {
+	full, err := p.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(full)
+}
+
+// Reader returns a reader for the given object path.
+func (p *LocalProvider) Reader(ctx context.Context, path string) (io.ReadCloser, error) {
+	full, err := p.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(full)
+}
+
+// ReaderAt implements RangeReaderProvider by seeking the opened file to
+// offset and limiting the returned reader to length bytes; length < 0
+// reads to the end of the file.
+func (p *LocalProvider) ReaderAt(ctx context.Context, path string, offset, length int64) (io.ReadCloser, error) {
+	full, err := p.resolve(path)
+	if err != nil {
+		return nil, WrapError("ReaderAt", path, err)
+	}
+	f, err := os.Open(full)
+	if err != nil {
+		return nil, WrapError("ReaderAt", path, err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, WrapError("ReaderAt", path, err)
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return &limitedReadCloser{r: f, remaining: length}, nil
+}
+
+// Delete removes an object at the given path.
+func (p *LocalProvider) Delete(ctx context.Context, path string) error {
+	full, err := p.resolve(path)
+	if err != nil {
+		return err
+	}
+	return os.Remove(full)
+}
+
+// Attrs returns metadata for the object at path.
+func (p *LocalProvider) Attrs(ctx context.Context, path string) (*ObjectAttrs, error) {
+	full, err := p.resolve(path)
+	if err != nil {
+		return nil, WrapError("Attrs", path, err)
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		return nil, WrapError("Attrs", path, err)
+	}
+	return &ObjectAttrs{
+		Name:    path,
+		Updated: info.ModTime(),
+		Size:    info.Size(),
+	}, nil
+}
+
+// List returns an iterator for objects with the given prefix. Unlike the
+// cloud providers, this walks the whole subtree under the prefix's
+// directory eagerly; local backups aren't expected to hold enough
+// objects for that to matter.
+func (p *LocalProvider) List(ctx context.Context, prefix string) ObjectIterator {
+	full, err := p.resolve(prefix)
+	if err != nil {
+		return &localIterator{err: err}
+	}
+
+	var attrs []*ObjectAttrs
+	walkErr := filepath.Walk(full, func(walked string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(p.root, walked)
+		if err != nil {
+			return err
+		}
+		attrs = append(attrs, &ObjectAttrs{
+			Name:    filepath.ToSlash(rel),
+			Updated: info.ModTime(),
+			Size:    info.Size(),
+		})
+		return nil
+	})
+	if walkErr != nil {
+		return &localIterator{err: walkErr}
+	}
+	return &localIterator{items: attrs}
+}
+
+// Close is a no-op: there is no connection to release.
+func (p *LocalProvider) Close() error {
+	return nil
+}
+
+// localIterator iterates over a pre-collected slice of ObjectAttrs.
+type localIterator struct {
+	items []*ObjectAttrs
+	index int
+	err   error
+}
+
+// Next returns the next object attributes.
+func (i *localIterator) Next() (*ObjectAttrs, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+	if i.index >= len(i.items) {
+		return nil, io.EOF
+	}
+	item := i.items[i.index]
+	i.index++
+	return item, nil
+}
+
+func init() {
+	RegisterProvider("local", func(ctx context.Context, config *Config) (Provider, error) {
+		return NewLocalProvider(config.LocalPath)
+	})
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+// providerFactories lists the Provider implementations that need no
+// external service (Docker, cloud credentials) to exercise, so the same
+// round-trip test can run against each in process.
+func providerFactories(t *testing.T) map[string]Provider {
+	t.Helper()
+
+	local, err := NewLocalProvider(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalProvider failed: %v", err)
+	}
+
+	return map[string]Provider{
+		"local":  local,
+		"memory": NewMemoryProvider(),
+	}
+}
+
+func TestLocalAndMemoryProvider_WriteReadDelete(t *testing.T) {
+	ctx := context.Background()
+
+	for name, provider := range providerFactories(t) {
+		t.Run(name, func(t *testing.T) {
+			w, err := provider.Writer(ctx, "dir/object.dat")
+			if err != nil {
+				t.Fatalf("Writer failed: %v", err)
+			}
+			if _, err := w.Write([]byte("hello world")); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			r, err := provider.Reader(ctx, "dir/object.dat")
+			if err != nil {
+				t.Fatalf("Reader failed: %v", err)
+			}
+			data, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatalf("ReadAll failed: %v", err)
+			}
+			if string(data) != "hello world" {
+				t.Errorf("data = %q, want %q", data, "hello world")
+			}
+
+			if err := provider.Delete(ctx, "dir/object.dat"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if _, err := provider.Reader(ctx, "dir/object.dat"); err == nil {
+				t.Error("expected Reader to fail after Delete")
+			}
+		})
+	}
+}
+
+func TestLocalAndMemoryProvider_List(t *testing.T) {
+	ctx := context.Background()
+
+	for name, provider := range providerFactories(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, path := range []string{"prefix/a.dat", "prefix/b.dat", "other/c.dat"} {
+				w, err := provider.Writer(ctx, path)
+				if err != nil {
+					t.Fatalf("Writer(%q) failed: %v", path, err)
+				}
+				if _, err := w.Write([]byte(path)); err != nil {
+					t.Fatalf("Write(%q) failed: %v", path, err)
+				}
+				if err := w.Close(); err != nil {
+					t.Fatalf("Close(%q) failed: %v", path, err)
+				}
+			}
+
+			iter := provider.List(ctx, "prefix/")
+			var got []string
+			for {
+				attrs, err := iter.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Next failed: %v", err)
+				}
+				got = append(got, attrs.Name)
+			}
+
+			if len(got) != 2 {
+				t.Errorf("List(prefix/) returned %d objects, want 2: %v", len(got), got)
+			}
+		})
+	}
+}
+
+func TestLocalAndMemoryProvider_Attrs(t *testing.T) {
+	ctx := context.Background()
+
+	for name, provider := range providerFactories(t) {
+		t.Run(name, func(t *testing.T) {
+			w, err := provider.Writer(ctx, "object.dat")
+			if err != nil {
+				t.Fatalf("Writer failed: %v", err)
+			}
+			if _, err := w.Write([]byte("hello world")); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			attrs, err := provider.Attrs(ctx, "object.dat")
+			if err != nil {
+				t.Fatalf("Attrs failed: %v", err)
+			}
+			if attrs.Size != int64(len("hello world")) {
+				t.Errorf("Size = %d, want %d", attrs.Size, len("hello world"))
+			}
+
+			if _, err := provider.Attrs(ctx, "missing.dat"); !IsNotFound(err) {
+				t.Errorf("Attrs(missing.dat) error = %v, want a not-found error", err)
+			}
+		})
+	}
+}
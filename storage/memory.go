@@ -0,0 +1,181 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryProvider implements Provider entirely in process memory. It is
+// meant for unit tests that need a real Provider (rather than a mock
+// with per-call function fields like mockProvider in storage_test.go)
+// without depending on Docker or cloud credentials; it is not meant to
+// be configured in production since nothing written to it survives the
+// process.
+type MemoryProvider struct {
+	mu      sync.Mutex
+	objects map[string]*memoryObject
+}
+
+type memoryObject struct {
+	data    []byte
+	created time.Time
+	updated time.Time
+}
+
+// NewMemoryProvider creates a new in-memory storage provider.
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{
+		objects: make(map[string]*memoryObject),
+	}
+}
+
+// Writer returns a writer for the given object path. The object is
+// created (or overwritten) only once Close is called.
+func (p *MemoryProvider) Writer(ctx context.Context, path string) (io.WriteCloser, error) {
+	return &memoryWriter{provider: p, path: path}, nil
+}
+
+// Reader returns a reader for the given object path.
+func (p *MemoryProvider) Reader(ctx context.Context, path string) (io.ReadCloser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	obj, ok := p.objects[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), nil
+}
+
+// Delete removes an object at the given path.
+func (p *MemoryProvider) Delete(ctx context.Context, path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.objects[path]; !ok {
+		return os.ErrNotExist
+	}
+	delete(p.objects, path)
+	return nil
+}
+
+// Attrs returns metadata for the object at path.
+func (p *MemoryProvider) Attrs(ctx context.Context, path string) (*ObjectAttrs, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	obj, ok := p.objects[path]
+	if !ok {
+		return nil, WrapError("Attrs", path, os.ErrNotExist)
+	}
+	return &ObjectAttrs{
+		Name:    path,
+		Created: obj.created,
+		Updated: obj.updated,
+		Size:    int64(len(obj.data)),
+	}, nil
+}
+
+// List returns an iterator for objects with the given prefix.
+func (p *MemoryProvider) List(ctx context.Context, prefix string) ObjectIterator {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var names []string
+	for name := range p.objects {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	attrs := make([]*ObjectAttrs, 0, len(names))
+	for _, name := range names {
+		obj := p.objects[name]
+		attrs = append(attrs, &ObjectAttrs{
+			Name:    name,
+			Created: obj.created,
+			Updated: obj.updated,
+			Size:    int64(len(obj.data)),
+		})
+	}
+	return &memoryIterator{items: attrs}
+}
+
+// Close is a no-op; the provider's state is simply garbage collected
+// with it.
+func (p *MemoryProvider) Close() error {
+	return nil
+}
+
+// memoryWriter buffers writes and commits them to the provider's map on
+// Close, matching the "upload completes on Close" semantics the GCS/S3
+// providers expose.
+type memoryWriter struct {
+	provider *MemoryProvider
+	path     string
+	buf      bytes.Buffer
+}
+
+func (w *memoryWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *memoryWriter) Close() error {
+	w.provider.mu.Lock()
+	defer w.provider.mu.Unlock()
+
+	now := time.Now()
+	created := now
+	if existing, ok := w.provider.objects[w.path]; ok {
+		created = existing.created
+	}
+	w.provider.objects[w.path] = &memoryObject{
+		data:    append([]byte(nil), w.buf.Bytes()...),
+		created: created,
+		updated: now,
+	}
+	return nil
+}
+
+// memoryIterator iterates over a pre-collected slice of ObjectAttrs.
+type memoryIterator struct {
+	items []*ObjectAttrs
+	index int
+}
+
+// Next returns the next object attributes.
+func (i *memoryIterator) Next() (*ObjectAttrs, error) {
+	if i.index >= len(i.items) {
+		return nil, io.EOF
+	}
+	item := i.items[i.index]
+	i.index++
+	return item, nil
+}
+
+func init() {
+	RegisterProvider("memory", func(ctx context.Context, config *Config) (Provider, error) {
+		return NewMemoryProvider(), nil
+	})
+}
@@ -21,9 +21,9 @@ import (
 	"io"
 	"time"
 
-	"go.opencensus.io/stats"
-	"go.opencensus.io/stats/view"
-	"go.opencensus.io/tag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
 // Status constants for metrics.
@@ -33,89 +33,53 @@ const (
 	errorTypeNone = "none"
 )
 
-// Metric keys for storage operations.
-var (
-	// StorageOperationKey identifies the type of storage operation.
-	StorageOperationKey tag.Key
-	// StorageProviderKey identifies the storage provider (gcs, s3, etc).
-	StorageProviderKey tag.Key
-	// StorageStatusKey indicates success or failure.
-	StorageStatusKey tag.Key
-	// StorageErrorTypeKey categorizes the type of error.
-	StorageErrorTypeKey tag.Key
-)
+// meter is this package's OpenTelemetry meter. Instruments are created
+// against it in init() below; the actual export destination (OTLP,
+// Prometheus, stdout, ...) is configured by whatever MeterProvider the
+// binary installs with otel.SetMeterProvider, not by this package.
+var meter = otel.Meter("github.com/google/goblet/storage")
 
-// Metrics for storage operations.
+// Instruments for storage operations.
 var (
-	// StorageOperationCount counts storage operations by type and status.
-	StorageOperationCount = stats.Int64(
-		"goblet/storage/operations",
-		"Number of storage operations",
-		stats.UnitDimensionless,
-	)
-
-	// StorageOperationLatency measures operation duration.
-	StorageOperationLatency = stats.Float64(
-		"goblet/storage/latency",
-		"Storage operation latency in milliseconds",
-		stats.UnitMilliseconds,
-	)
-
-	// StorageBytesTransferred tracks bytes read/written.
-	StorageBytesTransferred = stats.Int64(
-		"goblet/storage/bytes",
-		"Bytes transferred in storage operations",
-		stats.UnitBytes,
-	)
+	// storageOperationCount counts storage operations by operation,
+	// provider, status, and error_type attributes.
+	storageOperationCount metric.Int64Counter
+	// storageOperationLatency measures operation duration in
+	// milliseconds, by the same operation, provider, status, and
+	// error_type attributes.
+	storageOperationLatency metric.Float64Histogram
+	// storageBytesTransferred tracks bytes read/written, by operation
+	// ("read" or "write") and provider attributes.
+	storageBytesTransferred metric.Int64Counter
 )
 
 func init() {
 	var err error
-	StorageOperationKey, err = tag.NewKey("operation")
-	if err != nil {
-		panic(err)
-	}
-	StorageProviderKey, err = tag.NewKey("provider")
+	storageOperationCount, err = meter.Int64Counter(
+		"goblet.storage.operations",
+		metric.WithDescription("Number of storage operations"),
+	)
 	if err != nil {
 		panic(err)
 	}
-	StorageStatusKey, err = tag.NewKey("status")
+	storageOperationLatency, err = meter.Float64Histogram(
+		"goblet.storage.latency",
+		metric.WithDescription("Storage operation latency in milliseconds"),
+		metric.WithUnit("ms"),
+	)
 	if err != nil {
 		panic(err)
 	}
-	StorageErrorTypeKey, err = tag.NewKey("error_type")
+	storageBytesTransferred, err = meter.Int64Counter(
+		"goblet.storage.bytes",
+		metric.WithDescription("Bytes transferred in storage operations"),
+		metric.WithUnit("By"),
+	)
 	if err != nil {
 		panic(err)
 	}
 }
 
-// StorageViews returns all storage-related metric views.
-func StorageViews() []*view.View {
-	return []*view.View{
-		{
-			Name:        "goblet/storage/operations_count",
-			Description: "Count of storage operations by type and status",
-			Measure:     StorageOperationCount,
-			Aggregation: view.Count(),
-			TagKeys:     []tag.Key{StorageOperationKey, StorageProviderKey, StorageStatusKey, StorageErrorTypeKey},
-		},
-		{
-			Name:        "goblet/storage/latency_distribution",
-			Description: "Distribution of storage operation latencies",
-			Measure:     StorageOperationLatency,
-			Aggregation: view.Distribution(0, 10, 50, 100, 250, 500, 1000, 2500, 5000, 10000),
-			TagKeys:     []tag.Key{StorageOperationKey, StorageProviderKey, StorageStatusKey},
-		},
-		{
-			Name:        "goblet/storage/bytes_total",
-			Description: "Total bytes transferred",
-			Measure:     StorageBytesTransferred,
-			Aggregation: view.Sum(),
-			TagKeys:     []tag.Key{StorageOperationKey, StorageProviderKey},
-		},
-	}
-}
-
 // MetricsProvider wraps a Provider with metrics instrumentation.
 type MetricsProvider struct {
 	provider     Provider
@@ -228,16 +192,14 @@ func (m *MetricsProvider) Close() error {
 }
 
 func (m *MetricsProvider) recordMetrics(ctx context.Context, operation, status, errorType string, latency time.Duration) {
-	_ = stats.RecordWithTags(ctx,
-		[]tag.Mutator{
-			tag.Upsert(StorageOperationKey, operation),
-			tag.Upsert(StorageProviderKey, m.providerType),
-			tag.Upsert(StorageStatusKey, status),
-			tag.Upsert(StorageErrorTypeKey, errorType),
-		},
-		StorageOperationCount.M(1),
-		StorageOperationLatency.M(float64(latency.Milliseconds())),
+	attrs := metric.WithAttributes(
+		attribute.String("operation", operation),
+		attribute.String("provider", m.providerType),
+		attribute.String("status", status),
+		attribute.String("error_type", errorType),
 	)
+	storageOperationCount.Add(ctx, 1, attrs)
+	storageOperationLatency.Record(ctx, float64(latency.Milliseconds()), attrs)
 }
 
 // metricsWriter wraps an io.WriteCloser to track bytes written.
@@ -257,14 +219,10 @@ func (mw *metricsWriter) Write(p []byte) (n int, err error) {
 func (mw *metricsWriter) Close() error {
 	err := mw.writer.Close()
 
-	// Record bytes transferred
-	_ = stats.RecordWithTags(mw.ctx,
-		[]tag.Mutator{
-			tag.Upsert(StorageOperationKey, "write"),
-			tag.Upsert(StorageProviderKey, mw.providerType),
-		},
-		StorageBytesTransferred.M(mw.bytesWritten),
-	)
+	storageBytesTransferred.Add(mw.ctx, mw.bytesWritten, metric.WithAttributes(
+		attribute.String("operation", "write"),
+		attribute.String("provider", mw.providerType),
+	))
 
 	return err
 }
@@ -286,14 +244,10 @@ func (mr *metricsReader) Read(p []byte) (n int, err error) {
 func (mr *metricsReader) Close() error {
 	err := mr.reader.Close()
 
-	// Record bytes transferred
-	_ = stats.RecordWithTags(mr.ctx,
-		[]tag.Mutator{
-			tag.Upsert(StorageOperationKey, "read"),
-			tag.Upsert(StorageProviderKey, mr.providerType),
-		},
-		StorageBytesTransferred.M(mr.bytesRead),
-	)
+	storageBytesTransferred.Add(mr.ctx, mr.bytesRead, metric.WithAttributes(
+		attribute.String("operation", "read"),
+		attribute.String("provider", mr.providerType),
+	))
 
 	return err
 }
@@ -314,40 +268,13 @@ func (mi *metricsIterator) Next() (*ObjectAttrs, error) {
 	return attrs, err
 }
 
-// categorizeError categorizes errors for metrics tagging.
+// categorizeError categorizes errors for metrics tagging using the
+// structured taxonomy in errors.go, rather than matching substrings
+// against err.Error() (which breaks whenever a backend rewords a
+// message).
 func categorizeError(err error) string {
 	if err == nil {
 		return errorTypeNone
 	}
-
-	errStr := err.Error()
-	switch {
-	case contains(errStr, "not found", "no such", "does not exist"):
-		return "not_found"
-	case contains(errStr, "permission", "denied", "forbidden", "unauthorized"):
-		return "permission_denied"
-	case contains(errStr, "timeout", "deadline exceeded"):
-		return "timeout"
-	case contains(errStr, "connection", "network", "dial"):
-		return "network"
-	case contains(errStr, "context canceled"):
-		return "canceled"
-	case contains(errStr, "invalid", "malformed"):
-		return "invalid_argument"
-	default:
-		return "unknown"
-	}
-}
-
-func contains(s string, substrs ...string) bool {
-	for _, substr := range substrs {
-		if len(s) >= len(substr) {
-			for i := 0; i <= len(s)-len(substr); i++ {
-				if s[i:i+len(substr)] == substr {
-					return true
-				}
-			}
-		}
-	}
-	return false
+	return string(ClassifyError(err))
 }
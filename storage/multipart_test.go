@@ -0,0 +1,93 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// nopWriteCloser adapts a bytes.Buffer to io.WriteCloser for
+// progressWriter's tests.
+type nopWriteCloser struct {
+	*bytes.Buffer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestProgressWriter_ReportsCumulativeBytes(t *testing.T) {
+	var buf bytes.Buffer
+	var got []int64
+	w := &progressWriter{
+		WriteCloser: nopWriteCloser{&buf},
+		onProgress:  func(n int64) { got = append(got, n) },
+	}
+
+	if _, err := w.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := w.Write([]byte(" world")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	want := []int64{5, 11}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("onProgress calls = %v, want %v", got, want)
+	}
+	if buf.String() != "hello world" {
+		t.Errorf("underlying buffer = %q, want %q", buf.String(), "hello world")
+	}
+}
+
+func TestProgressReader_ReportsCumulativeBytes(t *testing.T) {
+	var got []int64
+	r := &progressReader{
+		Reader:     bytes.NewReader([]byte("hello world")),
+		onProgress: func(n int64) { got = append(got, n) },
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(data) != "hello world" {
+		t.Errorf("data = %q, want %q", data, "hello world")
+	}
+	if len(got) == 0 {
+		t.Fatal("onProgress was never called")
+	}
+	if last := got[len(got)-1]; last != int64(len(data)) {
+		t.Errorf("final onProgress count = %d, want %d", last, len(data))
+	}
+}
+
+func TestMultipartWriterOptions_Fields(t *testing.T) {
+	opts := MultipartWriterOptions{
+		PartSize:    8 << 20,
+		Concurrency: 4,
+		ContentType: "application/x-git-pack",
+	}
+
+	if opts.PartSize != 8<<20 {
+		t.Errorf("PartSize = %d, want %d", opts.PartSize, 8<<20)
+	}
+	if opts.Concurrency != 4 {
+		t.Errorf("Concurrency = %d, want 4", opts.Concurrency)
+	}
+	if opts.ContentType != "application/x-git-pack" {
+		t.Errorf("ContentType = %q, want application/x-git-pack", opts.ContentType)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+// RangeReaderProvider is an optional capability implemented by storage
+// backends that can read a slice of an object without fetching it in
+// full, e.g. a single object from a pack index, or resuming an
+// interrupted upload-pack at a known offset. Not every Provider
+// implementation supports this natively; callers should type-assert,
+// and fall back to ReadRange (which wraps the plain Reader) for the
+// ones that don't.
+type RangeReaderProvider interface {
+	// ReaderAt returns a reader over length bytes of the object at path
+	// starting at offset. length < 0 means read to the end of the
+	// object, mirroring io.SectionReader's convention for an unbounded
+	// range.
+	ReaderAt(ctx context.Context, path string, offset, length int64) (io.ReadCloser, error)
+}
+
+// ReadRange returns a reader over length bytes of the object at path
+// starting at offset, using provider's native RangeReaderProvider
+// support when available, or falling back to Provider.Reader followed
+// by discarding the leading offset bytes and limiting the rest to
+// length. length < 0 means read to the end of the object.
+//
+// The fallback still pays for the bytes before offset in transfer cost
+// from the backend; it exists so callers can use ReadRange uniformly
+// against any Provider rather than branching on a type assertion
+// themselves.
+func ReadRange(ctx context.Context, provider Provider, path string, offset, length int64) (io.ReadCloser, error) {
+	if ra, ok := provider.(RangeReaderProvider); ok {
+		return ra.ReaderAt(ctx, path, offset, length)
+	}
+
+	r, err := provider.Reader(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		if _, err := io.CopyN(io.Discard, r, offset); err != nil {
+			r.Close()
+			return nil, WrapError("ReaderAt", path, err)
+		}
+	}
+	if length < 0 {
+		return r, nil
+	}
+	return &limitedReadCloser{r: r, remaining: length}, nil
+}
+
+// limitedReadCloser caps Read at remaining bytes while still closing
+// the underlying ReadCloser, so a caller ranging into a full-object
+// stream doesn't have to track closing separately from limiting.
+type limitedReadCloser struct {
+	r         io.ReadCloser
+	remaining int64
+}
+
+func (l *limitedReadCloser) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+func (l *limitedReadCloser) Close() error {
+	return l.r.Close()
+}
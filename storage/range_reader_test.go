@@ -0,0 +1,123 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+)
+
+func TestLocalProvider_ReaderAt(t *testing.T) {
+	ctx := context.Background()
+	provider, err := NewLocalProvider(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalProvider failed: %v", err)
+	}
+
+	w, err := provider.Writer(ctx, "object.dat")
+	if err != nil {
+		t.Fatalf("Writer failed: %v", err)
+	}
+	if _, err := w.Write([]byte("hello world")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := provider.ReaderAt(ctx, "object.dat", 6, 5)
+	if err != nil {
+		t.Fatalf("ReaderAt failed: %v", err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(data) != "world" {
+		t.Errorf("data = %q, want %q", data, "world")
+	}
+
+	r, err = provider.ReaderAt(ctx, "object.dat", 6, -1)
+	if err != nil {
+		t.Fatalf("ReaderAt(offset, -1) failed: %v", err)
+	}
+	data, err = io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(data) != "world" {
+		t.Errorf("unbounded data = %q, want %q", data, "world")
+	}
+}
+
+func TestReadRange_UsesRangeReaderProviderWhenAvailable(t *testing.T) {
+	ctx := context.Background()
+	provider, err := NewLocalProvider(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalProvider failed: %v", err)
+	}
+	w, _ := provider.Writer(ctx, "object.dat")
+	w.Write([]byte("hello world"))
+	w.Close()
+
+	r, err := ReadRange(ctx, provider, "object.dat", 6, 5)
+	if err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "world" {
+		t.Errorf("data = %q, want %q", data, "world")
+	}
+}
+
+func TestReadRange_FallsBackForProvidersWithoutReaderAt(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockProvider{
+		readerFunc: func(ctx context.Context, path string) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader([]byte("hello world"))), nil
+		},
+	}
+
+	r, err := ReadRange(ctx, mock, "object.dat", 6, 5)
+	if err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(data) != "world" {
+		t.Errorf("data = %q, want %q", data, "world")
+	}
+
+	r, err = ReadRange(ctx, mock, "object.dat", 6, -1)
+	if err != nil {
+		t.Fatalf("ReadRange(offset, -1) failed: %v", err)
+	}
+	data, err = io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(data) != "world" {
+		t.Errorf("unbounded data = %q, want %q", data, "world")
+	}
+}
@@ -16,47 +16,158 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
 )
 
 // S3Provider implements Provider for S3-compatible storage (including Minio)
 type S3Provider struct {
 	client     *minio.Client
 	bucketName string
+
+	objectLockMode          minio.RetentionMode
+	objectLockRetentionDays int
+	legalHold               bool
+
+	sse               encrypt.ServerSide
+	sseMode           string
+	sseKeyFingerprint string
+
+	presignedURLDefaultTTL time.Duration
+	presignedURLMaxTTL     time.Duration
+
+	stopCredentialsRefresh func()
+	stopEventSource        func()
 }
 
 // NewS3Provider creates a new S3/Minio storage provider
 func NewS3Provider(ctx context.Context, config *Config) (*S3Provider, error) {
+	creds, stopCredentialsRefresh, err := newS3Credentials(config)
+	if err != nil {
+		return nil, err
+	}
+
+	sse, err := newServerSideEncryption(config)
+	if err != nil {
+		stopCredentialsRefresh()
+		return nil, err
+	}
+
 	client, err := minio.New(config.S3Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(config.S3AccessKeyID, config.S3SecretAccessKey, ""),
+		Creds:  creds,
 		Secure: config.S3UseSSL,
 		Region: config.S3Region,
 	})
 	if err != nil {
+		stopCredentialsRefresh()
 		return nil, err
 	}
+	if config.S3UserAgent != "" {
+		client.SetAppInfo(config.S3UserAgent, "")
+	}
 
 	// Ensure bucket exists
 	exists, err := client.BucketExists(ctx, config.S3Bucket)
 	if err != nil {
+		stopCredentialsRefresh()
 		return nil, err
 	}
 	if !exists {
 		err = client.MakeBucket(ctx, config.S3Bucket, minio.MakeBucketOptions{
-			Region: config.S3Region,
+			Region:        config.S3Region,
+			ObjectLocking: config.ObjectLockMode != "",
 		})
 		if err != nil {
+			stopCredentialsRefresh()
 			return nil, err
 		}
 	}
 
-	return &S3Provider{
-		client:     client,
-		bucketName: config.S3Bucket,
-	}, nil
+	presignedURLDefaultTTL := config.S3PresignedURLDefaultTTL
+	if presignedURLDefaultTTL <= 0 {
+		presignedURLDefaultTTL = DefaultPresignedURLTTL
+	}
+	presignedURLMaxTTL := config.S3PresignedURLMaxTTL
+	if presignedURLMaxTTL <= 0 {
+		presignedURLMaxTTL = MaxPresignedURLTTL
+	}
+
+	s := &S3Provider{
+		client:                  client,
+		bucketName:              config.S3Bucket,
+		objectLockRetentionDays: config.ObjectLockRetentionDays,
+		legalHold:               config.LegalHold,
+		sse:                     sse,
+		sseMode:                 config.S3SSEMode,
+		sseKeyFingerprint:       sseKeyFingerprint(config),
+		presignedURLDefaultTTL:  presignedURLDefaultTTL,
+		presignedURLMaxTTL:      presignedURLMaxTTL,
+		stopCredentialsRefresh:  stopCredentialsRefresh,
+	}
+
+	if config.ObjectLockMode != "" {
+		switch config.ObjectLockMode {
+		case "GOVERNANCE":
+			s.objectLockMode = minio.Governance
+		case "COMPLIANCE":
+			s.objectLockMode = minio.Compliance
+		default:
+			stopCredentialsRefresh()
+			return nil, fmt.Errorf("invalid ObjectLockMode %q: must be GOVERNANCE or COMPLIANCE", config.ObjectLockMode)
+		}
+
+		// Fail fast if the bucket wasn't already Object Lock enabled
+		// before goblet started managing it (MakeBucket only applies
+		// ObjectLocking to buckets it creates).
+		if _, _, _, _, err := client.GetObjectLockConfig(ctx, config.S3Bucket); err != nil {
+			stopCredentialsRefresh()
+			return nil, fmt.Errorf("bucket %q does not have Object Lock enabled, required by ObjectLockMode %q: %w", config.S3Bucket, config.ObjectLockMode, err)
+		}
+	}
+
+	s.stopEventSource = startEventSource(client, config)
+
+	return s, nil
+}
+
+// putObjectOptions builds the minio.PutObjectOptions carrying the
+// configured Object Lock mode, retention, and legal hold.
+//
+// When SSE is configured, it also stamps UserMetadata with the SSE mode
+// and a fingerprint of the encrypting key (see sseKeyFingerprint): since
+// S3/Minio don't expose which specific key encrypted an object after the
+// fact, this metadata is what makes key rotation auditable -- after
+// rotating S3SSECustomerKey or S3KMSKeyID, objects still carrying the
+// old fingerprint are the ones that need re-encrypting under the new
+// key.
+func (s *S3Provider) putObjectOptions() minio.PutObjectOptions {
+	opts := minio.PutObjectOptions{}
+	if s.objectLockMode != "" {
+		opts.Mode = &s.objectLockMode
+		if s.objectLockRetentionDays > 0 {
+			until := time.Now().AddDate(0, 0, s.objectLockRetentionDays)
+			opts.RetainUntilDate = &until
+		}
+	}
+	if s.legalHold {
+		on := minio.LegalHoldEnabled
+		opts.LegalHold = &on
+	}
+	if s.sse != nil {
+		opts.ServerSideEncryption = s.sse
+		opts.UserMetadata = map[string]string{
+			"sse-algorithm": s.sseMode,
+		}
+		if s.sseKeyFingerprint != "" {
+			opts.UserMetadata["sse-key-fingerprint"] = s.sseKeyFingerprint
+		}
+	}
+	return opts
 }
 
 // Writer returns a writer for the given object path
@@ -64,7 +175,7 @@ func (s *S3Provider) Writer(ctx context.Context, path string) (io.WriteCloser, e
 	pr, pw := io.Pipe()
 
 	go func() {
-		_, err := s.client.PutObject(ctx, s.bucketName, path, pr, -1, minio.PutObjectOptions{})
+		_, err := s.client.PutObject(ctx, s.bucketName, path, pr, -1, s.putObjectOptions())
 		if err != nil {
 			pr.CloseWithError(err)
 		} else {
@@ -75,9 +186,109 @@ func (s *S3Provider) Writer(ctx context.Context, path string) (io.WriteCloser, e
 	return pw, nil
 }
 
+// Attrs returns metadata for the object at path.
+func (s *S3Provider) Attrs(ctx context.Context, path string) (*ObjectAttrs, error) {
+	info, err := s.client.StatObject(ctx, s.bucketName, path, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, WrapError("Attrs", path, err)
+	}
+	return s3ObjectAttrs(info), nil
+}
+
+// s3ObjectAttrs translates a minio.ObjectInfo into the package's
+// backend-agnostic ObjectAttrs.
+func s3ObjectAttrs(info minio.ObjectInfo) *ObjectAttrs {
+	return &ObjectAttrs{
+		Name:         info.Key,
+		Created:      info.LastModified,
+		Updated:      info.LastModified,
+		Size:         info.Size,
+		ETag:         info.ETag,
+		ContentType:  info.ContentType,
+		StorageClass: info.StorageClass,
+		Metadata:     info.UserMetadata,
+	}
+}
+
+// WriterWithOptions implements ConditionalProvider. S3 has no concept of
+// GCS-style object generations, so IfGenerationMatch always fails;
+// IfNoneMatch is enforced by Stat-ing path immediately before the write,
+// which narrows but -- unlike GCS's native precondition -- doesn't fully
+// close the race against a concurrent writer to the same key.
+func (s *S3Provider) WriterWithOptions(ctx context.Context, path string, opts WriteOptions) (io.WriteCloser, error) {
+	if opts.IfGenerationMatch != nil {
+		return nil, fmt.Errorf("storage: S3 provider does not support IfGenerationMatch")
+	}
+	if opts.IfNoneMatch {
+		if _, err := s.client.StatObject(ctx, s.bucketName, path, minio.StatObjectOptions{}); err == nil {
+			return nil, &Error{Code: ErrCodeAlreadyExists, Op: "WriterWithOptions", Path: path, Err: fmt.Errorf("object already exists")}
+		} else if ClassifyError(err) != ErrCodeNotFound {
+			return nil, WrapError("WriterWithOptions", path, err)
+		}
+	}
+	return s.Writer(ctx, path)
+}
+
+// SetRetention applies (or extends) an Object Lock retention period on
+// an existing object, using the provider's configured ObjectLockMode.
+func (s *S3Provider) SetRetention(ctx context.Context, key string, until time.Time) error {
+	if s.objectLockMode == "" {
+		return fmt.Errorf("SetRetention requires ObjectLockMode to be configured on the provider")
+	}
+	return s.client.PutObjectRetention(ctx, s.bucketName, key, minio.PutObjectRetentionOptions{
+		Mode:            &s.objectLockMode,
+		RetainUntilDate: &until,
+	})
+}
+
+// SetLegalHold turns an Object Lock legal hold on or off for an existing
+// object, independent of any retention period.
+func (s *S3Provider) SetLegalHold(ctx context.Context, key string, on bool) error {
+	status := minio.LegalHoldDisabled
+	if on {
+		status = minio.LegalHoldEnabled
+	}
+	return s.client.PutObjectLegalHold(ctx, s.bucketName, key, minio.PutObjectLegalHoldOptions{
+		Status: &status,
+	})
+}
+
 // Reader returns a reader for the given object path
 func (s *S3Provider) Reader(ctx context.Context, path string) (io.ReadCloser, error) {
-	return s.client.GetObject(ctx, s.bucketName, path, minio.GetObjectOptions{})
+	opts := minio.GetObjectOptions{}
+	// Only SSE-C requires the customer key on reads; SSE-S3/SSE-KMS are
+	// transparent to the reader since the server holds the key.
+	if s.sse != nil && s.sse.Type() == encrypt.SSEC {
+		opts.ServerSideEncryption = s.sse
+	}
+	return s.client.GetObject(ctx, s.bucketName, path, opts)
+}
+
+// ReaderAt implements RangeReaderProvider using minio-go's
+// GetObjectOptions.SetRange.
+func (s *S3Provider) ReaderAt(ctx context.Context, path string, offset, length int64) (io.ReadCloser, error) {
+	opts := minio.GetObjectOptions{}
+	if s.sse != nil && s.sse.Type() == encrypt.SSEC {
+		opts.ServerSideEncryption = s.sse
+	}
+	switch {
+	case length < 0 && offset == 0:
+		// The whole object; SetRange(0, 0) would instead mean "just
+		// the first byte", so leave the Range header unset.
+	case length < 0:
+		if err := opts.SetRange(offset, 0); err != nil {
+			return nil, WrapError("ReaderAt", path, err)
+		}
+	default:
+		if err := opts.SetRange(offset, offset+length-1); err != nil {
+			return nil, WrapError("ReaderAt", path, err)
+		}
+	}
+	obj, err := s.client.GetObject(ctx, s.bucketName, path, opts)
+	if err != nil {
+		return nil, WrapError("ReaderAt", path, err)
+	}
+	return obj, nil
 }
 
 // Delete removes an object at the given path
@@ -100,6 +311,12 @@ func (s *S3Provider) List(ctx context.Context, prefix string) ObjectIterator {
 
 // Close closes the S3 client (no-op for Minio client)
 func (s *S3Provider) Close() error {
+	if s.stopEventSource != nil {
+		s.stopEventSource()
+	}
+	if s.stopCredentialsRefresh != nil {
+		s.stopCredentialsRefresh()
+	}
 	return nil
 }
 
@@ -120,21 +337,19 @@ func (i *s3Iterator) Next() (*ObjectAttrs, error) {
 			return nil, obj.Err
 		}
 
-		name := obj.Key
-		prefix := ""
+		attrs := s3ObjectAttrs(obj)
 		if obj.Key == "" {
 			// This is a prefix/directory entry
-			prefix = obj.Key
+			attrs.Prefix = obj.Key
 		}
-
-		return &ObjectAttrs{
-			Name:    name,
-			Prefix:  prefix,
-			Created: obj.LastModified,
-			Updated: obj.LastModified,
-			Size:    obj.Size,
-		}, nil
+		return attrs, nil
 	case <-i.ctx.Done():
 		return nil, i.ctx.Err()
 	}
 }
+
+func init() {
+	RegisterProvider("s3", func(ctx context.Context, config *Config) (Provider, error) {
+		return NewS3Provider(ctx, config)
+	})
+}
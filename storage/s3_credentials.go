@@ -0,0 +1,134 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// credentialsRefreshInterval is how often the background goroutine
+// started by newS3Credentials forces the underlying credentials.Credentials
+// to check for expiry, so a temporary STS/IAM credential is rotated well
+// before a request would otherwise hit an expired one.
+const credentialsRefreshInterval = time.Minute
+
+// newS3Credentials builds the credentials.Credentials for config.S3CredentialsMode
+// ("static" is the default) and, for the non-static modes, starts a
+// background goroutine that proactively refreshes the credential. The
+// returned stop function must be called (from Close) to terminate that
+// goroutine.
+func newS3Credentials(config *Config) (*credentials.Credentials, func(), error) {
+	mode := config.S3CredentialsMode
+	if mode == "" {
+		mode = "static"
+	}
+
+	switch mode {
+	case "static":
+		return credentials.NewStaticV4(config.S3AccessKeyID, config.S3SecretAccessKey, ""), func() {}, nil
+
+	case "env":
+		return credentials.NewEnvAWS(), func() {}, nil
+
+	case "iam":
+		return credentials.NewIAM(config.S3STSEndpoint), func() {}, nil
+
+	case "assume_role":
+		creds, err := credentials.NewSTSAssumeRole(config.S3STSEndpoint, credentials.STSAssumeRoleOptions{
+			AccessKey:       config.S3AccessKeyID,
+			SecretKey:       config.S3SecretAccessKey,
+			RoleARN:         config.S3RoleARN,
+			RoleSessionName: config.S3RoleSessionName,
+			ExternalID:      config.S3ExternalID,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot create STS assume-role credentials: %w", err)
+		}
+		return creds, startCredentialsRefresher(creds), nil
+
+	case "web-identity":
+		creds, err := credentials.NewSTSWebIdentity(config.S3STSEndpoint, func() (*credentials.WebIdentityToken, error) {
+			token, err := readS3IdentityToken(config)
+			if err != nil {
+				return nil, err
+			}
+			return &credentials.WebIdentityToken{Token: token}, nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot create STS web-identity credentials: %w", err)
+		}
+		return creds, startCredentialsRefresher(creds), nil
+
+	case "client-grants":
+		creds, err := credentials.NewSTSClientGrants(config.S3STSEndpoint, func() (*credentials.ClientGrantsToken, error) {
+			token, err := readS3IdentityToken(config)
+			if err != nil {
+				return nil, err
+			}
+			return &credentials.ClientGrantsToken{Token: token}, nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot create STS client-grants credentials: %w", err)
+		}
+		return creds, startCredentialsRefresher(creds), nil
+
+	default:
+		return nil, nil, fmt.Errorf("invalid S3CredentialsMode %q: must be static, env, iam, web-identity, client-grants, or assume_role", mode)
+	}
+}
+
+// readS3IdentityToken produces the OIDC/JWT assertion to exchange with
+// STS: config.S3TokenRefreshFunc takes priority (e.g. to reuse the same
+// token source goblet already uses to validate Claims), falling back to
+// reading S3WebIdentityTokenFile (the Kubernetes IRSA convention).
+func readS3IdentityToken(config *Config) (string, error) {
+	if config.S3TokenRefreshFunc != nil {
+		return config.S3TokenRefreshFunc(context.Background())
+	}
+	if config.S3WebIdentityTokenFile == "" {
+		return "", fmt.Errorf("S3CredentialsMode %q requires S3TokenRefreshFunc or S3WebIdentityTokenFile", config.S3CredentialsMode)
+	}
+	bs, err := os.ReadFile(config.S3WebIdentityTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("cannot read web identity token file %q: %w", config.S3WebIdentityTokenFile, err)
+	}
+	return strings.TrimSpace(string(bs)), nil
+}
+
+// startCredentialsRefresher runs a background goroutine that periodically
+// calls creds.Get(), which transparently renews the credential once it's
+// within its expiry window, and returns a stop function to terminate it.
+func startCredentialsRefresher(creds *credentials.Credentials) func() {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(credentialsRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_, _ = creds.Get()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return func() { close(stop) }
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+)
+
+// SetLifecycleRules implements LifecycleProvider using minio-go's
+// bucket-lifecycle APIs.
+func (s *S3Provider) SetLifecycleRules(ctx context.Context, rules []LifecycleRule) error {
+	cfg := lifecycle.NewConfiguration()
+	for i, r := range rules {
+		rule := lifecycle.Rule{
+			ID:     fmt.Sprintf("goblet-%d", i),
+			Status: "Enabled",
+			RuleFilter: lifecycle.Filter{
+				Prefix: r.Prefix,
+			},
+		}
+		if r.ExpireAfter > 0 {
+			rule.Expiration = lifecycle.Expiration{
+				Days: lifecycle.ExpirationDays(daysFromDuration(r.ExpireAfter)),
+			}
+		}
+		if r.TransitionAfter > 0 {
+			rule.Transition = lifecycle.Transition{
+				Days:         lifecycle.ExpirationDays(daysFromDuration(r.TransitionAfter)),
+				StorageClass: r.TransitionStorageClass,
+			}
+		}
+		if r.AbortIncompleteUploadsAfter > 0 {
+			rule.AbortIncompleteMultipartUpload = lifecycle.AbortIncompleteMultipartUpload{
+				DaysAfterInitiation: lifecycle.ExpirationDays(daysFromDuration(r.AbortIncompleteUploadsAfter)),
+			}
+		}
+		cfg.Rules = append(cfg.Rules, rule)
+	}
+
+	if err := s.client.SetBucketLifecycle(ctx, s.bucketName, cfg); err != nil {
+		return WrapError("SetLifecycleRules", "", err)
+	}
+	return nil
+}
+
+// LifecycleRules implements LifecycleProvider using minio-go's
+// bucket-lifecycle APIs.
+func (s *S3Provider) LifecycleRules(ctx context.Context) ([]LifecycleRule, error) {
+	cfg, err := s.client.GetBucketLifecycle(ctx, s.bucketName)
+	if err != nil {
+		return nil, WrapError("LifecycleRules", "", err)
+	}
+
+	rules := make([]LifecycleRule, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		rules = append(rules, LifecycleRule{
+			Prefix:                      r.RuleFilter.Prefix,
+			ExpireAfter:                 durationFromDays(int(r.Expiration.Days)),
+			TransitionAfter:             durationFromDays(int(r.Transition.Days)),
+			TransitionStorageClass:      r.Transition.StorageClass,
+			AbortIncompleteUploadsAfter: durationFromDays(int(r.AbortIncompleteMultipartUpload.DaysAfterInitiation)),
+		})
+	}
+	return rules, nil
+}
+
+// daysFromDuration rounds d up to a whole number of days, since S3
+// bucket lifecycle rules only support day-granularity ages.
+func daysFromDuration(d time.Duration) int {
+	days := int(d / (24 * time.Hour))
+	if d%(24*time.Hour) != 0 {
+		days++
+	}
+	if days < 1 {
+		days = 1
+	}
+	return days
+}
+
+func durationFromDays(days int) time.Duration {
+	return time.Duration(days) * 24 * time.Hour
+}
@@ -0,0 +1,46 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDaysFromDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want int
+	}{
+		{"exact days", 3 * 24 * time.Hour, 3},
+		{"rounds up partial day", 25 * time.Hour, 2},
+		{"sub-day rounds up to one day", time.Hour, 1},
+		{"zero rounds up to one day", 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := daysFromDuration(tt.d); got != tt.want {
+				t.Errorf("daysFromDuration(%v) = %d, want %d", tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationFromDays(t *testing.T) {
+	if got, want := durationFromDays(7), 7*24*time.Hour; got != want {
+		t.Errorf("durationFromDays(7) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// DefaultPresignedURLTTL and MaxPresignedURLTTL are the fallback values
+// applied when a Config doesn't set S3PresignedURLDefaultTTL/
+// S3PresignedURLMaxTTL.
+const (
+	DefaultPresignedURLTTL = 15 * time.Minute
+	MaxPresignedURLTTL     = time.Hour
+)
+
+// PresignedProvider is an optional capability implemented by storage
+// backends that can mint time-limited URLs for direct client access,
+// bypassing the goblet proxy for large transfers (e.g. multi-GB bundle
+// downloads). Not every Provider implementation supports this; callers
+// should type-assert.
+//
+// Because the returned URL is signed against whatever credentials the
+// provider currently holds, a caller that mints a fresh URL for each
+// request automatically picks up rotated STS/IAM credentials (see
+// S3CredentialsMode) without any extra bookkeeping — there is nothing to
+// "re-sign" later, since nothing is cached past the call.
+type PresignedProvider interface {
+	// PresignedGet returns a URL valid for ttl (0 selects the
+	// provider's configured default, and the provider may clamp
+	// oversized requests to its configured maximum) that performs a GET
+	// of key. reqParams, if non-nil, is forwarded as additional
+	// query-string response overrides (e.g.
+	// "response-content-disposition").
+	PresignedGet(ctx context.Context, key string, ttl time.Duration, reqParams url.Values) (*url.URL, error)
+	// PresignedPut returns a URL valid for ttl (subject to the same
+	// default/clamp rules as PresignedGet) that performs a PUT of key.
+	PresignedPut(ctx context.Context, key string, ttl time.Duration) (*url.URL, error)
+}
+
+// clampTTL applies defaultTTL when ttl is zero and caps the result at
+// maxTTL.
+func clampTTL(ttl, defaultTTL, maxTTL time.Duration) time.Duration {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	if ttl > maxTTL {
+		ttl = maxTTL
+	}
+	return ttl
+}
+
+// PresignedGet implements PresignedProvider.
+func (s *S3Provider) PresignedGet(ctx context.Context, key string, ttl time.Duration, reqParams url.Values) (*url.URL, error) {
+	ttl = clampTTL(ttl, s.presignedURLDefaultTTL, s.presignedURLMaxTTL)
+	return s.client.PresignedGetObject(ctx, s.bucketName, key, ttl, reqParams)
+}
+
+// PresignedPut implements PresignedProvider.
+func (s *S3Provider) PresignedPut(ctx context.Context, key string, ttl time.Duration) (*url.URL, error) {
+	ttl = clampTTL(ttl, s.presignedURLDefaultTTL, s.presignedURLMaxTTL)
+	return s.client.PresignedPutObject(ctx, s.bucketName, key, ttl)
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// newServerSideEncryption builds the encrypt.ServerSide to attach to
+// PutObjectOptions/GetObjectOptions from config.S3SSEMode. An empty or
+// "none" mode returns (nil, nil), leaving objects unencrypted (or subject
+// to whatever default encryption the bucket itself enforces).
+func newServerSideEncryption(config *Config) (encrypt.ServerSide, error) {
+	mode := config.S3SSEMode
+	if mode == "" {
+		mode = "none"
+	}
+
+	switch mode {
+	case "none":
+		return nil, nil
+
+	case "SSE-S3":
+		return encrypt.NewSSE(), nil
+
+	case "SSE-KMS":
+		if config.S3KMSKeyID == "" {
+			return nil, fmt.Errorf("S3SSEMode \"SSE-KMS\" requires S3KMSKeyID")
+		}
+		return encrypt.NewSSEKMS(config.S3KMSKeyID, nil)
+
+	case "SSE-C":
+		if !config.S3UseSSL {
+			return nil, fmt.Errorf("S3SSEMode \"SSE-C\" requires S3UseSSL to be true: customer keys must not travel over a plaintext connection")
+		}
+		key, err := readSSECustomerKey(config.S3SSECustomerKey)
+		if err != nil {
+			return nil, err
+		}
+		return encrypt.NewSSEC(key)
+
+	default:
+		return nil, fmt.Errorf("invalid S3SSEMode %q: must be none, SSE-S3, SSE-KMS, or SSE-C", mode)
+	}
+}
+
+// sseKeyFingerprint records which key encrypted an object, without
+// revealing the key itself, so a later key rotation can tell which
+// objects were written under a retired key: it's the SHA-256 of the
+// mode-specific key material (the customer key for SSE-C, the KMS key
+// ID for SSE-KMS), truncated to 16 hex characters. Empty for SSE-S3
+// (bucket-managed, no caller-visible key) and "none".
+func sseKeyFingerprint(config *Config) string {
+	var material string
+	switch config.S3SSEMode {
+	case "SSE-KMS":
+		material = config.S3KMSKeyID
+	case "SSE-C":
+		key, err := readSSECustomerKey(config.S3SSECustomerKey)
+		if err != nil {
+			return ""
+		}
+		material = string(key)
+	default:
+		return ""
+	}
+	sum := sha256.Sum256([]byte(material))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// readSSECustomerKey reads the raw 32-byte SSE-C customer key from the
+// file at path. The key is never logged by this package; callers must
+// take the same care with any error returned here, which does not
+// include the key material.
+func readSSECustomerKey(path string) ([]byte, error) {
+	if path == "" {
+		return nil, fmt.Errorf("S3SSEMode \"SSE-C\" requires S3SSECustomerKey (a path to the key file)")
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read SSE-C customer key file %q: %w", path, err)
+	}
+	return []byte(strings.TrimSpace(string(bs))), nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSSECustomerKeyFile(t *testing.T, key string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sse-c.key")
+	if err := os.WriteFile(path, []byte(key), 0600); err != nil {
+		t.Fatalf("failed to write SSE-C key file: %v", err)
+	}
+	return path
+}
+
+func TestNewServerSideEncryption_None(t *testing.T) {
+	sse, err := newServerSideEncryption(&Config{})
+	if err != nil {
+		t.Fatalf("newServerSideEncryption() error = %v", err)
+	}
+	if sse != nil {
+		t.Errorf("newServerSideEncryption() = %v, want nil for an unset mode", sse)
+	}
+}
+
+func TestNewServerSideEncryption_S3(t *testing.T) {
+	sse, err := newServerSideEncryption(&Config{S3SSEMode: "SSE-S3"})
+	if err != nil {
+		t.Fatalf("newServerSideEncryption() error = %v", err)
+	}
+	if sse == nil {
+		t.Fatal("newServerSideEncryption() = nil, want a non-nil encrypt.ServerSide for SSE-S3")
+	}
+}
+
+func TestNewServerSideEncryption_KMSRequiresKeyID(t *testing.T) {
+	if _, err := newServerSideEncryption(&Config{S3SSEMode: "SSE-KMS"}); err == nil {
+		t.Error("newServerSideEncryption() error = nil, want an error when S3KMSKeyID is unset")
+	}
+
+	sse, err := newServerSideEncryption(&Config{S3SSEMode: "SSE-KMS", S3KMSKeyID: "arn:aws:kms:us-east-1:1234:key/abc"})
+	if err != nil {
+		t.Fatalf("newServerSideEncryption() error = %v", err)
+	}
+	if sse == nil {
+		t.Fatal("newServerSideEncryption() = nil, want a non-nil encrypt.ServerSide for SSE-KMS")
+	}
+}
+
+func TestNewServerSideEncryption_CustomerKey(t *testing.T) {
+	keyFile := writeSSECustomerKeyFile(t, "01234567890123456789012345678901")
+
+	if _, err := newServerSideEncryption(&Config{S3SSEMode: "SSE-C", S3SSECustomerKey: keyFile}); err == nil {
+		t.Error("newServerSideEncryption() error = nil, want an error when S3UseSSL is false")
+	}
+
+	sse, err := newServerSideEncryption(&Config{S3SSEMode: "SSE-C", S3SSECustomerKey: keyFile, S3UseSSL: true})
+	if err != nil {
+		t.Fatalf("newServerSideEncryption() error = %v", err)
+	}
+	if sse == nil {
+		t.Fatal("newServerSideEncryption() = nil, want a non-nil encrypt.ServerSide for SSE-C")
+	}
+}
+
+func TestNewServerSideEncryption_InvalidMode(t *testing.T) {
+	if _, err := newServerSideEncryption(&Config{S3SSEMode: "rot13"}); err == nil {
+		t.Error("newServerSideEncryption() error = nil, want an error for an unrecognized S3SSEMode")
+	}
+}
+
+func TestSSEKeyFingerprint(t *testing.T) {
+	keyFile := writeSSECustomerKeyFile(t, "01234567890123456789012345678901")
+
+	if fp := sseKeyFingerprint(&Config{}); fp != "" {
+		t.Errorf("sseKeyFingerprint() = %q, want \"\" for an unset mode", fp)
+	}
+	if fp := sseKeyFingerprint(&Config{S3SSEMode: "SSE-S3"}); fp != "" {
+		t.Errorf("sseKeyFingerprint() = %q, want \"\" for SSE-S3 (bucket-managed key)", fp)
+	}
+
+	kmsFP := sseKeyFingerprint(&Config{S3SSEMode: "SSE-KMS", S3KMSKeyID: "key-a"})
+	if kmsFP == "" {
+		t.Fatal("sseKeyFingerprint() = \"\", want a non-empty fingerprint for SSE-KMS")
+	}
+	if got := sseKeyFingerprint(&Config{S3SSEMode: "SSE-KMS", S3KMSKeyID: "key-b"}); got == kmsFP {
+		t.Error("sseKeyFingerprint() returned the same fingerprint for two different KMS key IDs")
+	}
+
+	cFP := sseKeyFingerprint(&Config{S3SSEMode: "SSE-C", S3SSECustomerKey: keyFile})
+	if cFP == "" {
+		t.Fatal("sseKeyFingerprint() = \"\", want a non-empty fingerprint for SSE-C")
+	}
+	if again := sseKeyFingerprint(&Config{S3SSEMode: "SSE-C", S3SSECustomerKey: keyFile}); again != cFP {
+		t.Errorf("sseKeyFingerprint() not stable across calls: %q vs %q", cFP, again)
+	}
+}
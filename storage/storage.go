@@ -16,7 +16,10 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"sync"
 	"time"
 )
 
@@ -34,6 +37,12 @@ type Provider interface {
 	// List returns an iterator for objects with the given prefix
 	List(ctx context.Context, prefix string) ObjectIterator
 
+	// Attrs returns metadata for the object at path without reading its
+	// contents, so a caller can verify an upload (comparing Checksums
+	// against a locally-computed digest) or check whether an object
+	// already exists without a full Get.
+	Attrs(ctx context.Context, path string) (*ObjectAttrs, error)
+
 	// Close closes the provider connection
 	Close() error
 }
@@ -51,16 +60,81 @@ type ObjectAttrs struct {
 	Created time.Time
 	Updated time.Time
 	Size    int64
+
+	// ETag is the backend's native entity tag (GCS's generation-derived
+	// ETag, S3's ETag header), suitable for IfNoneMatch-style
+	// conditional requests against that same backend.
+	ETag string
+	// MD5 is the object's base64-or-hex-encoded MD5 digest, as reported
+	// by the backend, or "" if the backend didn't compute one (e.g. an
+	// S3 multipart upload's ETag isn't a plain MD5).
+	MD5 string
+	// CRC32C is the object's base64-encoded CRC32C checksum. Populated
+	// for GCS, which always computes one; left "" on backends that
+	// don't.
+	CRC32C string
+	// ContentType is the object's stored Content-Type.
+	ContentType string
+	// StorageClass is the backend's storage class/tier for the object
+	// (e.g. GCS's "STANDARD", S3's "STANDARD"/"GLACIER"), or "" if the
+	// backend doesn't expose one.
+	StorageClass string
+	// Metadata holds backend-specific user metadata key/value pairs
+	// attached to the object.
+	Metadata map[string]string
+}
+
+// WriteOptions configures a conditional write via ConditionalProvider.
+type WriteOptions struct {
+	// IfGenerationMatch, if non-nil, makes the write succeed only if the
+	// object's current generation/version equals this value (GCS
+	// generations; S3 doesn't support this and returns an error).
+	IfGenerationMatch *int64
+	// IfNoneMatch, if true, makes the write succeed only if no object
+	// currently exists at the path -- "upload only if missing" -- used
+	// to deduplicate a shared pack file across mirror instances without
+	// a separate Attrs-then-Writer race.
+	IfNoneMatch bool
+}
+
+// ConditionalProvider is an optional capability implemented by storage
+// backends that can enforce IfGenerationMatch/IfNoneMatch preconditions
+// natively, rather than requiring a caller to Attrs-then-Writer (which
+// races against a concurrent writer on the same path). Not every
+// Provider implementation supports this; callers should type-assert.
+type ConditionalProvider interface {
+	// WriterWithOptions behaves like Provider.Writer, except the write
+	// only completes if opts' preconditions hold; violating one fails
+	// the returned writer's Close with a *Error whose Code is
+	// ErrCodeAlreadyExists.
+	WriterWithOptions(ctx context.Context, path string, opts WriteOptions) (io.WriteCloser, error)
 }
 
 // Config holds storage provider configuration
 type Config struct {
-	// Provider type: "gcs" or "s3"
+	// Provider type: "gcs", "s3", "azure", "local", or "memory"
 	Provider string
 
 	// For GCS
 	GCSBucket string
 
+	// For Azure Blob Storage. AzureConnectionString, if set, takes
+	// precedence over AzureAccountName/AzureAccountKey; with neither
+	// set, AzureAccountName is combined with Azure's default credential
+	// chain (managed identity, environment, CLI), analogous to S3's
+	// "iam" mode. AzureEndpoint overrides the default
+	// "https://<account>.blob.core.windows.net/" service URL, e.g. to
+	// point at the Azurite emulator in tests.
+	AzureConnectionString string
+	AzureAccountName      string
+	AzureAccountKey       string
+	AzureContainer        string
+	AzureEndpoint         string
+
+	// For the local filesystem provider, the directory objects are
+	// written under.
+	LocalPath string
+
 	// For S3/Minio
 	S3Endpoint        string
 	S3Bucket          string
@@ -68,16 +142,162 @@ type Config struct {
 	S3SecretAccessKey string
 	S3Region          string
 	S3UseSSL          bool
+
+	// S3CredentialsMode selects how S3 credentials are obtained:
+	// "static" (the default, using S3AccessKeyID/S3SecretAccessKey),
+	// "env" (the AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY/AWS_SESSION_TOKEN
+	// environment variables), "iam" (EC2/ECS instance metadata),
+	// "web-identity" (STS AssumeRoleWithWebIdentity, e.g. Kubernetes
+	// IRSA), "client-grants" (STS AssumeRoleWithClientGrants), or
+	// "assume_role" (STS AssumeRole, layered on top of
+	// S3AccessKeyID/S3SecretAccessKey as the calling identity).
+	S3CredentialsMode string
+	// S3STSEndpoint is the STS (or IAM metadata) endpoint used by the
+	// iam/web-identity/client-grants/assume_role modes.
+	S3STSEndpoint string
+	// S3RoleARN and S3RoleSessionName are passed through to the STS
+	// AssumeRole call for the web-identity/client-grants/assume_role
+	// modes.
+	S3RoleARN         string
+	S3RoleSessionName string
+	// S3ExternalID is passed through to the STS AssumeRole call for the
+	// assume_role mode, for cross-account roles that require it.
+	S3ExternalID string
+	// S3WebIdentityTokenFile is read for the OIDC/JWT assertion exchanged
+	// with STS in web-identity/client-grants mode, following the
+	// Kubernetes IRSA convention (AWS_WEB_IDENTITY_TOKEN_FILE). Ignored
+	// if S3TokenRefreshFunc is set.
+	S3WebIdentityTokenFile string
+	// S3TokenRefreshFunc, if set, is called instead of reading
+	// S3WebIdentityTokenFile to obtain the OIDC/JWT assertion to exchange
+	// with STS, e.g. to reuse the token source goblet already validates
+	// for Claims.
+	S3TokenRefreshFunc func(context.Context) (string, error)
+	// S3UserAgent, if set, is appended to the User-Agent header on every
+	// request the S3 client sends, so operators can pick goblet's
+	// traffic out of S3/Minio access logs next to other tenants.
+	S3UserAgent string
+
+	// EventsEnabled subscribes the provider to S3 bucket notifications
+	// (Minio's ListenBucketNotification for the S3 provider) and invokes
+	// EventHandler for each s3:ObjectCreated:*/s3:ObjectRemoved:* event,
+	// so a bundle written by another replica (or an out-of-band backup
+	// job) can trigger a local cache prefetch or eviction.
+	EventsEnabled bool
+	// EventsPrefix and EventsSuffix filter which object keys generate
+	// notifications, mirroring the S3/Minio notification API.
+	EventsPrefix string
+	EventsSuffix string
+	// EventsQueueARN, if set, routes notifications through an AWS SQS
+	// queue instead of a direct long-poll subscription; only meaningful
+	// against real S3, not Minio.
+	EventsQueueARN string
+	// EventHandler, if set alongside EventsEnabled, is called from the
+	// provider's background event-loop goroutine for every translated
+	// Event.
+	EventHandler func(Event)
+
+	// S3SSEMode selects server-side encryption for objects written
+	// through the S3 provider: "none" (the default), "SSE-S3",
+	// "SSE-KMS", or "SSE-C".
+	S3SSEMode string
+	// S3KMSKeyID is the KMS key ID used when S3SSEMode is "SSE-KMS".
+	S3KMSKeyID string
+	// S3SSECustomerKey is a path to a file holding the raw customer key
+	// used when S3SSEMode is "SSE-C". Never logged.
+	S3SSECustomerKey string
+
+	// ObjectLockMode enables S3 Object Lock (WORM) on every object
+	// written through the S3 provider: "GOVERNANCE", "COMPLIANCE", or ""
+	// to leave objects unlocked. Requires the target bucket to already
+	// have Object Lock enabled; NewProvider verifies this and fails
+	// fast otherwise.
+	ObjectLockMode string
+	// ObjectLockRetentionDays is the retention period applied to each
+	// object when ObjectLockMode is set.
+	ObjectLockRetentionDays int
+	// LegalHold additionally places a legal hold on every object
+	// written through the S3 provider, blocking deletion independent
+	// of (and outlasting) any retention period.
+	LegalHold bool
+
+	// S3PresignedURLDefaultTTL is the lifetime applied to a presigned
+	// URL (see PresignedProvider) when the caller doesn't specify one.
+	// Defaults to 15 minutes.
+	S3PresignedURLDefaultTTL time.Duration
+	// S3PresignedURLMaxTTL caps the lifetime a caller may request for a
+	// presigned URL; requests for a longer TTL are clamped to this
+	// value. Defaults to 1 hour.
+	S3PresignedURLMaxTTL time.Duration
+
+	// GCSSignedURLServiceAccountKeyFile is a path to a service account
+	// JSON key file used to sign PresignedProvider URLs for the GCS
+	// backend (GCS's V4 signing needs a private key to sign with; the
+	// ambient credentials NewGCSProvider otherwise uses, e.g. a workload
+	// identity binding, normally can't sign directly). Leave "" to
+	// disable PresignedProvider support for GCS.
+	GCSSignedURLServiceAccountKeyFile string
+	// GCSSignedURLDefaultTTL and GCSSignedURLMaxTTL mirror
+	// S3PresignedURLDefaultTTL/S3PresignedURLMaxTTL for the GCS backend.
+	GCSSignedURLDefaultTTL time.Duration
+	GCSSignedURLMaxTTL     time.Duration
+}
+
+// ProviderFactory constructs a Provider from config. Built-in backends
+// register one under their own name via RegisterProvider from an
+// init(), in their own file (see gcs.go, s3.go, azure.go, local.go,
+// memory.go); a downstream user can register another one (Swift, Ceph
+// RGW, IPFS, ...) the same way, without forking goblet, mirroring the
+// extensible bucket-client registry used by projects like Thanos
+// objstore.
+type ProviderFactory func(ctx context.Context, config *Config) (Provider, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]ProviderFactory{}
+)
+
+// RegisterProvider registers factory under name, so
+// NewProvider(ctx, &Config{Provider: name}) constructs it.
+// Registering a name that's already registered overwrites the previous
+// factory; tests use this to substitute a fake provider for a built-in
+// one. Safe for concurrent use.
+func RegisterProvider(name string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = factory
 }
 
-// NewProvider creates a new storage provider based on configuration
+// Providers returns the names of all currently registered providers, in
+// no particular order.
+func Providers() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// ErrUnknownProvider is returned by NewProvider when config.Provider
+// doesn't match any name registered with RegisterProvider.
+var ErrUnknownProvider = errors.New("storage: unknown provider")
+
+// NewProvider creates a new storage provider based on configuration. An
+// empty config.Provider means no backup/offload backend is configured
+// and returns (nil, nil); any other unregistered name returns
+// ErrUnknownProvider.
 func NewProvider(ctx context.Context, config *Config) (Provider, error) {
-	switch config.Provider {
-	case "gcs":
-		return NewGCSProvider(ctx, config.GCSBucket)
-	case "s3":
-		return NewS3Provider(ctx, config)
-	default:
+	if config.Provider == "" {
 		return nil, nil // No backup configured
 	}
+
+	providersMu.RLock()
+	factory, ok := providers[config.Provider]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, config.Provider)
+	}
+	return factory(ctx, config)
 }
@@ -29,6 +29,7 @@ type mockProvider struct {
 	readerFunc func(ctx context.Context, path string) (io.ReadCloser, error)
 	deleteFunc func(ctx context.Context, path string) error
 	listFunc   func(ctx context.Context, prefix string) ObjectIterator
+	attrsFunc  func(ctx context.Context, path string) (*ObjectAttrs, error)
 	closeFunc  func() error
 }
 
@@ -60,6 +61,13 @@ func (m *mockProvider) List(ctx context.Context, prefix string) ObjectIterator {
 	return &mockIterator{}
 }
 
+func (m *mockProvider) Attrs(ctx context.Context, path string) (*ObjectAttrs, error) {
+	if m.attrsFunc != nil {
+		return m.attrsFunc(ctx, path)
+	}
+	return &ObjectAttrs{Name: path}, nil
+}
+
 func (m *mockProvider) Close() error {
 	if m.closeFunc != nil {
 		return m.closeFunc()
@@ -154,8 +162,8 @@ func TestNewProvider_UnsupportedProvider(t *testing.T) {
 	ctx := context.Background()
 	provider, err := NewProvider(ctx, config)
 
-	if err != nil {
-		t.Errorf("Unexpected error: %v", err)
+	if !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("err = %v, want ErrUnknownProvider", err)
 	}
 
 	if provider != nil {
@@ -163,6 +171,47 @@ func TestNewProvider_UnsupportedProvider(t *testing.T) {
 	}
 }
 
+func TestRegisterProvider(t *testing.T) {
+	const name = "test-registered-provider"
+	want := &mockProvider{}
+
+	RegisterProvider(name, func(ctx context.Context, config *Config) (Provider, error) {
+		return want, nil
+	})
+
+	got, err := NewProvider(context.Background(), &Config{Provider: name})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	if got != Provider(want) {
+		t.Errorf("NewProvider() = %v, want the registered factory's provider", got)
+	}
+
+	found := false
+	for _, p := range Providers() {
+		if p == name {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Providers() = %v, want it to include %q", Providers(), name)
+	}
+}
+
+func TestRegisterProvider_BuiltinsRegistered(t *testing.T) {
+	for _, name := range []string{"gcs", "s3", "azure", "local", "memory"} {
+		found := false
+		for _, p := range Providers() {
+			if p == name {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Providers() = %v, want it to include built-in %q", Providers(), name)
+		}
+	}
+}
+
 func TestConfig_S3Fields(t *testing.T) {
 	config := &Config{
 		Provider:          "s3",
@@ -210,6 +259,59 @@ func TestConfig_GCSFields(t *testing.T) {
 	}
 }
 
+func TestConfig_AzureFields(t *testing.T) {
+	config := &Config{
+		Provider:         "azure",
+		AzureAccountName: "myaccount",
+		AzureAccountKey:  "base64keyvalue==",
+		AzureContainer:   "my-container",
+		AzureEndpoint:    "http://127.0.0.1:10000/myaccount",
+	}
+
+	if config.Provider != "azure" {
+		t.Errorf("Provider = %q, want azure", config.Provider)
+	}
+
+	if config.AzureAccountName != "myaccount" {
+		t.Errorf("AzureAccountName = %q, want myaccount", config.AzureAccountName)
+	}
+
+	if config.AzureContainer != "my-container" {
+		t.Errorf("AzureContainer = %q, want my-container", config.AzureContainer)
+	}
+
+	if config.AzureEndpoint != "http://127.0.0.1:10000/myaccount" {
+		t.Errorf("AzureEndpoint = %q, want http://127.0.0.1:10000/myaccount", config.AzureEndpoint)
+	}
+}
+
+func TestNewProvider_Azure(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Azure provider test in short mode")
+	}
+
+	config := &Config{
+		Provider:         "azure",
+		AzureAccountName: "devstoreaccount1",
+		AzureAccountKey:  "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==",
+		AzureContainer:   "test-container",
+		AzureEndpoint:    "http://127.0.0.1:10000/devstoreaccount1",
+	}
+
+	ctx := context.Background()
+	provider, err := NewProvider(ctx, config)
+
+	// This will fail if Azurite is not running, which is expected in short mode.
+	if err != nil {
+		t.Logf("Note: Azure provider creation failed (expected if Azurite is not running): %v", err)
+	}
+
+	if provider != nil {
+		defer provider.Close()
+		t.Log("Successfully created Azure provider")
+	}
+}
+
 func TestObjectAttrs_Fields(t *testing.T) {
 	now := time.Now()
 	attrs := &ObjectAttrs{
@@ -241,6 +343,72 @@ func TestObjectAttrs_Fields(t *testing.T) {
 	}
 }
 
+func TestObjectAttrs_ChecksumAndMetadataFields(t *testing.T) {
+	attrs := &ObjectAttrs{
+		Name:         "test-object.dat",
+		ETag:         `"abc123"`,
+		MD5:          "XUFAKrxLKna5cZ2REBfFkg==",
+		CRC32C:       "yZRlqg==",
+		ContentType:  "application/x-git-pack",
+		StorageClass: "STANDARD",
+		Metadata:     map[string]string{"uploaded-by": "goblet"},
+	}
+
+	if attrs.ETag != `"abc123"` {
+		t.Errorf("ETag = %q, want %q", attrs.ETag, `"abc123"`)
+	}
+	if attrs.MD5 != "XUFAKrxLKna5cZ2REBfFkg==" {
+		t.Errorf("MD5 = %q, want XUFAKrxLKna5cZ2REBfFkg==", attrs.MD5)
+	}
+	if attrs.CRC32C != "yZRlqg==" {
+		t.Errorf("CRC32C = %q, want yZRlqg==", attrs.CRC32C)
+	}
+	if attrs.ContentType != "application/x-git-pack" {
+		t.Errorf("ContentType = %q, want application/x-git-pack", attrs.ContentType)
+	}
+	if attrs.StorageClass != "STANDARD" {
+		t.Errorf("StorageClass = %q, want STANDARD", attrs.StorageClass)
+	}
+	if attrs.Metadata["uploaded-by"] != "goblet" {
+		t.Errorf("Metadata[uploaded-by] = %q, want goblet", attrs.Metadata["uploaded-by"])
+	}
+}
+
+func TestWriteOptions_Fields(t *testing.T) {
+	var generation int64 = 42
+	opts := WriteOptions{
+		IfGenerationMatch: &generation,
+		IfNoneMatch:       true,
+	}
+
+	if opts.IfGenerationMatch == nil || *opts.IfGenerationMatch != 42 {
+		t.Errorf("IfGenerationMatch = %v, want 42", opts.IfGenerationMatch)
+	}
+	if !opts.IfNoneMatch {
+		t.Error("IfNoneMatch = false, want true")
+	}
+}
+
+func TestProvider_Attrs(t *testing.T) {
+	want := &ObjectAttrs{Name: "test/object.dat", Size: 42}
+	mock := &mockProvider{
+		attrsFunc: func(ctx context.Context, path string) (*ObjectAttrs, error) {
+			if path != "test/object.dat" {
+				t.Errorf("path = %q, want test/object.dat", path)
+			}
+			return want, nil
+		},
+	}
+
+	got, err := mock.Attrs(context.Background(), "test/object.dat")
+	if err != nil {
+		t.Fatalf("Attrs() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("Attrs() = %v, want %v", got, want)
+	}
+}
+
 func TestProvider_Writer(t *testing.T) {
 	writerCalled := false
 	capturedPath := ""
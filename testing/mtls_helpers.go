@@ -0,0 +1,77 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/google/goblet/auth/mtls"
+)
+
+// NewTestCA creates a fresh in-process CertificateAuthority for mTLS
+// tests, mirroring NewLocalBareGitRepo's "just works" ergonomics.
+func NewTestCA() *mtls.LocalCA {
+	ca, err := mtls.NewLocalCA()
+	if err != nil {
+		log.Fatalf("cannot create a test CA: %v", err)
+	}
+	return ca
+}
+
+// NewMTLSLocalGitRepo creates a local Git repo configured to present cert
+// as its client certificate on HTTPS fetches, so tests parallel to
+// TestValidAuthentication can exercise the mTLS path end-to-end instead of
+// the bearer-token path.
+func NewMTLSLocalGitRepo(cert *x509.Certificate, key *ecdsa.PrivateKey) GitRepo {
+	r := NewLocalGitRepo()
+
+	certPath := filepath.Join(string(r), "client-cert.pem")
+	keyPath := filepath.Join(string(r), "client-key.pem")
+
+	if err := writePEM(certPath, "CERTIFICATE", cert.Raw); err != nil {
+		log.Fatalf("cannot write client certificate: %v", err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		log.Fatalf("cannot marshal client key: %v", err)
+	}
+	if err := writePEM(keyPath, "EC PRIVATE KEY", keyBytes); err != nil {
+		log.Fatalf("cannot write client key: %v", err)
+	}
+
+	if _, err := r.Run("config", "http.sslCert", certPath); err != nil {
+		log.Fatalf("cannot configure http.sslCert: %v", err)
+	}
+	if _, err := r.Run("config", "http.sslKey", keyPath); err != nil {
+		log.Fatalf("cannot configure http.sslKey: %v", err)
+	}
+
+	return r
+}
+
+func writePEM(path, blockType string, der []byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("cannot create %s: %w", path, err)
+	}
+	defer f.Close()
+	return pem.Encode(f, &pem.Block{Type: blockType, Bytes: der})
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/goblet/storage"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// TestStorageEventsDeliverBundleUpload uploads a bundle directly through
+// a raw Minio client (simulating another replica or an out-of-band
+// backup job) and asserts that a provider with EventsEnabled observes the
+// s3:ObjectCreated notification within a timeout.
+func TestStorageEventsDeliverBundleUpload(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	setup := NewIntegrationTestSetup()
+	setup.Start(t)
+	defer setup.Stop(t)
+
+	accessKey, secretKey := setup.GetMinioCredentials()
+
+	events := make(chan storage.Event, 1)
+	storageConfig := &storage.Config{
+		Provider:          "s3",
+		S3Endpoint:        setup.GetMinioEndpoint(),
+		S3Bucket:          setup.GetMinioBucket(),
+		S3AccessKeyID:     accessKey,
+		S3SecretAccessKey: secretKey,
+		S3Region:          "us-east-1",
+		S3UseSSL:          false,
+		EventsEnabled:     true,
+		EventsSuffix:      ".bundle",
+		EventHandler: func(e storage.Event) {
+			select {
+			case events <- e:
+			default:
+			}
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	provider, err := storage.NewProvider(ctx, storageConfig)
+	if err != nil {
+		t.Fatalf("Failed to create storage provider: %v", err)
+	}
+	defer provider.Close()
+
+	minioClient, err := minio.New(setup.GetMinioEndpoint(), &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: false,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Minio client: %v", err)
+	}
+
+	objectName := "repo-cache/github.com/org/repo/backup-" + time.Now().Format("20060102-150405") + ".bundle"
+	data := []byte("mock git bundle data for event delivery test")
+	if _, err := minioClient.PutObject(ctx, setup.GetMinioBucket(), objectName, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{}); err != nil {
+		t.Fatalf("Failed to upload bundle: %v", err)
+	}
+	defer minioClient.RemoveObject(ctx, setup.GetMinioBucket(), objectName, minio.RemoveObjectOptions{})
+
+	select {
+	case event := <-events:
+		if event.Type != storage.EventObjectCreated {
+			t.Errorf("event.Type = %v, want EventObjectCreated", event.Type)
+		}
+		if event.Key != objectName {
+			t.Errorf("event.Key = %q, want %q", event.Key, objectName)
+		}
+	case <-time.After(20 * time.Second):
+		t.Fatal("did not observe the bundle upload notification within the timeout")
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -107,6 +108,52 @@ func TestStorageProviderInitialization(t *testing.T) {
 	t.Log("Successfully initialized S3 storage provider with Minio")
 }
 
+// TestStorageProviderWebIdentityCredentials exercises the STS
+// AssumeRoleWithWebIdentity credential path against Minio's built-in STS
+// endpoint, in place of the static S3AccessKeyID/S3SecretAccessKey used by
+// the rest of this file.
+func TestStorageProviderWebIdentityCredentials(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	setup := NewIntegrationTestSetup()
+	setup.Start(t)
+	defer setup.Stop(t)
+
+	tokenFile, err := os.CreateTemp("", "web-identity-token-*")
+	if err != nil {
+		t.Fatalf("Failed to create web identity token file: %v", err)
+	}
+	defer os.Remove(tokenFile.Name())
+	if _, err := tokenFile.WriteString(setup.GetMinioSTSWebIdentityToken()); err != nil {
+		t.Fatalf("Failed to write web identity token: %v", err)
+	}
+	tokenFile.Close()
+
+	storageConfig := &storage.Config{
+		Provider:               "s3",
+		S3Endpoint:             setup.GetMinioEndpoint(),
+		S3Bucket:               setup.GetMinioBucket(),
+		S3Region:               "us-east-1",
+		S3UseSSL:               false,
+		S3CredentialsMode:      "web-identity",
+		S3STSEndpoint:          setup.GetMinioSTSEndpoint(),
+		S3WebIdentityTokenFile: tokenFile.Name(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	provider, err := storage.NewProvider(ctx, storageConfig)
+	if err != nil {
+		t.Fatalf("Failed to create storage provider with web-identity credentials: %v", err)
+	}
+	defer provider.Close()
+
+	t.Log("Successfully initialized S3 storage provider with STS web-identity credentials")
+}
+
 // TestBundleBackupAndRestore tests backing up and restoring a repository bundle.
 func TestBundleBackupAndRestore(t *testing.T) {
 	if testing.Short() {
@@ -0,0 +1,99 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/goblet/storage"
+)
+
+// TestBundleBackupObjectLockBlocksDeletion writes a bundle with a 1-minute
+// Object Lock retention and asserts that deletion is rejected until the
+// retention expires, and that a legal hold blocks deletion regardless of
+// retention.
+func TestBundleBackupObjectLockBlocksDeletion(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	setup := NewIntegrationTestSetup()
+	setup.Start(t)
+	defer setup.Stop(t)
+
+	accessKey, secretKey := setup.GetMinioCredentials()
+	storageConfig := &storage.Config{
+		Provider:                "s3",
+		S3Endpoint:              setup.GetMinioEndpoint(),
+		S3Bucket:                setup.GetMinioBucket() + "-objectlock",
+		S3AccessKeyID:           accessKey,
+		S3SecretAccessKey:       secretKey,
+		S3Region:                "us-east-1",
+		S3UseSSL:                false,
+		ObjectLockMode:          "GOVERNANCE",
+		ObjectLockRetentionDays: 1,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	provider, err := storage.NewProvider(ctx, storageConfig)
+	if err != nil {
+		t.Fatalf("Failed to create Object Lock enabled storage provider: %v", err)
+	}
+	defer provider.Close()
+
+	key := "test-bundle-" + time.Now().Format("20060102-150405") + ".bundle"
+	writer, err := provider.Writer(ctx, key)
+	if err != nil {
+		t.Fatalf("Failed to get writer: %v", err)
+	}
+	if _, err := writer.Write([]byte("mock git bundle data")); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+
+	s3provider, ok := provider.(*storage.S3Provider)
+	if !ok {
+		t.Fatalf("provider is %T, want *storage.S3Provider", provider)
+	}
+
+	// Retention is still active: deletion must be rejected.
+	if err := provider.Delete(ctx, key); err == nil {
+		t.Error("Delete() succeeded while Object Lock retention is active, want AccessDenied")
+	}
+
+	// A legal hold must also block deletion, independent of retention.
+	if err := s3provider.SetLegalHold(ctx, key, true); err != nil {
+		t.Fatalf("SetLegalHold(on) failed: %v", err)
+	}
+	if err := s3provider.SetRetention(ctx, key, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("SetRetention(expired) failed: %v", err)
+	}
+	if err := provider.Delete(ctx, key); err == nil {
+		t.Error("Delete() succeeded under legal hold even with expired retention, want AccessDenied")
+	}
+
+	if err := s3provider.SetLegalHold(ctx, key, false); err != nil {
+		t.Fatalf("SetLegalHold(off) failed: %v", err)
+	}
+	if err := provider.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() failed after retention expired and legal hold cleared: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing
+
+import (
+	"context"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/goblet/storage"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// TestBundleBackupSSECRoundTrip writes a bundle using SSE-C, confirms the
+// object is reported as customer-key encrypted, and confirms reading it
+// back without the key fails.
+func TestBundleBackupSSECRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	setup := NewIntegrationTestSetup()
+	setup.Start(t)
+	defer setup.Stop(t)
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("Failed to generate SSE-C key: %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "sse-c.key")
+	if err := os.WriteFile(keyFile, key, 0600); err != nil {
+		t.Fatalf("Failed to write SSE-C key file: %v", err)
+	}
+
+	accessKey, secretKey := setup.GetMinioCredentials()
+	storageConfig := &storage.Config{
+		Provider:          "s3",
+		S3Endpoint:        setup.GetMinioEndpoint(),
+		S3Bucket:          setup.GetMinioBucket(),
+		S3AccessKeyID:     accessKey,
+		S3SecretAccessKey: secretKey,
+		S3Region:          "us-east-1",
+		S3UseSSL:          true,
+		S3SSEMode:         "SSE-C",
+		S3SSECustomerKey:  keyFile,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	provider, err := storage.NewProvider(ctx, storageConfig)
+	if err != nil {
+		t.Fatalf("Failed to create SSE-C storage provider: %v", err)
+	}
+	defer provider.Close()
+
+	objectName := "test-bundle-ssec-" + time.Now().Format("20060102-150405") + ".bundle"
+	writer, err := provider.Writer(ctx, objectName)
+	if err != nil {
+		t.Fatalf("Failed to get writer: %v", err)
+	}
+	if _, err := writer.Write([]byte("mock encrypted git bundle data")); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+	defer provider.Delete(ctx, objectName)
+
+	sseKey, err := encrypt.NewSSEC(key)
+	if err != nil {
+		t.Fatalf("Failed to build SSE-C material for verification: %v", err)
+	}
+	minioClient, err := minio.New(setup.GetMinioEndpoint(), &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Minio client: %v", err)
+	}
+
+	objInfo, err := minioClient.StatObject(ctx, setup.GetMinioBucket(), objectName, minio.StatObjectOptions{ServerSideEncryption: sseKey})
+	if err != nil {
+		t.Fatalf("StatObject with the correct key failed: %v", err)
+	}
+	if got := objInfo.Metadata.Get("X-Amz-Server-Side-Encryption-Customer-Algorithm"); got == "" {
+		t.Error("expected X-Amz-Server-Side-Encryption-Customer-Algorithm header on the stored object")
+	}
+
+	// Reading without the key must fail.
+	if _, err := minioClient.StatObject(ctx, setup.GetMinioBucket(), objectName, minio.StatObjectOptions{}); err == nil {
+		t.Error("StatObject without the SSE-C key succeeded, want an error")
+	}
+}
+
+// TestSSECRequiresTLS confirms the provider refuses to start with SSE-C
+// configured over a plaintext connection.
+func TestSSECRequiresTLS(t *testing.T) {
+	storageConfig := &storage.Config{
+		Provider:         "s3",
+		S3Endpoint:       "localhost:9000",
+		S3Bucket:         "unused",
+		S3UseSSL:         false,
+		S3SSEMode:        "SSE-C",
+		S3SSECustomerKey: "/nonexistent",
+	}
+
+	_, err := storage.NewProvider(context.Background(), storageConfig)
+	if err == nil {
+		t.Fatal("expected NewProvider to refuse SSE-C over a non-TLS connection")
+	}
+}
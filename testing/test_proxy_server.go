@@ -16,7 +16,12 @@
 package testing
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/http/cgi"
@@ -24,10 +29,14 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/google/goblet"
+	"github.com/google/goblet/auth/connector"
+	"github.com/google/goblet/lfs"
+	"github.com/google/goblet/storage"
 	"golang.org/x/oauth2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -59,6 +68,8 @@ type TestServer struct {
 	proxyServer       *httptest.Server
 	ProxyServerURL    string
 	serverConfig      *goblet.ServerConfig // Exposed for testing
+	lfsStorage        *fileLFSStorage
+	tokenIssuer       *TokenIssuer // Set by NewTokenScopedTestServer.
 }
 
 type TestServerConfig struct {
@@ -67,6 +78,45 @@ type TestServerConfig struct {
 	ErrorReporter     func(*http.Request, error)
 	RequestLogger     func(r *http.Request, status int, requestSize, responseSize int64, latency time.Duration)
 	UpstreamEnabled   *bool // Optional: set to false to disable upstream (for testing)
+	LFSEnabled        bool  // Optional: set to true to serve the LFS batch/object endpoints
+
+	// URLTokenSource, if set, takes priority over TokenSource: it's
+	// wired into goblet.ServerConfig.TokenSourceCtx, so its TokenForURL
+	// method gets the request's real context. Use this for tests
+	// exercising goblet.URLTokenSource implementations (the registry
+	// providers and anything else that needs the request URL or ctx to
+	// pick a token).
+	URLTokenSource goblet.URLTokenSource
+
+	// Connectors, if set, are exposed for tests exercising
+	// X-Goblet-Connector routing. They are not currently wired into the
+	// proxy's request path; tests call connector.FromRequest directly.
+	Connectors connector.Registry
+}
+
+// adaptLegacyTestTokenSource bridges TestServerConfig.TokenSource's
+// oauth2.TokenSource into the func(*url.URL) (*oauth2.Token, error)
+// signature goblet.ServerConfig.TokenSource expects. Returns nil if
+// config.TokenSource is unset.
+func adaptLegacyTestTokenSource(config *TestServerConfig) func(*url.URL) (*oauth2.Token, error) {
+	if config.TokenSource == nil {
+		return nil
+	}
+	return func(u *url.URL) (*oauth2.Token, error) {
+		return config.TokenSource.Token()
+	}
+}
+
+// adaptTestTokenSourceCtx bridges TestServerConfig.URLTokenSource into
+// goblet.ServerConfig.TokenSourceCtx, which ServerConfig prefers over
+// TokenSource and calls with the request's real context -- so a provider
+// that reads cancellation or a tenant ID placed by IsolationConfig
+// actually sees it. Returns nil if config.URLTokenSource is unset.
+func adaptTestTokenSourceCtx(config *TestServerConfig) func(context.Context, *url.URL) (*oauth2.Token, error) {
+	if config.URLTokenSource == nil {
+		return nil
+	}
+	return config.URLTokenSource.TokenForURL
 }
 
 func NewTestServer(config *TestServerConfig) *TestServer {
@@ -90,7 +140,8 @@ func NewTestServer(config *TestServerConfig) *TestServer {
 			LocalDiskCacheRoot: dir,
 			URLCanonializer:    s.testURLCanonicalizer,
 			RequestAuthorizer:  config.RequestAuthorizer,
-			TokenSource:        config.TokenSource,
+			TokenSource:        adaptLegacyTestTokenSource(config),
+			TokenSourceCtx:     adaptTestTokenSourceCtx(config),
 			ErrorReporter:      config.ErrorReporter,
 			RequestLogger:      config.RequestLogger,
 		}
@@ -106,10 +157,35 @@ func NewTestServer(config *TestServerConfig) *TestServer {
 			w.Header().Set("Content-Type", "text/plain")
 			fmt.Fprintf(w, "ok\n")
 		})
-		mux.Handle("/", goblet.HTTPHandler(serverConfig))
+		var basicAdapter *lfs.BasicAdapter
+		if config.LFSEnabled {
+			s.lfsStorage = newFileLFSStorage(dir)
+			basicAdapter = &lfs.BasicAdapter{} // BaseURL is filled in once the proxy server is listening.
+			lfsHandler := lfs.NewHandler(lfs.Config{
+				Storage:           s.lfsStorage,
+				RequestAuthorizer: config.RequestAuthorizer,
+				Adapters:          []lfs.TransferAdapter{basicAdapter},
+			})
+			mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+				if repoPath, ok := matchLFSBatchPath(req.URL.Path); ok {
+					lfsHandler.ServeBatch(repoPath, w, req)
+					return
+				}
+				if oid, ok := matchLFSObjectPath(req.URL.Path); ok {
+					lfsHandler.ServeObject(oid, w, req)
+					return
+				}
+				goblet.HTTPHandler(serverConfig).ServeHTTP(w, req)
+			})
+		} else {
+			mux.Handle("/", goblet.HTTPHandler(serverConfig))
+		}
 
 		s.proxyServer = httptest.NewServer(mux)
 		s.ProxyServerURL = s.proxyServer.URL
+		if basicAdapter != nil {
+			basicAdapter.BaseURL = s.ProxyServerURL
+		}
 	}
 	return s
 }
@@ -184,6 +260,31 @@ func (s *TestServer) CreateRandomCommitUpstream() (string, error) {
 
 }
 
+// CreateRandomLFSObjectUpstream writes a random blob directly into the
+// test server's LFS object store and returns its OID and size, mirroring
+// CreateRandomCommitUpstream for LFS-focused tests. The TestServerConfig
+// passed to NewTestServer must have set LFSEnabled.
+func (s *TestServer) CreateRandomLFSObjectUpstream(content []byte) (oid string, size int64, err error) {
+	if s.lfsStorage == nil {
+		return "", 0, fmt.Errorf("LFS is not enabled on this test server")
+	}
+	sum := sha256.Sum256(content)
+	oid = hex.EncodeToString(sum[:])
+
+	w, err := s.lfsStorage.Writer(context.Background(), "lfs/"+oid)
+	if err != nil {
+		return "", 0, err
+	}
+	if _, err := w.Write(content); err != nil {
+		_ = w.Close()
+		return "", 0, err
+	}
+	if err := w.Close(); err != nil {
+		return "", 0, err
+	}
+	return oid, int64(len(content)), nil
+}
+
 func (s *TestServer) Close() {
 	s.upstreamServer.Close()
 	s.proxyServer.Close()
@@ -198,6 +299,37 @@ func TestRequestAuthorizer(r *http.Request) error {
 	return status.Errorf(codes.Unauthenticated, "not a valid client auth token: %s", authzHeader)
 }
 
+// TestAuthBackend is an http.HandlerFunc implementing the same
+// "Bearer "+ValidClientAuthToken check as TestRequestAuthorizer, but as
+// the delegated-auth HTTP endpoint goblet.AuthBackend.Authorize POSTs
+// to, for tests exercising ServerConfig.AuthBackendURL instead of a
+// Go-side RequestAuthorizer. Mount it at "/authorize" on an
+// httptest.Server and pass that server's URL to goblet.NewAuthBackend.
+func TestAuthBackend(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Headers map[string][]string `json:"headers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("cannot decode authorize request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var authzHeader string
+	if v := req.Headers["Authorization"]; len(v) > 0 {
+		authzHeader = v[0]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if authzHeader == "Bearer "+ValidClientAuthToken {
+		json.NewEncoder(w).Encode(goblet.AuthBackendDecision{Allowed: true})
+		return
+	}
+	json.NewEncoder(w).Encode(goblet.AuthBackendDecision{
+		Allowed: false,
+		Message: fmt.Sprintf("not a valid client auth token: %s", authzHeader),
+	})
+}
+
 type GitRepo string
 
 func NewLocalBareGitRepo() GitRepo {
@@ -262,3 +394,81 @@ func (c *commandError) Error() string {
 	}
 	return strings.Join(ss, "\n")
 }
+
+// matchLFSBatchPath reports whether path is an LFS batch endpoint and, if
+// so, returns the repository path it was called for.
+func matchLFSBatchPath(path string) (repoPath string, ok bool) {
+	const suffix = "/info/lfs/objects/batch"
+	if !strings.HasSuffix(path, suffix) {
+		return "", false
+	}
+	return strings.TrimPrefix(strings.TrimSuffix(path, suffix), "/"), true
+}
+
+// matchLFSObjectPath reports whether path is a "basic" adapter object
+// endpoint and, if so, returns the object's OID.
+func matchLFSObjectPath(path string) (oid string, ok bool) {
+	const marker = "/info/lfs/objects/"
+	idx := strings.Index(path, marker)
+	if idx < 0 {
+		return "", false
+	}
+	oid = path[idx+len(marker):]
+	if oid == "" || strings.Contains(oid, "/") {
+		return "", false
+	}
+	return oid, true
+}
+
+// fileLFSStorage is a minimal storage.Provider backed by the test server's
+// temp cache directory, just enough to exercise the LFS handler in tests
+// without standing up a Minio/GCS backend.
+type fileLFSStorage struct {
+	root string
+}
+
+func newFileLFSStorage(root string) *fileLFSStorage {
+	return &fileLFSStorage{root: filepath.Join(root, "lfs-objects")}
+}
+
+func (f *fileLFSStorage) path(p string) string {
+	return filepath.Join(f.root, filepath.FromSlash(p))
+}
+
+func (f *fileLFSStorage) Writer(ctx context.Context, path string) (io.WriteCloser, error) {
+	full := f.path(path)
+	if err := os.MkdirAll(filepath.Dir(full), 0750); err != nil {
+		return nil, err
+	}
+	return os.Create(full)
+}
+
+func (f *fileLFSStorage) Reader(ctx context.Context, path string) (io.ReadCloser, error) {
+	return os.Open(f.path(path))
+}
+
+func (f *fileLFSStorage) Delete(ctx context.Context, path string) error {
+	return os.Remove(f.path(path))
+}
+
+func (f *fileLFSStorage) List(ctx context.Context, prefix string) storage.ObjectIterator {
+	return &fileLFSStorageIterator{err: io.EOF}
+}
+
+func (f *fileLFSStorage) Attrs(ctx context.Context, path string) (*storage.ObjectAttrs, error) {
+	info, err := os.Stat(f.path(path))
+	if err != nil {
+		return nil, err
+	}
+	return &storage.ObjectAttrs{Name: path, Updated: info.ModTime(), Size: info.Size()}, nil
+}
+
+func (f *fileLFSStorage) Close() error { return nil }
+
+type fileLFSStorageIterator struct {
+	err error
+}
+
+func (i *fileLFSStorageIterator) Next() (*storage.ObjectAttrs, error) {
+	return nil, i.err
+}
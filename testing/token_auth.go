@@ -0,0 +1,94 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/goblet/auth/token"
+)
+
+// tokenTTL is how long tokens minted by TokenIssuer.IssueToken remain
+// valid; short enough that a test relying on expiry would need to say
+// so explicitly.
+const tokenTTL = time.Minute
+
+// TokenIssuer mints short-lived scoped bearer tokens for tests exercising
+// token.Authorizer, backed by an ephemeral Ed25519 keypair generated once
+// per issuer.
+type TokenIssuer struct {
+	public  ed25519.PublicKey
+	private ed25519.PrivateKey
+}
+
+// NewTokenIssuer generates a fresh signing keypair and returns the
+// TokenIssuer that mints tokens with it.
+func NewTokenIssuer() *TokenIssuer {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		// ed25519.GenerateKey only fails if crypto/rand can't supply
+		// entropy, which would make the rest of the test binary
+		// non-functional anyway.
+		panic(fmt.Sprintf("testing: failed to generate token signing key: %v", err))
+	}
+	return &TokenIssuer{public: pub, private: priv}
+}
+
+// KeySource returns the token.KeySource a token.Authorizer should verify
+// this issuer's tokens against.
+func (ti *TokenIssuer) KeySource() token.KeySource {
+	return token.StaticKeySource{Key: ti.public}
+}
+
+// IssueToken mints a token scoped to repo, authorizing actions (e.g.
+// token.ActionPull, token.ActionPush).
+func (ti *TokenIssuer) IssueToken(repo string, actions ...string) string {
+	tok, err := token.Sign(jwt.SigningMethodEdDSA, ti.private, "test-key", repo, actions, tokenTTL)
+	if err != nil {
+		panic(fmt.Sprintf("testing: failed to sign token: %v", err))
+	}
+	return tok
+}
+
+// NewTokenScopedTestServer returns a TestServer whose RequestAuthorizer
+// enforces the repository/action scopes of bearer tokens minted by the
+// returned server's IssueToken method, layering any other
+// TestServerConfig fields the caller supplies on top. config.
+// RequestAuthorizer is ignored; set it via IssueToken-minted tokens
+// instead.
+func NewTokenScopedTestServer(config *TestServerConfig) *TestServer {
+	issuer := NewTokenIssuer()
+	authorizer := token.NewAuthorizer(issuer.KeySource())
+
+	cfg := *config
+	cfg.RequestAuthorizer = authorizer.AuthorizeRequest
+
+	ts := NewTestServer(&cfg)
+	ts.tokenIssuer = issuer
+	return ts
+}
+
+// IssueToken mints a token scoped to repo, authorizing actions. It
+// panics if ts wasn't created with NewTokenScopedTestServer.
+func (s *TestServer) IssueToken(repo string, actions ...string) string {
+	if s.tokenIssuer == nil {
+		panic("testing: IssueToken requires a TestServer created with NewTokenScopedTestServer")
+	}
+	return s.tokenIssuer.IssueToken(repo, actions...)
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/goblet/auth/token"
+)
+
+// TestTokenAuthorizerScopesByRepository verifies that a single proxy
+// backed by a token.Authorizer honors per-repository scope: a token
+// minted for "repo-a" can fetch it, but can't fetch "repo-b" through the
+// same proxy, even though both paths resolve to the same (test) upstream.
+func TestTokenAuthorizerScopesByRepository(t *testing.T) {
+	ts := NewTokenScopedTestServer(&TestServerConfig{
+		TokenSource: TestTokenSource,
+	})
+	defer ts.Close()
+
+	if _, err := ts.CreateRandomCommitUpstream(); err != nil {
+		t.Fatalf("Failed to create commit upstream: %v", err)
+	}
+
+	repoAToken := ts.IssueToken("repo-a", token.ActionPull)
+
+	allowedClient := NewLocalGitRepo()
+	defer allowedClient.Close()
+	if _, err := allowedClient.Run("-c", "http.extraHeader=Authorization: Bearer "+repoAToken,
+		"fetch", ts.ProxyServerURL+"/repo-a"); err != nil {
+		t.Fatalf("Fetch of repo-a with a repo-a-scoped token failed: %v", err)
+	}
+
+	deniedClient := NewLocalGitRepo()
+	defer deniedClient.Close()
+	output, err := deniedClient.Run("-c", "http.extraHeader=Authorization: Bearer "+repoAToken,
+		"fetch", ts.ProxyServerURL+"/repo-b")
+	if err == nil {
+		t.Fatal("Fetch of repo-b with a repo-a-scoped token succeeded, want denial")
+	}
+	if !strings.Contains(output, "403") && !strings.Contains(output, "not authorized") {
+		t.Logf("fetch output: %s", output)
+	}
+}
+
+// TestTokenAuthorizerScopesByAction verifies that a pull-only token can't
+// be used to push.
+func TestTokenAuthorizerScopesByAction(t *testing.T) {
+	ts := NewTokenScopedTestServer(&TestServerConfig{
+		TokenSource: TestTokenSource,
+	})
+	defer ts.Close()
+
+	if _, err := ts.CreateRandomCommitUpstream(); err != nil {
+		t.Fatalf("Failed to create commit upstream: %v", err)
+	}
+
+	pullOnlyToken := ts.IssueToken("repo-a", token.ActionPull)
+
+	client := NewLocalGitRepo()
+	defer client.Close()
+	if _, err := client.Run("commit", "--allow-empty", "--message=scoped push test"); err != nil {
+		t.Fatalf("Failed to create local commit: %v", err)
+	}
+
+	if _, err := client.Run("-c", "http.extraHeader=Authorization: Bearer "+pullOnlyToken,
+		"push", ts.ProxyServerURL+"/repo-a", "HEAD:refs/heads/pushed"); err == nil {
+		t.Fatal("Push with a pull-only token succeeded, want denial")
+	}
+}
@@ -15,15 +15,24 @@
 package testing
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/cgi"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/google/goblet"
+	"github.com/google/goblet/auth/githubapp"
 	"golang.org/x/oauth2"
 )
 
@@ -285,10 +294,9 @@ func TestTokenSource_ErrorHandling(t *testing.T) {
 }
 
 // TestMultipleUpstreams_Integration tests fetching from multiple upstream
-// servers with different authentication credentials.
+// servers with different authentication credentials, routed by
+// goblet.MultiUpstream instead of a hand-rolled URLCanonializer.
 func TestMultipleUpstreams_Integration(t *testing.T) {
-	t.Skip("Skipping complex multi-upstream test - see TestTokenSource_OrgSpecificTokens for similar coverage")
-
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
 	}
@@ -379,7 +387,7 @@ func TestMultipleUpstreams_Integration(t *testing.T) {
 
 	ts := NewTestServer(&TestServerConfig{
 		RequestAuthorizer: TestRequestAuthorizer,
-		TokenSource: &testTokenSource{
+		URLTokenSource: &testTokenSource{
 			tokenFunc: func(upstreamURL *url.URL) (*oauth2.Token, error) {
 				mu.Lock()
 				tokenCallCount++
@@ -406,27 +414,21 @@ func TestMultipleUpstreams_Integration(t *testing.T) {
 	})
 	defer ts.Close()
 
-	// Override the URL canonicalizer to handle both upstreams
-	upstreamMapping := map[string]string{
-		"/upstream1": upstream1Server.URL,
-		"/upstream2": upstream2Server.URL,
+	// Route both upstreams through a single MultiUpstream instead of a
+	// hand-rolled URLCanonializer closure.
+	upstream1URL, err := url.Parse(upstream1Server.URL)
+	if err != nil {
+		t.Fatalf("cannot parse upstream1 URL: %v", err)
 	}
-
-	originalCanonicalizer := ts.serverConfig.URLCanonializer
-	ts.serverConfig.URLCanonializer = func(u *url.URL) (*url.URL, error) {
-		for prefix, upstreamURL := range upstreamMapping {
-			if strings.HasPrefix(u.Path, prefix) {
-				parsedURL, err := url.Parse(upstreamURL)
-				if err != nil {
-					return nil, err
-				}
-				// Strip the prefix from the path
-				parsedURL.Path = strings.TrimPrefix(u.Path, prefix)
-				return parsedURL, nil
-			}
-		}
-		return originalCanonicalizer(u)
+	upstream2URL, err := url.Parse(upstream2Server.URL)
+	if err != nil {
+		t.Fatalf("cannot parse upstream2 URL: %v", err)
 	}
+	multi := goblet.NewMultiUpstream([]goblet.MultiUpstreamRule{
+		{Name: "upstream1", PathPrefix: "/upstream1", UpstreamURL: upstream1URL},
+		{Name: "upstream2", PathPrefix: "/upstream2", UpstreamURL: upstream2URL},
+	})
+	ts.serverConfig.URLCanonializer = multi.CanonicalizeURL
 
 	// Test fetching from upstream1
 	client1 := NewLocalGitRepo()
@@ -580,40 +582,48 @@ func TestTokenSource_EmptyToken(t *testing.T) {
 	t.Log("Empty token handled correctly (for public repositories)")
 }
 
-// TestTokenSource_WithGitHubAppPattern tests a realistic GitHub App
-// installation token pattern.
+// TestTokenSource_WithGitHubAppPattern drives the real
+// auth/githubapp.TokenSource, the TokenSource implementation goblet
+// ships for the GitHub App installation-token pattern, against a stub
+// GitHub API that knows about three orgs' installations.
 func TestTokenSource_WithGitHubAppPattern(t *testing.T) {
-	// Simulate GitHub App installation IDs for different orgs
 	installations := map[string]int64{
 		"acme-corp": 111,
 		"megacorp":  222,
 		"startup":   333,
 	}
 
-	extractOrg := func(u *url.URL) string {
-		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
-		if len(parts) >= 1 {
-			return parts[0]
-		}
-		return ""
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate test key: %v", err)
 	}
 
-	tokenFunc := func(upstreamURL *url.URL) (*oauth2.Token, error) {
-		org := extractOrg(upstreamURL)
-		installationID, ok := installations[org]
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) != 4 || parts[3] != "installation" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		id, ok := installations[parts[1]]
 		if !ok {
-			return nil, fmt.Errorf("no GitHub App installation for org: %s", org)
+			w.WriteHeader(http.StatusNotFound)
+			return
 		}
+		fmt.Fprintf(w, `{"id": %d}`, id)
+	})
+	mux.HandleFunc("/app/installations/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "ghs_installation_%s_token", "expires_at": %q}`,
+			strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/app/installations/"), "/access_tokens"),
+			time.Now().Add(time.Hour).Format(time.RFC3339))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
 
-		// Simulate generating an installation token
-		// In real implementation, this would:
-		// 1. Generate JWT signed with app private key
-		// 2. Exchange JWT for installation token
-		return &oauth2.Token{
-			AccessToken: fmt.Sprintf("ghs_installation_%d_token", installationID),
-			TokenType:   "Bearer",
-		}, nil
-	}
+	appTS := githubapp.New(1, key)
+	appTS.BaseURL = server.URL
+	tokenFunc := appTS.Token
 
 	tests := []struct {
 		url            string
@@ -660,17 +670,166 @@ func TestTokenSource_WithGitHubAppPattern(t *testing.T) {
 	}
 }
 
-// testTokenSource is a helper that implements oauth2.TokenSource
+// registryIntegrationEchoProvider is the name of a second TokenSource
+// provider registered just so TestRegistryConfigDrivenTestServer_MixesProviders
+// can prove a Registry dispatches to more than the built-in "static"
+// provider. Registered once from init(), since RegisterTokenSourceFactory
+// panics on a duplicate name and this file's tests can run more than once
+// in the same process (e.g. under -count=2).
+const registryIntegrationEchoProvider = "test-registry-integration-echo"
+
+func init() {
+	goblet.RegisterTokenSourceFactory(registryIntegrationEchoProvider, func(raw json.RawMessage) (goblet.URLTokenSource, error) {
+		var cfg struct {
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return nil, err
+		}
+		return goblet.URLTokenSourceFunc(func(*url.URL) (*oauth2.Token, error) {
+			return &oauth2.Token{AccessToken: cfg.AccessToken, TokenType: "Bearer"}, nil
+		}), nil
+	})
+}
+
+// TestRegistryConfigDrivenTestServer_MixesProviders exercises the full
+// config-to-fetch path for goblet.Registry: a JSON rule file naming two
+// distinct registered providers (the built-in "static" provider and
+// registryIntegrationEchoProvider) is loaded, built into a Registry, and
+// wired into a TestServer's URLTokenSource, proxying fetches to two
+// upstreams that each require a different bearer token.
+func TestRegistryConfigDrivenTestServer_MixesProviders(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	upstream1Token := "registry-upstream1-token"
+	upstream2Token := "registry-upstream2-token"
+
+	upstream1Repo := NewLocalBareGitRepo()
+	defer upstream1Repo.Close()
+	_, _ = upstream1Repo.Run("config", "http.receivepack", "1")
+	upstream1Server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer "+upstream1Token {
+			http.Error(w, "invalid auth for upstream1", http.StatusForbidden)
+			return
+		}
+		(&cgi.Handler{
+			Path: gitBinary, Dir: string(upstream1Repo),
+			Env: []string{"GIT_PROJECT_ROOT=" + string(upstream1Repo), "GIT_HTTP_EXPORT_ALL=1"},
+		}).ServeHTTP(w, req)
+	}))
+	defer upstream1Server.Close()
+
+	upstream2Repo := NewLocalBareGitRepo()
+	defer upstream2Repo.Close()
+	_, _ = upstream2Repo.Run("config", "http.receivepack", "1")
+	upstream2Server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer "+upstream2Token {
+			http.Error(w, "invalid auth for upstream2", http.StatusForbidden)
+			return
+		}
+		(&cgi.Handler{
+			Path: gitBinary, Dir: string(upstream2Repo),
+			Env: []string{"GIT_PROJECT_ROOT=" + string(upstream2Repo), "GIT_HTTP_EXPORT_ALL=1"},
+		}).ServeHTTP(w, req)
+	}))
+	defer upstream2Server.Close()
+
+	pushClient1 := NewLocalGitRepo()
+	defer pushClient1.Close()
+	commit1, err := pushClient1.CreateRandomCommit()
+	if err != nil {
+		t.Fatalf("Failed to create commit for upstream1: %v", err)
+	}
+	if _, err := pushClient1.Run("-c", "http.extraHeader=Authorization: Bearer "+upstream1Token,
+		"push", upstream1Server.URL, "HEAD:main"); err != nil {
+		t.Fatalf("Failed to push to upstream1: %v", err)
+	}
+
+	pushClient2 := NewLocalGitRepo()
+	defer pushClient2.Close()
+	commit2, err := pushClient2.CreateRandomCommit()
+	if err != nil {
+		t.Fatalf("Failed to create commit for upstream2: %v", err)
+	}
+	if _, err := pushClient2.Run("-c", "http.extraHeader=Authorization: Bearer "+upstream2Token,
+		"push", upstream2Server.URL, "HEAD:main"); err != nil {
+		t.Fatalf("Failed to push to upstream2: %v", err)
+	}
+
+	upstream1Host := strings.TrimPrefix(upstream1Server.URL, "http://")
+	upstream2Host := strings.TrimPrefix(upstream2Server.URL, "http://")
+
+	configPath := filepath.Join(t.TempDir(), "registry.json")
+	contents := fmt.Sprintf(`{"rules":[
+		{"name":"upstream1","host":%q,"provider":"static","provider_config":{"access_token":%q}},
+		{"name":"upstream2","host":%q,"provider":%q,"provider_config":{"access_token":%q}}
+	]}`, upstream1Host, upstream1Token, upstream2Host, registryIntegrationEchoProvider, upstream2Token)
+	if err := os.WriteFile(configPath, []byte(contents), 0644); err != nil {
+		t.Fatalf("cannot write registry config: %v", err)
+	}
+
+	rc, err := goblet.LoadRegistryConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadRegistryConfig() error = %v", err)
+	}
+	registry, err := rc.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	ts := NewTestServer(&TestServerConfig{
+		RequestAuthorizer: TestRequestAuthorizer,
+		URLTokenSource:    registry,
+	})
+	defer ts.Close()
+
+	upstream1URL, err := url.Parse(upstream1Server.URL)
+	if err != nil {
+		t.Fatalf("cannot parse upstream1 URL: %v", err)
+	}
+	upstream2URL, err := url.Parse(upstream2Server.URL)
+	if err != nil {
+		t.Fatalf("cannot parse upstream2 URL: %v", err)
+	}
+	multi := goblet.NewMultiUpstream([]goblet.MultiUpstreamRule{
+		{Name: "upstream1", PathPrefix: "/upstream1", UpstreamURL: upstream1URL},
+		{Name: "upstream2", PathPrefix: "/upstream2", UpstreamURL: upstream2URL},
+	})
+	ts.serverConfig.URLCanonializer = multi.CanonicalizeURL
+
+	client1 := NewLocalGitRepo()
+	defer client1.Close()
+	if _, err := client1.Run("-c", "http.extraHeader=Authorization: Bearer "+ValidClientAuthToken,
+		"fetch", ts.ProxyServerURL+"/upstream1"); err != nil {
+		t.Fatalf("Failed to fetch from upstream1: %v", err)
+	}
+	if fetchHead, err := client1.Run("rev-parse", "FETCH_HEAD"); err != nil {
+		t.Fatalf("Failed to parse FETCH_HEAD from upstream1: %v", err)
+	} else if strings.TrimSpace(fetchHead) != strings.TrimSpace(commit1) {
+		t.Errorf("Upstream1: FETCH_HEAD = %s, want %s", strings.TrimSpace(fetchHead), strings.TrimSpace(commit1))
+	}
+
+	client2 := NewLocalGitRepo()
+	defer client2.Close()
+	if _, err := client2.Run("-c", "http.extraHeader=Authorization: Bearer "+ValidClientAuthToken,
+		"fetch", ts.ProxyServerURL+"/upstream2"); err != nil {
+		t.Fatalf("Failed to fetch from upstream2: %v", err)
+	}
+	if fetchHead, err := client2.Run("rev-parse", "FETCH_HEAD"); err != nil {
+		t.Fatalf("Failed to parse FETCH_HEAD from upstream2: %v", err)
+	} else if strings.TrimSpace(fetchHead) != strings.TrimSpace(commit2) {
+		t.Errorf("Upstream2: FETCH_HEAD = %s, want %s", strings.TrimSpace(fetchHead), strings.TrimSpace(commit2))
+	}
+}
+
+// testTokenSource is a helper that implements goblet.URLTokenSource
 // with a custom function for testing.
 type testTokenSource struct {
 	tokenFunc func(*url.URL) (*oauth2.Token, error)
 }
 
-func (ts *testTokenSource) Token() (*oauth2.Token, error) {
-	// This should not be called directly in the new implementation
-	// but we provide a default implementation for compatibility
-	return &oauth2.Token{
-		AccessToken: "default-test-token",
-		TokenType:   "Bearer",
-	}, nil
+func (ts *testTokenSource) TokenForURL(ctx context.Context, u *url.URL) (*oauth2.Token, error) {
+	return ts.tokenFunc(u)
 }
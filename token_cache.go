@@ -0,0 +1,232 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultTokenCacheSize is used by NewLRUTokenCache, and by an
+// LRUTokenCache used with a non-positive Size, as the number of distinct
+// TokenCacheKeys to retain.
+const DefaultTokenCacheSize = 256
+
+// DefaultTokenRefreshSkew is how much earlier than a cached token's own
+// Expiry it's treated as stale, so a fetch doesn't race an upstream that
+// has already rotated the token.
+const DefaultTokenRefreshSkew = 60 * time.Second
+
+// DefaultNegativeCacheTTL is how long a TokenSource error is reused
+// before the next caller retries it.
+const DefaultNegativeCacheTTL = 5 * time.Second
+
+// TokenCacheKey identifies a cached token. TenantID namespaces deployments
+// whose TokenSourceCtx resolves different upstream credentials per
+// tenant (see IsolationConfig.TenantContext); managedRepository.getToken
+// takes it from the request context via GetTenantFromContext and passes
+// "" when none was set, so single-tenant deployments are unaffected.
+type TokenCacheKey struct {
+	UpstreamURL string
+	TenantID    string
+}
+
+func (k TokenCacheKey) String() string {
+	return fmt.Sprintf("%s\x00%s", k.UpstreamURL, k.TenantID)
+}
+
+// TokenCache sits in front of ServerConfig.TokenSource so a managed
+// repository doesn't call it (and so doesn't call the identity
+// provider) on every fetchUpstream. This matters most for GitHub App
+// installation tokens (10-minute lifetime) and STS-issued tokens, where
+// TokenSource itself makes a network call.
+type TokenCache interface {
+	// Token returns a cached token for key if it's still fresh. On a
+	// miss (including a cached entry past its expiry), it calls fetch,
+	// caches the result -- a success per its own Expiry minus a
+	// refresh skew, an error briefly -- and returns it. Concurrent
+	// misses for the same key are expected to collapse into a single
+	// fetch call.
+	Token(key TokenCacheKey, fetch func() (*oauth2.Token, error)) (*oauth2.Token, error)
+
+	// Invalidate evicts any cached result for key, so the next Token
+	// call for it always invokes fetch. Callers use this after an
+	// upstream rejects a cached token with 401/403, to force a fresh
+	// one on retry.
+	Invalidate(key TokenCacheKey)
+}
+
+// tokenCacheEntry is a cached TokenSource result, either a token or an
+// error, valid until expires.
+type tokenCacheEntry struct {
+	token   *oauth2.Token
+	err     error
+	expires time.Time
+}
+
+func (e tokenCacheEntry) fresh(now time.Time) bool {
+	return now.Before(e.expires)
+}
+
+// LRUTokenCache is the default TokenCache: an in-memory, size-bounded
+// LRU of TokenSource results, keyed by TokenCacheKey.
+type LRUTokenCache struct {
+	// Size bounds how many distinct keys are retained before the
+	// least-recently-used one is evicted. Defaults to
+	// DefaultTokenCacheSize if <= 0.
+	Size int
+	// RefreshSkew is subtracted from a cached token's Expiry to decide
+	// when it's stale. Defaults to DefaultTokenRefreshSkew if zero.
+	RefreshSkew time.Duration
+	// NegativeCacheTTL bounds how long a fetch error is reused.
+	// Defaults to DefaultNegativeCacheTTL if zero.
+	NegativeCacheTTL time.Duration
+	// Now, if set, replaces time.Now; tests use this to control expiry
+	// without sleeping.
+	Now func() time.Time
+
+	initOnce sync.Once
+	mu       sync.Mutex
+	entries  map[TokenCacheKey]*list.Element
+	order    *list.List // of *tokenCacheListEntry; most-recently-used at the front.
+
+	group singleflight.Group
+}
+
+// NewLRUTokenCache returns an LRUTokenCache bounded to size entries
+// (DefaultTokenCacheSize if size <= 0), with default refresh skew and
+// negative-cache TTL.
+func NewLRUTokenCache(size int) *LRUTokenCache {
+	return &LRUTokenCache{Size: size}
+}
+
+type tokenCacheListEntry struct {
+	key   TokenCacheKey
+	entry tokenCacheEntry
+}
+
+func (c *LRUTokenCache) init() {
+	c.initOnce.Do(func() {
+		if c.Size <= 0 {
+			c.Size = DefaultTokenCacheSize
+		}
+		if c.RefreshSkew == 0 {
+			c.RefreshSkew = DefaultTokenRefreshSkew
+		}
+		if c.NegativeCacheTTL == 0 {
+			c.NegativeCacheTTL = DefaultNegativeCacheTTL
+		}
+		if c.Now == nil {
+			c.Now = time.Now
+		}
+		c.entries = make(map[TokenCacheKey]*list.Element)
+		c.order = list.New()
+	})
+}
+
+// Token implements TokenCache.
+func (c *LRUTokenCache) Token(key TokenCacheKey, fetch func() (*oauth2.Token, error)) (*oauth2.Token, error) {
+	c.init()
+
+	if tok, err, ok := c.lookup(key); ok {
+		return tok, err
+	}
+
+	// Concurrent misses for the same key collapse into one fetch call,
+	// so a burst of overlapping fetchUpstream calls (or an IdP outage)
+	// doesn't turn into a burst of TokenSource calls.
+	v, err, _ := c.group.Do(key.String(), func() (interface{}, error) {
+		if tok, err, ok := c.lookup(key); ok {
+			return tok, err
+		}
+		tok, fetchErr := fetch()
+		c.store(key, tok, fetchErr)
+		return tok, fetchErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*oauth2.Token), nil
+}
+
+// Invalidate implements TokenCache.
+func (c *LRUTokenCache) Invalidate(key TokenCacheKey) {
+	c.init()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, found := c.entries[key]; found {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+}
+
+func (c *LRUTokenCache) lookup(key TokenCacheKey) (tok *oauth2.Token, err error, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, found := c.entries[key]
+	if !found {
+		return nil, nil, false
+	}
+	ce := el.Value.(*tokenCacheListEntry)
+	if !ce.entry.fresh(c.Now()) {
+		return nil, nil, false
+	}
+	c.order.MoveToFront(el)
+	return ce.entry.token, ce.entry.err, true
+}
+
+func (c *LRUTokenCache) store(key TokenCacheKey, tok *oauth2.Token, err error) {
+	now := c.Now()
+
+	var expires time.Time
+	switch {
+	case err != nil:
+		expires = now.Add(c.NegativeCacheTTL)
+	case tok.Expiry.IsZero():
+		// No expiry reported: oauth2.Token.Valid treats this as
+		// never-expiring, so cache it for the lifetime of the
+		// process's usual reuse window rather than refetch every time.
+		expires = now.Add(24 * time.Hour)
+	default:
+		expires = tok.Expiry.Add(-c.RefreshSkew)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := tokenCacheEntry{token: tok, err: err, expires: expires}
+	if el, found := c.entries[key]; found {
+		el.Value.(*tokenCacheListEntry).entry = entry
+		c.order.MoveToFront(el)
+		return
+	}
+
+	el := c.order.PushFront(&tokenCacheListEntry{key: key, entry: entry})
+	c.entries[key] = el
+
+	if c.order.Len() > c.Size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*tokenCacheListEntry).key)
+	}
+}
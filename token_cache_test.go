@@ -0,0 +1,154 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestLRUTokenCache_CachesUntilExpirySkew(t *testing.T) {
+	now := time.Now()
+	c := &LRUTokenCache{Now: func() time.Time { return now }}
+
+	var calls int32
+	fetch := func() (*oauth2.Token, error) {
+		atomic.AddInt32(&calls, 1)
+		return &oauth2.Token{AccessToken: "a", Expiry: now.Add(10 * time.Minute)}, nil
+	}
+	key := TokenCacheKey{UpstreamURL: "https://example.com/repo"}
+
+	for i := 0; i < 3; i++ {
+		tok, err := c.Token(key, fetch)
+		if err != nil {
+			t.Fatalf("Token() error = %v", err)
+		}
+		if tok.AccessToken != "a" {
+			t.Fatalf("Token().AccessToken = %q, want %q", tok.AccessToken, "a")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("fetch called %d times, want 1 (should be served from cache)", calls)
+	}
+
+	// Advance past Expiry - RefreshSkew: should refetch.
+	now = now.Add(10*time.Minute - DefaultTokenRefreshSkew + time.Second)
+	if _, err := c.Token(key, fetch); err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("fetch called %d times after expiry, want 2", calls)
+	}
+}
+
+func TestLRUTokenCache_NegativeCaching(t *testing.T) {
+	now := time.Now()
+	c := &LRUTokenCache{Now: func() time.Time { return now }, NegativeCacheTTL: time.Second}
+
+	var calls int32
+	wantErr := fmt.Errorf("idp unavailable")
+	fetch := func() (*oauth2.Token, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, wantErr
+	}
+	key := TokenCacheKey{UpstreamURL: "https://example.com/repo"}
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Token(key, fetch); err != wantErr {
+			t.Fatalf("Token() error = %v, want %v", err, wantErr)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("fetch called %d times, want 1 (negative result should be cached)", calls)
+	}
+
+	now = now.Add(2 * time.Second)
+	if _, err := c.Token(key, fetch); err != wantErr {
+		t.Fatalf("Token() error = %v, want %v", err, wantErr)
+	}
+	if calls != 2 {
+		t.Errorf("fetch called %d times after negative TTL expiry, want 2", calls)
+	}
+}
+
+func TestLRUTokenCache_DedupesConcurrentMisses(t *testing.T) {
+	c := &LRUTokenCache{}
+
+	var calls int32
+	start := make(chan struct{})
+	fetch := func() (*oauth2.Token, error) {
+		atomic.AddInt32(&calls, 1)
+		<-start
+		return &oauth2.Token{AccessToken: "a", Expiry: time.Now().Add(time.Minute)}, nil
+	}
+	key := TokenCacheKey{UpstreamURL: "https://example.com/repo"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Token(key, fetch); err != nil {
+				t.Errorf("Token() error = %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("fetch called %d times for concurrent misses, want 1 (singleflight should dedupe)", calls)
+	}
+}
+
+func TestLRUTokenCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := &LRUTokenCache{Size: 2}
+	fetch := func(token string) func() (*oauth2.Token, error) {
+		return func() (*oauth2.Token, error) {
+			return &oauth2.Token{AccessToken: token, Expiry: time.Now().Add(time.Hour)}, nil
+		}
+	}
+
+	keyA := TokenCacheKey{UpstreamURL: "a"}
+	keyB := TokenCacheKey{UpstreamURL: "b"}
+	keyC := TokenCacheKey{UpstreamURL: "c"}
+
+	if _, err := c.Token(keyA, fetch("a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Token(keyB, fetch("b")); err != nil {
+		t.Fatal(err)
+	}
+	// keyC evicts the least-recently-used key (keyA).
+	if _, err := c.Token(keyC, fetch("c")); err != nil {
+		t.Fatal(err)
+	}
+
+	var aCalls int32
+	if _, err := c.Token(keyA, func() (*oauth2.Token, error) {
+		atomic.AddInt32(&aCalls, 1)
+		return &oauth2.Token{AccessToken: "a2", Expiry: time.Now().Add(time.Hour)}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if aCalls != 1 {
+		t.Error("expected keyA to have been evicted and refetched")
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"gopkg.in/yaml.v3"
+)
+
+// URLTokenSource mints the credential a fetch against a particular
+// upstream URL should present. It is the interface-based successor to
+// ServerConfig.TokenSource's bare func(*url.URL) (*oauth2.Token, error)
+// signature: the added context lets a provider thread cancellation,
+// tracing, or tenant metadata through its own network calls the way
+// ServerConfig.TokenSourceCtx already does for the single-provider case.
+type URLTokenSource interface {
+	TokenForURL(ctx context.Context, u *url.URL) (*oauth2.Token, error)
+}
+
+// URLTokenSourceFunc adapts the func(*url.URL) (*oauth2.Token, error)
+// signature -- the one every TokenSource this repo ships implements
+// (auth/githubapp, auth/vault, auth/challenge, MultiUpstreamRule) --
+// into a URLTokenSource, discarding ctx. It exists so none of those
+// providers need to change their exported Token method just to plug
+// into a Registry.
+type URLTokenSourceFunc func(*url.URL) (*oauth2.Token, error)
+
+// TokenForURL implements URLTokenSource.
+func (f URLTokenSourceFunc) TokenForURL(ctx context.Context, u *url.URL) (*oauth2.Token, error) {
+	return f(u)
+}
+
+// TokenSourceFactory builds a URLTokenSource from its raw JSON
+// provider_config, so a Registry can be assembled from a config file
+// without the caller linking in every provider package it might name.
+type TokenSourceFactory func(raw json.RawMessage) (URLTokenSource, error)
+
+var (
+	tokenSourceFactoriesMu sync.Mutex
+	tokenSourceFactories   = map[string]TokenSourceFactory{}
+)
+
+// RegisterTokenSourceFactory makes a named provider available to
+// RegistryConfig.Build. Provider packages call this from an init(), the
+// way database/sql drivers call sql.Register, so linking one in is
+// enough to make it configurable -- no edit to this package needed to
+// add a new provider. Registering the same name twice is a programmer
+// error and panics.
+func RegisterTokenSourceFactory(name string, factory TokenSourceFactory) {
+	tokenSourceFactoriesMu.Lock()
+	defer tokenSourceFactoriesMu.Unlock()
+	if _, dup := tokenSourceFactories[name]; dup {
+		panic("goblet: RegisterTokenSourceFactory called twice for " + name)
+	}
+	tokenSourceFactories[name] = factory
+}
+
+func tokenSourceFactory(name string) (TokenSourceFactory, bool) {
+	tokenSourceFactoriesMu.Lock()
+	defer tokenSourceFactoriesMu.Unlock()
+	f, ok := tokenSourceFactories[name]
+	return f, ok
+}
+
+func init() {
+	RegisterTokenSourceFactory("static", newStaticTokenSource)
+}
+
+// staticTokenSourceConfig is the provider_config schema for the
+// built-in "static" provider, which always returns the same token --
+// the Registry equivalent of embedding a long-lived PAT directly.
+type staticTokenSourceConfig struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type,omitempty"`
+}
+
+func newStaticTokenSource(raw json.RawMessage) (URLTokenSource, error) {
+	var cfg staticTokenSourceConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("static tokensource: cannot parse provider config: %w", err)
+	}
+	if cfg.AccessToken == "" {
+		return nil, fmt.Errorf("static tokensource: provider config missing access_token")
+	}
+	tokenType := cfg.TokenType
+	if tokenType == "" {
+		tokenType = "Bearer"
+	}
+	tok := &oauth2.Token{AccessToken: cfg.AccessToken, TokenType: tokenType}
+	return URLTokenSourceFunc(func(*url.URL) (*oauth2.Token, error) { return tok, nil }), nil
+}
+
+// RegistryRule pairs one provider with the requests it applies to: Host
+// must match the upstream URL's host exactly (empty matches any host),
+// and PathPrefix must prefix its path (empty matches any path). The
+// first matching rule, in order, wins -- the same precedence
+// MultiUpstream uses for its own rule table.
+type RegistryRule struct {
+	// Name identifies this rule in errors; it does not need to be
+	// unique.
+	Name string
+	// Host, if set, must equal the upstream URL's host for this rule to
+	// match.
+	Host string
+	// PathPrefix, if set, must prefix the upstream URL's path for this
+	// rule to match.
+	PathPrefix string
+	// Provider mints the token for requests this rule matches. Nil
+	// means matching requests carry no token.
+	Provider URLTokenSource
+}
+
+// Registry dispatches TokenForURL to whichever RegistryRule matches an
+// upstream URL, so a single ServerConfig can mint credentials from
+// several providers -- a static PAT, a GitHub App, Vault, a
+// challenge-based exchange, or a caller's own URLTokenSource -- instead
+// of the one TokenSource every repository otherwise shares.
+type Registry struct {
+	Rules []RegistryRule
+}
+
+// NewRegistry returns a Registry serving rules in the given order;
+// earlier rules take priority when more than one matches a request.
+func NewRegistry(rules []RegistryRule) *Registry {
+	return &Registry{Rules: rules}
+}
+
+func (r *Registry) match(u *url.URL) (*RegistryRule, bool) {
+	for i := range r.Rules {
+		rule := &r.Rules[i]
+		if rule.Host != "" && rule.Host != u.Host {
+			continue
+		}
+		// A boundary check (rather than a bare strings.HasPrefix) keeps
+		// a rule configured with PathPrefix "/gh" from also matching
+		// "/ghenterprise/..." or "/gh-other/...", which would hand that
+		// request's credential-minting to the wrong provider -- see
+		// MultiUpstream.match's pathHasPrefixBoundary, which this
+		// mirrors.
+		if rule.PathPrefix != "" && !pathHasPrefixBoundary(u.Path, rule.PathPrefix) {
+			continue
+		}
+		return rule, true
+	}
+	return nil, false
+}
+
+// TokenForURL implements URLTokenSource, dispatching to the first rule
+// whose Host/PathPrefix matches u.
+func (r *Registry) TokenForURL(ctx context.Context, u *url.URL) (*oauth2.Token, error) {
+	rule, ok := r.match(u)
+	if !ok {
+		return nil, fmt.Errorf("tokensource registry: no rule matches %s", u)
+	}
+	if rule.Provider == nil {
+		return nil, nil
+	}
+	return rule.Provider.TokenForURL(ctx, u)
+}
+
+// TokenSource adapts Registry to the legacy ServerConfig.TokenSource
+// signature, for callers that haven't moved to the URLTokenSource
+// interface directly.
+func (r *Registry) TokenSource(u *url.URL) (*oauth2.Token, error) {
+	return r.TokenForURL(context.Background(), u)
+}
+
+// RegistryRuleConfig is one entry of a RegistryConfig, the subset of
+// RegistryRule that can be expressed as JSON; Provider names a factory
+// registered with RegisterTokenSourceFactory, and ProviderConfig is
+// passed to it verbatim.
+type RegistryRuleConfig struct {
+	Name           string          `json:"name" yaml:"name"`
+	Host           string          `json:"host" yaml:"host"`
+	PathPrefix     string          `json:"path_prefix" yaml:"path_prefix"`
+	Provider       string          `json:"provider" yaml:"provider"`
+	ProviderConfig json.RawMessage `json:"provider_config,omitempty" yaml:"-"`
+}
+
+// UnmarshalYAML re-encodes the provider_config node as JSON, since
+// yaml.Node has no counterpart to json.RawMessage's "decode later"
+// trick: json.RawMessage left tagged yaml:"-" would silently stay empty
+// for every rule loaded from a .yaml/.yml file.
+func (c *RegistryRuleConfig) UnmarshalYAML(node *yaml.Node) error {
+	var raw struct {
+		Name           string    `yaml:"name"`
+		Host           string    `yaml:"host"`
+		PathPrefix     string    `yaml:"path_prefix"`
+		Provider       string    `yaml:"provider"`
+		ProviderConfig yaml.Node `yaml:"provider_config"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	c.Name, c.Host, c.PathPrefix, c.Provider = raw.Name, raw.Host, raw.PathPrefix, raw.Provider
+	if raw.ProviderConfig.Kind != 0 {
+		var v interface{}
+		if err := raw.ProviderConfig.Decode(&v); err != nil {
+			return err
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		c.ProviderConfig = b
+	}
+	return nil
+}
+
+// RegistryConfig is the schema for a Registry rule file.
+type RegistryConfig struct {
+	Rules []RegistryRuleConfig `json:"rules" yaml:"rules"`
+}
+
+// LoadRegistryConfig reads and parses path as YAML (.yaml/.yml) or JSON
+// (anything else), mirroring LoadMultiUpstreamConfig. A rule's
+// provider_config is free-form per-provider JSON; RegistryRuleConfig's
+// UnmarshalYAML re-encodes it from the parsed YAML node so it comes out
+// the same whichever format the rule file used.
+func LoadRegistryConfig(path string) (*RegistryConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read tokensource registry config %q: %w", path, err)
+	}
+	var rc RegistryConfig
+	if strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
+		if err := yaml.Unmarshal(data, &rc); err != nil {
+			return nil, fmt.Errorf("cannot parse tokensource registry config %q as YAML: %w", path, err)
+		}
+	} else {
+		if err := json.Unmarshal(data, &rc); err != nil {
+			return nil, fmt.Errorf("cannot parse tokensource registry config %q as JSON: %w", path, err)
+		}
+	}
+	return &rc, nil
+}
+
+// Build resolves rc's entries into a Registry, instantiating each
+// entry's Provider via its registered TokenSourceFactory. A rule naming
+// an unregistered provider is an error; a rule with no Provider gets a
+// nil RegistryRule.Provider, the same as leaving MultiUpstreamRule.TokenSource unset.
+func (rc *RegistryConfig) Build() (*Registry, error) {
+	rules := make([]RegistryRule, 0, len(rc.Rules))
+	for _, c := range rc.Rules {
+		var provider URLTokenSource
+		if c.Provider != "" {
+			factory, ok := tokenSourceFactory(c.Provider)
+			if !ok {
+				return nil, fmt.Errorf("tokensource registry rule %q: unregistered provider %q", c.Name, c.Provider)
+			}
+			p, err := factory(c.ProviderConfig)
+			if err != nil {
+				return nil, fmt.Errorf("tokensource registry rule %q: provider %q: %w", c.Name, c.Provider, err)
+			}
+			provider = p
+		}
+		rules = append(rules, RegistryRule{Name: c.Name, Host: c.Host, PathPrefix: c.PathPrefix, Provider: provider})
+	}
+	return &Registry{Rules: rules}, nil
+}
@@ -0,0 +1,212 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestRegistryDispatchesByHostAndPath(t *testing.T) {
+	registry := NewRegistry([]RegistryRule{
+		{
+			Name: "internal",
+			Host: "github.com", PathPrefix: "/internal-org",
+			Provider: URLTokenSourceFunc(func(*url.URL) (*oauth2.Token, error) {
+				return &oauth2.Token{AccessToken: "internal-token"}, nil
+			}),
+		},
+		{
+			Name: "general",
+			Host: "github.com",
+			Provider: URLTokenSourceFunc(func(*url.URL) (*oauth2.Token, error) {
+				return &oauth2.Token{AccessToken: "general-token"}, nil
+			}),
+		},
+	})
+
+	internal, err := registry.TokenForURL(context.Background(), mustParseURL(t, "https://github.com/internal-org/repo"))
+	if err != nil {
+		t.Fatalf("TokenForURL() error = %v", err)
+	}
+	if internal.AccessToken != "internal-token" {
+		t.Errorf("AccessToken = %q, want internal-token (the narrower PathPrefix rule)", internal.AccessToken)
+	}
+
+	general, err := registry.TokenForURL(context.Background(), mustParseURL(t, "https://github.com/other-org/repo"))
+	if err != nil {
+		t.Fatalf("TokenForURL() error = %v", err)
+	}
+	if general.AccessToken != "general-token" {
+		t.Errorf("AccessToken = %q, want general-token", general.AccessToken)
+	}
+}
+
+func TestRegistryDoesNotMatchAdjacentPathPrefix(t *testing.T) {
+	registry := NewRegistry([]RegistryRule{
+		{
+			Name: "internal",
+			Host: "github.com", PathPrefix: "/internal-org",
+			Provider: URLTokenSourceFunc(func(*url.URL) (*oauth2.Token, error) {
+				return &oauth2.Token{AccessToken: "internal-token"}, nil
+			}),
+		},
+	})
+
+	if _, err := registry.TokenForURL(context.Background(), mustParseURL(t, "https://github.com/internal-org-other/repo")); err == nil {
+		t.Error("TokenForURL() matched PathPrefix \"/internal-org\" against \"/internal-org-other/...\", want no match since \"/internal-org\" isn't a path-segment prefix of it")
+	}
+}
+
+func TestRegistryNoMatchingRule(t *testing.T) {
+	registry := NewRegistry([]RegistryRule{
+		{Name: "github", Host: "github.com"},
+	})
+	if _, err := registry.TokenForURL(context.Background(), mustParseURL(t, "https://gitlab.com/org/repo")); err == nil {
+		t.Error("TokenForURL() succeeded for an upstream with no matching rule, want an error")
+	}
+}
+
+func TestRegistryNilProviderIsOptional(t *testing.T) {
+	registry := NewRegistry([]RegistryRule{{Name: "anonymous", Host: "example.com"}})
+	tok, err := registry.TokenForURL(context.Background(), mustParseURL(t, "https://example.com/repo"))
+	if err != nil {
+		t.Fatalf("TokenForURL() error = %v", err)
+	}
+	if tok != nil {
+		t.Errorf("TokenForURL() = %+v, want nil for a rule with no Provider", tok)
+	}
+}
+
+func TestRegistryTokenSourceAdaptsLegacySignature(t *testing.T) {
+	registry := NewRegistry([]RegistryRule{
+		{Name: "github", Host: "github.com", Provider: URLTokenSourceFunc(func(*url.URL) (*oauth2.Token, error) {
+			return &oauth2.Token{AccessToken: "github-token"}, nil
+		})},
+	})
+
+	// Registry.TokenSource has the func(*url.URL) (*oauth2.Token, error)
+	// signature ServerConfig.TokenSource expects, so it assigns directly.
+	var legacy func(u *url.URL) (*oauth2.Token, error) = registry.TokenSource
+	tok, err := legacy(mustParseURL(t, "https://github.com/org/repo"))
+	if err != nil {
+		t.Fatalf("TokenSource() error = %v", err)
+	}
+	if tok.AccessToken != "github-token" {
+		t.Errorf("AccessToken = %q, want github-token", tok.AccessToken)
+	}
+}
+
+const testEchoProvider = "test-echo-chunk10-6"
+
+func init() {
+	// Registered once from init(), since RegisterTokenSourceFactory
+	// panics on a duplicate name and this test can run more than once in
+	// the same process (e.g. under -count=2).
+	RegisterTokenSourceFactory(testEchoProvider, func(raw json.RawMessage) (URLTokenSource, error) {
+		var cfg struct {
+			Prefix string `json:"prefix"`
+		}
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return nil, err
+		}
+		return URLTokenSourceFunc(func(u *url.URL) (*oauth2.Token, error) {
+			return &oauth2.Token{AccessToken: cfg.Prefix + u.Host}, nil
+		}), nil
+	})
+}
+
+func TestRegistryConfigBuildMixesRegisteredProviders(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "registry.json")
+	const contents = `{"rules":[
+		{"name":"github","host":"github.com","provider":"static","provider_config":{"access_token":"gh-pat"}},
+		{"name":"echo","host":"gitlab.com","provider":"test-echo-chunk10-6","provider_config":{"prefix":"echo-"}}
+	]}`
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("cannot write config: %v", err)
+	}
+
+	rc, err := LoadRegistryConfig(path)
+	if err != nil {
+		t.Fatalf("LoadRegistryConfig() error = %v", err)
+	}
+	registry, err := rc.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	ghTok, err := registry.TokenForURL(context.Background(), mustParseURL(t, "https://github.com/org/repo"))
+	if err != nil {
+		t.Fatalf("TokenForURL(github) error = %v", err)
+	}
+	if ghTok.AccessToken != "gh-pat" {
+		t.Errorf("github AccessToken = %q, want gh-pat", ghTok.AccessToken)
+	}
+
+	glTok, err := registry.TokenForURL(context.Background(), mustParseURL(t, "https://gitlab.com/org/repo"))
+	if err != nil {
+		t.Fatalf("TokenForURL(gitlab) error = %v", err)
+	}
+	if glTok.AccessToken != "echo-gitlab.com" {
+		t.Errorf("gitlab AccessToken = %q, want echo-gitlab.com", glTok.AccessToken)
+	}
+}
+
+func TestLoadRegistryConfigYAMLCarriesProviderConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "registry.yaml")
+	const contents = "rules:\n" +
+		"  - name: github\n" +
+		"    host: github.com\n" +
+		"    provider: static\n" +
+		"    provider_config:\n" +
+		"      access_token: gh-pat\n"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("cannot write config: %v", err)
+	}
+
+	rc, err := LoadRegistryConfig(path)
+	if err != nil {
+		t.Fatalf("LoadRegistryConfig() error = %v", err)
+	}
+	registry, err := rc.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	tok, err := registry.TokenForURL(context.Background(), mustParseURL(t, "https://github.com/org/repo"))
+	if err != nil {
+		t.Fatalf("TokenForURL() error = %v", err)
+	}
+	if tok.AccessToken != "gh-pat" {
+		t.Errorf("AccessToken = %q, want gh-pat (provider_config parsed from YAML)", tok.AccessToken)
+	}
+}
+
+func TestRegistryConfigBuildRejectsUnregisteredProvider(t *testing.T) {
+	rc := &RegistryConfig{Rules: []RegistryRuleConfig{
+		{Name: "broken", Provider: "no-such-provider"},
+	}}
+	if _, err := rc.Build(); err == nil {
+		t.Error("Build() succeeded for an unregistered provider, want an error")
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"regexp"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UpstreamAuthError indicates the upstream rejected a request with HTTP
+// 401 or 403, as distinct from a network error or a 5xx. It's returned
+// by postUpstreamGitRequest and runGitFetchWithAuthRetry, and reported
+// through ServerConfig.ErrorReporter so operators can tell an expired
+// or rotated credential apart from an upstream outage in metrics.
+type UpstreamAuthError struct {
+	// Upstream is the URL the request was rejected by.
+	Upstream *url.URL
+	// StatusCode is the HTTP status the upstream responded with, or 0
+	// if it was inferred from the git CLI's own output rather than
+	// observed directly (see gitUpstreamAuthFailurePattern).
+	StatusCode int
+	// Err, if set, is the underlying error the status was inferred
+	// from (e.g. the git CLI's combined output).
+	Err error
+}
+
+func (e *UpstreamAuthError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("upstream %s rejected the request: HTTP %d", e.Upstream, e.StatusCode)
+	}
+	return fmt.Sprintf("upstream %s rejected the request: %v", e.Upstream, e.Err)
+}
+
+func (e *UpstreamAuthError) Unwrap() error {
+	return e.Err
+}
+
+// isUpstreamAuthError reports whether err is (or wraps) an
+// *UpstreamAuthError.
+func isUpstreamAuthError(err error) bool {
+	var authErr *UpstreamAuthError
+	return errors.As(err, &authErr)
+}
+
+// reportUpstreamAuthError surfaces authErr through
+// config.ErrorReporter, if one is configured, so it can be
+// distinguished from a plain network/server error in metrics.
+func (r *managedRepository) reportUpstreamAuthError(req *http.Request, authErr *UpstreamAuthError) {
+	if r.config.ErrorReporter != nil {
+		r.config.ErrorReporter(req, authErr)
+	}
+}
+
+// gitUpstreamAuthFailurePattern matches the git CLI's own fatal error
+// text for an upstream 401/403; unlike lsRefsUpstream's direct HTTP
+// client, a `git fetch` subprocess doesn't expose the raw status code,
+// only the message libcurl printed.
+var gitUpstreamAuthFailurePattern = regexp.MustCompile(`(?i)HTTP (?:Basic: Access denied|error 40[13])|returned error: 40[13]|Authentication failed`)
+
+// runGitFetchWithAuthRetry runs `git fetch` with args, authenticated
+// with a token for the upstream and the tenant carried in ctx, retrying
+// once with a force-refreshed token if the upstream's response looks
+// like a rejected credential (401/403) rather than any other failure.
+func (r *managedRepository) runGitFetchWithAuthRetry(ctx context.Context, op RunningOperation, args ...string) error {
+	err := r.runAuthenticatedGitFetch(ctx, op, args, false)
+	var authErr *UpstreamAuthError
+	if errors.As(err, &authErr) {
+		r.reportUpstreamAuthError(nil, authErr)
+		err = r.runAuthenticatedGitFetch(ctx, op, args, true)
+	}
+	return err
+}
+
+func (r *managedRepository) runAuthenticatedGitFetch(ctx context.Context, op RunningOperation, args []string, forceRefresh bool) error {
+	t, err := r.getToken(ctx, forceRefresh)
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot obtain an OAuth2 access token for the server: %v", err)
+	}
+
+	fullArgs := args
+	if t.AccessToken != "" {
+		fullArgs = append([]string{"-c", "http.extraHeader=Authorization: " + t.Type() + " " + t.AccessToken}, args...)
+	}
+	return r.runGitDetectingAuthFailure(ctx, op, fullArgs...)
+}
+
+// runGitDetectingAuthFailure behaves like runGit, except that when the
+// command fails and its stderr matches gitUpstreamAuthFailurePattern,
+// it returns an *UpstreamAuthError instead of the generic wrapped
+// error, so runGitFetchWithAuthRetry knows to retry with a fresh
+// token rather than give up.
+func (r *managedRepository) runGitDetectingAuthFailure(ctx context.Context, op RunningOperation, arg ...string) error {
+	var captured bytes.Buffer
+	cmd := exec.CommandContext(ctx, gitBinary, arg...)
+	cmd.Env = []string{}
+	cmd.Dir = r.localDiskPath
+	cmd.Stdout = &operationWriter{op}
+	cmd.Stderr = io.MultiWriter(&operationWriter{op}, &captured)
+	if err := cmd.Run(); err != nil {
+		if gitUpstreamAuthFailurePattern.Match(captured.Bytes()) {
+			return &UpstreamAuthError{Upstream: r.upstreamURL, Err: err}
+		}
+		return fmt.Errorf("failed to run a git command: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,345 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// BreakerState is a circuit breaker's current state.
+type BreakerState int
+
+const (
+	// BreakerClosed is the normal state: calls proceed to upstream and
+	// are counted toward UpstreamPolicy.FailureThreshold.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen means upstream has failed UpstreamPolicy.FailureThreshold
+	// times in a row; calls are rejected in favor of cache-only mode
+	// until UpstreamPolicy.OpenDuration elapses.
+	BreakerOpen
+	// BreakerHalfOpen means OpenDuration has elapsed and a bounded
+	// number of probe calls (UpstreamPolicy.HalfOpenProbes) are being
+	// let through to test whether upstream has recovered.
+	BreakerHalfOpen
+)
+
+// String implements fmt.Stringer.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerTransition describes a single circuit breaker state change,
+// passed to UpstreamPolicy.OnTransition.
+type BreakerTransition struct {
+	// RepoKey identifies which repository's breaker transitioned, in
+	// the same "<host>/<path>" form IsolationConfig.GetCachePath uses.
+	RepoKey string
+	From    BreakerState
+	To      BreakerState
+	// Claims carries whatever GetClaimsFromContext found on the
+	// request that triggered the transition, or nil if none.
+	Claims *Claims
+	Time   time.Time
+}
+
+// UpstreamPolicyOverride narrows UpstreamPolicy's rate limiting,
+// circuit breaker, and stale-while-revalidate settings to repositories
+// whose key (the same "<host>/<path>" GetCachePath builds) matches
+// Pattern. The first matching override in UpstreamPolicy.Overrides
+// wins; a repository matching none of them uses UpstreamPolicy's own
+// top-level fields.
+type UpstreamPolicyOverride struct {
+	Pattern          *regexp.Regexp
+	RateLimit        rate.Limit
+	RateBurst        int
+	FailureThreshold int
+	OpenDuration     time.Duration
+	HalfOpenProbes   int
+	MaxAge           time.Duration
+}
+
+// UpstreamPolicy governs whether and how a managedRepository talks to
+// its upstream, replacing a coarse boolean "upstream enabled" toggle
+// with a production-grade resiliency subsystem: a token-bucket rate
+// limiter bounds how often upstream is called at all; a circuit
+// breaker trips to cache-only mode after FailureThreshold consecutive
+// failures and probes for recovery with HalfOpenProbes rather than
+// resuming full traffic (or hammering a still-down upstream) the
+// instant OpenDuration elapses; and stale-while-revalidate lets a
+// caller serve a ref older than MaxAge from cache immediately while
+// refreshing it in the background instead of blocking the request on
+// upstream's RTT. Overrides narrows any of this per repository.
+//
+// A zero UpstreamPolicy has no rate limit and ShouldServeStale always
+// reports false; Allow reports true until RecordResult sees an error.
+// Its breaker still trips on the first recorded failure (a
+// FailureThreshold <= 0 counts as 1), and with a zero OpenDuration it
+// goes straight to half-open, letting one probe call through at a time.
+//
+// UpstreamPolicy is the standalone decision engine; calling it from the
+// request path (managedRepository.fetchUpstream and its ls-refs/fetch
+// callers) belongs to the HTTP/upload-pack handler, which is not
+// present in this checkout. The intended call pattern is: check
+// Allow(ctx, repoKey) before attempting the upstream call, and call
+// RecordResult(ctx, repoKey, err) with its outcome afterward.
+type UpstreamPolicy struct {
+	RateLimit rate.Limit
+	RateBurst int
+
+	FailureThreshold int
+	OpenDuration     time.Duration
+	HalfOpenProbes   int
+
+	MaxAge time.Duration
+
+	Overrides []UpstreamPolicyOverride
+
+	// OnTransition, if set, is called synchronously whenever a
+	// repository's breaker changes state.
+	OnTransition func(BreakerTransition)
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	breakers map[string]*circuitBreaker
+}
+
+// resolvedUpstreamPolicy is the effective set of tunables for one
+// repository, after applying the first matching UpstreamPolicyOverride
+// (or falling back to UpstreamPolicy's own fields).
+type resolvedUpstreamPolicy struct {
+	RateLimit        rate.Limit
+	RateBurst        int
+	FailureThreshold int
+	OpenDuration     time.Duration
+	HalfOpenProbes   int
+	MaxAge           time.Duration
+}
+
+func (p *UpstreamPolicy) resolve(repoKey string) resolvedUpstreamPolicy {
+	for _, o := range p.Overrides {
+		if o.Pattern != nil && o.Pattern.MatchString(repoKey) {
+			return resolvedUpstreamPolicy{
+				RateLimit:        o.RateLimit,
+				RateBurst:        o.RateBurst,
+				FailureThreshold: o.FailureThreshold,
+				OpenDuration:     o.OpenDuration,
+				HalfOpenProbes:   o.HalfOpenProbes,
+				MaxAge:           o.MaxAge,
+			}
+		}
+	}
+	return resolvedUpstreamPolicy{
+		RateLimit:        p.RateLimit,
+		RateBurst:        p.RateBurst,
+		FailureThreshold: p.FailureThreshold,
+		OpenDuration:     p.OpenDuration,
+		HalfOpenProbes:   p.HalfOpenProbes,
+		MaxAge:           p.MaxAge,
+	}
+}
+
+// Allow reports whether a request for repoKey may proceed to upstream,
+// per the rate limiter and circuit breaker resolved for repoKey. A
+// false return means the caller should serve from cache only; reason
+// explains why, for logging/metrics.
+func (p *UpstreamPolicy) Allow(ctx context.Context, repoKey string) (allowed bool, reason string) {
+	cfg := p.resolve(repoKey)
+
+	if cfg.RateLimit > 0 {
+		if !p.limiterFor(repoKey, cfg).Allow() {
+			return false, "upstream rate limit exceeded"
+		}
+	}
+
+	return p.breakerFor(repoKey).allow(cfg)
+}
+
+// RecordResult reports the outcome (nil for success) of an upstream
+// call for repoKey, potentially transitioning its circuit breaker and
+// invoking OnTransition with claims from ctx.
+func (p *UpstreamPolicy) RecordResult(ctx context.Context, repoKey string, err error) {
+	cfg := p.resolve(repoKey)
+	p.breakerFor(repoKey).recordResult(cfg, err, func(from, to BreakerState) {
+		if p.OnTransition != nil {
+			p.OnTransition(BreakerTransition{
+				RepoKey: repoKey,
+				From:    from,
+				To:      to,
+				Claims:  GetClaimsFromContext(ctx),
+				Time:    time.Now(),
+			})
+		}
+	})
+}
+
+// ShouldServeStale reports whether a cached ref last refreshed at
+// lastRefresh is old enough, per repoKey's resolved MaxAge, that a
+// caller should serve it immediately and refresh upstream in the
+// background rather than block the request on it. A zero MaxAge (the
+// default) disables stale-while-revalidate and always reports false.
+func (p *UpstreamPolicy) ShouldServeStale(repoKey string, lastRefresh time.Time) bool {
+	cfg := p.resolve(repoKey)
+	if cfg.MaxAge <= 0 {
+		return false
+	}
+	return time.Since(lastRefresh) > cfg.MaxAge
+}
+
+func (p *UpstreamPolicy) limiterFor(repoKey string, cfg resolvedUpstreamPolicy) *rate.Limiter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.limiters == nil {
+		p.limiters = map[string]*rate.Limiter{}
+	}
+	limiter, ok := p.limiters[repoKey]
+	if !ok {
+		burst := cfg.RateBurst
+		if burst <= 0 {
+			burst = 1
+		}
+		limiter = rate.NewLimiter(cfg.RateLimit, burst)
+		p.limiters[repoKey] = limiter
+	}
+	return limiter
+}
+
+func (p *UpstreamPolicy) breakerFor(repoKey string) *circuitBreaker {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.breakers == nil {
+		p.breakers = map[string]*circuitBreaker{}
+	}
+	b, ok := p.breakers[repoKey]
+	if !ok {
+		b = &circuitBreaker{}
+		p.breakers[repoKey] = b
+	}
+	return b
+}
+
+// circuitBreaker is the per-repository state machine behind
+// UpstreamPolicy. It's intentionally time-based rather than
+// goroutine/ticker-driven: Open -> HalfOpen is decided lazily the next
+// time allow is called, which keeps it trivial to unit test without
+// sleeping real wall-clock time.
+type circuitBreaker struct {
+	mu               sync.Mutex
+	state            BreakerState
+	consecutiveFails int
+	openedAt         time.Time
+	probesInFlight   int
+}
+
+func (b *circuitBreaker) allow(cfg resolvedUpstreamPolicy) (bool, string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		return true, ""
+
+	case BreakerOpen:
+		if time.Since(b.openedAt) < cfg.OpenDuration {
+			return false, "circuit breaker open"
+		}
+		b.state = BreakerHalfOpen
+		b.probesInFlight = 0
+		fallthrough
+
+	case BreakerHalfOpen:
+		probes := cfg.HalfOpenProbes
+		if probes <= 0 {
+			probes = 1
+		}
+		if b.probesInFlight >= probes {
+			return false, "circuit breaker half-open, probe budget exhausted"
+		}
+		b.probesInFlight++
+		return true, ""
+
+	default:
+		return true, ""
+	}
+}
+
+func (b *circuitBreaker) recordResult(cfg resolvedUpstreamPolicy, err error, onTransition func(from, to BreakerState)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		if err == nil {
+			b.consecutiveFails = 0
+			return
+		}
+		b.consecutiveFails++
+		threshold := cfg.FailureThreshold
+		if threshold <= 0 {
+			threshold = 1
+		}
+		if b.consecutiveFails >= threshold {
+			b.transition(BreakerOpen, onTransition)
+		}
+
+	case BreakerHalfOpen:
+		if b.probesInFlight > 0 {
+			b.probesInFlight--
+		}
+		if err != nil {
+			b.transition(BreakerOpen, onTransition)
+			return
+		}
+		if b.probesInFlight == 0 {
+			b.consecutiveFails = 0
+			b.transition(BreakerClosed, onTransition)
+		}
+
+	case BreakerOpen:
+		// A result arriving while open means it was in flight before
+		// the breaker tripped; it doesn't affect the open/half-open
+		// decision, which is driven by OpenDuration in allow.
+	}
+}
+
+func (b *circuitBreaker) transition(to BreakerState, onTransition func(from, to BreakerState)) {
+	from := b.state
+	if from == to {
+		return
+	}
+	b.state = to
+	if to == BreakerOpen {
+		b.openedAt = time.Now()
+		b.probesInFlight = 0
+	}
+	if onTransition != nil {
+		onTransition(from, to)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 Jacob Repp <jacobrepp@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goblet
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestUpstreamPolicy_ZeroValueAlwaysAllows(t *testing.T) {
+	var p UpstreamPolicy
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		if allowed, reason := p.Allow(ctx, "github.com/org/repo"); !allowed {
+			t.Fatalf("Allow() = false (%q), want true for a zero-value policy", reason)
+		}
+	}
+	if p.ShouldServeStale("github.com/org/repo", time.Now().Add(-24*time.Hour)) {
+		t.Error("ShouldServeStale() = true for a zero-value policy, want false")
+	}
+}
+
+func TestUpstreamPolicy_BreakerTripsAndPreventsThunderingHerd(t *testing.T) {
+	var transitions []BreakerTransition
+	p := &UpstreamPolicy{
+		FailureThreshold: 3,
+		OpenDuration:     time.Hour,
+		HalfOpenProbes:   1,
+		OnTransition: func(tr BreakerTransition) {
+			transitions = append(transitions, tr)
+		},
+	}
+	ctx := context.Background()
+	const repoKey = "github.com/org/flapping"
+
+	// Three consecutive failures trip the breaker.
+	for i := 0; i < 3; i++ {
+		allowed, reason := p.Allow(ctx, repoKey)
+		if !allowed {
+			t.Fatalf("Allow() call %d = false (%q), want true before the breaker trips", i, reason)
+		}
+		p.RecordResult(ctx, repoKey, errors.New("upstream unreachable"))
+	}
+
+	// Every further call is rejected instead of reaching upstream --
+	// this is what prevents a thundering herd against a downed
+	// upstream once it has already failed FailureThreshold times.
+	for i := 0; i < 5; i++ {
+		if allowed, reason := p.Allow(ctx, repoKey); allowed {
+			t.Fatalf("Allow() call %d = true, want false (%q) with the breaker open", i, reason)
+		}
+	}
+
+	if len(transitions) != 1 {
+		t.Fatalf("got %d transitions, want 1", len(transitions))
+	}
+	if transitions[0].From != BreakerClosed || transitions[0].To != BreakerOpen {
+		t.Errorf("transition = %v -> %v, want closed -> open", transitions[0].From, transitions[0].To)
+	}
+	if transitions[0].RepoKey != repoKey {
+		t.Errorf("transition.RepoKey = %q, want %q", transitions[0].RepoKey, repoKey)
+	}
+}
+
+func TestUpstreamPolicy_HalfOpenRecoversOnSuccess(t *testing.T) {
+	p := &UpstreamPolicy{
+		FailureThreshold: 1,
+		OpenDuration:     0, // elapses immediately, so the next Allow probes
+		HalfOpenProbes:   1,
+	}
+	ctx := context.Background()
+	const repoKey = "github.com/org/recovering"
+
+	p.Allow(ctx, repoKey)
+	p.RecordResult(ctx, repoKey, errors.New("boom"))
+
+	// OpenDuration is 0, so the breaker is immediately eligible to
+	// probe; exactly one probe should be let through.
+	allowed, reason := p.Allow(ctx, repoKey)
+	if !allowed {
+		t.Fatalf("Allow() = false (%q), want true for the half-open probe", reason)
+	}
+	if allowed, _ := p.Allow(ctx, repoKey); allowed {
+		t.Error("Allow() = true for a second concurrent call, want false (probe budget exhausted)")
+	}
+
+	p.RecordResult(ctx, repoKey, nil)
+
+	if allowed, reason := p.Allow(ctx, repoKey); !allowed {
+		t.Fatalf("Allow() = false (%q) after a successful probe, want true (breaker closed)", reason)
+	}
+}
+
+func TestUpstreamPolicy_OverrideNarrowsSettingsPerRepo(t *testing.T) {
+	p := &UpstreamPolicy{
+		FailureThreshold: 10,
+		Overrides: []UpstreamPolicyOverride{
+			{Pattern: regexp.MustCompile(`^github\.com/flaky-org/`), FailureThreshold: 1, OpenDuration: time.Hour},
+		},
+	}
+	ctx := context.Background()
+
+	// The override applies a lower threshold to its matching repo...
+	p.Allow(ctx, "github.com/flaky-org/repo")
+	p.RecordResult(ctx, "github.com/flaky-org/repo", errors.New("boom"))
+	if allowed, _ := p.Allow(ctx, "github.com/flaky-org/repo"); allowed {
+		t.Error("Allow() = true for the overridden repo after 1 failure, want false")
+	}
+
+	// ...while an unrelated repo keeps the base FailureThreshold of 10.
+	p.Allow(ctx, "github.com/other-org/repo")
+	p.RecordResult(ctx, "github.com/other-org/repo", errors.New("boom"))
+	if allowed, reason := p.Allow(ctx, "github.com/other-org/repo"); !allowed {
+		t.Errorf("Allow() = false (%q) for the non-overridden repo after 1 failure, want true", reason)
+	}
+}
+
+func TestUpstreamPolicy_ShouldServeStale(t *testing.T) {
+	p := &UpstreamPolicy{MaxAge: time.Hour}
+
+	if p.ShouldServeStale("github.com/org/repo", time.Now()) {
+		t.Error("ShouldServeStale() = true for a fresh ref, want false")
+	}
+	if !p.ShouldServeStale("github.com/org/repo", time.Now().Add(-2*time.Hour)) {
+		t.Error("ShouldServeStale() = false for a stale ref, want true")
+	}
+}